@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/spf13/cobra"
+)
+
+// configCmd 查看配置
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the effective configuration",
+}
+
+// configSourcesCmd 列出每个配置键的生效来源
+var configSourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "List the effective source (file, etcd, consul, http, dir, env) of each config key",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		_, store := configs.Bootstrap(cfgFile, cfgEnv)
+		env := configs.EnvSource{Prefix: configs.DefaultEnvPrefix}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "files: %s\n\n", strings.Join(store.Files(), ", "))
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tSOURCE\tFILE\tENV")
+		for _, ks := range store.Sources() {
+			if ks.Source == configs.SourceDefault && !all {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", ks.Key, ks.Source, ks.File, env.VarName(ks.Key))
+		}
+		return w.Flush()
+	},
+}
+
+// configShowCmd 输出生效配置，密码、密钥及密钥引用的值已隐藏
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration as JSON with secrets redacted",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, store := configs.Bootstrap(cfgFile, cfgEnv)
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(configs.ToMap(store.Redacted()))
+	},
+}
+
+// configKeygenCmd 生成加密配置值使用的密钥
+var configKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generate a key for encrypted config values",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := configs.GenerateEncryptionKey()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), key)
+		return nil
+	},
+}
+
+// configEncryptCmd 加密配置值，未提供参数时从标准输入读取，避免明文留在 shell 历史中
+var configEncryptCmd = &cobra.Command{
+	Use:   "encrypt [value]",
+	Short: "Encrypt a config value with the key in " + configs.KeyEnv + " or " + configs.KeyFileEnv,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := configs.LoadEncryptionKey()
+		if err != nil {
+			return err
+		}
+		value, err := argOrStdin(cmd, args)
+		if err != nil {
+			return err
+		}
+		out, err := configs.Encrypt(key, value)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), out)
+		return nil
+	},
+}
+
+// configDecryptCmd 解密 enc:v1: 开头的配置值
+var configDecryptCmd = &cobra.Command{
+	Use:   "decrypt [value]",
+	Short: "Decrypt an " + configs.EncryptedPrefix + " config value",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := configs.LoadEncryptionKey()
+		if err != nil {
+			return err
+		}
+		value, err := argOrStdin(cmd, args)
+		if err != nil {
+			return err
+		}
+		out, err := configs.Decrypt(key, value)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), out)
+		return nil
+	},
+}
+
+// argOrStdin 返回第一个参数，没有参数时读取标准输入的第一行
+func argOrStdin(cmd *cobra.Command, args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("read value from stdin: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func init() {
+	configSourcesCmd.Flags().Bool("all", false, "include keys left at their zero value")
+	configCmd.AddCommand(configSourcesCmd, configShowCmd, configKeygenCmd, configEncryptCmd, configDecryptCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -15,24 +15,56 @@ import (
 	"github.com/NSObjects/go-template/internal/api/data"
 	"github.com/NSObjects/go-template/internal/api/data/db"
 	"github.com/NSObjects/go-template/internal/api/service"
+	"github.com/NSObjects/go-template/internal/auth"
 	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/health"
 	"github.com/NSObjects/go-template/internal/log"
+	"github.com/NSObjects/go-template/internal/notify"
 	"github.com/NSObjects/go-template/internal/server"
 	"github.com/NSObjects/go-template/internal/utils"
 
 	"go.uber.org/fx"
 )
 
-func Run(cfg string) {
+func Run(cfg, env string) {
 	fx.New(
 		fx.Module("config", fx.Provide(func() (configs.Config, *configs.Store) {
-			merged, store := configs.Bootstrap(cfg)
+			merged, store := configs.Bootstrap(cfg, env)
 			return merged, store
 		})),
-		fx.Module("log", fx.Provide(func(cfg configs.Config) log.Logger {
-			return log.NewLogger(cfg)
-		})),
+		fx.Module("log",
+			fx.Provide(func(cfg configs.Config) log.Logger {
+				return log.NewLogger(cfg)
+			}),
+			// 日志级别随配置热更新
+			fx.Invoke(func(lc fx.Lifecycle, store *configs.Store, logger log.Logger) error {
+				stop, err := log.WatchLevel(store, logger)
+				if err != nil {
+					return err
+				}
+				lc.Append(fx.StopHook(stop))
+				return nil
+			}),
+		),
 		fx.Module("data", db.Model, utils.CasbinModule),
+		fx.Module("auth", fx.Provide(auth.NewKeySet, auth.NewLoginGuard, auth.NewIdentityProviders, auth.NewActionTokenSigner)),
+		fx.Module("notify", fx.Provide(notify.NewNotifier)),
+		fx.Module("health",
+			fx.Provide(health.NewHealthChecker),
+			fx.Invoke(func(hc *health.HealthChecker, w *utils.CasbinWatcher, store *configs.Store) {
+				hc.Register("casbin", w)
+				hc.Register("config", health.ConfigSources(store))
+			}),
+			// 热更新后健康检查持续失败时自动回滚配置
+			fx.Invoke(func(lc fx.Lifecycle, hc *health.HealthChecker, store *configs.Store) error {
+				stop, err := health.AutoRollback(store, hc)
+				if err != nil {
+					return err
+				}
+				lc.Append(fx.StopHook(stop))
+				return nil
+			}),
+		),
 		fx.Module("biz", biz.Model),
 		fx.Module("repos", data.Model),
 		fx.Module("service", service.Model),
@@ -7,7 +7,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var cfgFile string
+var (
+	cfgFile string
+	cfgEnv  string
+)
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -22,7 +25,7 @@ to quickly create a Cobra application.`,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	Run: func(cmd *cobra.Command, args []string) {
-		Run(cfgFile)
+		Run(cfgFile, cfgEnv)
 	},
 }
 
@@ -43,6 +46,8 @@ func init() {
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "configs/config.toml",
 		"config file (default is $HOME/.echo-admin.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgEnv, "env", "",
+		"run environment (dev|test|prod), merges config.<env>.* over the config file; defaults to APP_SYSTEM_ENV or system.env")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
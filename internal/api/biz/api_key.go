@@ -0,0 +1,236 @@
+/*
+ * Module: APIKey
+ * 服务间调用的 API 密钥：管理接口与请求认证
+ */
+
+package biz
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/auth"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/marmotedu/errors"
+)
+
+// apiKeyTouchInterval 最近使用时间的更新间隔，避免每个请求都写库
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyRepository API 密钥数据访问接口
+type APIKeyRepository interface {
+	// ListAPIKeys 分页查询，userID 为 0 时不过滤
+	ListAPIKeys(ctx context.Context, userID int64, offset, limit int) ([]*model.APIKey, int64, error)
+
+	// FindAPIKey 按 ID 查询，不存在时返回 code.ErrAPIKeyNotFound
+	FindAPIKey(ctx context.Context, id int64) (*model.APIKey, error)
+
+	// FindAPIKeyByPrefix 按查找前缀查询，不存在时返回 code.ErrAPIKeyNotFound
+	FindAPIKeyByPrefix(ctx context.Context, prefix string) (*model.APIKey, error)
+
+	// CreateAPIKey 保存新密钥
+	CreateAPIKey(ctx context.Context, key *model.APIKey) error
+
+	// UpdateAPIKey 更新名称、授权范围与过期时间
+	UpdateAPIKey(ctx context.Context, key *model.APIKey) error
+
+	// DeleteAPIKey 删除密钥，不存在时返回 code.ErrAPIKeyNotFound
+	DeleteAPIKey(ctx context.Context, id int64) error
+
+	// TouchAPIKey 记录最近使用时间
+	TouchAPIKey(ctx context.Context, id int64, at time.Time) error
+}
+
+// APIKeyUseCase API 密钥业务逻辑接口
+type APIKeyUseCase interface {
+	// List 查询 API 密钥
+	List(ctx context.Context, req param.APIKeyListRequest) ([]param.APIKeyData, int64, error)
+
+	// Create 创建 API 密钥，密钥明文只在返回值中出现一次
+	Create(ctx context.Context, req param.APIKeyCreateRequest) (param.APIKeyCreatedData, error)
+
+	// GetByID 查询 API 密钥详情
+	GetByID(ctx context.Context, id int64) (param.APIKeyData, error)
+
+	// Update 更新 API 密钥
+	Update(ctx context.Context, id int64, req param.APIKeyUpdateRequest) error
+
+	// Delete 吊销 API 密钥
+	Delete(ctx context.Context, id int64) error
+
+	// AuthenticateAPIKey 校验请求携带的密钥，返回所属用户的认证主体
+	AuthenticateAPIKey(ctx context.Context, key string) (*utils.Principal, error)
+}
+
+// APIKeyHandler API 密钥业务逻辑处理器
+type APIKeyHandler struct {
+	repo   APIKeyRepository
+	users  AuthRepository
+	rbac   RbacRepository
+	admins []string
+	now    func() time.Time
+}
+
+// NewAPIKeyHandler 创建 API 密钥业务逻辑处理器
+func NewAPIKeyHandler(repo APIKeyRepository, users AuthRepository, rbac RbacRepository, cfg configs.Config) APIKeyUseCase {
+	return &APIKeyHandler{
+		repo:   repo,
+		users:  users,
+		rbac:   rbac,
+		admins: cfg.Middleware.Casbin.Admins(),
+		now:    time.Now,
+	}
+}
+
+func (h *APIKeyHandler) List(ctx context.Context, req param.APIKeyListRequest) ([]param.APIKeyData, int64, error) {
+	keys, total, err := h.repo.ListAPIKeys(ctx, req.UserID, req.Offset(), req.Limit())
+	if err != nil {
+		return nil, 0, err
+	}
+	list := make([]param.APIKeyData, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, toAPIKeyData(k))
+	}
+	return list, total, nil
+}
+
+func (h *APIKeyHandler) Create(ctx context.Context, req param.APIKeyCreateRequest) (param.APIKeyCreatedData, error) {
+	if err := h.validateAPIKey(req.Scopes, req.ExpiresAt); err != nil {
+		return param.APIKeyCreatedData{}, err
+	}
+	if _, err := h.users.FindByID(ctx, req.UserID); err != nil {
+		return param.APIKeyCreatedData{}, err
+	}
+
+	secret, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		return param.APIKeyCreatedData{}, code.WrapInternalServerError(err, "generate api key failed")
+	}
+	key := &model.APIKey{
+		Name:      req.Name,
+		Prefix:    prefix,
+		Hash:      auth.HashToken(secret),
+		UserID:    req.UserID,
+		Scopes:    strings.Join(req.Scopes, " "),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := h.repo.CreateAPIKey(ctx, key); err != nil {
+		return param.APIKeyCreatedData{}, err
+	}
+	return param.APIKeyCreatedData{APIKeyData: toAPIKeyData(key), Key: secret}, nil
+}
+
+func (h *APIKeyHandler) GetByID(ctx context.Context, id int64) (param.APIKeyData, error) {
+	key, err := h.repo.FindAPIKey(ctx, id)
+	if err != nil {
+		return param.APIKeyData{}, err
+	}
+	return toAPIKeyData(key), nil
+}
+
+func (h *APIKeyHandler) Update(ctx context.Context, id int64, req param.APIKeyUpdateRequest) error {
+	if err := h.validateAPIKey(req.Scopes, req.ExpiresAt); err != nil {
+		return err
+	}
+	key, err := h.repo.FindAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	key.Name = req.Name
+	key.Scopes = strings.Join(req.Scopes, " ")
+	key.ExpiresAt = req.ExpiresAt
+	return h.repo.UpdateAPIKey(ctx, key)
+}
+
+func (h *APIKeyHandler) Delete(ctx context.Context, id int64) error {
+	return h.repo.DeleteAPIKey(ctx, id)
+}
+
+func (h *APIKeyHandler) AuthenticateAPIKey(ctx context.Context, secret string) (*utils.Principal, error) {
+	prefix, ok := auth.ParseAPIKey(secret)
+	if !ok {
+		return nil, code.NewError(code.ErrAPIKeyInvalid, "malformed api key")
+	}
+	key, err := h.repo.FindAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.IsCode(err, code.ErrAPIKeyNotFound) {
+			return nil, code.WrapError(err, code.ErrAPIKeyInvalid, "api key is invalid")
+		}
+		return nil, err
+	}
+	if !auth.VerifyAPIKey(secret, key.Hash) {
+		return nil, code.NewError(code.ErrAPIKeyInvalid, "api key is invalid")
+	}
+	now := h.now()
+	if key.ExpiresAt != nil && !now.Before(*key.ExpiresAt) {
+		return nil, code.NewError(code.ErrAPIKeyInvalid, "api key has expired")
+	}
+
+	// 所属用户被删除或禁用后密钥随之失效
+	user, err := h.users.FindByID(ctx, key.UserID)
+	if err != nil {
+		if errors.IsCode(err, code.ErrUserNotFound) {
+			return nil, code.WrapError(err, code.ErrAPIKeyInvalid, "api key owner no longer exists")
+		}
+		return nil, err
+	}
+	if err := checkUserStatus(user); err != nil {
+		return nil, err
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		// 最近使用时间仅用于审计，写入失败不影响本次请求
+		_ = h.repo.TouchAPIKey(ctx, key.ID, now)
+	}
+
+	// 密钥以所属用户的角色访问；请求中没有可信的租户来源，所属用户属于多个租户时密钥不携带租户与角色
+	claims, err := loadAccessClaims(ctx, h.rbac, h.admins, user.ID, "")
+	if err != nil && !errors.IsCode(err, code.ErrTenantRequired) {
+		return nil, err
+	}
+
+	return &utils.Principal{
+		ID:       strconv.FormatInt(key.UserID, 10),
+		Name:     user.Username,
+		Roles:    claims.roles,
+		TenantID: claims.tenantID,
+		Admin:    claims.admin,
+		Method:   utils.AuthMethodAPIKey,
+		Scopes:   splitScopes(key.Scopes),
+	}, nil
+}
+
+func (h *APIKeyHandler) validateAPIKey(scopes []string, expiresAt *time.Time) error {
+	for _, scope := range scopes {
+		if err := auth.ValidateScope(scope); err != nil {
+			return code.NewValidationError("scopes", err.Error())
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(h.now()) {
+		return code.NewValidationError("expires_at", "expires_at must be in the future")
+	}
+	return nil
+}
+
+func toAPIKeyData(k *model.APIKey) param.APIKeyData {
+	return param.APIKeyData{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		UserID:     k.UserID,
+		Scopes:     splitScopes(k.Scopes),
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
+
+func splitScopes(scopes string) []string {
+	return strings.Fields(scopes)
+}
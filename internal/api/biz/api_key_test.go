@@ -0,0 +1,247 @@
+package biz
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPIKeyRepository 内存实现的 API 密钥数据访问
+type fakeAPIKeyRepository struct {
+	mu      sync.Mutex
+	keys    map[int64]*model.APIKey
+	touches int
+}
+
+func newFakeAPIKeyRepository() *fakeAPIKeyRepository {
+	return &fakeAPIKeyRepository{keys: make(map[int64]*model.APIKey)}
+}
+
+func (f *fakeAPIKeyRepository) ListAPIKeys(_ context.Context, userID int64, offset, limit int) ([]*model.APIKey, int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*model.APIKey
+	for id := int64(1); id <= int64(len(f.keys)); id++ {
+		if k, ok := f.keys[id]; ok && (userID == 0 || k.UserID == userID) {
+			out = append(out, k)
+		}
+	}
+	total := int64(len(out))
+	if offset >= len(out) {
+		return nil, total, nil
+	}
+	return out[offset:min(offset+limit, len(out))], total, nil
+}
+
+func (f *fakeAPIKeyRepository) FindAPIKey(_ context.Context, id int64) (*model.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k, ok := f.keys[id]
+	if !ok {
+		return nil, code.NewError(code.ErrAPIKeyNotFound, "api key not found")
+	}
+	cp := *k
+	return &cp, nil
+}
+
+func (f *fakeAPIKeyRepository) FindAPIKeyByPrefix(_ context.Context, prefix string) (*model.APIKey, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, k := range f.keys {
+		if k.Prefix == prefix {
+			cp := *k
+			return &cp, nil
+		}
+	}
+	return nil, code.NewError(code.ErrAPIKeyNotFound, "api key not found")
+}
+
+func (f *fakeAPIKeyRepository) CreateAPIKey(_ context.Context, key *model.APIKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key.ID = int64(len(f.keys) + 1)
+	cp := *key
+	f.keys[key.ID] = &cp
+	return nil
+}
+
+func (f *fakeAPIKeyRepository) UpdateAPIKey(_ context.Context, key *model.APIKey) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cp := *key
+	f.keys[key.ID] = &cp
+	return nil
+}
+
+func (f *fakeAPIKeyRepository) DeleteAPIKey(_ context.Context, id int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.keys[id]; !ok {
+		return code.NewError(code.ErrAPIKeyNotFound, "api key not found")
+	}
+	delete(f.keys, id)
+	return nil
+}
+
+func (f *fakeAPIKeyRepository) TouchAPIKey(_ context.Context, id int64, at time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.touches++
+	f.keys[id].LastUsedAt = &at
+	return nil
+}
+
+func newAPIKeyTestHandler(t *testing.T) (*APIKeyHandler, *MockAuthRepository, *fakeAPIKeyRepository) {
+	t.Helper()
+	users := new(MockAuthRepository)
+	users.On("FindByID", mock.Anything, int64(7)).Return(&model.User{ID: 7, Username: "svc"}, nil)
+	users.On("FindByID", mock.Anything, int64(8)).Return(&model.User{ID: 8, Username: "gone", Status: UserStatusDisabled}, nil)
+	users.On("FindByID", mock.Anything, int64(9)).Return(nil, code.NewError(code.ErrUserNotFound, "user not found"))
+	repo := newFakeAPIKeyRepository()
+	handler := NewAPIKeyHandler(repo, users, nil, configs.Config{}).(*APIKeyHandler)
+	now := time.Unix(1700000000, 0)
+	handler.now = func() time.Time { return now }
+	return handler, users, repo
+}
+
+func TestAPIKeyHandler_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	handler, _, repo := newAPIKeyTestHandler(t)
+
+	created, err := handler.Create(ctx, param.APIKeyCreateRequest{Name: "billing", UserID: 7, Scopes: []string{"GET:/api/users*"}})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Key, "gtk_"+created.Prefix+"_"))
+	assert.NotContains(t, repo.keys[created.ID].Hash, created.Key)
+
+	p, err := handler.AuthenticateAPIKey(ctx, created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, &utils.Principal{ID: "7", Name: "svc", Method: utils.AuthMethodAPIKey, Scopes: []string{"GET:/api/users*"}}, p)
+
+	// 最近使用时间按间隔更新
+	_, err = handler.AuthenticateAPIKey(ctx, created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.touches)
+	later := handler.now().Add(apiKeyTouchInterval)
+	handler.now = func() time.Time { return later }
+	_, err = handler.AuthenticateAPIKey(ctx, created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.touches)
+
+	for _, bad := range []string{"", "not-a-key", created.Key[:len(created.Key)-1] + "x", "gtk_000000000000_secret"} {
+		_, err = handler.AuthenticateAPIKey(ctx, bad)
+		assert.True(t, errors.IsCode(err, code.ErrAPIKeyInvalid), bad)
+	}
+
+	// 吊销后立即失效
+	require.NoError(t, handler.Delete(ctx, created.ID))
+	_, err = handler.AuthenticateAPIKey(ctx, created.Key)
+	assert.True(t, errors.IsCode(err, code.ErrAPIKeyInvalid))
+	assert.True(t, errors.IsCode(handler.Delete(ctx, created.ID), code.ErrAPIKeyNotFound))
+}
+
+func TestAPIKeyHandler_OwnerClaims(t *testing.T) {
+	tests := []struct {
+		name    string
+		domains [][]string
+		want    *utils.Principal
+	}{
+		{
+			name:    "继承所属用户的角色与租户",
+			domains: [][]string{{"7", "admin", "acme"}},
+			want:    &utils.Principal{ID: "7", Name: "svc", Roles: []string{"admin"}, TenantID: "acme", Admin: true, Method: utils.AuthMethodAPIKey, Scopes: []string{"*"}},
+		},
+		{
+			name:    "所属用户属于多个租户",
+			domains: [][]string{{"7", "admin", "acme"}, {"7", "admin", "globex"}},
+			want:    &utils.Principal{ID: "7", Name: "svc", Method: utils.AuthMethodAPIKey, Scopes: []string{"*"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			handler, _, _ := newAPIKeyTestHandler(t)
+			rbac := &MockRbacRepository{domains: true}
+			rbac.On("Roles", mock.Anything, []string{"7"}).Return(tt.domains, nil)
+			rbac.On("RolesForUser", mock.Anything, "7", "acme").Return([]string{"admin"}, []string{"admin"}, nil)
+			handler.rbac = rbac
+			handler.admins = configs.DefaultAdminUsers
+
+			created, err := handler.Create(ctx, param.APIKeyCreateRequest{Name: "billing", UserID: 7, Scopes: []string{"*"}})
+			require.NoError(t, err)
+			p, err := handler.AuthenticateAPIKey(ctx, created.Key)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, p)
+		})
+	}
+}
+
+func TestAPIKeyHandler_Rejected(t *testing.T) {
+	ctx := context.Background()
+	handler, _, _ := newAPIKeyTestHandler(t)
+
+	expires := handler.now().Add(time.Hour)
+	expiring, err := handler.Create(ctx, param.APIKeyCreateRequest{Name: "temp", UserID: 7, Scopes: []string{"*"}, ExpiresAt: &expires})
+	require.NoError(t, err)
+	later := expires
+	handler.now = func() time.Time { return later }
+	_, err = handler.AuthenticateAPIKey(ctx, expiring.Key)
+	assert.True(t, errors.IsCode(err, code.ErrAPIKeyInvalid))
+
+	disabled, err := handler.Create(ctx, param.APIKeyCreateRequest{Name: "disabled", UserID: 8, Scopes: []string{"*"}})
+	require.NoError(t, err)
+	_, err = handler.AuthenticateAPIKey(ctx, disabled.Key)
+	assert.True(t, errors.IsCode(err, code.ErrAccountDisabled))
+
+	_, err = handler.Create(ctx, param.APIKeyCreateRequest{Name: "orphan", UserID: 9, Scopes: []string{"*"}})
+	assert.True(t, errors.IsCode(err, code.ErrUserNotFound))
+
+	_, err = handler.Create(ctx, param.APIKeyCreateRequest{Name: "bad", UserID: 7, Scopes: []string{"users:read"}})
+	assert.True(t, errors.IsCode(err, code.ErrValidation))
+
+	past := handler.now().Add(-time.Second)
+	_, err = handler.Create(ctx, param.APIKeyCreateRequest{Name: "past", UserID: 7, Scopes: []string{"*"}, ExpiresAt: &past})
+	assert.True(t, errors.IsCode(err, code.ErrValidation))
+}
+
+func TestAPIKeyHandler_Manage(t *testing.T) {
+	ctx := context.Background()
+	handler, _, _ := newAPIKeyTestHandler(t)
+
+	first, err := handler.Create(ctx, param.APIKeyCreateRequest{Name: "a", UserID: 7, Scopes: []string{"*"}})
+	require.NoError(t, err)
+	_, err = handler.Create(ctx, param.APIKeyCreateRequest{Name: "b", UserID: 8, Scopes: []string{"*"}})
+	require.NoError(t, err)
+
+	list, total, err := handler.List(ctx, param.APIKeyListRequest{UserID: 7})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, list, 1)
+	assert.Equal(t, "a", list[0].Name)
+
+	require.NoError(t, handler.Update(ctx, first.ID, param.APIKeyUpdateRequest{Name: "renamed", Scopes: []string{"GET:/api/users", "POST:/api/users"}}))
+	got, err := handler.GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", got.Name)
+	assert.Equal(t, []string{"GET:/api/users", "POST:/api/users"}, got.Scopes)
+
+	// 授权范围收窄后立即生效
+	p, err := handler.AuthenticateAPIKey(ctx, first.Key)
+	require.NoError(t, err)
+	assert.Equal(t, got.Scopes, p.Scopes)
+
+	err = handler.Update(ctx, 99, param.APIKeyUpdateRequest{Name: "x", Scopes: []string{"*"}})
+	assert.True(t, errors.IsCode(err, code.ErrAPIKeyNotFound))
+}
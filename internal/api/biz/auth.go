@@ -0,0 +1,473 @@
+/*
+ * Module: Auth
+ * 用户名密码登录、访问令牌签发与刷新令牌轮换
+ */
+
+package biz
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/auth"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/notify"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/marmotedu/errors"
+	"go.uber.org/fx"
+)
+
+const (
+	// DefaultAccessTokenTTL jwt.expire 未配置时的访问令牌有效期
+	DefaultAccessTokenTTL = auth.DefaultAccessTokenTTL
+	// DefaultRefreshTokenTTL jwt.refresh_expire 未配置时的刷新令牌有效期
+	DefaultRefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// 用户状态（model.User.Status）
+const (
+	UserStatusActive   int32 = 1
+	UserStatusDisabled int32 = 2
+)
+
+// AuthRepository 认证相关的用户数据访问接口
+type AuthRepository interface {
+	// FindByUsername 按用户名查询用户，不存在时返回 code.ErrUserNotFound
+	FindByUsername(ctx context.Context, username string) (*model.User, error)
+
+	// FindByID 按ID查询用户，不存在时返回 code.ErrUserNotFound
+	FindByID(ctx context.Context, id int64) (*model.User, error)
+
+	// UpdatePassword 更新密码哈希
+	UpdatePassword(ctx context.Context, id int64, hash string) error
+
+	// UpdateLastLogin 记录最近登录时间
+	UpdateLastLogin(ctx context.Context, id int64, at time.Time) error
+
+	// FindByEmail 按邮箱查询用户，不存在时返回 code.ErrUserNotFound
+	FindByEmail(ctx context.Context, email string) (*model.User, error)
+
+	// MarkEmailVerified 记录邮箱验证时间，用户邮箱已不是 email 时返回 code.ErrVerificationTokenInvalid
+	MarkEmailVerified(ctx context.Context, id int64, email string, at time.Time) error
+}
+
+// AuthUseCase 认证业务逻辑接口
+type AuthUseCase interface {
+	// Login 用户名密码登录并签发令牌对
+	Login(ctx context.Context, req param.AuthLoginRequest) (param.AuthTokenData, error)
+
+	// Refresh 使用刷新令牌换取新的令牌对，旧刷新令牌随即失效
+	Refresh(ctx context.Context, req param.AuthRefreshRequest) (param.AuthTokenData, error)
+
+	// Logout 吊销刷新令牌所在的令牌族及其访问令牌
+	Logout(ctx context.Context, req param.AuthLogoutRequest) error
+
+	// RevokeUser 吊销用户的全部会话（强制下线）
+	RevokeUser(ctx context.Context, userID int64) error
+
+	// Unlock 解除账号的登录锁定
+	Unlock(ctx context.Context, req param.AuthUnlockRequest) error
+
+	// CheckAccount 校验访问令牌所属账号仍然可用，账号被禁用时返回 code.ErrAccountDisabled
+	CheckAccount(ctx context.Context, userID int64) error
+
+	// EnrollMfa 为当前用户生成 TOTP 密钥，确认前不生效
+	EnrollMfa(ctx context.Context) (param.MfaEnrollData, error)
+
+	// ActivateMfa 校验验证码后启用两步验证，返回一次性恢复码
+	ActivateMfa(ctx context.Context, req param.MfaActivateRequest) (param.MfaRecoveryCodesData, error)
+
+	// VerifyMfa 登录第二步：校验 mfa_token 与验证码（或恢复码）后签发令牌对
+	VerifyMfa(ctx context.Context, req param.MfaVerifyRequest) (param.AuthTokenData, error)
+
+	// ResetMfa 管理员重置用户的两步验证（如设备丢失）
+	ResetMfa(ctx context.Context, userID int64) error
+
+	// OIDCAuthorize 生成身份提供方的授权地址（授权码 + PKCE）
+	OIDCAuthorize(ctx context.Context, req param.OIDCProviderRequest) (param.OIDCAuthorizeData, error)
+
+	// OIDCLink 为当前用户生成关联外部身份的授权地址
+	OIDCLink(ctx context.Context, req param.OIDCProviderRequest) (param.OIDCAuthorizeData, error)
+
+	// OIDCUnlink 解除当前用户与身份提供方的关联
+	OIDCUnlink(ctx context.Context, req param.OIDCProviderRequest) error
+
+	// OIDCCallback 用授权码换取外部身份，找到或创建本地用户后签发令牌对
+	OIDCCallback(ctx context.Context, req param.OIDCCallbackRequest) (param.AuthTokenData, error)
+
+	// ForgotPassword 向邮箱发送重置密码链接，邮箱不存在时同样返回成功，避免探测账号
+	ForgotPassword(ctx context.Context, req param.PasswordForgotRequest) error
+
+	// ResetPassword 校验重置令牌后更新密码，并吊销用户的全部会话
+	ResetPassword(ctx context.Context, req param.PasswordResetRequest) error
+
+	// SendVerification 向当前用户的邮箱发送验证链接
+	SendVerification(ctx context.Context) error
+
+	// VerifyEmail 校验验证令牌后标记邮箱已验证
+	VerifyEmail(ctx context.Context, req param.EmailVerifyRequest) error
+}
+
+// AuthHandler 认证业务逻辑处理器
+type AuthHandler struct {
+	repo       AuthRepository
+	mfa        MfaRepository
+	identities IdentityRepository
+	tokens     auth.TokenStore
+	challenges auth.ChallengeStore
+	states     auth.StateStore
+	providers  *auth.IdentityProviders
+	keys       *auth.KeySet
+	guard      *auth.LoginGuard
+	actions    auth.ActionTokenStore
+	signer     *auth.ActionTokenSigner
+	notifier   notify.Notifier
+	rbac       RbacRepository
+	admins     []string
+	jwt        configs.JWTConfig
+	mfaCfg     configs.MfaConfig
+	oidc       configs.OIDCConfig
+	verify     configs.VerificationConfig
+	now        func() time.Time
+}
+
+// AuthDeps 认证业务逻辑的依赖
+type AuthDeps struct {
+	fx.In
+
+	Repo       AuthRepository
+	Mfa        MfaRepository
+	Identities IdentityRepository
+	Tokens     auth.TokenStore
+	Challenges auth.ChallengeStore
+	States     auth.StateStore
+	Providers  *auth.IdentityProviders
+	Keys       *auth.KeySet
+	Guard      *auth.LoginGuard
+	Actions    auth.ActionTokenStore
+	Signer     *auth.ActionTokenSigner
+	Notifier   notify.Notifier
+	Rbac       RbacRepository
+	Config     configs.Config
+}
+
+// NewAuthHandler 创建认证业务逻辑处理器
+func NewAuthHandler(deps AuthDeps) AuthUseCase {
+	return &AuthHandler{
+		repo:       deps.Repo,
+		mfa:        deps.Mfa,
+		identities: deps.Identities,
+		tokens:     deps.Tokens,
+		challenges: deps.Challenges,
+		states:     deps.States,
+		providers:  deps.Providers,
+		keys:       deps.Keys,
+		guard:      deps.Guard,
+		actions:    deps.Actions,
+		signer:     deps.Signer,
+		notifier:   deps.Notifier,
+		rbac:       deps.Rbac,
+		admins:     deps.Config.Middleware.Casbin.Admins(),
+		jwt:        deps.Config.JWT,
+		mfaCfg:     deps.Config.Auth.Mfa,
+		oidc:       deps.Config.Auth.OIDC,
+		verify:     deps.Config.Auth.Verification,
+		now:        time.Now,
+	}
+}
+
+func (h *AuthHandler) Login(ctx context.Context, req param.AuthLoginRequest) (param.AuthTokenData, error) {
+	ip := utils.GetClientIP(ctx)
+	if err := h.guard.Check(ctx, req.Username, ip); err != nil {
+		return param.AuthTokenData{}, err
+	}
+
+	user, err := h.repo.FindByUsername(ctx, req.Username)
+	if err != nil {
+		if errors.IsCode(err, code.ErrUserNotFound) {
+			return param.AuthTokenData{}, h.loginFailed(ctx, req.Username, ip, nil)
+		}
+		return param.AuthTokenData{}, err
+	}
+
+	ok, needRehash, err := utils.Verify(user.Password, req.Password)
+	if err != nil || !ok {
+		return param.AuthTokenData{}, h.loginFailed(ctx, req.Username, ip, err)
+	}
+	// 密码正确后才提示账号已禁用，避免未认证的调用方探测账号状态
+	if err := checkUserStatus(user); err != nil {
+		return param.AuthTokenData{}, err
+	}
+
+	// 哈希策略升级后在登录成功时平滑重算，失败不影响本次登录
+	if needRehash {
+		if newHash, changed, err := utils.MustRehashIfNeeded(user.Password, req.Password); err == nil && changed {
+			_ = h.repo.UpdatePassword(ctx, user.ID, newHash)
+		}
+	}
+
+	// 开启了两步验证时先返回 mfa_token，验证码校验通过后再签发令牌对；
+	// 此时保留失败计数，避免反复用正确密码重置验证码的尝试次数
+	if challenge, ok, err := h.mfaChallenge(ctx, user.ID); err != nil || ok {
+		return challenge, err
+	}
+	if err := h.guard.Succeed(ctx, req.Username); err != nil {
+		return param.AuthTokenData{}, err
+	}
+	return h.issue(ctx, user)
+}
+
+// issue 开启新的令牌族并签发令牌对，令牌族的租户取请求所属租户
+func (h *AuthHandler) issue(ctx context.Context, user *model.User) (param.AuthTokenData, error) {
+	claims, err := h.accessClaims(ctx, user, utils.GetTenantID(ctx))
+	if err != nil {
+		return param.AuthTokenData{}, err
+	}
+	_ = h.repo.UpdateLastLogin(ctx, user.ID, h.now())
+
+	pair, err := h.newTokenPair()
+	if err != nil {
+		return param.AuthTokenData{}, err
+	}
+	pair.record.Family = uuid.NewString()
+	pair.record.UserID = user.ID
+	pair.record.TenantID = claims.tenantID
+	if err := h.tokens.Issue(ctx, pair.record); err != nil {
+		return param.AuthTokenData{}, err
+	}
+
+	return h.sign(user, claims, pair)
+}
+
+func (h *AuthHandler) Refresh(ctx context.Context, req param.AuthRefreshRequest) (param.AuthTokenData, error) {
+	pair, err := h.newTokenPair()
+	if err != nil {
+		return param.AuthTokenData{}, err
+	}
+
+	// 轮换是原子的：旧令牌若已被使用过，整个令牌族会被吊销
+	record, err := h.tokens.Rotate(ctx, auth.HashToken(req.RefreshToken), pair.record)
+	if err != nil {
+		return param.AuthTokenData{}, err
+	}
+	pair.record = record
+
+	user, err := h.repo.FindByID(ctx, record.UserID)
+	if err != nil {
+		if errors.IsCode(err, code.ErrUserNotFound) {
+			_ = h.tokens.RevokeFamily(ctx, record.Family)
+			return param.AuthTokenData{}, code.NewTokenInvalidError()
+		}
+		return param.AuthTokenData{}, err
+	}
+	if err := checkUserStatus(user); err != nil {
+		_ = h.tokens.RevokeFamily(ctx, record.Family)
+		return param.AuthTokenData{}, err
+	}
+	// 角色可能在两次刷新之间变化，每次刷新重新加载；租户沿用登录时选定的租户
+	claims, err := h.accessClaims(ctx, user, record.TenantID)
+	if err != nil {
+		_ = h.tokens.RevokeFamily(ctx, record.Family)
+		return param.AuthTokenData{}, err
+	}
+
+	return h.sign(user, claims, pair)
+}
+
+func (h *AuthHandler) Logout(ctx context.Context, req param.AuthLogoutRequest) error {
+	return h.tokens.RevokeByToken(ctx, auth.HashToken(req.RefreshToken))
+}
+
+func (h *AuthHandler) RevokeUser(ctx context.Context, userID int64) error {
+	return h.tokens.RevokeUser(ctx, userID)
+}
+
+func (h *AuthHandler) Unlock(ctx context.Context, req param.AuthUnlockRequest) error {
+	return h.guard.Unlock(ctx, req.Username)
+}
+
+func (h *AuthHandler) CheckAccount(ctx context.Context, userID int64) error {
+	user, err := h.repo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.IsCode(err, code.ErrUserNotFound) {
+			return code.NewTokenInvalidError()
+		}
+		return err
+	}
+	return checkUserStatus(user)
+}
+
+// loginFailed 记录失败并统一返回用户名或密码错误
+func (h *AuthHandler) loginFailed(ctx context.Context, username, ip string, cause error) error {
+	if err := h.guard.Fail(ctx, username, ip); err != nil {
+		return err
+	}
+	if cause != nil {
+		return code.WrapError(cause, code.ErrPasswordIncorrect, "invalid username or password")
+	}
+	return code.NewError(code.ErrPasswordIncorrect, "invalid username or password")
+}
+
+// checkUserStatus 拒绝已禁用的账号
+func checkUserStatus(user *model.User) error {
+	if user.Status == UserStatusDisabled {
+		return code.NewError(code.ErrAccountDisabled, "account is disabled")
+	}
+	return nil
+}
+
+// tokenPair 待签发的令牌对，刷新令牌明文只在响应中出现一次
+type tokenPair struct {
+	refresh string
+	record  auth.RefreshToken
+}
+
+// newTokenPair 生成刷新令牌与访问令牌 jti，令牌族与用户由调用方补全
+func (h *AuthHandler) newTokenPair() (tokenPair, error) {
+	refresh, err := newRefreshToken()
+	if err != nil {
+		return tokenPair{}, code.WrapInternalServerError(err, "generate refresh token failed")
+	}
+
+	now := h.now()
+	return tokenPair{
+		refresh: refresh,
+		record: auth.RefreshToken{
+			Hash:            auth.HashToken(refresh),
+			AccessJTI:       uuid.NewString(),
+			AccessExpiresAt: now.Add(h.accessTTL()),
+			ExpiresAt:       now.Add(h.refreshTTL()),
+		},
+	}, nil
+}
+
+// accessClaims 访问令牌中的角色、租户与管理员标记
+type accessClaims struct {
+	roles    []string
+	tenantID string
+	admin    bool
+}
+
+func (h *AuthHandler) accessClaims(ctx context.Context, user *model.User, tenantID string) (accessClaims, error) {
+	return loadAccessClaims(ctx, h.rbac, h.admins, user.ID, tenantID)
+}
+
+// loadAccessClaims 从策略中加载用户的角色与租户，持有 admins 中任一角色的用户标记为管理员。
+// 域模型下租户必须是用户有角色分配的域之一：tenantID 为空且用户只属于一个域时取该域，属于多个域时必须指定租户；
+// 非域模型下没有租户归属，忽略 tenantID。
+func loadAccessClaims(ctx context.Context, rbac RbacRepository, admins []string, userID int64, tenantID string) (accessClaims, error) {
+	if rbac == nil {
+		return accessClaims{}, nil
+	}
+	subject := strconv.FormatInt(userID, 10)
+
+	domain := ""
+	if rbac.Domains() {
+		rules, err := rbac.Roles(ctx, subject)
+		if err != nil {
+			return accessClaims{}, err
+		}
+		var domains []string
+		for _, rule := range rules {
+			if len(rule) > 2 && !slices.Contains(domains, rule[2]) {
+				domains = append(domains, rule[2])
+			}
+		}
+		switch {
+		case tenantID != "":
+			if !slices.Contains(domains, tenantID) {
+				return accessClaims{}, code.NewErrorf(code.ErrTenantMismatch, "user does not belong to tenant %s", tenantID)
+			}
+			domain = tenantID
+		case len(domains) == 1:
+			domain = domains[0]
+		case len(domains) > 1:
+			return accessClaims{}, code.NewError(code.ErrTenantRequired, "user belongs to multiple tenants, tenant is required")
+		default:
+			// 不属于任何域的用户没有角色
+			return accessClaims{}, nil
+		}
+	}
+
+	_, roles, err := rbac.RolesForUser(ctx, subject, domain)
+	if err != nil {
+		return accessClaims{}, err
+	}
+	admin := slices.ContainsFunc(roles, func(role string) bool {
+		return slices.Contains(admins, role)
+	})
+	return accessClaims{roles: roles, tenantID: domain, admin: admin}, nil
+}
+
+// sign 签发访问令牌并组装响应
+func (h *AuthHandler) sign(user *model.User, claims accessClaims, pair tokenPair) (param.AuthTokenData, error) {
+	access, err := h.signAccessToken(user, claims, pair.record.AccessJTI, pair.record.AccessExpiresAt)
+	if err != nil {
+		return param.AuthTokenData{}, err
+	}
+
+	return param.AuthTokenData{
+		AccessToken:      access,
+		RefreshToken:     pair.refresh,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(h.accessTTL() / time.Second),
+		RefreshExpiresIn: int64(h.refreshTTL() / time.Second),
+	}, nil
+}
+
+func (h *AuthHandler) signAccessToken(user *model.User, access accessClaims, jti string, expiresAt time.Time) (string, error) {
+	now := h.now()
+	claims := &utils.JwtCustomClaims{
+		Name:     user.Username,
+		ID:       user.ID,
+		Admin:    access.admin,
+		Roles:    access.roles,
+		TenantID: access.tenantID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    h.jwt.Issuer,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	signed, err := h.keys.Sign(claims)
+	if err != nil {
+		return "", code.WrapInternalServerError(err, "sign access token failed")
+	}
+	return signed, nil
+}
+
+func (h *AuthHandler) accessTTL() time.Duration {
+	if h.jwt.Expire > 0 {
+		return time.Duration(h.jwt.Expire) * time.Second
+	}
+	return DefaultAccessTokenTTL
+}
+
+func (h *AuthHandler) refreshTTL() time.Duration {
+	if h.jwt.RefreshExpire > 0 {
+		return time.Duration(h.jwt.RefreshExpire) * time.Second
+	}
+	return DefaultRefreshTokenTTL
+}
+
+// newRefreshToken 生成 256 位随机刷新令牌
+func newRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,413 @@
+package biz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/auth"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAuthRepository 模拟认证数据访问接口
+type MockAuthRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuthRepository) FindByUsername(ctx context.Context, username string) (*model.User, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockAuthRepository) FindByID(ctx context.Context, id int64) (*model.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockAuthRepository) UpdatePassword(ctx context.Context, id int64, hash string) error {
+	args := m.Called(ctx, id, hash)
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) UpdateLastLogin(ctx context.Context, id int64, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+
+func (m *MockAuthRepository) MarkEmailVerified(ctx context.Context, id int64, email string, at time.Time) error {
+	args := m.Called(ctx, id, email, at)
+	return args.Error(0)
+}
+
+func newTestAuthHandler(t *testing.T, repo AuthRepository, tokens auth.TokenStore) *AuthHandler {
+	t.Helper()
+	return newTestMfaAuthHandler(t, repo, newFakeMfaRepository(), tokens)
+}
+
+func newTestMfaAuthHandler(t *testing.T, repo AuthRepository, mfa MfaRepository, tokens auth.TokenStore) *AuthHandler {
+	t.Helper()
+	utils.SetBcryptCost(4)
+	t.Cleanup(func() { utils.SetBcryptCost(utils.DefaultBcryptCost) })
+
+	cfg := configs.Config{JWT: configs.JWTConfig{Secret: "test-secret", Expire: 60, RefreshExpire: 600, Issuer: "test"}}
+	keys, err := auth.NewKeySet(cfg)
+	require.NoError(t, err)
+	guard := auth.NewLoginGuard(auth.NewMemoryAttemptStore(), cfg)
+	signer, err := auth.NewActionTokenSigner(cfg)
+	require.NoError(t, err)
+	return NewAuthHandler(AuthDeps{
+		Repo:       repo,
+		Mfa:        mfa,
+		Identities: newFakeIdentityRepository(),
+		Tokens:     tokens,
+		Challenges: auth.NewMemoryChallengeStore(),
+		States:     auth.NewMemoryStateStore(),
+		Providers:  &auth.IdentityProviders{},
+		Keys:       keys,
+		Guard:      guard,
+		Actions:    auth.NewMemoryActionTokenStore(),
+		Signer:     signer,
+		Notifier:   &fakeNotifier{},
+		Config:     cfg,
+	}).(*AuthHandler)
+}
+
+func TestAuthHandler_Login(t *testing.T) {
+	utils.SetBcryptCost(4)
+	hash, err := utils.Hash("secret-pass")
+	require.NoError(t, err)
+	utils.SetBcryptCost(utils.DefaultBcryptCost)
+
+	tests := []struct {
+		name      string
+		password  string
+		setupMock func(*MockAuthRepository)
+		wantCode  int
+	}{
+		{
+			name:     "成功场景",
+			password: "secret-pass",
+			setupMock: func(m *MockAuthRepository) {
+				m.On("FindByUsername", mock.Anything, "alice").Return(&model.User{ID: 7, Username: "alice", Password: hash}, nil)
+				m.On("UpdateLastLogin", mock.Anything, int64(7), mock.Anything).Return(nil)
+			},
+		},
+		{
+			name:     "密码错误",
+			password: "wrong-pass",
+			setupMock: func(m *MockAuthRepository) {
+				m.On("FindByUsername", mock.Anything, "alice").Return(&model.User{ID: 7, Username: "alice", Password: hash}, nil)
+			},
+			wantCode: code.ErrPasswordIncorrect,
+		},
+		{
+			name:     "用户不存在",
+			password: "secret-pass",
+			setupMock: func(m *MockAuthRepository) {
+				m.On("FindByUsername", mock.Anything, "alice").Return(nil, code.NewError(code.ErrUserNotFound, "user not found"))
+			},
+			wantCode: code.ErrPasswordIncorrect,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockAuthRepository)
+			tt.setupMock(repo)
+			handler := newTestAuthHandler(t, repo, auth.NewMemoryTokenStore())
+
+			result, err := handler.Login(context.Background(), param.AuthLoginRequest{Username: "alice", Password: tt.password})
+			if tt.wantCode != 0 {
+				assert.True(t, errors.IsCode(err, tt.wantCode), "unexpected error: %v", err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "Bearer", result.TokenType)
+			assert.Equal(t, int64(60), result.ExpiresIn)
+			assert.Equal(t, int64(600), result.RefreshExpiresIn)
+			assert.NotEmpty(t, result.RefreshToken)
+
+			claims := &utils.JwtCustomClaims{}
+			_, err = jwt.ParseWithClaims(result.AccessToken, claims, func(*jwt.Token) (interface{}, error) {
+				return []byte("test-secret"), nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, int64(7), claims.ID)
+			assert.Equal(t, "alice", claims.Name)
+			assert.Equal(t, "7", claims.Subject)
+			assert.NotEmpty(t, claims.RegisteredClaims.ID)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthHandler_AccessClaims(t *testing.T) {
+	tests := []struct {
+		name      string
+		tenantID  string
+		domains   [][]string
+		roles     []string
+		wantRoles []string
+		wantAdmin bool
+		wantCode  int
+	}{
+		{
+			name:      "唯一的域作为租户",
+			domains:   [][]string{{"7", "admin", "acme"}},
+			roles:     []string{"admin"},
+			wantRoles: []string{"admin"},
+			wantAdmin: true,
+		},
+		{
+			name:      "指定所属的租户",
+			tenantID:  "acme",
+			domains:   [][]string{{"7", "editor", "acme"}, {"7", "viewer", "globex"}},
+			roles:     []string{"editor", "viewer"},
+			wantRoles: []string{"editor", "viewer"},
+		},
+		{
+			name:     "不属于指定的租户",
+			tenantID: "globex",
+			domains:  [][]string{{"7", "admin", "acme"}},
+			wantCode: code.ErrTenantMismatch,
+		},
+		{
+			name:     "属于多个租户时必须指定",
+			domains:  [][]string{{"7", "editor", "acme"}, {"7", "viewer", "globex"}},
+			wantCode: code.ErrTenantRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockAuthRepository)
+			handler := newTestAuthHandler(t, repo, auth.NewMemoryTokenStore())
+			rbac := &MockRbacRepository{domains: true}
+			rbac.On("Roles", mock.Anything, []string{"7"}).Return(tt.domains, nil)
+			rbac.On("RolesForUser", mock.Anything, "7", mock.Anything).Return(tt.roles, tt.roles, nil)
+			handler.rbac = rbac
+
+			hash, err := utils.Hash("secret-pass")
+			require.NoError(t, err)
+			repo.On("FindByUsername", mock.Anything, "alice").Return(&model.User{ID: 7, Username: "alice", Password: hash}, nil)
+			repo.On("UpdateLastLogin", mock.Anything, int64(7), mock.Anything).Return(nil)
+			ctx := utils.WithTenantID(context.Background(), tt.tenantID)
+
+			result, err := handler.Login(ctx, param.AuthLoginRequest{Username: "alice", Password: "secret-pass"})
+			if tt.wantCode != 0 {
+				assert.True(t, errors.IsCode(err, tt.wantCode), "unexpected error: %v", err)
+				return
+			}
+			require.NoError(t, err)
+			claims := accessClaimsForTest(t, result.AccessToken)
+			assert.Equal(t, "acme", claims.TenantID)
+			assert.Equal(t, tt.wantRoles, claims.Roles)
+			assert.Equal(t, tt.wantAdmin, claims.Admin)
+
+			// 刷新沿用登录时的租户，不再读取请求所属租户
+			repo.On("FindByID", mock.Anything, int64(7)).Return(&model.User{ID: 7, Username: "alice"}, nil)
+			refreshed, err := handler.Refresh(context.Background(), param.AuthRefreshRequest{RefreshToken: result.RefreshToken})
+			require.NoError(t, err)
+			claims = accessClaimsForTest(t, refreshed.AccessToken)
+			assert.Equal(t, "acme", claims.TenantID)
+			assert.Equal(t, tt.wantRoles, claims.Roles)
+			assert.Equal(t, tt.wantAdmin, claims.Admin)
+			rbac.AssertCalled(t, "RolesForUser", mock.Anything, "7", "acme")
+		})
+	}
+}
+
+func TestAuthHandler_LoginRehash(t *testing.T) {
+	utils.SetBcryptCost(4)
+	legacy, err := utils.Hash("secret-pass")
+	require.NoError(t, err)
+
+	repo := new(MockAuthRepository)
+	repo.On("FindByUsername", mock.Anything, "alice").Return(&model.User{ID: 7, Username: "alice", Password: legacy}, nil)
+	repo.On("UpdatePassword", mock.Anything, int64(7), mock.MatchedBy(func(h string) bool { return h != legacy })).Return(nil)
+	repo.On("UpdateLastLogin", mock.Anything, int64(7), mock.Anything).Return(nil)
+
+	handler := newTestAuthHandler(t, repo, auth.NewMemoryTokenStore())
+	// 提升 cost 后旧哈希需要重算
+	utils.SetBcryptCost(5)
+
+	_, err = handler.Login(context.Background(), param.AuthLoginRequest{Username: "alice", Password: "secret-pass"})
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestAuthHandler_LoginLockout(t *testing.T) {
+	utils.SetBcryptCost(4)
+	hash, err := utils.Hash("secret-pass")
+	require.NoError(t, err)
+
+	repo := new(MockAuthRepository)
+	repo.On("FindByUsername", mock.Anything, "alice").Return(&model.User{ID: 7, Username: "alice", Password: hash}, nil)
+	handler := newTestAuthHandler(t, repo, auth.NewMemoryTokenStore())
+	ctx := context.Background()
+
+	_, err = handler.Login(ctx, param.AuthLoginRequest{Username: "alice", Password: "wrong-pass"})
+	assert.True(t, errors.IsCode(err, code.ErrPasswordIncorrect))
+
+	// 退避期内即使密码正确也拒绝，且不再校验密码
+	_, err = handler.Login(ctx, param.AuthLoginRequest{Username: "alice", Password: "secret-pass"})
+	assert.True(t, errors.IsCode(err, code.ErrTooManyAttempts))
+	repo.AssertNumberOfCalls(t, "FindByUsername", 1)
+
+	// 管理员解锁后可以登录
+	repo.On("UpdateLastLogin", mock.Anything, int64(7), mock.Anything).Return(nil)
+	require.NoError(t, handler.Unlock(ctx, param.AuthUnlockRequest{Username: "alice"}))
+	_, err = handler.Login(ctx, param.AuthLoginRequest{Username: "alice", Password: "secret-pass"})
+	assert.NoError(t, err)
+}
+
+func TestAuthHandler_DisabledUser(t *testing.T) {
+	utils.SetBcryptCost(4)
+	hash, err := utils.Hash("secret-pass")
+	require.NoError(t, err)
+
+	repo := new(MockAuthRepository)
+	tokens := auth.NewMemoryTokenStore()
+	handler := newTestAuthHandler(t, repo, tokens)
+	login := loginForTest(t, handler, repo)
+
+	// 登录后被禁用：刷新与访问令牌均被拒绝
+	repo.On("FindByID", mock.Anything, int64(7)).Return(&model.User{ID: 7, Username: "alice", Status: UserStatusDisabled}, nil)
+	_, err = handler.Refresh(context.Background(), param.AuthRefreshRequest{RefreshToken: login.RefreshToken})
+	assert.True(t, errors.IsCode(err, code.ErrAccountDisabled))
+	err = handler.CheckAccount(context.Background(), 7)
+	assert.True(t, errors.IsCode(err, code.ErrAccountDisabled))
+	revoked, err := tokens.IsRevoked(context.Background(), accessJTI(t, login.AccessToken))
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	repo = new(MockAuthRepository)
+	repo.On("FindByUsername", mock.Anything, "bob").Return(&model.User{ID: 8, Username: "bob", Password: hash, Status: UserStatusDisabled}, nil)
+	handler = newTestAuthHandler(t, repo, tokens)
+	_, err = handler.Login(context.Background(), param.AuthLoginRequest{Username: "bob", Password: "secret-pass"})
+	assert.True(t, errors.IsCode(err, code.ErrAccountDisabled))
+}
+
+// loginForTest 登录并返回令牌对
+func loginForTest(t *testing.T, handler *AuthHandler, repo *MockAuthRepository) param.AuthTokenData {
+	t.Helper()
+	hash, err := utils.Hash("secret-pass")
+	require.NoError(t, err)
+	repo.On("FindByUsername", mock.Anything, "alice").Return(&model.User{ID: 7, Username: "alice", Password: hash}, nil)
+	repo.On("UpdateLastLogin", mock.Anything, int64(7), mock.Anything).Return(nil)
+
+	result, err := handler.Login(context.Background(), param.AuthLoginRequest{Username: "alice", Password: "secret-pass"})
+	require.NoError(t, err)
+	return result
+}
+
+// accessJTI 解析访问令牌的 jti
+func accessJTI(t *testing.T, token string) string {
+	t.Helper()
+	return accessClaimsForTest(t, token).RegisteredClaims.ID
+}
+
+// accessClaimsForTest 解析访问令牌的声明
+func accessClaimsForTest(t *testing.T, token string) *utils.JwtCustomClaims {
+	t.Helper()
+	claims := &utils.JwtCustomClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte("test-secret"), nil
+	})
+	require.NoError(t, err)
+	return claims
+}
+
+func TestAuthHandler_RefreshRotatesToken(t *testing.T) {
+	repo := new(MockAuthRepository)
+	repo.On("FindByID", mock.Anything, int64(7)).Return(&model.User{ID: 7, Username: "alice"}, nil)
+
+	tokens := auth.NewMemoryTokenStore()
+	handler := newTestAuthHandler(t, repo, tokens)
+	login := loginForTest(t, handler, repo)
+
+	result, err := handler.Refresh(context.Background(), param.AuthRefreshRequest{RefreshToken: login.RefreshToken})
+	require.NoError(t, err)
+	assert.NotEqual(t, login.RefreshToken, result.RefreshToken)
+
+	// 轮换后旧访问令牌被吊销
+	revoked, err := tokens.IsRevoked(context.Background(), accessJTI(t, login.AccessToken))
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	_, err = handler.Refresh(context.Background(), param.AuthRefreshRequest{RefreshToken: result.RefreshToken})
+	assert.NoError(t, err)
+}
+
+func TestAuthHandler_RefreshReuseRevokesFamily(t *testing.T) {
+	repo := new(MockAuthRepository)
+	repo.On("FindByID", mock.Anything, int64(7)).Return(&model.User{ID: 7, Username: "alice"}, nil)
+
+	tokens := auth.NewMemoryTokenStore()
+	handler := newTestAuthHandler(t, repo, tokens)
+	login := loginForTest(t, handler, repo)
+
+	rotated, err := handler.Refresh(context.Background(), param.AuthRefreshRequest{RefreshToken: login.RefreshToken})
+	require.NoError(t, err)
+
+	// 旧令牌被重放：拒绝并吊销整个令牌族
+	_, err = handler.Refresh(context.Background(), param.AuthRefreshRequest{RefreshToken: login.RefreshToken})
+	assert.True(t, errors.IsCode(err, code.ErrTokenInvalid))
+
+	_, err = handler.Refresh(context.Background(), param.AuthRefreshRequest{RefreshToken: rotated.RefreshToken})
+	assert.True(t, errors.IsCode(err, code.ErrTokenInvalid))
+	revoked, err := tokens.IsRevoked(context.Background(), accessJTI(t, rotated.AccessToken))
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestAuthHandler_Logout(t *testing.T) {
+	repo := new(MockAuthRepository)
+	tokens := auth.NewMemoryTokenStore()
+	handler := newTestAuthHandler(t, repo, tokens)
+	login := loginForTest(t, handler, repo)
+
+	require.NoError(t, handler.Logout(context.Background(), param.AuthLogoutRequest{RefreshToken: login.RefreshToken}))
+
+	_, err := handler.Refresh(context.Background(), param.AuthRefreshRequest{RefreshToken: login.RefreshToken})
+	assert.True(t, errors.IsCode(err, code.ErrTokenInvalid))
+	revoked, err := tokens.IsRevoked(context.Background(), accessJTI(t, login.AccessToken))
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func TestAuthHandler_RevokeUser(t *testing.T) {
+	repo := new(MockAuthRepository)
+	tokens := auth.NewMemoryTokenStore()
+	handler := newTestAuthHandler(t, repo, tokens)
+	login := loginForTest(t, handler, repo)
+
+	require.NoError(t, handler.RevokeUser(context.Background(), 7))
+
+	_, err := handler.Refresh(context.Background(), param.AuthRefreshRequest{RefreshToken: login.RefreshToken})
+	assert.True(t, errors.IsCode(err, code.ErrTokenInvalid))
+}
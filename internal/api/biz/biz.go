@@ -10,4 +10,10 @@ import (
 	"go.uber.org/fx"
 )
 
-var Model = fx.Options(fx.Provide(NewUserHandler))
+var Model = fx.Options(
+	fx.Provide(NewUserHandler),
+	fx.Provide(NewAuthHandler),
+	fx.Provide(NewAPIKeyHandler),
+	fx.Provide(NewRbacHandler),
+	fx.Provide(NewConfigHandler),
+)
@@ -0,0 +1,98 @@
+/*
+ * Module: Config
+ * 配置历史版本查询与运行时回滚
+ */
+
+package biz
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/marmotedu/errors"
+)
+
+// ConfigUseCase 配置历史业务逻辑接口
+type ConfigUseCase interface {
+	// ListVersions 查询保留的历史版本，最新的在前
+	ListVersions(ctx context.Context) ([]param.ConfigVersionData, error)
+
+	// GetVersion 查询历史版本，不存在时返回 code.ErrConfigVersionNotFound
+	GetVersion(ctx context.Context, id int64) (param.ConfigVersionData, error)
+
+	// Rollback 回滚到历史版本，返回回滚产生的新版本
+	Rollback(ctx context.Context, id int64) (param.ConfigVersionData, error)
+}
+
+// ConfigHandler 配置历史业务逻辑处理器
+type ConfigHandler struct {
+	store *configs.Store
+}
+
+// NewConfigHandler 创建配置历史业务逻辑处理器
+func NewConfigHandler(store *configs.Store) ConfigUseCase {
+	return &ConfigHandler{store: store}
+}
+
+func (h *ConfigHandler) ListVersions(ctx context.Context) ([]param.ConfigVersionData, error) {
+	versions := h.store.RedactedVersions()
+	list := make([]param.ConfigVersionData, len(versions))
+	for i, v := range versions {
+		list[i] = toConfigVersionData(v)
+	}
+	return list, nil
+}
+
+func (h *ConfigHandler) GetVersion(ctx context.Context, id int64) (param.ConfigVersionData, error) {
+	for _, v := range h.store.RedactedVersions() {
+		if v.ID == id {
+			return toConfigVersionData(v), nil
+		}
+	}
+	return param.ConfigVersionData{}, code.NewError(code.ErrConfigVersionNotFound, "config version not found")
+}
+
+func (h *ConfigHandler) Rollback(ctx context.Context, id int64) (param.ConfigVersionData, error) {
+	v, err := h.store.Rollback(id)
+	if err != nil {
+		return param.ConfigVersionData{}, rollbackError(err)
+	}
+	operator := ""
+	if p, ok := utils.PrincipalFromContext(ctx); ok {
+		operator = p.Name
+	}
+	slog.Warn("config rolled back", slog.Int64("target", id), slog.Int64("version", v.ID), slog.String("operator", operator))
+	return h.GetVersion(ctx, v.ID)
+}
+
+// rollbackError 版本不存在返回 code.ErrConfigVersionNotFound，目标配置校验失败返回 code.ErrValidation，其余为内部错误
+func rollbackError(err error) error {
+	var verr *configs.ValidationError
+	switch {
+	case errors.Is(err, configs.ErrVersionNotFound):
+		return code.WrapError(err, code.ErrConfigVersionNotFound, "config version not found")
+	case errors.As(err, &verr):
+		return code.WrapError(err, code.ErrValidation, "config version is invalid")
+	default:
+		return code.WrapInternalServerError(err, "rollback config failed")
+	}
+}
+
+func toConfigVersionData(v configs.Version) param.ConfigVersionData {
+	changes := make([]param.ConfigFieldChange, len(v.Changes))
+	for i, c := range v.Changes {
+		changes[i] = param.ConfigFieldChange{Key: c.Key, Old: c.Old, New: c.New}
+	}
+	return param.ConfigVersionData{
+		ID:         v.ID,
+		Source:     v.Source,
+		Revision:   v.Revision,
+		RollbackOf: v.RollbackOf,
+		Timestamp:  v.Timestamp,
+		Changes:    changes,
+	}
+}
@@ -0,0 +1,58 @@
+package biz
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigHandler_Rollback(t *testing.T) {
+	store := configs.NewStore(configs.Config{JWT: configs.JWTConfig{Secret: "old-secret"}, Log: configs.LogConfig{Level: "info"}})
+	c := store.Current()
+	c.JWT.Secret = "new-secret"
+	c.Log.Level = "debug"
+	store.Update(c)
+	h := NewConfigHandler(store)
+	ctx := context.Background()
+
+	list, err := h.ListVersions(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, int64(2), list[0].ID)
+	assert.Equal(t, configs.SourceUpdate, list[0].Source)
+	// 密钥在变化记录中隐藏
+	require.Len(t, list[0].Changes, 2)
+	assert.Equal(t, "jwt.secret", list[0].Changes[0].Key)
+	assert.NotEqual(t, "new-secret", list[0].Changes[0].New)
+	assert.Equal(t, "debug", list[0].Changes[1].New)
+
+	v, err := h.Rollback(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), v.ID)
+	assert.Equal(t, int64(1), v.RollbackOf)
+	assert.Equal(t, "info", store.Current().Log.Level)
+
+	_, err = h.GetVersion(ctx, 9)
+	assert.True(t, errors.IsCode(err, code.ErrConfigVersionNotFound))
+	_, err = h.Rollback(ctx, 9)
+	assert.True(t, errors.IsCode(err, code.ErrConfigVersionNotFound))
+}
+
+func TestConfigHandler_RollbackError(t *testing.T) {
+	err := rollbackError(configs.ErrVersionNotFound)
+	assert.True(t, errors.IsCode(err, code.ErrConfigVersionNotFound))
+
+	err = rollbackError(&configs.ValidationError{Fields: []configs.FieldError{{Key: "log.level", Message: "is invalid"}}})
+	assert.True(t, errors.IsCode(err, code.ErrValidation))
+	var verr *configs.ValidationError
+	assert.True(t, errors.As(err, &verr))
+
+	err = rollbackError(errors.New("boom"))
+	assert.True(t, errors.IsCode(err, code.ErrInternalServer))
+	assert.False(t, errors.IsCode(err, code.ErrConfigVersionNotFound))
+}
@@ -0,0 +1,293 @@
+/*
+ * Module: Auth
+ * TOTP 两步验证：登记、启用、登录第二步与管理员重置
+ */
+
+package biz
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"strconv"
+	"strings"
+
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/auth"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/marmotedu/errors"
+)
+
+const (
+	// DefaultRecoveryCodes auth.mfa.recovery_codes 未配置时生成的恢复码数量
+	DefaultRecoveryCodes = 10
+	// DefaultMfaIssuer auth.mfa.issuer 与 jwt.issuer 均未配置时的服务名称
+	DefaultMfaIssuer = "go-template"
+)
+
+// MfaRepository 两步验证数据访问接口
+type MfaRepository interface {
+	// FindMfa 查询用户的两步验证设置，未登记时返回 code.ErrMfaNotEnrolled
+	FindMfa(ctx context.Context, userID int64) (*model.UserMfa, error)
+
+	// SaveMfaSecret 登记尚未启用的密钥，覆盖之前未确认的密钥
+	SaveMfaSecret(ctx context.Context, userID int64, secret string) error
+
+	// EnableMfa 启用两步验证，记录确认时使用的步长并替换全部恢复码
+	EnableMfa(ctx context.Context, userID, step int64, recoveryHashes []string) error
+
+	// UseTotpStep 记录已使用的步长，step 不晚于上次使用的步长时返回 false
+	UseTotpStep(ctx context.Context, userID, step int64) (bool, error)
+
+	// RecoveryCodes 返回未使用的恢复码
+	RecoveryCodes(ctx context.Context, userID int64) ([]*model.UserMfaRecoveryCode, error)
+
+	// UseRecoveryCode 标记恢复码已使用，已被使用过时返回 false
+	UseRecoveryCode(ctx context.Context, id int64) (bool, error)
+
+	// DeleteMfa 删除两步验证设置及全部恢复码
+	DeleteMfa(ctx context.Context, userID int64) error
+}
+
+func (h *AuthHandler) EnrollMfa(ctx context.Context) (param.MfaEnrollData, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return param.MfaEnrollData{}, err
+	}
+	if err := h.ensureMfaDisabled(ctx, userID); err != nil {
+		return param.MfaEnrollData{}, err
+	}
+	user, err := h.repo.FindByID(ctx, userID)
+	if err != nil {
+		return param.MfaEnrollData{}, err
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return param.MfaEnrollData{}, code.WrapInternalServerError(err, "generate totp secret failed")
+	}
+	if err := h.mfa.SaveMfaSecret(ctx, userID, secret); err != nil {
+		return param.MfaEnrollData{}, err
+	}
+
+	return param.MfaEnrollData{
+		Secret: secret,
+		URI:    auth.TOTPURI(h.mfaIssuer(), user.Username, secret),
+	}, nil
+}
+
+func (h *AuthHandler) ActivateMfa(ctx context.Context, req param.MfaActivateRequest) (param.MfaRecoveryCodesData, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return param.MfaRecoveryCodesData{}, err
+	}
+	setting, err := h.mfa.FindMfa(ctx, userID)
+	if err != nil {
+		return param.MfaRecoveryCodesData{}, err
+	}
+	if setting.Enabled {
+		return param.MfaRecoveryCodesData{}, code.NewError(code.ErrMfaAlreadyEnabled, "mfa is already enabled")
+	}
+
+	step, ok := auth.ValidateTOTP(setting.Secret, req.Code, h.now())
+	if !ok {
+		return param.MfaRecoveryCodesData{}, code.NewError(code.ErrMfaCodeInvalid, "invalid verification code")
+	}
+
+	codes, hashes, err := h.newRecoveryCodes()
+	if err != nil {
+		return param.MfaRecoveryCodesData{}, err
+	}
+	if err := h.mfa.EnableMfa(ctx, userID, step, hashes); err != nil {
+		return param.MfaRecoveryCodesData{}, err
+	}
+	return param.MfaRecoveryCodesData{RecoveryCodes: codes}, nil
+}
+
+func (h *AuthHandler) VerifyMfa(ctx context.Context, req param.MfaVerifyRequest) (param.AuthTokenData, error) {
+	challenge := auth.HashToken(req.MfaToken)
+	userID, err := h.challenges.Get(ctx, challenge)
+	if err != nil {
+		return param.AuthTokenData{}, err
+	}
+	user, err := h.repo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.IsCode(err, code.ErrUserNotFound) {
+			return param.AuthTokenData{}, code.NewTokenInvalidError()
+		}
+		return param.AuthTokenData{}, err
+	}
+
+	// 验证码与密码共用失败计数，防止在 mfa_token 有效期内穷举
+	ip := utils.GetClientIP(ctx)
+	if err := h.guard.Check(ctx, user.Username, ip); err != nil {
+		return param.AuthTokenData{}, err
+	}
+	ok, err := h.verifySecondFactor(ctx, userID, req)
+	if err != nil {
+		return param.AuthTokenData{}, err
+	}
+	if !ok {
+		if err := h.guard.Fail(ctx, user.Username, ip); err != nil {
+			return param.AuthTokenData{}, err
+		}
+		return param.AuthTokenData{}, code.NewError(code.ErrMfaCodeInvalid, "invalid verification code")
+	}
+
+	// 并发提交同一 mfa_token 时只有一个请求能完成登录
+	if _, err := h.challenges.Consume(ctx, challenge); err != nil {
+		return param.AuthTokenData{}, err
+	}
+	if err := h.guard.Succeed(ctx, user.Username); err != nil {
+		return param.AuthTokenData{}, err
+	}
+	if err := checkUserStatus(user); err != nil {
+		return param.AuthTokenData{}, err
+	}
+	return h.issue(ctx, user)
+}
+
+func (h *AuthHandler) ResetMfa(ctx context.Context, userID int64) error {
+	return h.mfa.DeleteMfa(ctx, userID)
+}
+
+// mfaChallenge 账号已启用两步验证时签发 mfa_token，否则返回空值
+func (h *AuthHandler) mfaChallenge(ctx context.Context, userID int64) (param.AuthTokenData, bool, error) {
+	setting, err := h.mfa.FindMfa(ctx, userID)
+	if err != nil {
+		if errors.IsCode(err, code.ErrMfaNotEnrolled) {
+			return param.AuthTokenData{}, false, nil
+		}
+		return param.AuthTokenData{}, false, err
+	}
+	if !setting.Enabled {
+		return param.AuthTokenData{}, false, nil
+	}
+
+	token, err := newRefreshToken()
+	if err != nil {
+		return param.AuthTokenData{}, false, code.WrapInternalServerError(err, "generate mfa token failed")
+	}
+	ttl := h.mfaCfg.ChallengeTTL
+	if ttl <= 0 {
+		ttl = auth.DefaultChallengeTTL
+	}
+	if err := h.challenges.Issue(ctx, auth.HashToken(token), userID, ttl); err != nil {
+		return param.AuthTokenData{}, false, err
+	}
+	return param.AuthTokenData{
+		MfaRequired: true,
+		MfaToken:    token,
+		ExpiresIn:   int64(ttl.Seconds()),
+	}, true, nil
+}
+
+// verifySecondFactor 校验验证码或恢复码，两者都会被标记为已使用
+func (h *AuthHandler) verifySecondFactor(ctx context.Context, userID int64, req param.MfaVerifyRequest) (bool, error) {
+	setting, err := h.mfa.FindMfa(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if !setting.Enabled {
+		return false, code.NewError(code.ErrMfaNotEnrolled, "mfa is not enabled")
+	}
+
+	if req.Code != "" {
+		step, ok := auth.ValidateTOTP(setting.Secret, req.Code, h.now())
+		if !ok {
+			return false, nil
+		}
+		return h.mfa.UseTotpStep(ctx, userID, step)
+	}
+
+	recovery := normalizeRecoveryCode(req.RecoveryCode)
+	codes, err := h.mfa.RecoveryCodes(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range codes {
+		if ok, _, err := utils.Verify(c.Hash, recovery); err == nil && ok {
+			return h.mfa.UseRecoveryCode(ctx, c.ID)
+		}
+	}
+	return false, nil
+}
+
+func (h *AuthHandler) ensureMfaDisabled(ctx context.Context, userID int64) error {
+	setting, err := h.mfa.FindMfa(ctx, userID)
+	if err != nil {
+		if errors.IsCode(err, code.ErrMfaNotEnrolled) {
+			return nil
+		}
+		return err
+	}
+	if setting.Enabled {
+		return code.NewError(code.ErrMfaAlreadyEnabled, "mfa is already enabled")
+	}
+	return nil
+}
+
+// newRecoveryCodes 生成恢复码明文与对应哈希，明文只在启用时返回一次
+func (h *AuthHandler) newRecoveryCodes() ([]string, []string, error) {
+	n := h.mfaCfg.RecoveryCodes
+	if n <= 0 {
+		n = DefaultRecoveryCodes
+	}
+
+	codes := make([]string, 0, n)
+	hashes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		c, err := newRecoveryCode()
+		if err != nil {
+			return nil, nil, code.WrapInternalServerError(err, "generate recovery code failed")
+		}
+		hash, err := utils.Hash(normalizeRecoveryCode(c))
+		if err != nil {
+			return nil, nil, code.WrapInternalServerError(err, "hash recovery code failed")
+		}
+		codes = append(codes, c)
+		hashes = append(hashes, hash)
+	}
+	return codes, hashes, nil
+}
+
+func (h *AuthHandler) mfaIssuer() string {
+	if h.mfaCfg.Issuer != "" {
+		return h.mfaCfg.Issuer
+	}
+	if h.jwt.Issuer != "" {
+		return h.jwt.Issuer
+	}
+	return DefaultMfaIssuer
+}
+
+// newRecoveryCode 生成形如 abcde-fghij 的 50 位随机恢复码
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	s := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))[:10]
+	return s[:5] + "-" + s[5:], nil
+}
+
+// normalizeRecoveryCode 忽略大小写、空格与分隔符
+func normalizeRecoveryCode(c string) string {
+	c = strings.ToLower(c)
+	return strings.NewReplacer("-", "", " ", "").Replace(c)
+}
+
+// currentUserID 当前请求的认证用户
+func currentUserID(ctx context.Context) (int64, error) {
+	p, ok := utils.PrincipalFromContext(ctx)
+	if !ok {
+		return 0, code.NewTokenInvalidError()
+	}
+	id, err := strconv.ParseInt(p.ID, 10, 64)
+	if err != nil {
+		return 0, code.NewTokenInvalidError()
+	}
+	return id, nil
+}
@@ -0,0 +1,235 @@
+package biz
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/auth"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMfaRepository 内存实现的两步验证数据访问
+type fakeMfaRepository struct {
+	mu       sync.Mutex
+	settings map[int64]*model.UserMfa
+	codes    []*model.UserMfaRecoveryCode
+}
+
+func newFakeMfaRepository() *fakeMfaRepository {
+	return &fakeMfaRepository{settings: make(map[int64]*model.UserMfa)}
+}
+
+func (f *fakeMfaRepository) FindMfa(_ context.Context, userID int64) (*model.UserMfa, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.settings[userID]
+	if !ok {
+		return nil, code.NewError(code.ErrMfaNotEnrolled, "mfa is not enrolled")
+	}
+	cp := *s
+	return &cp, nil
+}
+
+func (f *fakeMfaRepository) SaveMfaSecret(_ context.Context, userID int64, secret string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok := f.settings[userID]; ok && s.Enabled {
+		return code.NewError(code.ErrMfaAlreadyEnabled, "mfa is already enabled")
+	}
+	f.settings[userID] = &model.UserMfa{UserID: userID, Secret: secret}
+	return nil
+}
+
+func (f *fakeMfaRepository) EnableMfa(_ context.Context, userID, step int64, hashes []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.settings[userID]
+	s.Enabled = true
+	s.LastStep = step
+	for i, h := range hashes {
+		f.codes = append(f.codes, &model.UserMfaRecoveryCode{ID: int64(i + 1), UserID: userID, Hash: h})
+	}
+	return nil
+}
+
+func (f *fakeMfaRepository) UseTotpStep(_ context.Context, userID, step int64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.settings[userID]
+	if step <= s.LastStep {
+		return false, nil
+	}
+	s.LastStep = step
+	return true, nil
+}
+
+func (f *fakeMfaRepository) RecoveryCodes(_ context.Context, userID int64) ([]*model.UserMfaRecoveryCode, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []*model.UserMfaRecoveryCode
+	for _, c := range f.codes {
+		if c.UserID == userID && c.UsedAt == nil {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeMfaRepository) UseRecoveryCode(_ context.Context, id int64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.codes {
+		if c.ID == id && c.UsedAt == nil {
+			now := time.Now()
+			c.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeMfaRepository) DeleteMfa(_ context.Context, userID int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.settings, userID)
+	return nil
+}
+
+// newMfaTestHandler 返回时钟固定的处理器与已登记用户 alice(7) 的上下文
+func newMfaTestHandler(t *testing.T) (*AuthHandler, *MockAuthRepository, *fakeMfaRepository, context.Context) {
+	t.Helper()
+	repo := new(MockAuthRepository)
+	mfa := newFakeMfaRepository()
+	handler := newTestMfaAuthHandler(t, repo, mfa, auth.NewMemoryTokenStore())
+	now := time.Unix(1700000000, 0)
+	handler.now = func() time.Time { return now }
+	// 连续的失败用例不受退避影响
+	handler.guard = auth.NewLoginGuard(auth.NewMemoryAttemptStore(), configs.Config{Auth: configs.AuthConfig{
+		Lockout: configs.LockoutConfig{BaseDelay: time.Nanosecond, MaxDelay: time.Nanosecond},
+	}})
+
+	hash, err := utils.Hash("secret-pass")
+	require.NoError(t, err)
+	user := &model.User{ID: 7, Username: "alice", Password: hash}
+	repo.On("FindByUsername", mock.Anything, "alice").Return(user, nil)
+	repo.On("FindByID", mock.Anything, int64(7)).Return(user, nil)
+	repo.On("UpdateLastLogin", mock.Anything, int64(7), mock.Anything).Return(nil)
+
+	ctx := utils.WithPrincipal(context.Background(), &utils.Principal{ID: "7", Name: "alice"})
+	return handler, repo, mfa, ctx
+}
+
+func totpForTest(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	c, err := auth.TOTPCode(secret, auth.TOTPStep(at))
+	require.NoError(t, err)
+	return c
+}
+
+func TestAuthHandler_MfaEnrollment(t *testing.T) {
+	handler, _, mfa, ctx := newMfaTestHandler(t)
+
+	enroll, err := handler.EnrollMfa(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, enroll.Secret)
+	assert.Contains(t, enroll.URI, "otpauth://totp/test:alice?")
+
+	// 未确认前登录不受影响
+	login, err := handler.Login(ctx, param.AuthLoginRequest{Username: "alice", Password: "secret-pass"})
+	require.NoError(t, err)
+	assert.False(t, login.MfaRequired)
+	assert.NotEmpty(t, login.AccessToken)
+
+	_, err = handler.ActivateMfa(ctx, param.MfaActivateRequest{Code: "000000"})
+	assert.True(t, errors.IsCode(err, code.ErrMfaCodeInvalid))
+
+	codes, err := handler.ActivateMfa(ctx, param.MfaActivateRequest{Code: totpForTest(t, enroll.Secret, handler.now())})
+	require.NoError(t, err)
+	assert.Len(t, codes.RecoveryCodes, DefaultRecoveryCodes)
+	for _, c := range mfa.codes {
+		assert.NotContains(t, codes.RecoveryCodes, c.Hash)
+	}
+
+	_, err = handler.EnrollMfa(ctx)
+	assert.True(t, errors.IsCode(err, code.ErrMfaAlreadyEnabled))
+
+	_, err = handler.EnrollMfa(context.Background())
+	assert.True(t, errors.IsCode(err, code.ErrTokenInvalid))
+}
+
+func TestAuthHandler_MfaLogin(t *testing.T) {
+	handler, _, _, ctx := newMfaTestHandler(t)
+	enroll, err := handler.EnrollMfa(ctx)
+	require.NoError(t, err)
+	recovery, err := handler.ActivateMfa(ctx, param.MfaActivateRequest{Code: totpForTest(t, enroll.Secret, handler.now())})
+	require.NoError(t, err)
+
+	login := func() string {
+		t.Helper()
+		result, err := handler.Login(ctx, param.AuthLoginRequest{Username: "alice", Password: "secret-pass"})
+		require.NoError(t, err)
+		require.True(t, result.MfaRequired)
+		assert.Empty(t, result.AccessToken)
+		assert.Empty(t, result.RefreshToken)
+		return result.MfaToken
+	}
+
+	// 启用时使用的验证码不能再次用于登录
+	token := login()
+	_, err = handler.VerifyMfa(ctx, param.MfaVerifyRequest{MfaToken: token, Code: totpForTest(t, enroll.Secret, handler.now())})
+	assert.True(t, errors.IsCode(err, code.ErrMfaCodeInvalid))
+
+	later := handler.now().Add(auth.TOTPPeriod)
+	handler.now = func() time.Time { return later }
+	result, err := handler.VerifyMfa(ctx, param.MfaVerifyRequest{MfaToken: token, Code: totpForTest(t, enroll.Secret, later)})
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.AccessToken)
+	assert.NotEmpty(t, result.RefreshToken)
+
+	// mfa_token 只能使用一次
+	_, err = handler.VerifyMfa(ctx, param.MfaVerifyRequest{MfaToken: token, Code: totpForTest(t, enroll.Secret, later)})
+	assert.True(t, errors.IsCode(err, code.ErrTokenInvalid))
+
+	// 恢复码可以替代验证码，但只能使用一次
+	token = login()
+	_, err = handler.VerifyMfa(ctx, param.MfaVerifyRequest{MfaToken: token, RecoveryCode: recovery.RecoveryCodes[0]})
+	require.NoError(t, err)
+	token = login()
+	_, err = handler.VerifyMfa(ctx, param.MfaVerifyRequest{MfaToken: token, RecoveryCode: recovery.RecoveryCodes[0]})
+	assert.True(t, errors.IsCode(err, code.ErrMfaCodeInvalid))
+
+	// 管理员重置后恢复为单因素登录
+	require.NoError(t, handler.ResetMfa(ctx, 7))
+	result, err = handler.Login(ctx, param.AuthLoginRequest{Username: "alice", Password: "secret-pass"})
+	require.NoError(t, err)
+	assert.False(t, result.MfaRequired)
+	assert.NotEmpty(t, result.AccessToken)
+}
+
+func TestAuthHandler_MfaAttemptsLimited(t *testing.T) {
+	handler, _, _, ctx := newMfaTestHandler(t)
+	enroll, err := handler.EnrollMfa(ctx)
+	require.NoError(t, err)
+	_, err = handler.ActivateMfa(ctx, param.MfaActivateRequest{Code: totpForTest(t, enroll.Secret, handler.now())})
+	require.NoError(t, err)
+
+	handler.guard = auth.NewLoginGuard(auth.NewMemoryAttemptStore(), configs.Config{})
+	result, err := handler.Login(ctx, param.AuthLoginRequest{Username: "alice", Password: "secret-pass"})
+	require.NoError(t, err)
+
+	// 验证码错误与密码错误共用退避计数
+	_, err = handler.VerifyMfa(ctx, param.MfaVerifyRequest{MfaToken: result.MfaToken, Code: "000000"})
+	assert.True(t, errors.IsCode(err, code.ErrMfaCodeInvalid))
+	_, err = handler.VerifyMfa(ctx, param.MfaVerifyRequest{MfaToken: result.MfaToken, Code: "000000"})
+	assert.True(t, errors.IsCode(err, code.ErrTooManyAttempts))
+}
@@ -0,0 +1,271 @@
+/*
+ * Module: Auth
+ * OpenID Connect 单点登录：授权跳转、回调换取身份、关联本地用户并签发令牌对
+ */
+
+package biz
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/auth"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/marmotedu/errors"
+)
+
+// IdentityRepository 外部身份关联数据访问接口
+type IdentityRepository interface {
+	// FindIdentity 按提供方与 subject 查询关联，未关联时返回 code.ErrIdentityNotLinked
+	FindIdentity(ctx context.Context, provider, subject string) (*model.UserIdentity, error)
+
+	// FindUserByEmail 按邮箱查询用户，不存在时返回 code.ErrUserNotFound
+	FindUserByEmail(ctx context.Context, email string) (*model.User, error)
+
+	// LinkIdentity 关联外部身份，已关联到其他用户时返回 code.ErrIdentityAlreadyLinked
+	LinkIdentity(ctx context.Context, identity *model.UserIdentity) error
+
+	// CreateUserWithIdentity 创建用户并关联外部身份，用户名已存在时返回 code.ErrUserAlreadyExists
+	CreateUserWithIdentity(ctx context.Context, user *model.User, identity *model.UserIdentity) error
+
+	// UnlinkIdentity 解除用户与提供方的关联，未关联时返回 code.ErrIdentityNotLinked
+	UnlinkIdentity(ctx context.Context, userID int64, provider string) error
+}
+
+// usernameUnsafe 自动创建用户时从外部身份推导用户名需要去掉的字符
+var usernameUnsafe = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+func (h *AuthHandler) OIDCAuthorize(ctx context.Context, req param.OIDCProviderRequest) (param.OIDCAuthorizeData, error) {
+	return h.oidcAuthorize(ctx, req.Provider, 0)
+}
+
+func (h *AuthHandler) OIDCLink(ctx context.Context, req param.OIDCProviderRequest) (param.OIDCAuthorizeData, error) {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return param.OIDCAuthorizeData{}, err
+	}
+	return h.oidcAuthorize(ctx, req.Provider, userID)
+}
+
+func (h *AuthHandler) OIDCUnlink(ctx context.Context, req param.OIDCProviderRequest) error {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+	return h.identities.UnlinkIdentity(ctx, userID, req.Provider)
+}
+
+func (h *AuthHandler) OIDCCallback(ctx context.Context, req param.OIDCCallbackRequest) (param.AuthTokenData, error) {
+	// 先作废 state，无论成功与否都不能被再次使用
+	st, err := h.states.Consume(ctx, req.State)
+	if err != nil {
+		return param.AuthTokenData{}, err
+	}
+	if st.Provider != req.Provider {
+		return param.AuthTokenData{}, code.NewError(code.ErrOIDCStateInvalid, "authorization state does not match provider")
+	}
+	if req.Error != "" {
+		return param.AuthTokenData{}, code.NewErrorf(code.ErrOIDCStateInvalid, "authorization denied: %s", req.Error)
+	}
+	if req.Code == "" {
+		return param.AuthTokenData{}, code.NewValidationError("code", "code is required")
+	}
+
+	provider, err := h.providers.Get(req.Provider)
+	if err != nil {
+		return param.AuthTokenData{}, err
+	}
+	identity, err := provider.Exchange(ctx, req.Code, st.Verifier, st.Nonce)
+	if err != nil {
+		return param.AuthTokenData{}, err
+	}
+
+	var user *model.User
+	if st.UserID != 0 {
+		user, err = h.linkIdentity(ctx, st.UserID, identity)
+	} else {
+		user, err = h.resolveIdentity(ctx, identity)
+	}
+	if err != nil {
+		return param.AuthTokenData{}, err
+	}
+	if err := checkUserStatus(user); err != nil {
+		return param.AuthTokenData{}, err
+	}
+
+	// 关联操作由已登录用户发起，无需再次两步验证
+	if st.UserID == 0 {
+		if challenge, ok, err := h.mfaChallenge(ctx, user.ID); err != nil || ok {
+			return challenge, err
+		}
+	}
+	return h.issue(ctx, user)
+}
+
+func (h *AuthHandler) oidcAuthorize(ctx context.Context, name string, userID int64) (param.OIDCAuthorizeData, error) {
+	provider, err := h.providers.Get(name)
+	if err != nil {
+		return param.OIDCAuthorizeData{}, err
+	}
+
+	st := auth.AuthState{Provider: name, UserID: userID}
+	var state string
+	for _, v := range []*string{&state, &st.Verifier, &st.Nonce} {
+		if *v, err = auth.NewPKCEVerifier(); err != nil {
+			return param.OIDCAuthorizeData{}, code.WrapInternalServerError(err, "generate authorization state failed")
+		}
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, st.Nonce, auth.PKCEChallenge(st.Verifier))
+	if err != nil {
+		return param.OIDCAuthorizeData{}, err
+	}
+	if err := h.states.Save(ctx, state, st, h.oidcStateTTL()); err != nil {
+		return param.OIDCAuthorizeData{}, err
+	}
+	return param.OIDCAuthorizeData{AuthorizationURL: authURL, State: state}, nil
+}
+
+// resolveIdentity 依次按已有关联、已验证邮箱、自动创建查找本地用户
+func (h *AuthHandler) resolveIdentity(ctx context.Context, identity *auth.ExternalIdentity) (*model.User, error) {
+	linked, err := h.identities.FindIdentity(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		user, err := h.repo.FindByID(ctx, linked.UserID)
+		if errors.IsCode(err, code.ErrUserNotFound) {
+			return nil, code.WrapError(err, code.ErrIdentityNotLinked, "linked user no longer exists")
+		}
+		return user, err
+	}
+	if !errors.IsCode(err, code.ErrIdentityNotLinked) {
+		return nil, err
+	}
+
+	cfg := h.oidcProvider(identity.Provider)
+	// 只信任身份提供方验证过的邮箱，否则任何人都能注册同名邮箱接管本地账号；
+	// 本地账号的邮箱同样需要验证过，否则抢先以他人邮箱注册的本地账号会关联到真正邮箱所有者的外部身份
+	if cfg.LinkByEmail && identity.Email != "" && identity.EmailVerified {
+		user, err := h.identities.FindUserByEmail(ctx, identity.Email)
+		if err == nil {
+			if user.EmailVerifiedAt == nil {
+				return nil, code.NewError(code.ErrIdentityNotLinked, "local account email is not verified, sign in and link the identity instead")
+			}
+			return h.linkIdentity(ctx, user.ID, identity)
+		}
+		if !errors.IsCode(err, code.ErrUserNotFound) {
+			return nil, err
+		}
+	}
+
+	if !cfg.AutoCreate {
+		return nil, code.NewError(code.ErrIdentityNotLinked, "identity is not linked to a user")
+	}
+	return h.createUserForIdentity(ctx, identity)
+}
+
+func (h *AuthHandler) linkIdentity(ctx context.Context, userID int64, identity *auth.ExternalIdentity) (*model.User, error) {
+	user, err := h.repo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	err = h.identities.LinkIdentity(ctx, &model.UserIdentity{
+		UserID:   userID,
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// createUserForIdentity 创建只能通过外部身份登录的用户，密码为不可用的随机值
+func (h *AuthHandler) createUserForIdentity(ctx context.Context, identity *auth.ExternalIdentity) (*model.User, error) {
+	secret, err := newRefreshToken()
+	if err != nil {
+		return nil, code.WrapInternalServerError(err, "generate password failed")
+	}
+	hash, err := utils.Hash(secret)
+	if err != nil {
+		return nil, code.WrapInternalServerError(err, "hash password failed")
+	}
+
+	email := ""
+	var verifiedAt *time.Time
+	if identity.EmailVerified {
+		email = identity.Email
+		now := h.now()
+		verifiedAt = &now
+	}
+	base := identityUsername(identity)
+	username := base
+	// 用户名冲突时追加随机后缀重试
+	for attempt := 0; attempt < 3; attempt++ {
+		user := &model.User{
+			Username:        username,
+			Email:           email,
+			EmailVerifiedAt: verifiedAt,
+			Password:        hash,
+			Status:          UserStatusActive,
+			LastLogin:       h.now(),
+		}
+		err = h.identities.CreateUserWithIdentity(ctx, user, &model.UserIdentity{
+			Provider: identity.Provider,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+		})
+		if err == nil {
+			return user, nil
+		}
+		if !errors.IsCode(err, code.ErrUserAlreadyExists) {
+			return nil, err
+		}
+		suffix := make([]byte, 3)
+		if _, err := rand.Read(suffix); err != nil {
+			return nil, code.WrapInternalServerError(err, "generate username failed")
+		}
+		username = base + "_" + hex.EncodeToString(suffix)
+	}
+	return nil, err
+}
+
+func (h *AuthHandler) oidcProvider(name string) configs.OIDCProviderConfig {
+	for _, p := range h.oidc.Providers {
+		if p.Name == name {
+			return p
+		}
+	}
+	return configs.OIDCProviderConfig{}
+}
+
+func (h *AuthHandler) oidcStateTTL() time.Duration {
+	if h.oidc.StateTTL > 0 {
+		return h.oidc.StateTTL
+	}
+	return auth.DefaultOIDCStateTTL
+}
+
+// identityUsername 优先使用 preferred_username，其次邮箱前缀，长度符合用户名校验规则
+func identityUsername(identity *auth.ExternalIdentity) string {
+	name := identity.PreferredUsername
+	if name == "" && identity.Email != "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+	name = usernameUnsafe.ReplaceAllString(name, "")
+	if len(name) < 3 {
+		name = identity.Provider + "_" + usernameUnsafe.ReplaceAllString(identity.Subject, "")
+	}
+	// 预留随机后缀的长度
+	if len(name) > 40 {
+		name = name[:40]
+	}
+	return name
+}
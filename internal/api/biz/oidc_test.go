@@ -0,0 +1,287 @@
+package biz
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/auth"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIdentityRepository 内存实现的外部身份关联数据访问
+type fakeIdentityRepository struct {
+	mu         sync.Mutex
+	identities []*model.UserIdentity
+	users      []*model.User
+}
+
+func newFakeIdentityRepository() *fakeIdentityRepository {
+	return &fakeIdentityRepository{}
+}
+
+func (f *fakeIdentityRepository) FindIdentity(_ context.Context, provider, subject string) (*model.UserIdentity, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, i := range f.identities {
+		if i.Provider == provider && i.Subject == subject {
+			cp := *i
+			return &cp, nil
+		}
+	}
+	return nil, code.NewError(code.ErrIdentityNotLinked, "identity is not linked")
+}
+
+func (f *fakeIdentityRepository) FindUserByEmail(_ context.Context, email string) (*model.User, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, u := range f.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, code.NewError(code.ErrUserNotFound, "user not found")
+}
+
+func (f *fakeIdentityRepository) LinkIdentity(_ context.Context, identity *model.UserIdentity) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.link(identity)
+}
+
+func (f *fakeIdentityRepository) link(identity *model.UserIdentity) error {
+	for _, i := range f.identities {
+		if i.Provider == identity.Provider && i.Subject == identity.Subject {
+			if i.UserID != identity.UserID {
+				return code.NewError(code.ErrIdentityAlreadyLinked, "identity is linked to another user")
+			}
+			return nil
+		}
+	}
+	f.identities = append(f.identities, identity)
+	return nil
+}
+
+func (f *fakeIdentityRepository) CreateUserWithIdentity(_ context.Context, user *model.User, identity *model.UserIdentity) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, u := range f.users {
+		if u.Username == user.Username {
+			return code.NewError(code.ErrUserAlreadyExists, "user already exists")
+		}
+	}
+	user.ID = int64(100 + len(f.users))
+	f.users = append(f.users, user)
+	identity.UserID = user.ID
+	return f.link(identity)
+}
+
+func (f *fakeIdentityRepository) UnlinkIdentity(_ context.Context, userID int64, provider string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, identity := range f.identities {
+		if identity.UserID == userID && identity.Provider == provider {
+			f.identities = append(f.identities[:i], f.identities[i+1:]...)
+			return nil
+		}
+	}
+	return code.NewError(code.ErrIdentityNotLinked, "identity is not linked")
+}
+
+// fakeIdentityProvider 授权码即为对应外部身份的身份提供方
+type fakeIdentityProvider struct {
+	identities map[string]*auth.ExternalIdentity
+	nonces     map[string]string
+}
+
+func (p *fakeIdentityProvider) Name() string {
+	return "corp"
+}
+
+func (p *fakeIdentityProvider) AuthCodeURL(_ context.Context, state, nonce, challenge string) (string, error) {
+	p.nonces[state] = nonce
+	return "https://idp.example.com/authorize?" + url.Values{"state": {state}, "code_challenge": {challenge}}.Encode(), nil
+}
+
+func (p *fakeIdentityProvider) Exchange(_ context.Context, authCode, verifier, nonce string) (*auth.ExternalIdentity, error) {
+	identity, ok := p.identities[authCode]
+	if !ok || verifier == "" || nonce == "" {
+		return nil, code.NewError(code.ErrOIDCStateInvalid, "invalid_grant")
+	}
+	cp := *identity
+	return &cp, nil
+}
+
+func newOIDCTestHandler(t *testing.T, cfg configs.OIDCProviderConfig) (*AuthHandler, *fakeIdentityRepository, *fakeIdentityProvider) {
+	t.Helper()
+	handler, repo, _, _ := newMfaTestHandler(t)
+	// 自动创建的用户同样会记录登录时间
+	repo.On("UpdateLastLogin", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	identities := newFakeIdentityRepository()
+	provider := &fakeIdentityProvider{identities: map[string]*auth.ExternalIdentity{
+		"alice-code": {Provider: "corp", Subject: "sub-alice", Email: "alice@example.com", EmailVerified: true, PreferredUsername: "alice"},
+		"bob-code":   {Provider: "corp", Subject: "sub-bob", Email: "bob@example.com", PreferredUsername: "bob"},
+	}, nonces: make(map[string]string)}
+	require.NoError(t, handler.providers.Register(provider))
+
+	cfg.Name = "corp"
+	handler.identities = identities
+	handler.oidc = configs.OIDCConfig{Providers: []configs.OIDCProviderConfig{cfg}}
+	return handler, identities, provider
+}
+
+// oidcState 发起授权并返回 state
+func oidcState(t *testing.T, handler *AuthHandler, ctx context.Context, link bool) string {
+	t.Helper()
+	req := param.OIDCProviderRequest{Provider: "corp"}
+	var data param.OIDCAuthorizeData
+	var err error
+	if link {
+		data, err = handler.OIDCLink(ctx, req)
+	} else {
+		data, err = handler.OIDCAuthorize(ctx, req)
+	}
+	require.NoError(t, err)
+	u, err := url.Parse(data.AuthorizationURL)
+	require.NoError(t, err)
+	assert.Equal(t, data.State, u.Query().Get("state"))
+	assert.NotEmpty(t, u.Query().Get("code_challenge"))
+	return data.State
+}
+
+func TestAuthHandler_OIDCCallback(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("未关联且未开启自动创建", func(t *testing.T) {
+		handler, _, _ := newOIDCTestHandler(t, configs.OIDCProviderConfig{})
+		state := oidcState(t, handler, ctx, false)
+		_, err := handler.OIDCCallback(ctx, param.OIDCCallbackRequest{Provider: "corp", Code: "alice-code", State: state})
+		assert.True(t, errors.IsCode(err, code.ErrIdentityNotLinked))
+	})
+
+	t.Run("按已验证邮箱关联已有用户", func(t *testing.T) {
+		handler, identities, _ := newOIDCTestHandler(t, configs.OIDCProviderConfig{LinkByEmail: true})
+		verifiedAt := time.Now()
+		identities.users = append(identities.users, &model.User{ID: 7, Username: "alice", Email: "alice@example.com", EmailVerifiedAt: &verifiedAt})
+
+		state := oidcState(t, handler, ctx, false)
+		result, err := handler.OIDCCallback(ctx, param.OIDCCallbackRequest{Provider: "corp", Code: "alice-code", State: state})
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.AccessToken)
+		require.Len(t, identities.identities, 1)
+		assert.Equal(t, int64(7), identities.identities[0].UserID)
+
+		// 之后直接按关联登录
+		state = oidcState(t, handler, ctx, false)
+		_, err = handler.OIDCCallback(ctx, param.OIDCCallbackRequest{Provider: "corp", Code: "alice-code", State: state})
+		require.NoError(t, err)
+		assert.Len(t, identities.identities, 1)
+	})
+
+	t.Run("未验证的邮箱不会关联已有用户", func(t *testing.T) {
+		handler, identities, _ := newOIDCTestHandler(t, configs.OIDCProviderConfig{LinkByEmail: true})
+		identities.users = append(identities.users, &model.User{ID: 8, Username: "bob", Email: "bob@example.com"})
+
+		state := oidcState(t, handler, ctx, false)
+		_, err := handler.OIDCCallback(ctx, param.OIDCCallbackRequest{Provider: "corp", Code: "bob-code", State: state})
+		assert.True(t, errors.IsCode(err, code.ErrIdentityNotLinked))
+	})
+
+	t.Run("本地邮箱未验证时不会关联已有用户", func(t *testing.T) {
+		handler, identities, _ := newOIDCTestHandler(t, configs.OIDCProviderConfig{LinkByEmail: true, AutoCreate: true})
+		identities.users = append(identities.users, &model.User{ID: 7, Username: "alice", Email: "alice@example.com"})
+
+		state := oidcState(t, handler, ctx, false)
+		_, err := handler.OIDCCallback(ctx, param.OIDCCallbackRequest{Provider: "corp", Code: "alice-code", State: state})
+		assert.True(t, errors.IsCode(err, code.ErrIdentityNotLinked))
+		assert.Empty(t, identities.identities)
+		assert.Len(t, identities.users, 1)
+	})
+
+	t.Run("自动创建用户", func(t *testing.T) {
+		handler, identities, _ := newOIDCTestHandler(t, configs.OIDCProviderConfig{AutoCreate: true})
+		identities.users = append(identities.users, &model.User{ID: 8, Username: "bob"})
+
+		state := oidcState(t, handler, ctx, false)
+		result, err := handler.OIDCCallback(ctx, param.OIDCCallbackRequest{Provider: "corp", Code: "bob-code", State: state})
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.AccessToken)
+		require.Len(t, identities.users, 2)
+		created := identities.users[1]
+		// 用户名冲突时追加后缀，未验证的邮箱不落库
+		assert.Regexp(t, `^bob_[0-9a-f]{6}$`, created.Username)
+		assert.Empty(t, created.Email)
+		assert.Equal(t, UserStatusActive, created.Status)
+	})
+
+	t.Run("state 只能使用一次且绑定提供方", func(t *testing.T) {
+		handler, _, _ := newOIDCTestHandler(t, configs.OIDCProviderConfig{AutoCreate: true})
+		state := oidcState(t, handler, ctx, false)
+		_, err := handler.OIDCCallback(ctx, param.OIDCCallbackRequest{Provider: "other", Code: "alice-code", State: state})
+		assert.True(t, errors.IsCode(err, code.ErrOIDCStateInvalid))
+		_, err = handler.OIDCCallback(ctx, param.OIDCCallbackRequest{Provider: "corp", Code: "alice-code", State: state})
+		assert.True(t, errors.IsCode(err, code.ErrOIDCStateInvalid))
+
+		state = oidcState(t, handler, ctx, false)
+		_, err = handler.OIDCCallback(ctx, param.OIDCCallbackRequest{Provider: "corp", State: state, Error: "access_denied"})
+		assert.True(t, errors.IsCode(err, code.ErrOIDCStateInvalid))
+	})
+
+	t.Run("未配置的提供方", func(t *testing.T) {
+		handler, _, _ := newOIDCTestHandler(t, configs.OIDCProviderConfig{})
+		_, err := handler.OIDCAuthorize(ctx, param.OIDCProviderRequest{Provider: "other"})
+		assert.True(t, errors.IsCode(err, code.ErrOIDCProviderNotFound))
+	})
+}
+
+func TestAuthHandler_OIDCLink(t *testing.T) {
+	handler, identities, _ := newOIDCTestHandler(t, configs.OIDCProviderConfig{})
+	ctx := utils.WithPrincipal(context.Background(), &utils.Principal{ID: "7", Name: "alice"})
+
+	_, err := handler.OIDCLink(context.Background(), param.OIDCProviderRequest{Provider: "corp"})
+	assert.True(t, errors.IsCode(err, code.ErrTokenInvalid))
+
+	state := oidcState(t, handler, ctx, true)
+	result, err := handler.OIDCCallback(context.Background(), param.OIDCCallbackRequest{Provider: "corp", Code: "bob-code", State: state})
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.AccessToken)
+	require.Len(t, identities.identities, 1)
+	assert.Equal(t, int64(7), identities.identities[0].UserID)
+
+	// 已关联到其他用户的外部身份不能再次关联
+	identities.identities[0].UserID = 9
+	state = oidcState(t, handler, ctx, true)
+	_, err = handler.OIDCCallback(context.Background(), param.OIDCCallbackRequest{Provider: "corp", Code: "bob-code", State: state})
+	assert.True(t, errors.IsCode(err, code.ErrIdentityAlreadyLinked))
+
+	identities.identities[0].UserID = 7
+	require.NoError(t, handler.OIDCUnlink(ctx, param.OIDCProviderRequest{Provider: "corp"}))
+	err = handler.OIDCUnlink(ctx, param.OIDCProviderRequest{Provider: "corp"})
+	assert.True(t, errors.IsCode(err, code.ErrIdentityNotLinked))
+}
+
+func TestAuthHandler_OIDCCallbackRequiresMfa(t *testing.T) {
+	handler, identities, _ := newOIDCTestHandler(t, configs.OIDCProviderConfig{})
+	identities.identities = append(identities.identities, &model.UserIdentity{UserID: 7, Provider: "corp", Subject: "sub-alice"})
+	ctx := utils.WithPrincipal(context.Background(), &utils.Principal{ID: "7", Name: "alice"})
+
+	mfa := newFakeMfaRepository()
+	mfa.settings[7] = &model.UserMfa{UserID: 7, Secret: "JBSWY3DPEHPK3PXP", Enabled: true}
+	handler.mfa = mfa
+
+	state := oidcState(t, handler, ctx, false)
+	result, err := handler.OIDCCallback(ctx, param.OIDCCallbackRequest{Provider: "corp", Code: "alice-code", State: state})
+	require.NoError(t, err)
+	assert.True(t, result.MfaRequired)
+	assert.Empty(t, result.AccessToken)
+}
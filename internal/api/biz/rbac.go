@@ -0,0 +1,486 @@
+/*
+ * Module: Rbac
+ * Casbin 策略（p）与角色分配（g）管理
+ */
+
+package biz
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/code"
+)
+
+const (
+	// RbacFormatCSV casbin 策略文件格式，每行形如 "p, alice, /api/users, GET" 或 "g, alice, admin"，
+	// 域模型下为 "p, alice, tenant1, /api/users, GET" 与 "g, alice, admin, tenant1"
+	RbacFormatCSV = "csv"
+	// RbacFormatJSON param.RbacPolicySet 的 JSON 编码
+	RbacFormatJSON = "json"
+
+	// RbacImportMerge 只新增不存在的规则
+	RbacImportMerge = "merge"
+	// RbacImportReplace 用导入内容替换全部规则
+	RbacImportReplace = "replace"
+)
+
+// RbacRepository 策略数据访问接口。
+// 规则以 casbin 的 []string 形式传递，实现方必须通过 enforcer 修改，保证内存策略与持久化一致。
+type RbacRepository interface {
+	// Domains 当前模型是否为域（租户）模型：p = sub, dom, obj, act；g = user, role, dom
+	Domains() bool
+
+	// Policies 按字段过滤 p 规则，空字符串表示不过滤
+	Policies(ctx context.Context, filter ...string) ([][]string, error)
+
+	// AddPolicies 新增 p 规则，已存在的规则被跳过，返回实际新增的规则
+	AddPolicies(ctx context.Context, rules [][]string) ([][]string, error)
+
+	// UpdatePolicy 替换 p 规则，原规则不存在时返回 false
+	UpdatePolicy(ctx context.Context, oldRule, newRule []string) (bool, error)
+
+	// RemovePolicy 删除 p 规则，规则不存在时返回 false
+	RemovePolicy(ctx context.Context, rule []string) (bool, error)
+
+	// Roles 按字段过滤 g 规则，空字符串表示不过滤
+	Roles(ctx context.Context, filter ...string) ([][]string, error)
+
+	// AddRoles 新增 g 规则，已存在的规则被跳过，返回实际新增的规则
+	AddRoles(ctx context.Context, rules [][]string) ([][]string, error)
+
+	// RemoveRole 删除 g 规则，规则不存在时返回 false
+	RemoveRole(ctx context.Context, rule []string) (bool, error)
+
+	// RolesForUser 返回直接分配的角色与包含继承在内的全部角色，域模型下限定在 domain 内
+	RolesForUser(ctx context.Context, user, domain string) (roles []string, implicit []string, err error)
+
+	// PermissionsForUser 返回用户通过自身及角色获得的全部 p 规则，域模型下限定在 domain 内
+	PermissionsForUser(ctx context.Context, user, domain string) ([][]string, error)
+
+	// Replace 用给定规则整体替换现有的 p 与 g 规则
+	Replace(ctx context.Context, policies, roles [][]string) error
+}
+
+// RbacUseCase 策略管理业务逻辑接口
+type RbacUseCase interface {
+	// ListPolicies 查询策略
+	ListPolicies(ctx context.Context, req param.RbacPolicyListRequest) ([]param.RbacPolicy, int64, error)
+
+	// AddPolicy 新增策略
+	AddPolicy(ctx context.Context, req param.RbacPolicy) error
+
+	// UpdatePolicy 替换策略
+	UpdatePolicy(ctx context.Context, req param.RbacPolicyUpdateRequest) error
+
+	// RemovePolicy 删除策略
+	RemovePolicy(ctx context.Context, req param.RbacPolicy) error
+
+	// ListRoles 查询角色分配
+	ListRoles(ctx context.Context, req param.RbacRoleListRequest) ([]param.RbacRoleAssignment, int64, error)
+
+	// AssignRole 为用户分配角色
+	AssignRole(ctx context.Context, req param.RbacRoleAssignment) error
+
+	// RevokeRole 撤销用户角色
+	RevokeRole(ctx context.Context, req param.RbacRoleAssignment) error
+
+	// UserRoles 查询用户角色
+	UserRoles(ctx context.Context, req param.RbacUserRequest) (param.RbacUserRolesData, error)
+
+	// UserPermissions 查询用户的有效权限
+	UserPermissions(ctx context.Context, req param.RbacUserRequest) (param.RbacUserPermissionsData, error)
+
+	// Export 导出全部策略
+	Export(ctx context.Context, req param.RbacExportRequest) ([]byte, error)
+
+	// Import 导入策略
+	Import(ctx context.Context, req param.RbacImportRequest, data io.Reader) (param.RbacImportResult, error)
+}
+
+// RbacHandler 策略管理业务逻辑处理器
+type RbacHandler struct {
+	repo RbacRepository
+}
+
+// NewRbacHandler 创建策略管理业务逻辑处理器
+func NewRbacHandler(repo RbacRepository) RbacUseCase {
+	return &RbacHandler{repo: repo}
+}
+
+func (h *RbacHandler) ListPolicies(ctx context.Context, req param.RbacPolicyListRequest) ([]param.RbacPolicy, int64, error) {
+	filter := []string{req.Subject, req.Object, req.Action}
+	if h.repo.Domains() {
+		filter = []string{req.Subject, req.Domain, req.Object, req.Action}
+	}
+	rules, err := h.repo.Policies(ctx, filter...)
+	if err != nil {
+		return nil, 0, err
+	}
+	rules, total := paginate(rules, &req.APIQuery)
+	return toPolicies(rules), total, nil
+}
+
+func (h *RbacHandler) AddPolicy(ctx context.Context, req param.RbacPolicy) error {
+	rule, err := h.policyRule(req)
+	if err != nil {
+		return err
+	}
+	added, err := h.repo.AddPolicies(ctx, [][]string{rule})
+	if err != nil {
+		return err
+	}
+	if len(added) == 0 {
+		return code.NewError(code.ErrRbacPolicyExists, "policy already exists")
+	}
+	return nil
+}
+
+func (h *RbacHandler) UpdatePolicy(ctx context.Context, req param.RbacPolicyUpdateRequest) error {
+	oldRule, err := h.policyRule(req.Old)
+	if err != nil {
+		return err
+	}
+	newRule, err := h.policyRule(req.New)
+	if err != nil {
+		return err
+	}
+
+	existing, err := h.repo.Policies(ctx, newRule...)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return code.NewError(code.ErrRbacPolicyExists, "policy already exists")
+	}
+
+	ok, err := h.repo.UpdatePolicy(ctx, oldRule, newRule)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return code.NewError(code.ErrRbacPolicyNotFound, "policy not found")
+	}
+	return nil
+}
+
+func (h *RbacHandler) RemovePolicy(ctx context.Context, req param.RbacPolicy) error {
+	rule, err := h.policyRule(req)
+	if err != nil {
+		return err
+	}
+	ok, err := h.repo.RemovePolicy(ctx, rule)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return code.NewError(code.ErrRbacPolicyNotFound, "policy not found")
+	}
+	return nil
+}
+
+func (h *RbacHandler) ListRoles(ctx context.Context, req param.RbacRoleListRequest) ([]param.RbacRoleAssignment, int64, error) {
+	filter := []string{req.User, req.Role}
+	if h.repo.Domains() {
+		filter = append(filter, req.Domain)
+	}
+	rules, err := h.repo.Roles(ctx, filter...)
+	if err != nil {
+		return nil, 0, err
+	}
+	rules, total := paginate(rules, &req.APIQuery)
+	return toRoleAssignments(rules), total, nil
+}
+
+func (h *RbacHandler) AssignRole(ctx context.Context, req param.RbacRoleAssignment) error {
+	rule, err := h.roleRule(req)
+	if err != nil {
+		return err
+	}
+	added, err := h.repo.AddRoles(ctx, [][]string{rule})
+	if err != nil {
+		return err
+	}
+	if len(added) == 0 {
+		return code.NewError(code.ErrRbacRoleExists, "role assignment already exists")
+	}
+	return nil
+}
+
+func (h *RbacHandler) RevokeRole(ctx context.Context, req param.RbacRoleAssignment) error {
+	rule, err := h.roleRule(req)
+	if err != nil {
+		return err
+	}
+	ok, err := h.repo.RemoveRole(ctx, rule)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return code.NewError(code.ErrRbacRoleNotFound, "role assignment not found")
+	}
+	return nil
+}
+
+func (h *RbacHandler) UserRoles(ctx context.Context, req param.RbacUserRequest) (param.RbacUserRolesData, error) {
+	if err := h.checkDomain(req.Domain); err != nil {
+		return param.RbacUserRolesData{}, err
+	}
+	roles, implicit, err := h.repo.RolesForUser(ctx, req.User, req.Domain)
+	if err != nil {
+		return param.RbacUserRolesData{}, err
+	}
+	return param.RbacUserRolesData{
+		User:          req.User,
+		Domain:        req.Domain,
+		Roles:         nonNil(roles),
+		ImplicitRoles: nonNil(implicit),
+	}, nil
+}
+
+func (h *RbacHandler) UserPermissions(ctx context.Context, req param.RbacUserRequest) (param.RbacUserPermissionsData, error) {
+	if err := h.checkDomain(req.Domain); err != nil {
+		return param.RbacUserPermissionsData{}, err
+	}
+	rules, err := h.repo.PermissionsForUser(ctx, req.User, req.Domain)
+	if err != nil {
+		return param.RbacUserPermissionsData{}, err
+	}
+	return param.RbacUserPermissionsData{User: req.User, Domain: req.Domain, Permissions: toPolicies(rules)}, nil
+}
+
+func (h *RbacHandler) Export(ctx context.Context, req param.RbacExportRequest) ([]byte, error) {
+	policies, err := h.repo.Policies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := h.repo.Roles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Format == RbacFormatJSON {
+		data, err := json.Marshal(param.RbacPolicySet{Policies: toPolicies(policies), Roles: toRoleAssignments(roles)})
+		if err != nil {
+			return nil, code.WrapError(err, code.ErrEncodingJSON, "encode policies")
+		}
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for _, rule := range policies {
+		_ = w.Write(append([]string{"p"}, rule...))
+	}
+	for _, rule := range roles {
+		_ = w.Write(append([]string{"g"}, rule...))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, code.WrapError(err, code.ErrEncodingFailed, "encode policies")
+	}
+	return buf.Bytes(), nil
+}
+
+func (h *RbacHandler) Import(ctx context.Context, req param.RbacImportRequest, data io.Reader) (param.RbacImportResult, error) {
+	var (
+		set param.RbacPolicySet
+		err error
+	)
+	if req.Format == RbacFormatJSON {
+		set, err = decodePolicyJSON(data)
+	} else {
+		set, err = decodePolicyCSV(data)
+	}
+	if err != nil {
+		return param.RbacImportResult{}, err
+	}
+
+	// 规则的域字段必须与当前模型一致
+	policies := make([][]string, 0, len(set.Policies))
+	for i, p := range set.Policies {
+		rule, err := h.policyRule(p)
+		if err != nil {
+			return param.RbacImportResult{}, code.WrapError(err, code.ErrRbacInvalidImport, fmt.Sprintf("policies[%d]", i))
+		}
+		policies = append(policies, rule)
+	}
+	roles := make([][]string, 0, len(set.Roles))
+	for i, r := range set.Roles {
+		rule, err := h.roleRule(r)
+		if err != nil {
+			return param.RbacImportResult{}, code.WrapError(err, code.ErrRbacInvalidImport, fmt.Sprintf("roles[%d]", i))
+		}
+		roles = append(roles, rule)
+	}
+
+	if req.Mode == RbacImportReplace {
+		if err := h.repo.Replace(ctx, policies, roles); err != nil {
+			return param.RbacImportResult{}, err
+		}
+		return param.RbacImportResult{Policies: len(policies), Roles: len(roles)}, nil
+	}
+
+	addedPolicies, err := h.repo.AddPolicies(ctx, policies)
+	if err != nil {
+		return param.RbacImportResult{}, err
+	}
+	addedRoles, err := h.repo.AddRoles(ctx, roles)
+	if err != nil {
+		return param.RbacImportResult{}, err
+	}
+	return param.RbacImportResult{Policies: len(addedPolicies), Roles: len(addedRoles)}, nil
+}
+
+// checkDomain 域模型下必须指定域，非域模型下不能指定域
+func (h *RbacHandler) checkDomain(domain string) error {
+	if h.repo.Domains() && domain == "" {
+		return code.NewValidationError("dom", "domain is required by the casbin model")
+	}
+	if !h.repo.Domains() && domain != "" {
+		return code.NewValidationError("dom", "domain is not supported by the casbin model")
+	}
+	return nil
+}
+
+func (h *RbacHandler) policyRule(p param.RbacPolicy) ([]string, error) {
+	if err := h.checkDomain(p.Domain); err != nil {
+		return nil, err
+	}
+	if h.repo.Domains() {
+		return []string{p.Subject, p.Domain, p.Object, p.Action}, nil
+	}
+	return []string{p.Subject, p.Object, p.Action}, nil
+}
+
+func (h *RbacHandler) roleRule(r param.RbacRoleAssignment) ([]string, error) {
+	if err := h.checkDomain(r.Domain); err != nil {
+		return nil, err
+	}
+	if h.repo.Domains() {
+		return []string{r.User, r.Role, r.Domain}, nil
+	}
+	return []string{r.User, r.Role}, nil
+}
+
+// decodePolicyCSV 解析 casbin 策略文件格式，忽略空行与 # 注释
+func decodePolicyCSV(data io.Reader) (param.RbacPolicySet, error) {
+	r := csv.NewReader(data)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	set := param.RbacPolicySet{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return set, code.WrapError(err, code.ErrRbacInvalidImport, "invalid csv")
+		}
+		line, _ := r.FieldPos(0)
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+		if !allNonEmpty(record) {
+			return set, code.NewErrorf(code.ErrRbacInvalidImport, "line %d: empty field", line)
+		}
+
+		switch {
+		case record[0] == "p" && len(record) == 4:
+			set.Policies = append(set.Policies, param.RbacPolicy{Subject: record[1], Object: record[2], Action: record[3]})
+		case record[0] == "p" && len(record) == 5:
+			set.Policies = append(set.Policies, param.RbacPolicy{Subject: record[1], Domain: record[2], Object: record[3], Action: record[4]})
+		case record[0] == "g" && len(record) == 3:
+			set.Roles = append(set.Roles, param.RbacRoleAssignment{User: record[1], Role: record[2]})
+		case record[0] == "g" && len(record) == 4:
+			set.Roles = append(set.Roles, param.RbacRoleAssignment{User: record[1], Role: record[2], Domain: record[3]})
+		default:
+			return set, code.NewErrorf(code.ErrRbacInvalidImport, "line %d: expected \"p, sub, [dom,] obj, act\" or \"g, user, role[, dom]\"", line)
+		}
+	}
+	return set, nil
+}
+
+func decodePolicyJSON(data io.Reader) (param.RbacPolicySet, error) {
+	var set param.RbacPolicySet
+	if err := json.NewDecoder(data).Decode(&set); err != nil {
+		return set, code.WrapError(err, code.ErrRbacInvalidImport, "invalid json")
+	}
+	for i, p := range set.Policies {
+		if !allNonEmpty([]string{p.Subject, p.Object, p.Action}) {
+			return set, code.NewErrorf(code.ErrRbacInvalidImport, "policies[%d]: sub, obj and act are required", i)
+		}
+	}
+	for i, r := range set.Roles {
+		if !allNonEmpty([]string{r.User, r.Role}) {
+			return set, code.NewErrorf(code.ErrRbacInvalidImport, "roles[%d]: user and role are required", i)
+		}
+	}
+	return set, nil
+}
+
+// toPolicies 按规则长度区分是否包含域
+func toPolicies(rules [][]string) []param.RbacPolicy {
+	list := make([]param.RbacPolicy, 0, len(rules))
+	for _, rule := range rules {
+		switch len(rule) {
+		case 3:
+			list = append(list, param.RbacPolicy{Subject: rule[0], Object: rule[1], Action: rule[2]})
+		case 4:
+			list = append(list, param.RbacPolicy{Subject: rule[0], Domain: rule[1], Object: rule[2], Action: rule[3]})
+		}
+	}
+	return list
+}
+
+func toRoleAssignments(rules [][]string) []param.RbacRoleAssignment {
+	list := make([]param.RbacRoleAssignment, 0, len(rules))
+	for _, rule := range rules {
+		switch len(rule) {
+		case 2:
+			list = append(list, param.RbacRoleAssignment{User: rule[0], Role: rule[1]})
+		case 3:
+			list = append(list, param.RbacRoleAssignment{User: rule[0], Role: rule[1], Domain: rule[2]})
+		}
+	}
+	return list
+}
+
+// paginate 策略全部保存在 enforcer 内存中，分页在内存中完成
+func paginate(rules [][]string, q *param.APIQuery) ([][]string, int64) {
+	total := int64(len(rules))
+	limit := q.Limit()
+	offset := q.Offset()
+	if offset >= len(rules) {
+		return nil, total
+	}
+	end := offset + limit
+	if end > len(rules) {
+		end = len(rules)
+	}
+	return rules[offset:end], total
+}
+
+func allNonEmpty(values []string) bool {
+	for _, v := range values {
+		if v == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,259 @@
+package biz
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockRbacRepository 模拟策略数据访问接口
+type MockRbacRepository struct {
+	mock.Mock
+	domains bool
+}
+
+func (m *MockRbacRepository) Domains() bool {
+	return m.domains
+}
+
+func (m *MockRbacRepository) Policies(ctx context.Context, filter ...string) ([][]string, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([][]string), args.Error(1)
+}
+
+func (m *MockRbacRepository) AddPolicies(ctx context.Context, rules [][]string) ([][]string, error) {
+	args := m.Called(ctx, rules)
+	return args.Get(0).([][]string), args.Error(1)
+}
+
+func (m *MockRbacRepository) UpdatePolicy(ctx context.Context, oldRule, newRule []string) (bool, error) {
+	args := m.Called(ctx, oldRule, newRule)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRbacRepository) RemovePolicy(ctx context.Context, rule []string) (bool, error) {
+	args := m.Called(ctx, rule)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRbacRepository) Roles(ctx context.Context, filter ...string) ([][]string, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([][]string), args.Error(1)
+}
+
+func (m *MockRbacRepository) AddRoles(ctx context.Context, rules [][]string) ([][]string, error) {
+	args := m.Called(ctx, rules)
+	return args.Get(0).([][]string), args.Error(1)
+}
+
+func (m *MockRbacRepository) RemoveRole(ctx context.Context, rule []string) (bool, error) {
+	args := m.Called(ctx, rule)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRbacRepository) RolesForUser(ctx context.Context, user, domain string) ([]string, []string, error) {
+	args := m.Called(ctx, user, domain)
+	return args.Get(0).([]string), args.Get(1).([]string), args.Error(2)
+}
+
+func (m *MockRbacRepository) PermissionsForUser(ctx context.Context, user, domain string) ([][]string, error) {
+	args := m.Called(ctx, user, domain)
+	return args.Get(0).([][]string), args.Error(1)
+}
+
+func (m *MockRbacRepository) Replace(ctx context.Context, policies, roles [][]string) error {
+	args := m.Called(ctx, policies, roles)
+	return args.Error(0)
+}
+
+func TestRbacHandler_ListPolicies(t *testing.T) {
+	repo := new(MockRbacRepository)
+	repo.On("Policies", mock.Anything, []string{"admin", "", ""}).Return([][]string{
+		{"admin", "/api/users", "GET"},
+		{"admin", "/api/users", "POST"},
+		{"admin", "/api/users", "DELETE"},
+	}, nil)
+
+	h := NewRbacHandler(repo)
+	list, total, err := h.ListPolicies(context.Background(), param.RbacPolicyListRequest{
+		APIQuery: param.APIQuery{Page: 2, Count: 2},
+		Subject:  "admin",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, []param.RbacPolicy{{Subject: "admin", Object: "/api/users", Action: "DELETE"}}, list)
+}
+
+func TestRbacHandler_AddPolicy(t *testing.T) {
+	rule := param.RbacPolicy{Subject: "admin", Object: "/api/users", Action: "GET"}
+
+	repo := new(MockRbacRepository)
+	repo.On("AddPolicies", mock.Anything, [][]string{{"admin", "/api/users", "GET"}}).Return([][]string{}, nil)
+	err := NewRbacHandler(repo).AddPolicy(context.Background(), rule)
+	assert.True(t, errors.IsCode(err, code.ErrRbacPolicyExists))
+
+	repo = new(MockRbacRepository)
+	repo.On("AddPolicies", mock.Anything, mock.Anything).Return([][]string{{"admin", "/api/users", "GET"}}, nil)
+	assert.NoError(t, NewRbacHandler(repo).AddPolicy(context.Background(), rule))
+}
+
+func TestRbacHandler_UpdatePolicy(t *testing.T) {
+	req := param.RbacPolicyUpdateRequest{
+		Old: param.RbacPolicy{Subject: "admin", Object: "/api/users", Action: "GET"},
+		New: param.RbacPolicy{Subject: "admin", Object: "/api/users", Action: "PUT"},
+	}
+
+	tests := []struct {
+		name      string
+		setupMock func(*MockRbacRepository)
+		wantCode  int
+	}{
+		{
+			name: "成功场景",
+			setupMock: func(m *MockRbacRepository) {
+				m.On("Policies", mock.Anything, mock.Anything).Return([][]string{}, nil)
+				m.On("UpdatePolicy", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+			},
+		},
+		{
+			name: "新策略已存在",
+			setupMock: func(m *MockRbacRepository) {
+				m.On("Policies", mock.Anything, mock.Anything).Return([][]string{{"admin", "/api/users", "PUT"}}, nil)
+			},
+			wantCode: code.ErrRbacPolicyExists,
+		},
+		{
+			name: "原策略不存在",
+			setupMock: func(m *MockRbacRepository) {
+				m.On("Policies", mock.Anything, mock.Anything).Return([][]string{}, nil)
+				m.On("UpdatePolicy", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+			},
+			wantCode: code.ErrRbacPolicyNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockRbacRepository)
+			tt.setupMock(repo)
+			err := NewRbacHandler(repo).UpdatePolicy(context.Background(), req)
+			if tt.wantCode == 0 {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.IsCode(err, tt.wantCode))
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRbacHandler_RevokeRole(t *testing.T) {
+	repo := new(MockRbacRepository)
+	repo.On("RemoveRole", mock.Anything, []string{"alice", "admin"}).Return(false, nil)
+	err := NewRbacHandler(repo).RevokeRole(context.Background(), param.RbacRoleAssignment{User: "alice", Role: "admin"})
+	assert.True(t, errors.IsCode(err, code.ErrRbacRoleNotFound))
+}
+
+func TestRbacHandler_Export(t *testing.T) {
+	repo := new(MockRbacRepository)
+	repo.On("Policies", mock.Anything, []string(nil)).Return([][]string{{"admin", "/api/users", "GET"}}, nil)
+	repo.On("Roles", mock.Anything, []string(nil)).Return([][]string{{"alice", "admin"}}, nil)
+	h := NewRbacHandler(repo)
+
+	data, err := h.Export(context.Background(), param.RbacExportRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "p,admin,/api/users,GET\ng,alice,admin\n", string(data))
+
+	data, err = h.Export(context.Background(), param.RbacExportRequest{Format: RbacFormatJSON})
+	require.NoError(t, err)
+	var set param.RbacPolicySet
+	require.NoError(t, json.Unmarshal(data, &set))
+	assert.Equal(t, []param.RbacRoleAssignment{{User: "alice", Role: "admin"}}, set.Roles)
+}
+
+func TestRbacHandler_Import(t *testing.T) {
+	csvData := "# 管理员\np, admin, /api/users, GET\n\ng, alice, admin\ng, bob, admin\n"
+
+	t.Run("合并导入", func(t *testing.T) {
+		repo := new(MockRbacRepository)
+		repo.On("AddPolicies", mock.Anything, [][]string{{"admin", "/api/users", "GET"}}).Return([][]string{}, nil)
+		repo.On("AddRoles", mock.Anything, [][]string{{"alice", "admin"}, {"bob", "admin"}}).Return([][]string{{"bob", "admin"}}, nil)
+
+		result, err := NewRbacHandler(repo).Import(context.Background(), param.RbacImportRequest{}, strings.NewReader(csvData))
+		require.NoError(t, err)
+		assert.Equal(t, param.RbacImportResult{Policies: 0, Roles: 1}, result)
+	})
+
+	t.Run("替换导入", func(t *testing.T) {
+		repo := new(MockRbacRepository)
+		repo.On("Replace", mock.Anything, [][]string{{"admin", "/api/users", "GET"}}, [][]string{{"alice", "admin"}}).Return(nil)
+
+		body := `{"policies":[{"sub":"admin","obj":"/api/users","act":"GET"}],"roles":[{"user":"alice","role":"admin"}]}`
+		result, err := NewRbacHandler(repo).Import(context.Background(),
+			param.RbacImportRequest{Format: RbacFormatJSON, Mode: RbacImportReplace}, strings.NewReader(body))
+		require.NoError(t, err)
+		assert.Equal(t, param.RbacImportResult{Policies: 1, Roles: 1}, result)
+		repo.AssertExpectations(t)
+	})
+
+	invalid := []struct {
+		name   string
+		format string
+		body   string
+	}{
+		{name: "csv 字段数错误", body: "p, admin, /api/users\n"},
+		{name: "csv 未知类型", body: "x, admin, /api/users, GET\n"},
+		{name: "csv 空字段", body: "g, alice, \n"},
+		{name: "json 格式错误", format: RbacFormatJSON, body: `{"policies":`},
+		{name: "json 缺少字段", format: RbacFormatJSON, body: `{"roles":[{"user":"alice"}]}`},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockRbacRepository)
+			_, err := NewRbacHandler(repo).Import(context.Background(),
+				param.RbacImportRequest{Format: tt.format}, strings.NewReader(tt.body))
+			assert.True(t, errors.IsCode(err, code.ErrRbacInvalidImport))
+			repo.AssertNotCalled(t, "AddPolicies", mock.Anything, mock.Anything)
+			repo.AssertNotCalled(t, "Replace", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRbacHandler_Domains(t *testing.T) {
+	repo := &MockRbacRepository{domains: true}
+	repo.On("AddPolicies", mock.Anything, [][]string{{"admin", "tenant1", "/api/users", "GET"}}).Return([][]string{{"admin", "tenant1", "/api/users", "GET"}}, nil)
+	repo.On("AddRoles", mock.Anything, [][]string{{"alice", "admin", "tenant1"}}).Return([][]string{{"alice", "admin", "tenant1"}}, nil)
+	repo.On("RolesForUser", mock.Anything, "alice", "tenant1").Return([]string{"admin"}, []string{"admin"}, nil)
+	h := NewRbacHandler(repo)
+	ctx := context.Background()
+
+	require.NoError(t, h.AddPolicy(ctx, param.RbacPolicy{Subject: "admin", Domain: "tenant1", Object: "/api/users", Action: "GET"}))
+	require.NoError(t, h.AssignRole(ctx, param.RbacRoleAssignment{User: "alice", Role: "admin", Domain: "tenant1"}))
+	roles, err := h.UserRoles(ctx, param.RbacUserRequest{User: "alice", Domain: "tenant1"})
+	require.NoError(t, err)
+	assert.Equal(t, "tenant1", roles.Domain)
+
+	// 域模型下必须指定域
+	err = h.AddPolicy(ctx, param.RbacPolicy{Subject: "admin", Object: "/api/users", Action: "GET"})
+	assert.True(t, errors.IsCode(err, code.ErrValidation))
+	_, err = h.UserPermissions(ctx, param.RbacUserRequest{User: "alice"})
+	assert.True(t, errors.IsCode(err, code.ErrValidation))
+
+	// 导入内容与模型不一致
+	_, err = h.Import(ctx, param.RbacImportRequest{}, strings.NewReader("p, admin, /api/users, GET\n"))
+	assert.True(t, errors.IsCode(err, code.ErrRbacInvalidImport))
+
+	repo.On("Replace", mock.Anything, [][]string{{"admin", "tenant1", "/api/users", "GET"}}, [][]string{{"alice", "admin", "tenant1"}}).Return(nil)
+	_, err = h.Import(ctx, param.RbacImportRequest{Mode: RbacImportReplace},
+		strings.NewReader("p, admin, tenant1, /api/users, GET\ng, alice, admin, tenant1\n"))
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
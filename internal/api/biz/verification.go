@@ -0,0 +1,187 @@
+/*
+ * Module: Auth
+ * 忘记密码与邮箱验证：签发一次性令牌并通过邮件投递链接
+ */
+
+package biz
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/auth"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/log"
+	"github.com/NSObjects/go-template/internal/notify"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/marmotedu/errors"
+)
+
+const (
+	// DefaultResetTokenTTL auth.verification.reset_ttl 未配置时的重置密码链接有效期
+	DefaultResetTokenTTL = 30 * time.Minute
+	// DefaultVerifyTokenTTL auth.verification.verify_ttl 未配置时的邮箱验证链接有效期
+	DefaultVerifyTokenTTL = 24 * time.Hour
+)
+
+func (h *AuthHandler) ForgotPassword(ctx context.Context, req param.PasswordForgotRequest) error {
+	user, err := h.repo.FindByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.IsCode(err, code.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.Status == UserStatusDisabled {
+		return nil
+	}
+
+	link, err := h.issueActionToken(ctx, user, auth.PurposePasswordReset, h.resetTTL(), h.verify.ResetURL)
+	if err != nil {
+		return err
+	}
+	err = h.notifier.Send(ctx, notify.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen the link below within %s to reset your password:\n\n%s\n\nIf you did not request a password reset, you can ignore this email.",
+			user.Username, h.resetTTL(), link),
+	})
+	if err != nil {
+		// 投递失败同样返回成功，避免通过响应区分邮箱是否已注册
+		log.Warn("send password reset mail failed", slog.Int64("user_id", user.ID), slog.String("error", err.Error()))
+	}
+	return nil
+}
+
+func (h *AuthHandler) ResetPassword(ctx context.Context, req param.PasswordResetRequest) error {
+	user, _, err := h.consumeActionToken(ctx, req.Token, auth.PurposePasswordReset)
+	if err != nil {
+		return err
+	}
+	if err := checkUserStatus(user); err != nil {
+		return err
+	}
+
+	hash, err := utils.Hash(req.Password)
+	if err != nil {
+		return code.WrapInternalServerError(err, "hash password failed")
+	}
+	if err := h.repo.UpdatePassword(ctx, user.ID, hash); err != nil {
+		return err
+	}
+	// 密码可能已经泄露，重置后让所有已登录的会话下线
+	if err := h.tokens.RevokeUser(ctx, user.ID); err != nil {
+		return err
+	}
+	return h.guard.Unlock(ctx, user.Username)
+}
+
+func (h *AuthHandler) SendVerification(ctx context.Context) error {
+	userID, err := currentUserID(ctx)
+	if err != nil {
+		return err
+	}
+	user, err := h.repo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return code.NewValidationError("email", "account has no email address")
+	}
+	if user.EmailVerifiedAt != nil {
+		return code.NewValidationError("email", "email is already verified")
+	}
+
+	link, err := h.issueActionToken(ctx, user, auth.PurposeVerifyEmail, h.verifyTTL(), h.verify.VerifyURL)
+	if err != nil {
+		return err
+	}
+	return h.notifier.Send(ctx, notify.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nOpen the link below within %s to verify your email address:\n\n%s",
+			user.Username, h.verifyTTL(), link),
+	})
+}
+
+func (h *AuthHandler) VerifyEmail(ctx context.Context, req param.EmailVerifyRequest) error {
+	user, tok, err := h.consumeActionToken(ctx, req.Token, auth.PurposeVerifyEmail)
+	if err != nil {
+		return err
+	}
+	return h.repo.MarkEmailVerified(ctx, user.ID, tok.Email, h.now())
+}
+
+// issueActionToken 签发令牌并登记摘要，返回附带令牌的链接
+func (h *AuthHandler) issueActionToken(ctx context.Context, user *model.User, purpose string, ttl time.Duration, base string) (string, error) {
+	token, err := h.signer.Sign(purpose, user.ID, h.now().Add(ttl))
+	if err != nil {
+		return "", err
+	}
+	err = h.actions.Save(ctx, auth.HashToken(token), auth.ActionToken{Purpose: purpose, UserID: user.ID, Email: user.Email}, ttl)
+	if err != nil {
+		return "", err
+	}
+	return actionLink(base, token)
+}
+
+// consumeActionToken 校验签名后作废令牌，令牌签发后邮箱发生变更时拒绝
+func (h *AuthHandler) consumeActionToken(ctx context.Context, token, purpose string) (*model.User, auth.ActionToken, error) {
+	userID, err := h.signer.Verify(token, purpose, h.now())
+	if err != nil {
+		return nil, auth.ActionToken{}, err
+	}
+	tok, err := h.actions.Consume(ctx, auth.HashToken(token))
+	if err != nil {
+		return nil, auth.ActionToken{}, err
+	}
+	if tok.Purpose != purpose || tok.UserID != userID {
+		return nil, auth.ActionToken{}, code.NewError(code.ErrVerificationTokenInvalid, "verification token mismatch")
+	}
+
+	user, err := h.repo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.IsCode(err, code.ErrUserNotFound) {
+			return nil, auth.ActionToken{}, code.WrapError(err, code.ErrVerificationTokenInvalid, "verification token owner no longer exists")
+		}
+		return nil, auth.ActionToken{}, err
+	}
+	if user.Email != tok.Email {
+		return nil, auth.ActionToken{}, code.NewError(code.ErrVerificationTokenInvalid, "email has changed since the token was issued")
+	}
+	return user, tok, nil
+}
+
+// actionLink 把令牌作为 token 查询参数附加到前端地址，未配置地址时直接返回令牌
+func actionLink(base, token string) (string, error) {
+	if base == "" {
+		return token, nil
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", code.WrapInternalServerError(err, "invalid verification url")
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func (h *AuthHandler) resetTTL() time.Duration {
+	if h.verify.ResetTTL > 0 {
+		return h.verify.ResetTTL
+	}
+	return DefaultResetTokenTTL
+}
+
+func (h *AuthHandler) verifyTTL() time.Duration {
+	if h.verify.VerifyTTL > 0 {
+		return h.verify.VerifyTTL
+	}
+	return DefaultVerifyTokenTTL
+}
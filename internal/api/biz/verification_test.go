@@ -0,0 +1,185 @@
+package biz
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/auth"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/notify"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNotifier 记录投递的通知
+type fakeNotifier struct {
+	mu   sync.Mutex
+	sent []notify.Message
+	err  error
+}
+
+func (f *fakeNotifier) Send(_ context.Context, msg notify.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+// lastToken 从最近一封通知的链接中取出令牌
+func (f *fakeNotifier) lastToken(t *testing.T) string {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	require.NotEmpty(t, f.sent)
+	for _, field := range strings.Fields(f.sent[len(f.sent)-1].Body) {
+		if u, err := url.Parse(field); err == nil && u.Query().Get("token") != "" {
+			return u.Query().Get("token")
+		}
+	}
+	t.Fatal("no token in notification")
+	return ""
+}
+
+func newVerificationTestHandler(t *testing.T, repo *MockAuthRepository) (*AuthHandler, *fakeNotifier, auth.TokenStore) {
+	t.Helper()
+	tokens := auth.NewMemoryTokenStore()
+	h := newTestAuthHandler(t, repo, tokens)
+	h.verify.ResetURL = "https://app.example.com/reset-password"
+	h.verify.VerifyURL = "https://app.example.com/verify-email?lang=en"
+	n := &fakeNotifier{}
+	h.notifier = n
+	return h, n, tokens
+}
+
+func TestAuthHandler_ResetPassword(t *testing.T) {
+	ctx := context.Background()
+	alice := &model.User{ID: 7, Username: "alice", Email: "alice@example.com", Status: UserStatusActive}
+	repo := new(MockAuthRepository)
+	repo.On("FindByEmail", mock.Anything, "alice@example.com").Return(alice, nil)
+	repo.On("FindByEmail", mock.Anything, "nobody@example.com").Return(nil, code.NewError(code.ErrUserNotFound, "user not found"))
+	repo.On("FindByID", mock.Anything, int64(7)).Return(alice, nil)
+	repo.On("UpdateLastLogin", mock.Anything, int64(7), mock.Anything).Return(nil)
+	var newHash string
+	repo.On("UpdatePassword", mock.Anything, int64(7), mock.Anything).Run(func(args mock.Arguments) {
+		newHash = args.String(2)
+	}).Return(nil)
+	h, n, _ := newVerificationTestHandler(t, repo)
+
+	// 未注册的邮箱同样返回成功，但不投递
+	require.NoError(t, h.ForgotPassword(ctx, param.PasswordForgotRequest{Email: "nobody@example.com"}))
+	assert.Empty(t, n.sent)
+
+	session, err := h.issue(ctx, alice)
+	require.NoError(t, err)
+
+	require.NoError(t, h.ForgotPassword(ctx, param.PasswordForgotRequest{Email: "alice@example.com"}))
+	require.Len(t, n.sent, 1)
+	assert.Equal(t, "alice@example.com", n.sent[0].To)
+	assert.Contains(t, n.sent[0].Body, "https://app.example.com/reset-password?token=")
+	token := n.lastToken(t)
+
+	require.NoError(t, h.ResetPassword(ctx, param.PasswordResetRequest{Token: token, Password: "new-secret"}))
+	ok, _, err := utils.Verify(newHash, "new-secret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// 已有会话全部失效
+	_, err = h.Refresh(ctx, param.AuthRefreshRequest{RefreshToken: session.RefreshToken})
+	assert.Error(t, err)
+
+	// 令牌只能使用一次
+	err = h.ResetPassword(ctx, param.PasswordResetRequest{Token: token, Password: "other-secret"})
+	assert.True(t, errors.IsCode(err, code.ErrVerificationTokenInvalid))
+	repo.AssertNumberOfCalls(t, "UpdatePassword", 1)
+}
+
+func TestAuthHandler_ResetPasswordRejected(t *testing.T) {
+	ctx := context.Background()
+	alice := &model.User{ID: 7, Username: "alice", Email: "alice@example.com", Status: UserStatusActive}
+	repo := new(MockAuthRepository)
+	repo.On("FindByEmail", mock.Anything, "alice@example.com").Return(alice, nil)
+	repo.On("FindByID", mock.Anything, int64(7)).Return(&model.User{ID: 7, Username: "alice", Email: "new@example.com"}, nil)
+	h, n, _ := newVerificationTestHandler(t, repo)
+
+	require.NoError(t, h.ForgotPassword(ctx, param.PasswordForgotRequest{Email: "alice@example.com"}))
+	token := n.lastToken(t)
+
+	// 签发后邮箱已变更
+	err := h.ResetPassword(ctx, param.PasswordResetRequest{Token: token, Password: "new-secret"})
+	assert.True(t, errors.IsCode(err, code.ErrVerificationTokenInvalid))
+
+	// 过期
+	require.NoError(t, h.ForgotPassword(ctx, param.PasswordForgotRequest{Email: "alice@example.com"}))
+	token = n.lastToken(t)
+	later := time.Now().Add(h.resetTTL())
+	h.now = func() time.Time { return later }
+	err = h.ResetPassword(ctx, param.PasswordResetRequest{Token: token, Password: "new-secret"})
+	assert.True(t, errors.IsCode(err, code.ErrVerificationTokenInvalid))
+
+	// 用途不符与伪造
+	h.now = time.Now
+	for _, bad := range []string{"garbage", token + "x"} {
+		err = h.VerifyEmail(ctx, param.EmailVerifyRequest{Token: bad})
+		assert.True(t, errors.IsCode(err, code.ErrVerificationTokenInvalid), bad)
+	}
+	err = h.VerifyEmail(ctx, param.EmailVerifyRequest{Token: token})
+	assert.True(t, errors.IsCode(err, code.ErrVerificationTokenInvalid))
+	repo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+
+	// 投递失败不暴露邮箱是否存在
+	n.err = code.NewError(code.ErrNotifyFailed, "smtp down")
+	assert.NoError(t, h.ForgotPassword(ctx, param.PasswordForgotRequest{Email: "alice@example.com"}))
+}
+
+func TestAuthHandler_VerifyEmail(t *testing.T) {
+	ctx := utils.WithPrincipal(context.Background(), &utils.Principal{ID: "7", Name: "alice"})
+	alice := &model.User{ID: 7, Username: "alice", Email: "alice@example.com", Status: UserStatusActive}
+	repo := new(MockAuthRepository)
+	repo.On("FindByID", mock.Anything, int64(7)).Return(alice, nil)
+	repo.On("MarkEmailVerified", mock.Anything, int64(7), "alice@example.com", mock.Anything).Return(nil)
+	h, n, _ := newVerificationTestHandler(t, repo)
+
+	require.NoError(t, h.SendVerification(ctx))
+	require.Len(t, n.sent, 1)
+	assert.Contains(t, n.sent[0].Body, "https://app.example.com/verify-email?lang=en&token=")
+	token := n.lastToken(t)
+
+	require.NoError(t, h.VerifyEmail(context.Background(), param.EmailVerifyRequest{Token: token}))
+	repo.AssertNumberOfCalls(t, "MarkEmailVerified", 1)
+
+	err := h.VerifyEmail(context.Background(), param.EmailVerifyRequest{Token: token})
+	assert.True(t, errors.IsCode(err, code.ErrVerificationTokenInvalid))
+
+	// 验证邮箱令牌不能用于重置密码，且不会因此被作废
+	require.NoError(t, h.SendVerification(ctx))
+	token = n.lastToken(t)
+	err = h.ResetPassword(context.Background(), param.PasswordResetRequest{Token: token, Password: "new-secret"})
+	assert.True(t, errors.IsCode(err, code.ErrVerificationTokenInvalid))
+	require.NoError(t, h.VerifyEmail(context.Background(), param.EmailVerifyRequest{Token: token}))
+
+	// 已验证或没有邮箱时拒绝发送
+	verified := time.Now()
+	h.repo = verifiedRepo(&model.User{ID: 7, Email: "alice@example.com", EmailVerifiedAt: &verified})
+	assert.True(t, errors.IsCode(h.SendVerification(ctx), code.ErrValidation))
+	h.repo = verifiedRepo(&model.User{ID: 7})
+	assert.True(t, errors.IsCode(h.SendVerification(ctx), code.ErrValidation))
+	assert.Error(t, h.SendVerification(context.Background()))
+}
+
+func verifiedRepo(user *model.User) *MockAuthRepository {
+	repo := new(MockAuthRepository)
+	repo.On("FindByID", mock.Anything, user.ID).Return(user, nil)
+	return repo
+}
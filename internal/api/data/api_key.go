@@ -0,0 +1,95 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/api/biz"
+	"github.com/NSObjects/go-template/internal/api/data/db"
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/marmotedu/errors"
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	d *db.DataManager
+}
+
+func NewAPIKeyRepository(d *db.DataManager) biz.APIKeyRepository {
+	return apiKeyRepository{d: d}
+}
+
+func (r apiKeyRepository) ListAPIKeys(ctx context.Context, userID int64, offset, limit int) ([]*model.APIKey, int64, error) {
+	q := r.d.Mysql.WithContext(ctx).Model(&model.APIKey{})
+	if userID != 0 {
+		q = q.Where("user_id = ?", userID)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, code.WrapDatabaseError(err, "count api keys")
+	}
+	var keys []*model.APIKey
+	if err := q.Order("id DESC").Offset(offset).Limit(limit).Find(&keys).Error; err != nil {
+		return nil, 0, code.WrapDatabaseError(err, "query api keys")
+	}
+	return keys, total, nil
+}
+
+func (r apiKeyRepository) FindAPIKey(ctx context.Context, id int64) (*model.APIKey, error) {
+	return r.find(ctx, "id = ?", id)
+}
+
+func (r apiKeyRepository) FindAPIKeyByPrefix(ctx context.Context, prefix string) (*model.APIKey, error) {
+	return r.find(ctx, "prefix = ?", prefix)
+}
+
+func (r apiKeyRepository) find(ctx context.Context, query string, arg any) (*model.APIKey, error) {
+	var key model.APIKey
+	if err := r.d.Mysql.WithContext(ctx).Where(query, arg).Take(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.WrapError(err, code.ErrAPIKeyNotFound, "api key not found")
+		}
+		return nil, code.WrapDatabaseError(err, "query api key")
+	}
+	return &key, nil
+}
+
+func (r apiKeyRepository) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	if err := r.d.Mysql.WithContext(ctx).Create(key).Error; err != nil {
+		return code.WrapDatabaseError(err, "create api key")
+	}
+	return nil
+}
+
+func (r apiKeyRepository) UpdateAPIKey(ctx context.Context, key *model.APIKey) error {
+	err := r.d.Mysql.WithContext(ctx).Model(&model.APIKey{}).Where("id = ?", key.ID).Updates(map[string]any{
+		"name":       key.Name,
+		"scopes":     key.Scopes,
+		"expires_at": key.ExpiresAt,
+		"updated_at": time.Now(),
+	}).Error
+	if err != nil {
+		return code.WrapDatabaseError(err, "update api key")
+	}
+	return nil
+}
+
+func (r apiKeyRepository) DeleteAPIKey(ctx context.Context, id int64) error {
+	res := r.d.Mysql.WithContext(ctx).Where("id = ?", id).Delete(&model.APIKey{})
+	if res.Error != nil {
+		return code.WrapDatabaseError(res.Error, "delete api key")
+	}
+	if res.RowsAffected == 0 {
+		return code.NewError(code.ErrAPIKeyNotFound, "api key not found")
+	}
+	return nil
+}
+
+func (r apiKeyRepository) TouchAPIKey(ctx context.Context, id int64, at time.Time) error {
+	err := r.d.Mysql.WithContext(ctx).Model(&model.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", at).Error
+	if err != nil {
+		return code.WrapDatabaseError(err, "touch api key")
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/api/data/db"
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/glebarez/sqlite"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestAPIKeyRepository(t *testing.T) apiKeyRepository {
+	t.Helper()
+	gdb, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := gdb.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, gdb.Exec(`CREATE TABLE api_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		prefix TEXT NOT NULL UNIQUE,
+		hash TEXT NOT NULL,
+		user_id INTEGER NOT NULL,
+		scopes TEXT NOT NULL DEFAULT '',
+		expires_at DATETIME,
+		last_used_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error)
+	return apiKeyRepository{d: &db.DataManager{Mysql: gdb}}
+}
+
+func TestAPIKeyRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestAPIKeyRepository(t)
+
+	for i, prefix := range []string{"aaaaaaaaaaaa", "bbbbbbbbbbbb", "cccccccccccc"} {
+		require.NoError(t, repo.CreateAPIKey(ctx, &model.APIKey{Name: prefix, Prefix: prefix, Hash: "h", UserID: int64(7 + i%2), Scopes: "*"}))
+	}
+
+	keys, total, err := repo.ListAPIKeys(ctx, 7, 0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "cccccccccccc", keys[0].Prefix)
+
+	key, err := repo.FindAPIKeyByPrefix(ctx, "bbbbbbbbbbbb")
+	require.NoError(t, err)
+	assert.Nil(t, key.LastUsedAt)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, repo.TouchAPIKey(ctx, key.ID, now))
+	key.Name, key.Scopes = "renamed", "GET:/api/users"
+	require.NoError(t, repo.UpdateAPIKey(ctx, key))
+	key, err = repo.FindAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", key.Name)
+	assert.Equal(t, "GET:/api/users", key.Scopes)
+	require.NotNil(t, key.LastUsedAt)
+	assert.True(t, now.Equal(*key.LastUsedAt))
+
+	require.NoError(t, repo.DeleteAPIKey(ctx, key.ID))
+	assert.True(t, errors.IsCode(repo.DeleteAPIKey(ctx, key.ID), code.ErrAPIKeyNotFound))
+	_, err = repo.FindAPIKey(ctx, key.ID)
+	assert.True(t, errors.IsCode(err, code.ErrAPIKeyNotFound))
+}
@@ -0,0 +1,108 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/api/biz"
+	"github.com/NSObjects/go-template/internal/api/data/db"
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/auth"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/marmotedu/errors"
+	"gorm.io/gorm"
+)
+
+type authRepository struct {
+	d *db.DataManager
+}
+
+func NewAuthRepository(d *db.DataManager) biz.AuthRepository {
+	return authRepository{d: d}
+}
+
+func (a authRepository) FindByUsername(ctx context.Context, username string) (*model.User, error) {
+	u := a.d.Query.User
+	user, err := u.WithContext(ctx).Where(u.Username.Eq(username)).First()
+	if err != nil {
+		return nil, wrapUserLookupError(err)
+	}
+	return user, nil
+}
+
+func (a authRepository) FindByID(ctx context.Context, id int64) (*model.User, error) {
+	u := a.d.Query.User
+	user, err := u.WithContext(ctx).Where(u.ID.Eq(id)).First()
+	if err != nil {
+		return nil, wrapUserLookupError(err)
+	}
+	return user, nil
+}
+
+func (a authRepository) UpdatePassword(ctx context.Context, id int64, hash string) error {
+	u := a.d.Query.User
+	if _, err := u.WithContext(ctx).Where(u.ID.Eq(id)).Update(u.Password, hash); err != nil {
+		return code.WrapDatabaseError(err, "update password")
+	}
+	return nil
+}
+
+func (a authRepository) UpdateLastLogin(ctx context.Context, id int64, at time.Time) error {
+	u := a.d.Query.User
+	if _, err := u.WithContext(ctx).Where(u.ID.Eq(id)).Update(u.LastLogin, at); err != nil {
+		return code.WrapDatabaseError(err, "update last login")
+	}
+	return nil
+}
+
+func (a authRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	u := a.d.Query.User
+	user, err := u.WithContext(ctx).Where(u.Email.Eq(email)).First()
+	if err != nil {
+		return nil, wrapUserLookupError(err)
+	}
+	return user, nil
+}
+
+func (a authRepository) MarkEmailVerified(ctx context.Context, id int64, email string, at time.Time) error {
+	u := a.d.Query.User
+	info, err := u.WithContext(ctx).Where(u.ID.Eq(id), u.Email.Eq(email)).Update(u.EmailVerifiedAt, at)
+	if err != nil {
+		return code.WrapDatabaseError(err, "mark email verified")
+	}
+	if info.RowsAffected == 0 {
+		return code.NewError(code.ErrVerificationTokenInvalid, "email has changed since the token was issued")
+	}
+	return nil
+}
+
+func wrapUserLookupError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return code.WrapError(err, code.ErrUserNotFound, "user not found")
+	}
+	return code.WrapDatabaseError(err, "query user")
+}
+
+// NewTokenStore 配置了 Redis 时使用 Redis 存储令牌族与吊销名单，否则退化为进程内存储（仅适合单实例/开发环境）
+func NewTokenStore(d *db.DataManager) auth.TokenStore {
+	if d != nil && d.Redis != nil {
+		return auth.NewRedisTokenStore(d.Redis)
+	}
+	return auth.NewMemoryTokenStore()
+}
+
+// NewAttemptStore 配置了 Redis 时在副本间共享登录失败计数，否则退化为进程内存储
+func NewAttemptStore(d *db.DataManager) auth.AttemptStore {
+	if d != nil && d.Redis != nil {
+		return auth.NewRedisAttemptStore(d.Redis)
+	}
+	return auth.NewMemoryAttemptStore()
+}
+
+// NewActionTokenStore 配置了 Redis 时在副本间共享重置密码、邮箱验证等一次性令牌，否则退化为进程内存储
+func NewActionTokenStore(d *db.DataManager) auth.ActionTokenStore {
+	if d != nil && d.Redis != nil {
+		return auth.NewRedisActionTokenStore(d.Redis)
+	}
+	return auth.NewMemoryActionTokenStore()
+}
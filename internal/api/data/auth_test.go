@@ -0,0 +1,66 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/api/data/db"
+	"github.com/NSObjects/go-template/internal/api/data/query"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/glebarez/sqlite"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestAuthRepository(t *testing.T) authRepository {
+	t.Helper()
+	gdb, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := gdb.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, gdb.Exec(`CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT NOT NULL,
+		email TEXT NOT NULL DEFAULT '',
+		mobile TEXT NOT NULL DEFAULT '',
+		password TEXT NOT NULL DEFAULT '',
+		gender INTEGER NOT NULL DEFAULT 0,
+		age INTEGER NOT NULL DEFAULT 0,
+		status INTEGER NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		last_login DATETIME,
+		bio TEXT NOT NULL DEFAULT '',
+		email_verified_at DATETIME
+	)`).Error)
+	require.NoError(t, gdb.Exec(`INSERT INTO users (username, email) VALUES ('alice', 'alice@example.com')`).Error)
+	return authRepository{d: &db.DataManager{Mysql: gdb, Query: query.Use(gdb)}}
+}
+
+func TestAuthRepository_EmailVerification(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestAuthRepository(t)
+
+	user, err := repo.FindByEmail(ctx, "alice@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Username)
+	assert.Nil(t, user.EmailVerifiedAt)
+
+	_, err = repo.FindByEmail(ctx, "nobody@example.com")
+	assert.True(t, errors.IsCode(err, code.ErrUserNotFound))
+
+	// 邮箱已变更时不标记
+	at := time.Unix(1700000000, 0).UTC()
+	err = repo.MarkEmailVerified(ctx, user.ID, "old@example.com", at)
+	assert.True(t, errors.IsCode(err, code.ErrVerificationTokenInvalid))
+
+	require.NoError(t, repo.MarkEmailVerified(ctx, user.ID, "alice@example.com", at))
+	user, err = repo.FindByID(ctx, user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, user.EmailVerifiedAt)
+	assert.True(t, at.Equal(*user.EmailVerifiedAt))
+}
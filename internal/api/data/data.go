@@ -10,4 +10,14 @@ import "go.uber.org/fx"
 
 var Model = fx.Options(
 	fx.Provide(NewUserRepository),
+	fx.Provide(NewAuthRepository),
+	fx.Provide(NewTokenStore),
+	fx.Provide(NewAttemptStore),
+	fx.Provide(NewMfaRepository),
+	fx.Provide(NewChallengeStore),
+	fx.Provide(NewIdentityRepository),
+	fx.Provide(NewStateStore),
+	fx.Provide(NewActionTokenStore),
+	fx.Provide(NewAPIKeyRepository),
+	fx.Provide(NewRbacRepository),
 )
@@ -13,6 +13,7 @@ import (
 	"github.com/IBM/sarama"
 	"github.com/NSObjects/go-template/internal/api/data/query"
 	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/tenant"
 	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/fx"
@@ -43,6 +44,10 @@ func NewDataManager(lc fx.Lifecycle, cfg configs.Config) *DataManager {
 	// 初始化MySQL
 	if cfg.Mysql.Host != "" {
 		dm.Mysql = NewMysql(cfg.Mysql)
+		// 含租户字段的表按 context 中的租户自动隔离
+		if err := dm.Mysql.Use(tenant.NewPlugin(cfg.Tenant.Column)); err != nil {
+			panic(err)
+		}
 	}
 
 	// 初始化MongoDB
@@ -222,6 +227,14 @@ func NewDB(dm *DataManager) *gorm.DB {
 	return dm.Mysql
 }
 
+// NewRedisClient 提供Redis客户端，未配置Redis时为nil
+func NewRedisClient(dm *DataManager) *redis.Client {
+	if dm == nil {
+		return nil
+	}
+	return dm.Redis
+}
+
 // NewQuery 为了向后兼容，提供获取Query的方法
 func NewQuery(dm *DataManager) *query.Query {
 	if dm == nil || dm.Query == nil {
@@ -230,8 +243,36 @@ func NewQuery(dm *DataManager) *query.Query {
 	return dm.Query
 }
 
+// watchParams 连接池热更新依赖，未提供 Store 时不订阅
+type watchParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Data      *DataManager
+	Store     *configs.Store `optional:"true"`
+}
+
+// watchConfig 让连接池大小随配置热更新
+func watchConfig(p watchParams) error {
+	if p.Store == nil || p.Data.Mysql == nil {
+		return nil
+	}
+	sqlDB, err := p.Data.Mysql.DB()
+	if err != nil {
+		return err
+	}
+	stop, err := WatchPool(p.Store, sqlDB)
+	if err != nil {
+		return err
+	}
+	p.Lifecycle.Append(fx.StopHook(stop))
+	return nil
+}
+
 var Model = fx.Options(
+	fx.Invoke(watchConfig),
 	fx.Provide(NewDataManager),
 	fx.Provide(NewDB),
+	fx.Provide(NewRedisClient),
 	fx.Provide(NewQuery),
 )
@@ -9,6 +9,7 @@ package db
 import (
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"time"
 
@@ -62,3 +63,18 @@ func configureSQLPool(sqlDB *sql.DB, cfg configs.MysqlConfig) {
 	// 5 minutes default if not set elsewhere; safe choice
 	sqlDB.SetConnMaxLifetime(5 * time.Minute)
 }
+
+// WatchPool 订阅 mysql 连接池配置，热更新后调整连接数上限，返回取消订阅的函数。
+// 与启动时相同，取值为 0 的项保持当前设置。
+func WatchPool(store *configs.Store, sqlDB *sql.DB) (func(), error) {
+	return store.SubscribeFunc("mysql", func(c configs.Change) {
+		old, cur := c.Old.(configs.MysqlConfig), c.New.(configs.MysqlConfig)
+		if old.MaxOpenConns == cur.MaxOpenConns && old.MaxIdleConns == cur.MaxIdleConns {
+			return
+		}
+		configureSQLPool(sqlDB, cur)
+		slog.Info("mysql pool resized",
+			slog.Int("max_open_conns", cur.MaxOpenConns),
+			slog.Int("max_idle_conns", cur.MaxIdleConns))
+	})
+}
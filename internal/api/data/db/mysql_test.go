@@ -0,0 +1,31 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestWatchPool(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := gdb.DB()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	store := configs.NewStore(configs.Config{Mysql: configs.MysqlConfig{MaxOpenConns: 10}})
+	configureSQLPool(sqlDB, store.Current().Mysql)
+	stop, err := WatchPool(store, sqlDB)
+	require.NoError(t, err)
+	defer stop()
+
+	c := store.Current()
+	c.Mysql.MaxOpenConns = 3
+	store.Update(c)
+	assert.Eventually(t, func() bool { return sqlDB.Stats().MaxOpenConnections == 3 }, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,111 @@
+package data
+
+import (
+	"context"
+
+	"github.com/NSObjects/go-template/internal/api/biz"
+	"github.com/NSObjects/go-template/internal/api/data/db"
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/auth"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/marmotedu/errors"
+	"gorm.io/gorm"
+)
+
+type identityRepository struct {
+	d *db.DataManager
+}
+
+func NewIdentityRepository(d *db.DataManager) biz.IdentityRepository {
+	return identityRepository{d: d}
+}
+
+func (r identityRepository) FindIdentity(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := r.d.Mysql.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).Take(&identity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.WrapError(err, code.ErrIdentityNotLinked, "identity is not linked")
+		}
+		return nil, code.WrapDatabaseError(err, "query identity")
+	}
+	return &identity, nil
+}
+
+func (r identityRepository) FindUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	u := r.d.Query.User
+	user, err := u.WithContext(ctx).Where(u.Email.Eq(email)).First()
+	if err != nil {
+		return nil, wrapUserLookupError(err)
+	}
+	return user, nil
+}
+
+func (r identityRepository) LinkIdentity(ctx context.Context, identity *model.UserIdentity) error {
+	err := r.d.Mysql.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return linkIdentity(tx, identity)
+	})
+	return wrapIdentityError(err, "link identity")
+}
+
+func (r identityRepository) CreateUserWithIdentity(ctx context.Context, user *model.User, identity *model.UserIdentity) error {
+	err := r.d.Mysql.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.User{}).Where("username = ?", user.Username).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return code.NewError(code.ErrUserAlreadyExists, "user already exists")
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return linkIdentity(tx, identity)
+	})
+	return wrapIdentityError(err, "create user with identity")
+}
+
+func (r identityRepository) UnlinkIdentity(ctx context.Context, userID int64, provider string) error {
+	res := r.d.Mysql.WithContext(ctx).Where("user_id = ? AND provider = ?", userID, provider).Delete(&model.UserIdentity{})
+	if res.Error != nil {
+		return code.WrapDatabaseError(res.Error, "unlink identity")
+	}
+	if res.RowsAffected == 0 {
+		return code.NewError(code.ErrIdentityNotLinked, "identity is not linked")
+	}
+	return nil
+}
+
+// linkIdentity 已关联到同一用户时视为成功，关联到其他用户时返回 code.ErrIdentityAlreadyLinked
+func linkIdentity(tx *gorm.DB, identity *model.UserIdentity) error {
+	var existing model.UserIdentity
+	err := tx.Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).Take(&existing).Error
+	if err == nil {
+		if existing.UserID != identity.UserID {
+			return code.NewError(code.ErrIdentityAlreadyLinked, "identity is linked to another user")
+		}
+		*identity = existing
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return tx.Create(identity).Error
+}
+
+// wrapIdentityError 事务中返回的业务错误原样返回，其余按数据库错误处理
+func wrapIdentityError(err error, operation string) error {
+	if err == nil || errors.IsCode(err, code.ErrIdentityAlreadyLinked) || errors.IsCode(err, code.ErrUserAlreadyExists) {
+		return err
+	}
+	return code.WrapDatabaseError(err, operation)
+}
+
+// NewStateStore 配置了 Redis 时在副本间共享授权上下文，否则退化为进程内存储
+func NewStateStore(d *db.DataManager) auth.StateStore {
+	if d != nil && d.Redis != nil {
+		return auth.NewRedisStateStore(d.Redis)
+	}
+	return auth.NewMemoryStateStore()
+}
@@ -0,0 +1,58 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/api/data/db"
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/glebarez/sqlite"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestIdentityRepository(t *testing.T) identityRepository {
+	t.Helper()
+	gdb, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := gdb.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, gdb.Exec(`CREATE TABLE user_identities (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		provider TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		email TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (provider, subject)
+	)`).Error)
+	return identityRepository{d: &db.DataManager{Mysql: gdb}}
+}
+
+func TestIdentityRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestIdentityRepository(t)
+
+	_, err := repo.FindIdentity(ctx, "corp", "sub-1")
+	assert.True(t, errors.IsCode(err, code.ErrIdentityNotLinked))
+
+	require.NoError(t, repo.LinkIdentity(ctx, &model.UserIdentity{UserID: 7, Provider: "corp", Subject: "sub-1"}))
+	// 重复关联到同一用户视为成功
+	require.NoError(t, repo.LinkIdentity(ctx, &model.UserIdentity{UserID: 7, Provider: "corp", Subject: "sub-1"}))
+	err = repo.LinkIdentity(ctx, &model.UserIdentity{UserID: 8, Provider: "corp", Subject: "sub-1"})
+	assert.True(t, errors.IsCode(err, code.ErrIdentityAlreadyLinked))
+
+	identity, err := repo.FindIdentity(ctx, "corp", "sub-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), identity.UserID)
+	assert.NotZero(t, identity.ID)
+
+	assert.True(t, errors.IsCode(repo.UnlinkIdentity(ctx, 8, "corp"), code.ErrIdentityNotLinked))
+	require.NoError(t, repo.UnlinkIdentity(ctx, 7, "corp"))
+	_, err = repo.FindIdentity(ctx, "corp", "sub-1")
+	assert.True(t, errors.IsCode(err, code.ErrIdentityNotLinked))
+}
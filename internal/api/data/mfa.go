@@ -0,0 +1,138 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/api/biz"
+	"github.com/NSObjects/go-template/internal/api/data/db"
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/auth"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/marmotedu/errors"
+	"gorm.io/gorm"
+)
+
+type mfaRepository struct {
+	d *db.DataManager
+}
+
+func NewMfaRepository(d *db.DataManager) biz.MfaRepository {
+	return mfaRepository{d: d}
+}
+
+func (m mfaRepository) FindMfa(ctx context.Context, userID int64) (*model.UserMfa, error) {
+	var setting model.UserMfa
+	err := m.d.Mysql.WithContext(ctx).Where("user_id = ?", userID).Take(&setting).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, code.WrapError(err, code.ErrMfaNotEnrolled, "mfa is not enrolled")
+		}
+		return nil, code.WrapDatabaseError(err, "query mfa")
+	}
+	return &setting, nil
+}
+
+func (m mfaRepository) SaveMfaSecret(ctx context.Context, userID int64, secret string) error {
+	err := m.d.Mysql.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 只覆盖未启用的记录，已启用的密钥需由管理员重置后才能重新登记
+		res := tx.Model(&model.UserMfa{}).
+			Where("user_id = ? AND enabled = ?", userID, false).
+			Updates(map[string]any{"secret": secret, "updated_at": time.Now()})
+		if res.Error != nil || res.RowsAffected > 0 {
+			return res.Error
+		}
+
+		var count int64
+		if err := tx.Model(&model.UserMfa{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return code.NewError(code.ErrMfaAlreadyEnabled, "mfa is already enabled")
+		}
+		return tx.Create(&model.UserMfa{UserID: userID, Secret: secret}).Error
+	})
+	return wrapMfaError(err, "save mfa secret")
+}
+
+func (m mfaRepository) EnableMfa(ctx context.Context, userID, step int64, recoveryHashes []string) error {
+	err := m.d.Mysql.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&model.UserMfa{}).
+			Where("user_id = ? AND enabled = ?", userID, false).
+			Updates(map[string]any{"enabled": true, "last_step": step, "updated_at": time.Now()})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return code.NewError(code.ErrMfaAlreadyEnabled, "mfa is already enabled")
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&model.UserMfaRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]model.UserMfaRecoveryCode, 0, len(recoveryHashes))
+		for _, h := range recoveryHashes {
+			codes = append(codes, model.UserMfaRecoveryCode{UserID: userID, Hash: h})
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+	return wrapMfaError(err, "enable mfa")
+}
+
+func (m mfaRepository) UseTotpStep(ctx context.Context, userID, step int64) (bool, error) {
+	res := m.d.Mysql.WithContext(ctx).Model(&model.UserMfa{}).
+		Where("user_id = ? AND enabled = ? AND last_step < ?", userID, true, step).
+		Update("last_step", step)
+	if res.Error != nil {
+		return false, code.WrapDatabaseError(res.Error, "update mfa step")
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (m mfaRepository) RecoveryCodes(ctx context.Context, userID int64) ([]*model.UserMfaRecoveryCode, error) {
+	var codes []*model.UserMfaRecoveryCode
+	err := m.d.Mysql.WithContext(ctx).Where("user_id = ? AND used_at IS NULL", userID).Find(&codes).Error
+	if err != nil {
+		return nil, code.WrapDatabaseError(err, "query recovery codes")
+	}
+	return codes, nil
+}
+
+func (m mfaRepository) UseRecoveryCode(ctx context.Context, id int64) (bool, error) {
+	res := m.d.Mysql.WithContext(ctx).Model(&model.UserMfaRecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, code.WrapDatabaseError(res.Error, "use recovery code")
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (m mfaRepository) DeleteMfa(ctx context.Context, userID int64) error {
+	err := m.d.Mysql.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.UserMfaRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.UserMfa{}).Error
+	})
+	return code.WrapDatabaseError(err, "delete mfa")
+}
+
+// wrapMfaError 事务中返回的业务错误原样返回，其余按数据库错误处理
+func wrapMfaError(err error, operation string) error {
+	if err == nil || errors.IsCode(err, code.ErrMfaAlreadyEnabled) {
+		return err
+	}
+	return code.WrapDatabaseError(err, operation)
+}
+
+// NewChallengeStore 配置了 Redis 时在副本间共享 mfa_token，否则退化为进程内存储
+func NewChallengeStore(d *db.DataManager) auth.ChallengeStore {
+	if d != nil && d.Redis != nil {
+		return auth.NewRedisChallengeStore(d.Redis)
+	}
+	return auth.NewMemoryChallengeStore()
+}
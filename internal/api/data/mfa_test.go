@@ -0,0 +1,82 @@
+package data
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/api/data/db"
+	"github.com/NSObjects/go-template/internal/api/data/model"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/glebarez/sqlite"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestMfaRepository(t *testing.T) mfaRepository {
+	t.Helper()
+	gdb, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := gdb.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	// sqlite 只有 INTEGER PRIMARY KEY 才会自增，不能直接使用 MySQL 的列类型迁移
+	require.NoError(t, gdb.AutoMigrate(&model.UserMfa{}))
+	require.NoError(t, gdb.Exec(`CREATE TABLE user_mfa_recovery_codes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		hash TEXT NOT NULL,
+		used_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error)
+	return mfaRepository{d: &db.DataManager{Mysql: gdb}}
+}
+
+func TestMfaRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestMfaRepository(t)
+
+	_, err := repo.FindMfa(ctx, 7)
+	assert.True(t, errors.IsCode(err, code.ErrMfaNotEnrolled))
+
+	// 未启用前可以重新登记
+	require.NoError(t, repo.SaveMfaSecret(ctx, 7, "FIRST"))
+	require.NoError(t, repo.SaveMfaSecret(ctx, 7, "SECOND"))
+	setting, err := repo.FindMfa(ctx, 7)
+	require.NoError(t, err)
+	assert.Equal(t, "SECOND", setting.Secret)
+	assert.False(t, setting.Enabled)
+
+	require.NoError(t, repo.EnableMfa(ctx, 7, 100, []string{"h1", "h2"}))
+	assert.True(t, errors.IsCode(repo.EnableMfa(ctx, 7, 100, nil), code.ErrMfaAlreadyEnabled))
+	assert.True(t, errors.IsCode(repo.SaveMfaSecret(ctx, 7, "THIRD"), code.ErrMfaAlreadyEnabled))
+
+	// 同一步长只能使用一次
+	ok, err := repo.UseTotpStep(ctx, 7, 100)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = repo.UseTotpStep(ctx, 7, 101)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	codes, err := repo.RecoveryCodes(ctx, 7)
+	require.NoError(t, err)
+	require.Len(t, codes, 2)
+	ok, err = repo.UseRecoveryCode(ctx, codes[0].ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = repo.UseRecoveryCode(ctx, codes[0].ID)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	codes, err = repo.RecoveryCodes(ctx, 7)
+	require.NoError(t, err)
+	assert.Len(t, codes, 1)
+
+	require.NoError(t, repo.DeleteMfa(ctx, 7))
+	_, err = repo.FindMfa(ctx, 7)
+	assert.True(t, errors.IsCode(err, code.ErrMfaNotEnrolled))
+	codes, err = repo.RecoveryCodes(ctx, 7)
+	require.NoError(t, err)
+	assert.Empty(t, codes)
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+)
+
+const TableNameAPIKey = "api_keys"
+
+// APIKey 服务间调用的 API 密钥，只保存密钥摘要，调用方以 UserID 对应用户的身份访问并受 Scopes 限制
+type APIKey struct {
+	ID     int64  `gorm:"column:id;type:bigint unsigned;primaryKey;autoIncrement:true" json:"id"`
+	Name   string `gorm:"column:name;type:varchar(100)" json:"name"`
+	Prefix string `gorm:"column:prefix;type:varchar(16);uniqueIndex:prefix_idx,priority:1" json:"prefix"`
+	Hash   string `gorm:"column:hash;type:char(64)" json:"-"`
+	UserID int64  `gorm:"column:user_id;type:bigint unsigned;index:user_id_idx,priority:1" json:"user_id"`
+	// Scopes 以空格分隔的授权范围
+	Scopes     string     `gorm:"column:scopes;type:varchar(1000)" json:"scopes"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at;type:datetime" json:"expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at;type:datetime" json:"last_used_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at;type:datetime;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at;type:datetime;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName APIKey's table name
+func (*APIKey) TableName() string {
+	return TableNameAPIKey
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+)
+
+const TableNameUserIdentity = "user_identities"
+
+// UserIdentity 用户关联的外部身份，同一提供方的 subject 只能关联一个用户
+type UserIdentity struct {
+	ID        int64     `gorm:"column:id;type:bigint unsigned;primaryKey;autoIncrement:true" json:"id"`
+	UserID    int64     `gorm:"column:user_id;type:bigint unsigned;index:user_id_idx,priority:1" json:"user_id"`
+	Provider  string    `gorm:"column:provider;type:varchar(50);uniqueIndex:provider_subject_idx,priority:1" json:"provider"`
+	Subject   string    `gorm:"column:subject;type:varchar(255);uniqueIndex:provider_subject_idx,priority:2" json:"subject"`
+	Email     string    `gorm:"column:email;type:varchar(100)" json:"email"`
+	CreatedAt time.Time `gorm:"column:created_at;type:datetime;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// TableName UserIdentity's table name
+func (*UserIdentity) TableName() string {
+	return TableNameUserIdentity
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	TableNameUserMfa             = "user_mfa"
+	TableNameUserMfaRecoveryCode = "user_mfa_recovery_codes"
+)
+
+// UserMfa 用户的 TOTP 两步验证设置，Enabled 为 false 时表示已生成密钥但尚未确认
+type UserMfa struct {
+	UserID    int64     `gorm:"column:user_id;type:bigint unsigned;primaryKey" json:"user_id"`
+	Secret    string    `gorm:"column:secret;type:varchar(64)" json:"-"`
+	Enabled   bool      `gorm:"column:enabled;type:tinyint(1);default:0" json:"enabled"`
+	LastStep  int64     `gorm:"column:last_step;type:bigint;default:0" json:"-"`
+	CreatedAt time.Time `gorm:"column:created_at;type:datetime;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;type:datetime;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TableName UserMfa's table name
+func (*UserMfa) TableName() string {
+	return TableNameUserMfa
+}
+
+// UserMfaRecoveryCode 一次性恢复码，只保存哈希
+type UserMfaRecoveryCode struct {
+	ID        int64      `gorm:"column:id;type:bigint unsigned;primaryKey;autoIncrement:true" json:"id"`
+	UserID    int64      `gorm:"column:user_id;type:bigint unsigned;index:user_id_idx,priority:1" json:"user_id"`
+	Hash      string     `gorm:"column:hash;type:char(60)" json:"-"`
+	UsedAt    *time.Time `gorm:"column:used_at;type:datetime" json:"used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;type:datetime;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// TableName UserMfaRecoveryCode's table name
+func (*UserMfaRecoveryCode) TableName() string {
+	return TableNameUserMfaRecoveryCode
+}
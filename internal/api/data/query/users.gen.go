@@ -44,6 +44,7 @@ func newUser(db *gorm.DB, opts ...gen.DOOption) user {
 	_user.UpdatedAt = field.NewTime(tableName, "updated_at")
 	_user.LastLogin = field.NewTime(tableName, "last_login")
 	_user.Bio = field.NewString(tableName, "bio")
+	_user.EmailVerifiedAt = field.NewTime(tableName, "email_verified_at")
 
 	_user.fillFieldMap()
 
@@ -53,19 +54,20 @@ func newUser(db *gorm.DB, opts ...gen.DOOption) user {
 type user struct {
 	userDo
 
-	ALL       field.Asterisk
-	ID        field.Int64
-	Username  field.String
-	Email     field.String
-	Mobile    field.String
-	Password  field.String
-	Gender    field.Int32
-	Age       field.Int32
-	Status    field.Int32
-	CreatedAt field.Time
-	UpdatedAt field.Time
-	LastLogin field.Time
-	Bio       field.String
+	ALL             field.Asterisk
+	ID              field.Int64
+	Username        field.String
+	Email           field.String
+	Mobile          field.String
+	Password        field.String
+	Gender          field.Int32
+	Age             field.Int32
+	Status          field.Int32
+	CreatedAt       field.Time
+	UpdatedAt       field.Time
+	LastLogin       field.Time
+	Bio             field.String
+	EmailVerifiedAt field.Time
 
 	fieldMap map[string]field.Expr
 }
@@ -94,6 +96,7 @@ func (u *user) updateTableName(table string) *user {
 	u.UpdatedAt = field.NewTime(table, "updated_at")
 	u.LastLogin = field.NewTime(table, "last_login")
 	u.Bio = field.NewString(table, "bio")
+	u.EmailVerifiedAt = field.NewTime(table, "email_verified_at")
 
 	u.fillFieldMap()
 
@@ -110,7 +113,7 @@ func (u *user) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
 }
 
 func (u *user) fillFieldMap() {
-	u.fieldMap = make(map[string]field.Expr, 12)
+	u.fieldMap = make(map[string]field.Expr, 13)
 	u.fieldMap["id"] = u.ID
 	u.fieldMap["username"] = u.Username
 	u.fieldMap["email"] = u.Email
@@ -123,6 +126,7 @@ func (u *user) fillFieldMap() {
 	u.fieldMap["updated_at"] = u.UpdatedAt
 	u.fieldMap["last_login"] = u.LastLogin
 	u.fieldMap["bio"] = u.Bio
+	u.fieldMap["email_verified_at"] = u.EmailVerifiedAt
 }
 
 func (u user) clone(db *gorm.DB) user {
@@ -0,0 +1,241 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/NSObjects/go-template/internal/api/biz"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/casbin/casbin/v2"
+)
+
+// rbacRepository 所有修改都经由 enforcer 完成，内存中的策略与 casbin_rule 表始终一致
+type rbacRepository struct {
+	e *casbin.SyncedEnforcer
+	// mu 保证"检查后写入"与整体替换的原子性
+	mu sync.Mutex
+}
+
+func NewRbacRepository(e *casbin.SyncedEnforcer) biz.RbacRepository {
+	return &rbacRepository{e: e}
+}
+
+func (r *rbacRepository) Domains() bool {
+	return utils.IsDomainModel(r.e)
+}
+
+func (r *rbacRepository) Policies(_ context.Context, filter ...string) ([][]string, error) {
+	rules, err := r.e.GetFilteredPolicy(0, filter...)
+	if err != nil {
+		return nil, wrapEnforcerError(err, "query policies")
+	}
+	return rules, nil
+}
+
+func (r *rbacRepository) AddPolicies(_ context.Context, rules [][]string) ([][]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	added, err := r.missing(rules, r.e.HasPolicy)
+	if err != nil || len(added) == 0 {
+		return added, err
+	}
+	if _, err := r.e.AddPolicies(added); err != nil {
+		return nil, wrapEnforcerError(err, "add policies")
+	}
+	return added, nil
+}
+
+func (r *rbacRepository) UpdatePolicy(_ context.Context, oldRule, newRule []string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ok, err := r.e.HasPolicy(oldRule)
+	if err != nil {
+		return false, wrapEnforcerError(err, "query policy")
+	}
+	if !ok {
+		return false, nil
+	}
+	if _, err := r.e.UpdatePolicy(oldRule, newRule); err != nil {
+		return false, wrapEnforcerError(err, "update policy")
+	}
+	return true, nil
+}
+
+func (r *rbacRepository) RemovePolicy(_ context.Context, rule []string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ok, err := r.e.RemovePolicy(rule)
+	if err != nil {
+		return false, wrapEnforcerError(err, "remove policy")
+	}
+	return ok, nil
+}
+
+func (r *rbacRepository) Roles(_ context.Context, filter ...string) ([][]string, error) {
+	rules, err := r.e.GetFilteredGroupingPolicy(0, filter...)
+	if err != nil {
+		return nil, wrapEnforcerError(err, "query roles")
+	}
+	return rules, nil
+}
+
+func (r *rbacRepository) AddRoles(_ context.Context, rules [][]string) ([][]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	added, err := r.missing(rules, r.e.HasGroupingPolicy)
+	if err != nil || len(added) == 0 {
+		return added, err
+	}
+	if _, err := r.e.AddGroupingPolicies(added); err != nil {
+		return nil, wrapEnforcerError(err, "add roles")
+	}
+	return added, nil
+}
+
+func (r *rbacRepository) RemoveRole(_ context.Context, rule []string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ok, err := r.e.RemoveGroupingPolicy(rule)
+	if err != nil {
+		return false, wrapEnforcerError(err, "remove role")
+	}
+	return ok, nil
+}
+
+func (r *rbacRepository) RolesForUser(_ context.Context, user, domain string) ([]string, []string, error) {
+	roles, err := r.e.GetRolesForUser(user, domains(domain)...)
+	if err != nil {
+		return nil, nil, wrapEnforcerError(err, "query user roles")
+	}
+	implicit, err := r.e.GetImplicitRolesForUser(user, domains(domain)...)
+	if err != nil {
+		return nil, nil, wrapEnforcerError(err, "query user roles")
+	}
+	return roles, implicit, nil
+}
+
+func (r *rbacRepository) PermissionsForUser(_ context.Context, user, domain string) ([][]string, error) {
+	rules, err := r.e.GetImplicitPermissionsForUser(user, domains(domain)...)
+	if err != nil {
+		return nil, wrapEnforcerError(err, "query user permissions")
+	}
+	return rules, nil
+}
+
+// Replace 持有 enforcer 写锁在内存中整体替换策略，并发的 Enforce 只会看到替换前或替换后的完整策略，
+// 规则无效时在锁内恢复原策略；之后一次性写回存储，写回失败时从存储重新加载，避免内存与数据库不一致。
+// 重建过程中不逐条广播，SavePolicy 完成后由 watcher 通知其他副本全量加载
+func (r *rbacRepository) Replace(_ context.Context, policies, roles [][]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.e.EnableAutoSave(false)
+	r.e.EnableAutoNotifyWatcher(false)
+	defer func() {
+		r.e.EnableAutoSave(true)
+		r.e.EnableAutoNotifyWatcher(true)
+	}()
+
+	if err := r.swap(policies, roles); err != nil {
+		return err
+	}
+	if r.e.GetAdapter() == nil {
+		return nil
+	}
+	if err := r.e.SavePolicy(); err != nil {
+		if lerr := r.e.LoadPolicy(); lerr != nil {
+			return wrapEnforcerError(fmt.Errorf("%w; reload policies: %v", err, lerr), "save policies")
+		}
+		return wrapEnforcerError(err, "save policies")
+	}
+	return nil
+}
+
+// swap 持有 enforcer 写锁替换内存中的策略，失败时恢复原策略
+func (r *rbacRepository) swap(policies, roles [][]string) error {
+	lock := r.e.GetLock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	e := r.e.Enforcer
+	oldPolicies, err := e.GetPolicy()
+	if err != nil {
+		return wrapEnforcerError(err, "query policies")
+	}
+	oldRoles, err := e.GetGroupingPolicy()
+	if err != nil {
+		return wrapEnforcerError(err, "query roles")
+	}
+	if err := replacePolicies(e, policies, roles); err != nil {
+		if rerr := replacePolicies(e, oldPolicies, oldRoles); rerr != nil {
+			return fmt.Errorf("%w; restore policies: %v", err, rerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// replacePolicies 清空后写入策略与角色并重建角色继承关系，调用方持有 enforcer 写锁
+func replacePolicies(e *casbin.Enforcer, policies, roles [][]string) error {
+	e.ClearPolicy()
+	if len(policies) > 0 {
+		if _, err := e.AddPolicies(policies); err != nil {
+			return wrapEnforcerError(err, "replace policies")
+		}
+	}
+	if len(roles) > 0 {
+		if _, err := e.AddGroupingPolicies(roles); err != nil {
+			return wrapEnforcerError(err, "replace roles")
+		}
+	}
+	if err := e.BuildRoleLinks(); err != nil {
+		return wrapEnforcerError(err, "build role links")
+	}
+	return nil
+}
+
+// missing 过滤掉已存在以及重复的规则
+func (r *rbacRepository) missing(rules [][]string, has func(...interface{}) (bool, error)) ([][]string, error) {
+	seen := make(map[string]bool, len(rules))
+	result := make([][]string, 0, len(rules))
+	for _, rule := range rules {
+		key := ruleKey(rule)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		ok, err := has(rule)
+		if err != nil {
+			return nil, wrapEnforcerError(err, "query rule")
+		}
+		if !ok {
+			result = append(result, rule)
+		}
+	}
+	return result, nil
+}
+
+// domains 非域模型下不传域参数
+func domains(domain string) []string {
+	if domain == "" {
+		return nil
+	}
+	return []string{domain}
+}
+
+func ruleKey(rule []string) string {
+	return strings.Join(rule, "\x00")
+}
+
+func wrapEnforcerError(err error, msg string) error {
+	return code.WrapError(err, code.ErrRbacEnforcer, msg)
+}
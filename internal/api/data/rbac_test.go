@@ -0,0 +1,226 @@
+package data
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRbacModel = `
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[role_definition]
+g = _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub) && r.obj == p.obj && r.act == p.act
+`
+
+func newTestEnforcer(t *testing.T) *casbin.SyncedEnforcer {
+	t.Helper()
+	m, err := model.NewModelFromString(testRbacModel)
+	require.NoError(t, err)
+	e, err := casbin.NewSyncedEnforcer(m)
+	require.NoError(t, err)
+	return e
+}
+
+func TestRbacRepository_Changes(t *testing.T) {
+	ctx := context.Background()
+	e := newTestEnforcer(t)
+	repo := NewRbacRepository(e)
+
+	added, err := repo.AddPolicies(ctx, [][]string{
+		{"admin", "/api/users", "GET"},
+		{"admin", "/api/users", "GET"},
+		{"editor", "/api/posts", "POST"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, added, 2)
+
+	added, err = repo.AddRoles(ctx, [][]string{{"alice", "editor"}, {"editor", "admin"}})
+	require.NoError(t, err)
+	assert.Len(t, added, 2)
+
+	// 修改立即对鉴权生效
+	ok, err := e.Enforce("alice", "/api/users", "GET")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	roles, implicit, err := repo.RolesForUser(ctx, "alice", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"editor"}, roles)
+	assert.ElementsMatch(t, []string{"editor", "admin"}, implicit)
+
+	perms, err := repo.PermissionsForUser(ctx, "alice", "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, [][]string{{"admin", "/api/users", "GET"}, {"editor", "/api/posts", "POST"}}, perms)
+
+	ok, err = repo.UpdatePolicy(ctx, []string{"admin", "/api/users", "GET"}, []string{"admin", "/api/users", "PUT"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = repo.UpdatePolicy(ctx, []string{"admin", "/api/users", "GET"}, []string{"admin", "/api/users", "PATCH"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	filtered, err := repo.Policies(ctx, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"admin", "/api/users", "PUT"}}, filtered)
+
+	ok, err = repo.RemoveRole(ctx, []string{"editor", "admin"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = e.Enforce("alice", "/api/users", "PUT")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestRbacRepository_Replace(t *testing.T) {
+	ctx := context.Background()
+	e := newTestEnforcer(t)
+	repo := NewRbacRepository(e)
+
+	_, err := repo.AddPolicies(ctx, [][]string{{"admin", "/api/users", "GET"}})
+	require.NoError(t, err)
+	_, err = repo.AddRoles(ctx, [][]string{{"alice", "admin"}})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Replace(ctx, [][]string{{"viewer", "/api/posts", "GET"}}, [][]string{{"bob", "viewer"}}))
+
+	policies, err := repo.Policies(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"viewer", "/api/posts", "GET"}}, policies)
+
+	// 旧的角色继承关系必须一并清除
+	ok, err := e.Enforce("alice", "/api/users", "GET")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = e.Enforce("bob", "/api/posts", "GET")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestRbacRepository_ReplaceConcurrentEnforce(t *testing.T) {
+	ctx := context.Background()
+	e := newTestEnforcer(t)
+	repo := NewRbacRepository(e)
+	rules := [][]string{{"admin", "/api/users", "GET"}}
+	require.NoError(t, repo.Replace(ctx, rules, [][]string{{"alice", "admin"}}))
+
+	// 替换前后 alice 都有权限，替换过程中的 Enforce 不能看到空的或不完整的策略
+	done := make(chan struct{})
+	denied := make(chan struct{}, 1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if ok, err := e.Enforce("alice", "/api/users", "GET"); err != nil || !ok {
+				select {
+				case denied <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		require.NoError(t, repo.Replace(ctx, rules, [][]string{{"alice", "admin"}, {"bob", "admin"}}))
+	}
+	close(done)
+	wg.Wait()
+	assert.Empty(t, denied)
+}
+
+func TestRbacRepository_ReplaceInvalid(t *testing.T) {
+	ctx := context.Background()
+	e := newTestEnforcer(t)
+	repo := NewRbacRepository(e)
+	require.NoError(t, repo.Replace(ctx, [][]string{{"admin", "/api/users", "GET"}}, [][]string{{"alice", "admin"}}))
+
+	// 规则无效时保留原策略
+	err := repo.Replace(ctx, [][]string{{"viewer", "/api/posts", "GET"}}, [][]string{{"bob"}})
+	require.Error(t, err)
+	ok, err := e.Enforce("alice", "/api/users", "GET")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	policies, err := repo.Policies(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"admin", "/api/users", "GET"}}, policies)
+}
+
+// failingAdapter 写回与加载都失败的存储
+type failingAdapter struct{}
+
+func (failingAdapter) LoadPolicy(model.Model) error { return errors.New("load failed") }
+func (failingAdapter) SavePolicy(model.Model) error { return errors.New("save failed") }
+func (failingAdapter) AddPolicy(string, string, []string) error {
+	return nil
+}
+func (failingAdapter) RemovePolicy(string, string, []string) error {
+	return nil
+}
+func (failingAdapter) RemoveFilteredPolicy(string, string, int, ...string) error {
+	return nil
+}
+
+func TestRbacRepository_ReplaceSaveFailure(t *testing.T) {
+	e := newTestEnforcer(t)
+	e.SetAdapter(failingAdapter{})
+	repo := NewRbacRepository(e)
+
+	// 写回失败且重新加载同样失败时，两个错误都返回
+	err := repo.Replace(context.Background(), [][]string{{"admin", "/api/users", "GET"}}, nil)
+	require.Error(t, err)
+	assert.True(t, errors.IsCode(err, code.ErrRbacEnforcer))
+	var cause = errors.Cause(err).Error()
+	assert.Contains(t, cause, "save failed")
+	assert.Contains(t, cause, "load failed")
+}
+
+func TestRbacRepository_Domains(t *testing.T) {
+	ctx := context.Background()
+	m, err := utils.LoadCasbinModel(configs.CasbinConfig{Domains: true})
+	require.NoError(t, err)
+	e, err := casbin.NewSyncedEnforcer(m)
+	require.NoError(t, err)
+	repo := NewRbacRepository(e)
+	require.True(t, repo.Domains())
+
+	_, err = repo.AddPolicies(ctx, [][]string{{"admin", "tenant1", "/api/users", "GET"}})
+	require.NoError(t, err)
+	_, err = repo.AddRoles(ctx, [][]string{{"alice", "admin", "tenant1"}})
+	require.NoError(t, err)
+
+	// 角色只在分配的域内生效
+	roles, _, err := repo.RolesForUser(ctx, "alice", "tenant1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin"}, roles)
+	roles, _, err = repo.RolesForUser(ctx, "alice", "tenant2")
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+
+	perms, err := repo.PermissionsForUser(ctx, "alice", "tenant1")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"admin", "tenant1", "/api/users", "GET"}}, perms)
+}
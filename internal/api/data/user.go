@@ -78,6 +78,13 @@ func (u userRepository) GetByID(ctx context.Context, id int64) (param.UserData,
 }
 
 func (u userRepository) Update(ctx context.Context, id int64, req param.UserUpdateRequest) error {
+	// 邮箱变更后需要重新验证
+	if req.Email != "" {
+		q := u.d.Query.User
+		if _, err := q.WithContext(ctx).Where(q.ID.Eq(id), q.Email.Neq(req.Email)).Update(q.EmailVerifiedAt, nil); err != nil {
+			return err
+		}
+	}
 	_, err := u.d.Query.User.WithContext(ctx).Where(u.d.Query.User.ID.Eq(id)).Updates(model.User{
 		Username: req.Username,
 		Email:    req.Email,
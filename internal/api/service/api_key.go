@@ -0,0 +1,135 @@
+/*
+ * Module: APIKey
+ * API 密钥管理接口
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"github.com/NSObjects/go-template/internal/api/biz"
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/resp"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type APIKeyController struct {
+	keys biz.APIKeyUseCase
+}
+
+func NewAPIKeyController(h biz.APIKeyUseCase) RegisterRouter {
+	return &APIKeyController{keys: h}
+}
+
+func (c *APIKeyController) RegisterRouter(g *echo.Group, m ...echo.MiddlewareFunc) {
+	g.GET("/api-keys", c.List, RequireAdmin).Name = "查询API密钥"
+	g.POST("/api-keys", c.Create, RequireAdmin).Name = "创建API密钥"
+	g.GET("/api-keys/:id", c.GetByID, RequireAdmin).Name = "获取API密钥详情"
+	g.PUT("/api-keys/:id", c.Update, RequireAdmin).Name = "更新API密钥"
+	g.DELETE("/api-keys/:id", c.Delete, RequireAdmin).Name = "吊销API密钥"
+}
+
+func (c *APIKeyController) List(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.APIKeyListRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	list, total, err := c.keys.List(bizCtx, req)
+	if err != nil {
+		return err
+	}
+
+	// 返回列表数据 - 使用统一的响应格式
+	return resp.ListDataResponse(ctx, list, total)
+}
+
+func (c *APIKeyController) Create(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.APIKeyCreateRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	result, err := c.keys.Create(bizCtx, req)
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.OneDataResponse(ctx, result)
+}
+
+func (c *APIKeyController) GetByID(ctx echo.Context) error {
+	// 获取路径参数
+	id, err := apiKeyID(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	result, err := c.keys.GetByID(bizCtx, id)
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.OneDataResponse(ctx, result)
+}
+
+func (c *APIKeyController) Update(ctx echo.Context) error {
+	// 获取路径参数
+	id, err := apiKeyID(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 绑定和验证请求体参数
+	var req param.APIKeyUpdateRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.keys.Update(bizCtx, id, req); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
+
+func (c *APIKeyController) Delete(ctx echo.Context) error {
+	// 获取路径参数
+	id, err := apiKeyID(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.keys.Delete(bizCtx, id); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
+
+func apiKeyID(ctx echo.Context) (int64, error) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, code.NewValidationError("id", "invalid api key id")
+	}
+	return id, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/server/middlewares"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAPIKeyUseCase 模拟 API 密钥业务逻辑接口
+type MockAPIKeyUseCase struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyUseCase) List(ctx context.Context, req param.APIKeyListRequest) ([]param.APIKeyData, int64, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]param.APIKeyData), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockAPIKeyUseCase) Create(ctx context.Context, req param.APIKeyCreateRequest) (param.APIKeyCreatedData, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(param.APIKeyCreatedData), args.Error(1)
+}
+
+func (m *MockAPIKeyUseCase) GetByID(ctx context.Context, id int64) (param.APIKeyData, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(param.APIKeyData), args.Error(1)
+}
+
+func (m *MockAPIKeyUseCase) Update(ctx context.Context, id int64, req param.APIKeyUpdateRequest) error {
+	args := m.Called(ctx, id, req)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyUseCase) Delete(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyUseCase) AuthenticateAPIKey(ctx context.Context, key string) (*utils.Principal, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*utils.Principal), args.Error(1)
+}
+
+func TestAPIKeyController_Create(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCall bool
+	}{
+		{name: "成功场景", body: `{"name":"billing","user_id":7,"scopes":["GET:/api/users*"]}`, wantCall: true},
+		{name: "缺少授权范围", body: `{"name":"billing","user_id":7,"scopes":[]}`},
+		{name: "缺少所属用户", body: `{"name":"billing","scopes":["*"]}`},
+		{name: "缺少名称", body: `{"user_id":7,"scopes":["*"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := new(MockAPIKeyUseCase)
+			mockUseCase.On("Create", mock.Anything, mock.Anything).Return(param.APIKeyCreatedData{Key: "gtk_x_y"}, nil)
+			controller := &APIKeyController{keys: mockUseCase}
+
+			c, rec := newAuthTestContext(tt.body)
+			err := controller.Create(c)
+			if !tt.wantCall {
+				assert.Error(t, err)
+				mockUseCase.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), "gtk_x_y")
+		})
+	}
+}
+
+func TestAPIKeyController_Delete(t *testing.T) {
+	mockUseCase := new(MockAPIKeyUseCase)
+	mockUseCase.On("Delete", mock.Anything, int64(3)).Return(nil)
+	controller := &APIKeyController{keys: mockUseCase}
+
+	c, rec := newAuthTestContext("")
+	c.SetParamNames("id")
+	c.SetParamValues("3")
+	assert.NoError(t, controller.Delete(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	c, _ = newAuthTestContext("")
+	c.SetParamNames("id")
+	c.SetParamValues("abc")
+	assert.True(t, errors.IsCode(controller.Delete(c), code.ErrValidation))
+	mockUseCase.AssertNumberOfCalls(t, "Delete", 1)
+}
+
+func TestAPIKeyController_RequireAdmin(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *utils.Principal
+		wantStatus int
+	}{
+		{name: "未认证", wantStatus: http.StatusUnauthorized},
+		{name: "非管理员", principal: &utils.Principal{ID: "7", Name: "alice", Method: utils.AuthMethodAPIKey, Scopes: []string{"*"}}, wantStatus: http.StatusForbidden},
+		{name: "管理员", principal: adminPrincipal, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := new(MockAPIKeyUseCase)
+			mockUseCase.On("Delete", mock.Anything, int64(3)).Return(nil)
+			e := echo.New()
+			e.HTTPErrorHandler = middlewares.ErrorHandler
+			e.Use(withPrincipal(tt.principal))
+			NewAPIKeyController(mockUseCase).RegisterRouter(e.Group("/api"))
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/api-keys/3", nil))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,330 @@
+/*
+ * Module: Auth
+ * 登录、令牌刷新与注销接口
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"github.com/NSObjects/go-template/internal/api/biz"
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/resp"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type AuthController struct {
+	auth biz.AuthUseCase
+}
+
+func NewAuthController(h biz.AuthUseCase) RegisterRouter {
+	return &AuthController{auth: h}
+}
+
+func (c *AuthController) RegisterRouter(g *echo.Group, m ...echo.MiddlewareFunc) {
+	g.POST("/auth/login", c.Login).Name = "用户登录"
+	g.POST("/auth/refresh", c.Refresh).Name = "刷新令牌"
+	g.POST("/auth/logout", c.Logout).Name = "用户注销"
+	g.DELETE("/auth/users/:id/sessions", c.RevokeUser, RequireAdmin).Name = "强制下线用户"
+	g.DELETE("/auth/lockouts/:username", c.Unlock, RequireAdmin).Name = "解除登录锁定"
+	g.POST("/auth/mfa/verify", c.VerifyMfa).Name = "两步验证登录"
+	g.POST("/auth/mfa/enroll", c.EnrollMfa).Name = "登记两步验证"
+	g.POST("/auth/mfa/activate", c.ActivateMfa).Name = "启用两步验证"
+	g.DELETE("/auth/users/:id/mfa", c.ResetMfa, RequireAdmin).Name = "重置两步验证"
+	g.GET("/auth/oidc/:provider/authorize", c.OIDCAuthorize).Name = "单点登录授权"
+	g.GET("/auth/oidc/:provider/callback", c.OIDCCallback).Name = "单点登录回调"
+	g.POST("/auth/oidc/:provider/link", c.OIDCLink).Name = "关联外部身份"
+	g.DELETE("/auth/oidc/:provider/link", c.OIDCUnlink).Name = "解除外部身份关联"
+	g.POST("/auth/password/forgot", c.ForgotPassword).Name = "忘记密码"
+	g.POST("/auth/password/reset", c.ResetPassword).Name = "重置密码"
+	g.POST("/auth/email/verify/send", c.SendVerification).Name = "发送邮箱验证"
+	g.POST("/auth/email/verify", c.VerifyEmail).Name = "验证邮箱"
+}
+
+func (c *AuthController) Login(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.AuthLoginRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	result, err := c.auth.Login(bizCtx, req)
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.OneDataResponse(ctx, result)
+}
+
+func (c *AuthController) Refresh(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.AuthRefreshRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	result, err := c.auth.Refresh(bizCtx, req)
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.OneDataResponse(ctx, result)
+}
+
+func (c *AuthController) Logout(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.AuthLogoutRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.auth.Logout(bizCtx, req); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
+
+func (c *AuthController) RevokeUser(ctx echo.Context) error {
+	// 获取路径参数
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return code.NewValidationError("id", "invalid user id")
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.auth.RevokeUser(bizCtx, id); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
+
+func (c *AuthController) Unlock(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.AuthUnlockRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.auth.Unlock(bizCtx, req); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
+
+func (c *AuthController) VerifyMfa(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.MfaVerifyRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	result, err := c.auth.VerifyMfa(bizCtx, req)
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.OneDataResponse(ctx, result)
+}
+
+func (c *AuthController) EnrollMfa(ctx echo.Context) error {
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	result, err := c.auth.EnrollMfa(bizCtx)
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.OneDataResponse(ctx, result)
+}
+
+func (c *AuthController) ActivateMfa(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.MfaActivateRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	result, err := c.auth.ActivateMfa(bizCtx, req)
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.OneDataResponse(ctx, result)
+}
+
+func (c *AuthController) ResetMfa(ctx echo.Context) error {
+	// 获取路径参数
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return code.NewValidationError("id", "invalid user id")
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.auth.ResetMfa(bizCtx, id); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
+
+func (c *AuthController) OIDCAuthorize(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.OIDCProviderRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	result, err := c.auth.OIDCAuthorize(bizCtx, req)
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.OneDataResponse(ctx, result)
+}
+
+func (c *AuthController) OIDCCallback(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.OIDCCallbackRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	result, err := c.auth.OIDCCallback(bizCtx, req)
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.OneDataResponse(ctx, result)
+}
+
+func (c *AuthController) OIDCLink(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.OIDCProviderRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	result, err := c.auth.OIDCLink(bizCtx, req)
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.OneDataResponse(ctx, result)
+}
+
+func (c *AuthController) OIDCUnlink(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.OIDCProviderRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.auth.OIDCUnlink(bizCtx, req); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
+
+func (c *AuthController) ForgotPassword(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.PasswordForgotRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.auth.ForgotPassword(bizCtx, req); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
+
+func (c *AuthController) ResetPassword(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.PasswordResetRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.auth.ResetPassword(bizCtx, req); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
+
+func (c *AuthController) SendVerification(ctx echo.Context) error {
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.auth.SendVerification(bizCtx); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
+
+func (c *AuthController) VerifyEmail(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.EmailVerifyRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.auth.VerifyEmail(bizCtx, req); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
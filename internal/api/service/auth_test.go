@@ -0,0 +1,400 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/server/middlewares"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAuthUseCase 模拟认证业务逻辑接口
+type MockAuthUseCase struct {
+	mock.Mock
+}
+
+func (m *MockAuthUseCase) Login(ctx context.Context, req param.AuthLoginRequest) (param.AuthTokenData, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(param.AuthTokenData), args.Error(1)
+}
+
+func (m *MockAuthUseCase) Refresh(ctx context.Context, req param.AuthRefreshRequest) (param.AuthTokenData, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(param.AuthTokenData), args.Error(1)
+}
+
+func (m *MockAuthUseCase) Logout(ctx context.Context, req param.AuthLogoutRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) RevokeUser(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) Unlock(ctx context.Context, req param.AuthUnlockRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) CheckAccount(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) EnrollMfa(ctx context.Context) (param.MfaEnrollData, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(param.MfaEnrollData), args.Error(1)
+}
+
+func (m *MockAuthUseCase) ActivateMfa(ctx context.Context, req param.MfaActivateRequest) (param.MfaRecoveryCodesData, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(param.MfaRecoveryCodesData), args.Error(1)
+}
+
+func (m *MockAuthUseCase) VerifyMfa(ctx context.Context, req param.MfaVerifyRequest) (param.AuthTokenData, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(param.AuthTokenData), args.Error(1)
+}
+
+func (m *MockAuthUseCase) ResetMfa(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) OIDCAuthorize(ctx context.Context, req param.OIDCProviderRequest) (param.OIDCAuthorizeData, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(param.OIDCAuthorizeData), args.Error(1)
+}
+
+func (m *MockAuthUseCase) OIDCLink(ctx context.Context, req param.OIDCProviderRequest) (param.OIDCAuthorizeData, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(param.OIDCAuthorizeData), args.Error(1)
+}
+
+func (m *MockAuthUseCase) OIDCUnlink(ctx context.Context, req param.OIDCProviderRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) OIDCCallback(ctx context.Context, req param.OIDCCallbackRequest) (param.AuthTokenData, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(param.AuthTokenData), args.Error(1)
+}
+
+func (m *MockAuthUseCase) ForgotPassword(ctx context.Context, req param.PasswordForgotRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) ResetPassword(ctx context.Context, req param.PasswordResetRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) SendVerification(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockAuthUseCase) VerifyEmail(ctx context.Context, req param.EmailVerifyRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func newAuthTestContext(body string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = &middlewares.Validator{Validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	return e.NewContext(req, rec), rec
+}
+
+// serveAuthAs 以认证主体 p 通过路由处理请求，覆盖路由上的中间件
+func serveAuthAs(m *MockAuthUseCase, p *utils.Principal, method, target string) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.Validator = &middlewares.Validator{Validator: validator.New()}
+	e.HTTPErrorHandler = middlewares.ErrorHandler
+	e.Use(withPrincipal(p))
+	NewAuthController(m).RegisterRouter(e.Group("/api"))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestAuthController_Login(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		setupMock   func(*MockAuthUseCase)
+		expectError bool
+	}{
+		{
+			name: "成功场景",
+			body: `{"username":"alice","password":"secret-pass"}`,
+			setupMock: func(m *MockAuthUseCase) {
+				m.On("Login", mock.Anything, param.AuthLoginRequest{Username: "alice", Password: "secret-pass"}).
+					Return(param.AuthTokenData{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer"}, nil)
+			},
+		},
+		{
+			name: "密码错误",
+			body: `{"username":"alice","password":"wrong-pass"}`,
+			setupMock: func(m *MockAuthUseCase) {
+				m.On("Login", mock.Anything, mock.Anything).
+					Return(param.AuthTokenData{}, code.NewError(code.ErrPasswordIncorrect, "invalid username or password"))
+			},
+			expectError: true,
+		},
+		{
+			name:        "参数验证失败",
+			body:        `{"username":"alice"}`,
+			setupMock:   func(m *MockAuthUseCase) {},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := new(MockAuthUseCase)
+			tt.setupMock(mockUseCase)
+			controller := &AuthController{auth: mockUseCase}
+
+			c, rec := newAuthTestContext(tt.body)
+			err := controller.Login(c)
+
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				var response struct {
+					Code int                 `json:"code"`
+					Data param.AuthTokenData `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				assert.Equal(t, http.StatusOK, response.Code)
+				assert.Equal(t, "access", response.Data.AccessToken)
+				assert.Equal(t, "refresh", response.Data.RefreshToken)
+			}
+			mockUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthController_Logout(t *testing.T) {
+	mockUseCase := new(MockAuthUseCase)
+	mockUseCase.On("Logout", mock.Anything, param.AuthLogoutRequest{RefreshToken: "refresh"}).Return(nil)
+	controller := &AuthController{auth: mockUseCase}
+
+	c, rec := newAuthTestContext(`{"refresh_token":"refresh"}`)
+	assert.NoError(t, controller.Logout(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestAuthController_RevokeUser(t *testing.T) {
+	mockUseCase := new(MockAuthUseCase)
+	mockUseCase.On("RevokeUser", mock.Anything, int64(7)).Return(nil)
+	controller := &AuthController{auth: mockUseCase}
+
+	c, rec := newAuthTestContext("")
+	c.SetParamNames("id")
+	c.SetParamValues("7")
+	assert.NoError(t, controller.RevokeUser(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockUseCase.AssertExpectations(t)
+
+	// 只允许管理员强制下线其他用户
+	user := &utils.Principal{ID: "8", Name: "bob", Method: utils.AuthMethodJWT}
+	assert.Equal(t, http.StatusUnauthorized, serveAuthAs(mockUseCase, nil, http.MethodDelete, "/api/auth/users/7/sessions").Code)
+	assert.Equal(t, http.StatusForbidden, serveAuthAs(mockUseCase, user, http.MethodDelete, "/api/auth/users/7/sessions").Code)
+	assert.Equal(t, http.StatusOK, serveAuthAs(mockUseCase, adminPrincipal, http.MethodDelete, "/api/auth/users/7/sessions").Code)
+	mockUseCase.AssertNumberOfCalls(t, "RevokeUser", 2)
+}
+
+func TestAuthController_Unlock(t *testing.T) {
+	mockUseCase := new(MockAuthUseCase)
+	mockUseCase.On("Unlock", mock.Anything, param.AuthUnlockRequest{Username: "alice"}).Return(nil)
+	controller := &AuthController{auth: mockUseCase}
+
+	c, rec := newAuthTestContext("")
+	c.SetParamNames("username")
+	c.SetParamValues("alice")
+	assert.NoError(t, controller.Unlock(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockUseCase.AssertExpectations(t)
+
+	// 只允许管理员解除锁定，否则任何人都能绕过登录退避
+	user := &utils.Principal{ID: "8", Name: "bob", Method: utils.AuthMethodJWT}
+	assert.Equal(t, http.StatusUnauthorized, serveAuthAs(mockUseCase, nil, http.MethodDelete, "/api/auth/lockouts/alice").Code)
+	assert.Equal(t, http.StatusForbidden, serveAuthAs(mockUseCase, user, http.MethodDelete, "/api/auth/lockouts/alice").Code)
+	assert.Equal(t, http.StatusOK, serveAuthAs(mockUseCase, adminPrincipal, http.MethodDelete, "/api/auth/lockouts/alice").Code)
+	mockUseCase.AssertNumberOfCalls(t, "Unlock", 2)
+}
+
+func TestAuthController_VerifyMfa(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCall bool
+	}{
+		{name: "验证码", body: `{"mfa_token":"t","code":"123456"}`, wantCall: true},
+		{name: "恢复码", body: `{"mfa_token":"t","recovery_code":"abcde-fghij"}`, wantCall: true},
+		{name: "缺少验证码", body: `{"mfa_token":"t"}`},
+		{name: "验证码格式错误", body: `{"mfa_token":"t","code":"12ab56"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := new(MockAuthUseCase)
+			mockUseCase.On("VerifyMfa", mock.Anything, mock.Anything).Return(param.AuthTokenData{AccessToken: "access"}, nil)
+			controller := &AuthController{auth: mockUseCase}
+
+			c, rec := newAuthTestContext(tt.body)
+			err := controller.VerifyMfa(c)
+			if !tt.wantCall {
+				assert.Error(t, err)
+				mockUseCase.AssertNotCalled(t, "VerifyMfa", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+	}
+}
+
+func TestAuthController_ResetMfa(t *testing.T) {
+	mockUseCase := new(MockAuthUseCase)
+	mockUseCase.On("ResetMfa", mock.Anything, int64(7)).Return(nil)
+	controller := &AuthController{auth: mockUseCase}
+
+	c, rec := newAuthTestContext("")
+	c.SetParamNames("id")
+	c.SetParamValues("7")
+	assert.NoError(t, controller.ResetMfa(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockUseCase.AssertExpectations(t)
+
+	// 只允许管理员重置其他用户的两步验证
+	user := &utils.Principal{ID: "8", Name: "bob", Method: utils.AuthMethodJWT}
+	assert.Equal(t, http.StatusUnauthorized, serveAuthAs(mockUseCase, nil, http.MethodDelete, "/api/auth/users/7/mfa").Code)
+	assert.Equal(t, http.StatusForbidden, serveAuthAs(mockUseCase, user, http.MethodDelete, "/api/auth/users/7/mfa").Code)
+	assert.Equal(t, http.StatusOK, serveAuthAs(mockUseCase, adminPrincipal, http.MethodDelete, "/api/auth/users/7/mfa").Code)
+	mockUseCase.AssertNumberOfCalls(t, "ResetMfa", 2)
+}
+
+func TestAuthController_OIDCCallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		wantCall bool
+	}{
+		{name: "授权码", query: "code=abc&state=s1", wantCall: true},
+		{name: "拒绝授权", query: "error=access_denied&state=s1", wantCall: true},
+		{name: "缺少 state", query: "code=abc"},
+		{name: "缺少授权码", query: "state=s1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := new(MockAuthUseCase)
+			mockUseCase.On("OIDCCallback", mock.Anything, mock.Anything).Return(param.AuthTokenData{AccessToken: "access"}, nil)
+			controller := &AuthController{auth: mockUseCase}
+
+			e := echo.New()
+			e.Validator = &middlewares.Validator{Validator: validator.New()}
+			req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/corp/callback?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("provider")
+			c.SetParamValues("corp")
+
+			err := controller.OIDCCallback(c)
+			if !tt.wantCall {
+				assert.Error(t, err)
+				mockUseCase.AssertNotCalled(t, "OIDCCallback", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			req0 := mockUseCase.Calls[0].Arguments.Get(1).(param.OIDCCallbackRequest)
+			assert.Equal(t, "corp", req0.Provider)
+			assert.Equal(t, "s1", req0.State)
+		})
+	}
+}
+
+func TestAuthController_ForgotPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCall bool
+	}{
+		{name: "成功场景", body: `{"email":"alice@example.com"}`, wantCall: true},
+		{name: "邮箱格式错误", body: `{"email":"alice"}`},
+		{name: "缺少邮箱", body: `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := new(MockAuthUseCase)
+			mockUseCase.On("ForgotPassword", mock.Anything, param.PasswordForgotRequest{Email: "alice@example.com"}).Return(nil)
+			controller := &AuthController{auth: mockUseCase}
+
+			c, rec := newAuthTestContext(tt.body)
+			err := controller.ForgotPassword(c)
+			if !tt.wantCall {
+				assert.Error(t, err)
+				mockUseCase.AssertNotCalled(t, "ForgotPassword", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthController_ResetPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCall bool
+	}{
+		{name: "成功场景", body: `{"token":"t.s","password":"new-secret"}`, wantCall: true},
+		{name: "密码过短", body: `{"token":"t.s","password":"123"}`},
+		{name: "缺少令牌", body: `{"password":"new-secret"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := new(MockAuthUseCase)
+			mockUseCase.On("ResetPassword", mock.Anything, param.PasswordResetRequest{Token: "t.s", Password: "new-secret"}).Return(nil)
+			controller := &AuthController{auth: mockUseCase}
+
+			c, rec := newAuthTestContext(tt.body)
+			err := controller.ResetPassword(c)
+			if !tt.wantCall {
+				assert.Error(t, err)
+				mockUseCase.AssertNotCalled(t, "ResetPassword", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			mockUseCase.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Module: Config
+ * 配置历史版本与回滚接口
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"github.com/NSObjects/go-template/internal/api/biz"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/resp"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type ConfigController struct {
+	config biz.ConfigUseCase
+}
+
+func NewConfigController(h biz.ConfigUseCase) RegisterRouter {
+	return &ConfigController{config: h}
+}
+
+func (c *ConfigController) RegisterRouter(g *echo.Group, m ...echo.MiddlewareFunc) {
+	g.GET("/config/versions", c.ListVersions, RequireAdmin).Name = "查询配置历史版本"
+	g.GET("/config/versions/:id", c.GetVersion, RequireAdmin).Name = "获取配置历史版本"
+	g.POST("/config/versions/:id/rollback", c.Rollback, RequireAdmin).Name = "回滚配置"
+}
+
+func (c *ConfigController) ListVersions(ctx echo.Context) error {
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	list, err := c.config.ListVersions(bizCtx)
+	if err != nil {
+		return err
+	}
+
+	// 返回列表数据 - 使用统一的响应格式
+	return resp.ListDataResponse(ctx, list, int64(len(list)))
+}
+
+func (c *ConfigController) GetVersion(ctx echo.Context) error {
+	// 获取路径参数
+	id, err := configVersionID(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	result, err := c.config.GetVersion(bizCtx, id)
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.OneDataResponse(ctx, result)
+}
+
+func (c *ConfigController) Rollback(ctx echo.Context) error {
+	// 获取路径参数
+	id, err := configVersionID(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	result, err := c.config.Rollback(bizCtx, id)
+	if err != nil {
+		return err
+	}
+
+	// 返回回滚产生的新版本 - 使用统一的响应格式
+	return resp.OneDataResponse(ctx, result)
+}
+
+func configVersionID(ctx echo.Context) (int64, error) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, code.NewValidationError("id", "invalid config version id")
+	}
+	return id, nil
+}
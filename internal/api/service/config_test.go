@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/server/middlewares"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockConfigUseCase 模拟配置历史业务逻辑接口
+type MockConfigUseCase struct {
+	mock.Mock
+}
+
+func (m *MockConfigUseCase) ListVersions(ctx context.Context) ([]param.ConfigVersionData, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]param.ConfigVersionData), args.Error(1)
+}
+
+func (m *MockConfigUseCase) GetVersion(ctx context.Context, id int64) (param.ConfigVersionData, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(param.ConfigVersionData), args.Error(1)
+}
+
+func (m *MockConfigUseCase) Rollback(ctx context.Context, id int64) (param.ConfigVersionData, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(param.ConfigVersionData), args.Error(1)
+}
+
+func TestConfigController_Rollback(t *testing.T) {
+	mockUseCase := new(MockConfigUseCase)
+	mockUseCase.On("Rollback", mock.Anything, int64(3)).Return(param.ConfigVersionData{ID: 5, Source: "rollback", RollbackOf: 3}, nil)
+	mockUseCase.On("Rollback", mock.Anything, int64(4)).Return(param.ConfigVersionData{}, code.NewError(code.ErrConfigVersionNotFound, "config version not found"))
+	controller := &ConfigController{config: mockUseCase}
+
+	c, rec := newAuthTestContext("")
+	c.SetParamNames("id")
+	c.SetParamValues("3")
+	assert.NoError(t, controller.Rollback(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"rollback_of":3`)
+
+	c, _ = newAuthTestContext("")
+	c.SetParamNames("id")
+	c.SetParamValues("4")
+	assert.True(t, errors.IsCode(controller.Rollback(c), code.ErrConfigVersionNotFound))
+
+	c, _ = newAuthTestContext("")
+	c.SetParamNames("id")
+	c.SetParamValues("abc")
+	assert.True(t, errors.IsCode(controller.Rollback(c), code.ErrValidation))
+	mockUseCase.AssertNumberOfCalls(t, "Rollback", 2)
+}
+
+func TestConfigController_RequireAdmin(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *utils.Principal
+		wantStatus int
+	}{
+		{name: "未认证", wantStatus: http.StatusUnauthorized},
+		{name: "非管理员", principal: &utils.Principal{ID: "7", Name: "alice", Method: utils.AuthMethodJWT}, wantStatus: http.StatusForbidden},
+		{name: "管理员", principal: adminPrincipal, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := new(MockConfigUseCase)
+			mockUseCase.On("Rollback", mock.Anything, int64(3)).Return(param.ConfigVersionData{ID: 5, Source: "rollback", RollbackOf: 3}, nil)
+			e := echo.New()
+			e.HTTPErrorHandler = middlewares.ErrorHandler
+			e.Use(withPrincipal(tt.principal))
+			NewConfigController(mockUseCase).RegisterRouter(e.Group("/api"))
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/config/versions/3/rollback", nil))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ * Module: APIKey
+ * API 密钥管理相关的请求/响应结构
+ */
+
+package param
+
+import (
+	"time"
+)
+
+// APIKeyListRequest
+// 查询 API 密钥
+
+// UserID 按所属用户过滤，0 表示不过滤
+
+type APIKeyListRequest struct {
+	APIQuery
+
+	UserID int64 `json:"user_id" query:"user_id" form:"user_id" validate:"min=0"`
+}
+
+// APIKeyCreateRequest
+// 创建 API 密钥
+
+// Name 名称，用于区分调用方
+
+// UserID 所属用户，调用方以该用户的身份访问
+
+// Scopes 授权范围：* 或 METHOD:/path，path 以 * 结尾时按前缀匹配
+
+// ExpiresAt 过期时间，为空表示永不过期
+
+type APIKeyCreateRequest struct {
+	Name string `json:"name" form:"name" xml:"name" validate:"required,max=100"`
+
+	UserID int64 `json:"user_id" form:"user_id" xml:"user_id" validate:"required,gt=0"`
+
+	Scopes []string `json:"scopes" form:"scopes" xml:"scopes" validate:"required,min=1,max=50,dive,required,max=255"`
+
+	ExpiresAt *time.Time `json:"expires_at" form:"expires_at" xml:"expires_at"`
+}
+
+// APIKeyUpdateRequest
+// 更新 API 密钥，整体替换名称、授权范围与过期时间
+
+// Name 名称
+
+// Scopes 授权范围
+
+// ExpiresAt 过期时间，为空表示永不过期
+
+type APIKeyUpdateRequest struct {
+	Name string `json:"name" form:"name" xml:"name" validate:"required,max=100"`
+
+	Scopes []string `json:"scopes" form:"scopes" xml:"scopes" validate:"required,min=1,max=50,dive,required,max=255"`
+
+	ExpiresAt *time.Time `json:"expires_at" form:"expires_at" xml:"expires_at"`
+}
+
+// APIKeyData
+// API 密钥详情，不包含密钥明文
+
+// Prefix 密钥前缀，用于识别密钥
+
+// LastUsedAt 最近使用时间
+
+type APIKeyData struct {
+	ID int64 `json:"id"`
+
+	Name string `json:"name"`
+
+	Prefix string `json:"prefix"`
+
+	UserID int64 `json:"user_id"`
+
+	Scopes []string `json:"scopes"`
+
+	ExpiresAt *time.Time `json:"expires_at"`
+
+	LastUsedAt *time.Time `json:"last_used_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// APIKeyCreatedData
+// 新建的 API 密钥
+
+// Key 密钥明文，只在创建时返回一次
+
+type APIKeyCreatedData struct {
+	APIKeyData
+
+	Key string `json:"key"`
+}
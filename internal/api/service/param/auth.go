@@ -0,0 +1,201 @@
+/*
+ * Module: Auth
+ * 登录、令牌刷新与注销相关的请求/响应结构
+ */
+
+package param
+
+// AuthLoginRequest
+// 用户名密码登录
+
+// Username 用户名
+
+// Password 密码
+
+type AuthLoginRequest struct {
+	Username string `json:"username" form:"username" xml:"username" validate:"required,min=3,max=50"`
+
+	Password string `json:"password" form:"password" xml:"password" validate:"required,min=6,max=128"`
+}
+
+// AuthRefreshRequest
+// 使用 refresh token 换取新的令牌对
+
+// RefreshToken 刷新令牌
+
+type AuthRefreshRequest struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token" xml:"refresh_token" validate:"required"`
+}
+
+// AuthLogoutRequest
+// 注销当前会话
+
+// RefreshToken 需要作废的刷新令牌
+
+type AuthLogoutRequest struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token" xml:"refresh_token" validate:"required"`
+}
+
+// AuthUnlockRequest
+// 解除账号的登录锁定
+
+// Username 被锁定的用户名
+
+type AuthUnlockRequest struct {
+	Username string `json:"username" param:"username" validate:"required,min=3,max=50"`
+}
+
+// AuthTokenData
+// 令牌对
+
+// AccessToken 访问令牌（JWT）
+
+// RefreshToken 刷新令牌（不透明字符串，单次有效）
+
+// TokenType 令牌类型，固定为 Bearer
+
+// ExpiresIn 访问令牌有效期（秒）
+
+// RefreshExpiresIn 刷新令牌有效期（秒）
+
+// MfaRequired 账号已开启两步验证，需携带 MfaToken 调用 /auth/mfa/verify 完成登录，此时 ExpiresIn 为 MfaToken 的有效期
+
+// MfaToken 登录第二步使用的短期凭据
+
+type AuthTokenData struct {
+	AccessToken string `json:"access_token,omitempty"`
+
+	RefreshToken string `json:"refresh_token,omitempty"`
+
+	TokenType string `json:"token_type,omitempty"`
+
+	ExpiresIn int64 `json:"expires_in"`
+
+	RefreshExpiresIn int64 `json:"refresh_expires_in,omitempty"`
+
+	MfaRequired bool `json:"mfa_required,omitempty"`
+
+	MfaToken string `json:"mfa_token,omitempty"`
+}
+
+// MfaVerifyRequest
+// 登录第二步，验证码与恢复码二选一
+
+// MfaToken 登录第一步返回的 mfa_token
+
+// Code 身份验证器 App 中的 6 位验证码
+
+// RecoveryCode 一次性恢复码
+
+type MfaVerifyRequest struct {
+	MfaToken string `json:"mfa_token" form:"mfa_token" xml:"mfa_token" validate:"required"`
+
+	Code string `json:"code" form:"code" xml:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+
+	RecoveryCode string `json:"recovery_code" form:"recovery_code" xml:"recovery_code" validate:"required_without=Code,omitempty,max=32"`
+}
+
+// MfaActivateRequest
+// 确认登记的密钥并启用两步验证
+
+// Code 身份验证器 App 中的 6 位验证码
+
+type MfaActivateRequest struct {
+	Code string `json:"code" form:"code" xml:"code" validate:"required,len=6,numeric"`
+}
+
+// MfaEnrollData
+// 待确认的 TOTP 密钥
+
+// Secret Base32 编码的密钥，供无法扫码时手动输入
+
+// URI otpauth URI，通常渲染为二维码
+
+type MfaEnrollData struct {
+	Secret string `json:"secret"`
+
+	URI string `json:"uri"`
+}
+
+// MfaRecoveryCodesData
+// 一次性恢复码，只在启用时返回一次
+
+// RecoveryCodes 恢复码明文
+
+type MfaRecoveryCodesData struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// OIDCProviderRequest
+// 指定身份提供方
+
+// Provider 身份提供方名称，对应 [[auth.oidc.providers]] 的 name
+
+type OIDCProviderRequest struct {
+	Provider string `json:"provider" param:"provider" validate:"required,max=50"`
+}
+
+// OIDCCallbackRequest
+// 身份提供方回调携带的参数
+
+// Provider 身份提供方名称
+
+// Code 授权码
+
+// State 授权时返回的 state
+
+// Error 用户拒绝授权等情况下身份提供方返回的错误
+
+type OIDCCallbackRequest struct {
+	Provider string `json:"provider" param:"provider" validate:"required,max=50"`
+
+	Code string `json:"code" query:"code" validate:"required_without=Error"`
+
+	State string `json:"state" query:"state" validate:"required"`
+
+	Error string `json:"error" query:"error"`
+}
+
+// OIDCAuthorizeData
+// 身份提供方授权地址
+
+// AuthorizationURL 前端跳转到该地址完成登录
+
+// State 回调时原样返回，用于防止 CSRF
+
+type OIDCAuthorizeData struct {
+	AuthorizationURL string `json:"authorization_url"`
+
+	State string `json:"state"`
+}
+
+// PasswordForgotRequest
+// 申请重置密码，无论邮箱是否存在都返回成功
+
+// Email 账号绑定的邮箱
+
+type PasswordForgotRequest struct {
+	Email string `json:"email" form:"email" xml:"email" validate:"required,email,max=100"`
+}
+
+// PasswordResetRequest
+// 使用邮件中的令牌重置密码
+
+// Token 重置密码令牌
+
+// Password 新密码
+
+type PasswordResetRequest struct {
+	Token string `json:"token" form:"token" xml:"token" validate:"required,max=512"`
+
+	Password string `json:"password" form:"password" xml:"password" validate:"required,min=6,max=128"`
+}
+
+// EmailVerifyRequest
+// 使用邮件中的令牌验证邮箱
+
+// Token 邮箱验证令牌
+
+type EmailVerifyRequest struct {
+	Token string `json:"token" form:"token" xml:"token" validate:"required,max=512"`
+}
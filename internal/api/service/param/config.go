@@ -0,0 +1,46 @@
+/*
+ * Module: Config
+ * 配置历史版本与回滚相关的请求/响应结构
+ */
+
+package param
+
+import (
+	"time"
+)
+
+// ConfigVersionData
+// 配置历史版本，敏感值已隐藏
+
+// Source 触发该版本的来源：initial、file、etcd、consul、update 或 rollback
+
+// Revision 远程来源的版本（etcd ModRevision、consul ModifyIndex）
+
+// RollbackOf 回滚产生的版本对应的目标版本
+
+// Changes 相对上一版本的变化
+
+type ConfigVersionData struct {
+	ID int64 `json:"id"`
+
+	Source string `json:"source"`
+
+	Revision int64 `json:"revision"`
+
+	RollbackOf int64 `json:"rollback_of,omitempty"`
+
+	Timestamp time.Time `json:"timestamp"`
+
+	Changes []ConfigFieldChange `json:"changes"`
+}
+
+// ConfigFieldChange
+// 单个配置键的变化
+
+type ConfigFieldChange struct {
+	Key string `json:"key"`
+
+	Old any `json:"old"`
+
+	New any `json:"new"`
+}
@@ -0,0 +1,170 @@
+/*
+ * Module: Rbac
+ * Casbin 策略与角色管理相关的请求/响应结构
+ */
+
+package param
+
+// RbacPolicy
+// 权限策略（p 规则）
+
+// Subject 主体（用户或角色）
+
+// Domain 域（租户），仅域模型下使用
+
+// Object 资源路径
+
+// Action 请求方法
+
+type RbacPolicy struct {
+	Subject string `json:"sub" query:"sub" form:"sub" xml:"sub" validate:"required,max=100"`
+
+	Domain string `json:"dom,omitempty" query:"dom" form:"dom" xml:"dom" validate:"max=100"`
+
+	Object string `json:"obj" query:"obj" form:"obj" xml:"obj" validate:"required,max=255"`
+
+	Action string `json:"act" query:"act" form:"act" xml:"act" validate:"required,max=20"`
+}
+
+// RbacPolicyListRequest
+// 查询策略，空字段表示不过滤
+
+type RbacPolicyListRequest struct {
+	APIQuery
+
+	Subject string `json:"sub" query:"sub" form:"sub"`
+
+	Domain string `json:"dom" query:"dom" form:"dom"`
+
+	Object string `json:"obj" query:"obj" form:"obj"`
+
+	Action string `json:"act" query:"act" form:"act"`
+}
+
+// RbacPolicyUpdateRequest
+// 替换一条策略
+
+// Old 原策略
+
+// New 新策略
+
+type RbacPolicyUpdateRequest struct {
+	Old RbacPolicy `json:"old" xml:"old" validate:"required"`
+
+	New RbacPolicy `json:"new" xml:"new" validate:"required"`
+}
+
+// RbacRoleAssignment
+// 角色分配（g 规则）
+
+// User 用户或子角色
+
+// Role 角色
+
+// Domain 域（租户），仅域模型下使用
+
+type RbacRoleAssignment struct {
+	User string `json:"user" query:"user" form:"user" xml:"user" validate:"required,max=100"`
+
+	Role string `json:"role" query:"role" form:"role" xml:"role" validate:"required,max=100"`
+
+	Domain string `json:"dom,omitempty" query:"dom" form:"dom" xml:"dom" validate:"max=100"`
+}
+
+// RbacRoleListRequest
+// 查询角色分配，空字段表示不过滤
+
+type RbacRoleListRequest struct {
+	APIQuery
+
+	User string `json:"user" query:"user" form:"user"`
+
+	Role string `json:"role" query:"role" form:"role"`
+
+	Domain string `json:"dom" query:"dom" form:"dom"`
+}
+
+// RbacUserRequest
+// 查询用户角色或权限
+
+// User 用户（路径参数）
+
+// Domain 域（租户），域模型下必填
+
+type RbacUserRequest struct {
+	User string `param:"user" validate:"required,max=100"`
+
+	Domain string `json:"dom" query:"dom" form:"dom" validate:"max=100"`
+}
+
+// RbacUserRolesData
+// 用户角色
+
+// Roles 直接分配的角色
+
+// ImplicitRoles 包含继承关系在内的全部角色
+
+type RbacUserRolesData struct {
+	User string `json:"user"`
+
+	Domain string `json:"dom,omitempty"`
+
+	Roles []string `json:"roles"`
+
+	ImplicitRoles []string `json:"implicit_roles"`
+}
+
+// RbacUserPermissionsData
+// 用户的有效权限（含角色继承）
+
+type RbacUserPermissionsData struct {
+	User string `json:"user"`
+
+	Domain string `json:"dom,omitempty"`
+
+	Permissions []RbacPolicy `json:"permissions"`
+}
+
+// RbacExportRequest
+// 导出策略
+
+// Format 导出格式：csv（默认）或 json
+
+type RbacExportRequest struct {
+	Format string `json:"format" query:"format" form:"format" validate:"omitempty,oneof=csv json"`
+}
+
+// RbacImportRequest
+// 导入策略，请求体为导出格式的数据
+
+// Format 数据格式：csv（默认）或 json
+
+// Mode merge（默认）只新增不存在的规则；replace 用导入内容替换全部规则
+
+type RbacImportRequest struct {
+	Format string `json:"format" query:"format" form:"format" validate:"omitempty,oneof=csv json"`
+
+	Mode string `json:"mode" query:"mode" form:"mode" validate:"omitempty,oneof=merge replace"`
+}
+
+// RbacPolicySet
+// 策略集合，即 JSON 导入导出格式
+
+type RbacPolicySet struct {
+	Policies []RbacPolicy `json:"policies" validate:"dive"`
+
+	Roles []RbacRoleAssignment `json:"roles" validate:"dive"`
+}
+
+// RbacImportResult
+// 导入结果
+
+// Policies 新增的策略数
+
+// Roles 新增的角色分配数
+
+type RbacImportResult struct {
+	Policies int `json:"policies"`
+
+	Roles int `json:"roles"`
+}
@@ -0,0 +1,255 @@
+/*
+ * Module: Rbac
+ * Casbin 策略与角色管理接口
+ */
+
+package service
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/NSObjects/go-template/internal/api/biz"
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/resp"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/marmotedu/errors"
+)
+
+// maxRbacImportSize 导入文件大小上限
+const maxRbacImportSize = 4 << 20
+
+type RbacController struct {
+	rbac biz.RbacUseCase
+}
+
+func NewRbacController(h biz.RbacUseCase) RegisterRouter {
+	return &RbacController{rbac: h}
+}
+
+func (c *RbacController) RegisterRouter(g *echo.Group, m ...echo.MiddlewareFunc) {
+	g.GET("/rbac/policies", c.ListPolicies, RequireAdmin).Name = "查询权限策略"
+	g.POST("/rbac/policies", c.AddPolicy, RequireAdmin).Name = "新增权限策略"
+	g.PUT("/rbac/policies", c.UpdatePolicy, RequireAdmin).Name = "修改权限策略"
+	g.DELETE("/rbac/policies", c.RemovePolicy, RequireAdmin).Name = "删除权限策略"
+	g.GET("/rbac/roles", c.ListRoles, RequireAdmin).Name = "查询角色分配"
+	g.POST("/rbac/roles", c.AssignRole, RequireAdmin).Name = "分配角色"
+	g.DELETE("/rbac/roles", c.RevokeRole, RequireAdmin).Name = "撤销角色"
+	g.GET("/rbac/users/:user/roles", c.UserRoles, RequireAdmin).Name = "查询用户角色"
+	g.GET("/rbac/users/:user/permissions", c.UserPermissions, RequireAdmin).Name = "查询用户权限"
+	g.GET("/rbac/export", c.Export, RequireAdmin).Name = "导出权限策略"
+	g.POST("/rbac/import", c.Import, RequireAdmin).Name = "导入权限策略"
+}
+
+func (c *RbacController) ListPolicies(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.RbacPolicyListRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	list, total, err := c.rbac.ListPolicies(bizCtx, req)
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.ListDataResponse(ctx, list, total)
+}
+
+func (c *RbacController) AddPolicy(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.RbacPolicy
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.rbac.AddPolicy(bizCtx, req); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
+
+func (c *RbacController) UpdatePolicy(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.RbacPolicyUpdateRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.rbac.UpdatePolicy(bizCtx, req); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
+
+func (c *RbacController) RemovePolicy(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.RbacPolicy
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.rbac.RemovePolicy(bizCtx, req); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
+
+func (c *RbacController) ListRoles(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.RbacRoleListRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	list, total, err := c.rbac.ListRoles(bizCtx, req)
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.ListDataResponse(ctx, list, total)
+}
+
+func (c *RbacController) AssignRole(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.RbacRoleAssignment
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.rbac.AssignRole(bizCtx, req); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
+
+func (c *RbacController) RevokeRole(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.RbacRoleAssignment
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	if err := c.rbac.RevokeRole(bizCtx, req); err != nil {
+		return err
+	}
+
+	// 返回操作成功 - 使用统一的响应格式
+	return resp.OperateSuccess(ctx)
+}
+
+func (c *RbacController) UserRoles(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.RbacUserRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	result, err := c.rbac.UserRoles(bizCtx, req)
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.OneDataResponse(ctx, result)
+}
+
+func (c *RbacController) UserPermissions(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.RbacUserRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	result, err := c.rbac.UserPermissions(bizCtx, req)
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.OneDataResponse(ctx, result)
+}
+
+// Export 以附件形式返回原始数据，便于直接再导入
+func (c *RbacController) Export(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.RbacExportRequest
+	if err := BindAndValidate(ctx, &req); err != nil {
+		return err
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	data, err := c.rbac.Export(bizCtx, req)
+	if err != nil {
+		return err
+	}
+
+	contentType, filename := "text/csv; charset=utf-8", "policy.csv"
+	if req.Format == biz.RbacFormatJSON {
+		contentType, filename = echo.MIMEApplicationJSONCharsetUTF8, "policy.json"
+	}
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	return ctx.Blob(http.StatusOK, contentType, data)
+}
+
+// Import 参数通过查询字符串传递，请求体为导出格式的原始数据
+func (c *RbacController) Import(ctx echo.Context) error {
+	// 绑定和验证请求参数
+	var req param.RbacImportRequest
+	if err := (&echo.DefaultBinder{}).BindQueryParams(ctx, &req); err != nil {
+		return errors.WrapC(err, code.ErrBind, "bind request failed")
+	}
+	if err := ctx.Validate(&req); err != nil {
+		return errors.WrapC(err, code.ErrValidation, "validation failed")
+	}
+
+	data, err := io.ReadAll(io.LimitReader(ctx.Request().Body, maxRbacImportSize+1))
+	if err != nil {
+		return code.WrapError(err, code.ErrBind, "read request body")
+	}
+	if len(data) > maxRbacImportSize {
+		return code.NewError(code.ErrRbacInvalidImport, "import file too large")
+	}
+
+	// 调用业务逻辑 - 构造包含链路追踪信息的context
+	bizCtx := utils.BuildContext(ctx)
+	result, err := c.rbac.Import(bizCtx, req, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	// 返回数据 - 使用统一的响应格式
+	return resp.OneDataResponse(ctx, result)
+}
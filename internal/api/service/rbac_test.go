@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/api/service/param"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/server/middlewares"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockRbacUseCase 模拟策略管理业务逻辑接口
+type MockRbacUseCase struct {
+	mock.Mock
+}
+
+func (m *MockRbacUseCase) ListPolicies(ctx context.Context, req param.RbacPolicyListRequest) ([]param.RbacPolicy, int64, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]param.RbacPolicy), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRbacUseCase) AddPolicy(ctx context.Context, req param.RbacPolicy) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *MockRbacUseCase) UpdatePolicy(ctx context.Context, req param.RbacPolicyUpdateRequest) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *MockRbacUseCase) RemovePolicy(ctx context.Context, req param.RbacPolicy) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *MockRbacUseCase) ListRoles(ctx context.Context, req param.RbacRoleListRequest) ([]param.RbacRoleAssignment, int64, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]param.RbacRoleAssignment), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockRbacUseCase) AssignRole(ctx context.Context, req param.RbacRoleAssignment) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *MockRbacUseCase) RevokeRole(ctx context.Context, req param.RbacRoleAssignment) error {
+	return m.Called(ctx, req).Error(0)
+}
+
+func (m *MockRbacUseCase) UserRoles(ctx context.Context, req param.RbacUserRequest) (param.RbacUserRolesData, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(param.RbacUserRolesData), args.Error(1)
+}
+
+func (m *MockRbacUseCase) UserPermissions(ctx context.Context, req param.RbacUserRequest) (param.RbacUserPermissionsData, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(param.RbacUserPermissionsData), args.Error(1)
+}
+
+func (m *MockRbacUseCase) Export(ctx context.Context, req param.RbacExportRequest) ([]byte, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockRbacUseCase) Import(ctx context.Context, req param.RbacImportRequest, data io.Reader) (param.RbacImportResult, error) {
+	args := m.Called(ctx, req, data)
+	return args.Get(0).(param.RbacImportResult), args.Error(1)
+}
+
+// adminPrincipal 管理接口测试使用的认证主体
+var adminPrincipal = &utils.Principal{ID: "1", Name: "root", Admin: true, Method: utils.AuthMethodJWT}
+
+// withPrincipal 模拟认证中间件写入认证主体，p 为 nil 时视为未认证
+func withPrincipal(p *utils.Principal) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if p != nil {
+				utils.SetPrincipal(c, p)
+			}
+			return next(c)
+		}
+	}
+}
+
+// serveRbac 以管理员身份通过路由处理请求，覆盖路径参数与错误处理
+func serveRbac(m *MockRbacUseCase, method, target, contentType, body string) *httptest.ResponseRecorder {
+	return serveRbacAs(m, adminPrincipal, method, target, contentType, body)
+}
+
+func serveRbacAs(m *MockRbacUseCase, p *utils.Principal, method, target, contentType, body string) *httptest.ResponseRecorder {
+	e := echo.New()
+	e.Validator = &middlewares.Validator{Validator: validator.New()}
+	e.HTTPErrorHandler = middlewares.ErrorHandler
+	e.Use(withPrincipal(p))
+	NewRbacController(m).RegisterRouter(e.Group("/api"))
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set(echo.HeaderContentType, contentType)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRbacController_RequireAdmin(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *utils.Principal
+		wantStatus int
+	}{
+		{name: "未认证", wantStatus: http.StatusUnauthorized},
+		{name: "非管理员", principal: &utils.Principal{ID: "7", Name: "alice", Method: utils.AuthMethodJWT}, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(MockRbacUseCase)
+			rec := serveRbacAs(m, tt.principal, http.MethodPost, "/api/rbac/policies", echo.MIMEApplicationJSON, `{"sub":"alice","obj":"/api/rbac/policies","act":"POST"}`)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			m.AssertNotCalled(t, "AddPolicy", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRbacController_AddPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		setupMock  func(*MockRbacUseCase)
+		wantStatus int
+	}{
+		{
+			name: "成功场景",
+			body: `{"sub":"admin","obj":"/api/users","act":"GET"}`,
+			setupMock: func(m *MockRbacUseCase) {
+				m.On("AddPolicy", mock.Anything, param.RbacPolicy{Subject: "admin", Object: "/api/users", Action: "GET"}).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "策略已存在",
+			body: `{"sub":"admin","obj":"/api/users","act":"GET"}`,
+			setupMock: func(m *MockRbacUseCase) {
+				m.On("AddPolicy", mock.Anything, mock.Anything).Return(code.NewError(code.ErrRbacPolicyExists, "policy already exists"))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "参数验证失败",
+			body:       `{"sub":"admin"}`,
+			setupMock:  func(*MockRbacUseCase) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := new(MockRbacUseCase)
+			tt.setupMock(m)
+			rec := serveRbac(m, http.MethodPost, "/api/rbac/policies", echo.MIMEApplicationJSON, tt.body)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRbacController_RevokeRoleByQuery(t *testing.T) {
+	m := new(MockRbacUseCase)
+	m.On("RevokeRole", mock.Anything, param.RbacRoleAssignment{User: "alice", Role: "admin"}).Return(nil)
+
+	rec := serveRbac(m, http.MethodDelete, "/api/rbac/roles?user=alice&role=admin", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	m.AssertExpectations(t)
+}
+
+func TestRbacController_UserPermissions(t *testing.T) {
+	m := new(MockRbacUseCase)
+	m.On("UserPermissions", mock.Anything, param.RbacUserRequest{User: "alice", Domain: "tenant1"}).Return(param.RbacUserPermissionsData{
+		User:        "alice",
+		Domain:      "tenant1",
+		Permissions: []param.RbacPolicy{{Subject: "admin", Domain: "tenant1", Object: "/api/users", Action: "GET"}},
+	}, nil)
+
+	rec := serveRbac(m, http.MethodGet, "/api/rbac/users/alice/permissions?dom=tenant1", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"obj":"/api/users"`)
+}
+
+func TestRbacController_Export(t *testing.T) {
+	m := new(MockRbacUseCase)
+	m.On("Export", mock.Anything, param.RbacExportRequest{}).Return([]byte("p,admin,/api/users,GET\n"), nil)
+
+	rec := serveRbac(m, http.MethodGet, "/api/rbac/export", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "p,admin,/api/users,GET\n", rec.Body.String())
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentDisposition), "policy.csv")
+
+	rec = serveRbac(m, http.MethodGet, "/api/rbac/export?format=xml", "", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRbacController_Import(t *testing.T) {
+	body := "p, admin, /api/users, GET\n"
+	m := new(MockRbacUseCase)
+	m.On("Import", mock.Anything, param.RbacImportRequest{Mode: "replace"}, mock.MatchedBy(func(r io.Reader) bool {
+		data, _ := io.ReadAll(r)
+		return string(data) == body
+	})).Return(param.RbacImportResult{Policies: 1}, nil)
+
+	rec := serveRbac(m, http.MethodPost, "/api/rbac/import?mode=replace", "text/csv", body)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	m.AssertExpectations(t)
+
+	rec = serveRbac(m, http.MethodPost, "/api/rbac/import?mode=append", "text/csv", body)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -4,12 +4,19 @@ import (
 	"net/http/httptest"
 
 	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/utils"
 	"github.com/labstack/echo/v4"
 	"github.com/marmotedu/errors"
 	"go.uber.org/fx"
 )
 
-var Model = fx.Options(fx.Provide(AsRoute(NewUserController)))
+var Model = fx.Options(
+	fx.Provide(AsRoute(NewUserController)),
+	fx.Provide(AsRoute(NewAuthController)),
+	fx.Provide(AsRoute(NewRbacController)),
+	fx.Provide(AsRoute(NewAPIKeyController)),
+	fx.Provide(AsRoute(NewConfigController)),
+)
 
 func AsRoute(f any) any {
 	return fx.Annotate(
@@ -31,6 +38,21 @@ func BindAndValidate(ctx echo.Context, obj any) error {
 	return nil
 }
 
+// RequireAdmin 管理接口只允许管理员访问。
+// 不依赖中间件管道是否启用了 casbin：未启用 jwt/api_key 认证时没有认证主体，管理接口一律拒绝。
+func RequireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		p, ok := utils.GetPrincipal(c)
+		if !ok {
+			return code.NewError(code.ErrUnauthorized, "authentication required")
+		}
+		if !p.Admin {
+			return code.NewError(code.ErrPermissionDenied, "administrator required")
+		}
+		return next(c)
+	}
+}
+
 type RegisterRouter interface {
 	RegisterRouter(s *echo.Group, middlewareFunc ...echo.MiddlewareFunc)
 }
@@ -0,0 +1,135 @@
+/*
+ * Action Token
+ * 重置密码、邮箱验证等一次性操作令牌
+ *
+ * 令牌形如 <payload>.<signature>：payload 携带用途、用户与过期时间，以 HMAC-SHA256 签名，
+ * 伪造或篡改的令牌无需查询存储即可拒绝；签发时以摘要为键登记到 ActionTokenStore，
+ * 使用时原子地取出并删除，保证令牌只能使用一次。
+ */
+
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+)
+
+const (
+	// PurposePasswordReset 重置密码
+	PurposePasswordReset = "password_reset"
+	// PurposeVerifyEmail 验证邮箱
+	PurposeVerifyEmail = "verify_email"
+)
+
+// ActionToken 令牌登记的操作上下文
+type ActionToken struct {
+	Purpose string `json:"purpose"`
+	UserID  int64  `json:"user_id"`
+	// Email 签发时的邮箱，邮箱变更后旧的验证链接随之失效
+	Email string `json:"email,omitempty"`
+}
+
+// ActionTokenStore 一次性令牌存储，只保存令牌摘要
+type ActionTokenStore interface {
+	// Save 登记令牌，ttl 后自动失效
+	Save(ctx context.Context, hash string, tok ActionToken, ttl time.Duration) error
+
+	// Consume 原子地取出并删除令牌，不存在或已过期时返回 code.ErrVerificationTokenInvalid
+	Consume(ctx context.Context, hash string) (ActionToken, error)
+}
+
+// actionClaims 令牌载荷
+type actionClaims struct {
+	Purpose   string `json:"p"`
+	UserID    int64  `json:"u"`
+	ExpiresAt int64  `json:"x"`
+	Nonce     string `json:"n"`
+}
+
+// ActionTokenSigner 签发与校验一次性操作令牌
+type ActionTokenSigner struct {
+	secret []byte
+}
+
+// actionTokenKeyLabel 由 jwt.secret 派生令牌密钥时使用的标签
+const actionTokenKeyLabel = "action-token"
+
+// NewActionTokenSigner 创建令牌签名器。
+// 密钥优先取 auth.verification.secret；未配置时由 jwt.secret 派生（HMAC-SHA256(jwt.secret, "action-token")），
+// 不与访问令牌共用同一个密钥；都未配置时随机生成，此时令牌只在当前进程内有效，多副本部署时必须配置。
+func NewActionTokenSigner(cfg configs.Config) (*ActionTokenSigner, error) {
+	if secret := cfg.Auth.Verification.Secret; secret != "" {
+		return &ActionTokenSigner{secret: []byte(secret)}, nil
+	}
+	if secret := cfg.JWT.Secret; secret != "" {
+		m := hmac.New(sha256.New, []byte(secret))
+		m.Write([]byte(actionTokenKeyLabel))
+		return &ActionTokenSigner{secret: m.Sum(nil)}, nil
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, code.WrapInternalServerError(err, "generate action token secret")
+	}
+	return &ActionTokenSigner{secret: key}, nil
+}
+
+// Sign 签发令牌
+func (s *ActionTokenSigner) Sign(purpose string, userID int64, expiresAt time.Time) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", code.WrapInternalServerError(err, "generate action token")
+	}
+	payload, err := json.Marshal(actionClaims{
+		Purpose:   purpose,
+		UserID:    userID,
+		ExpiresAt: expiresAt.Unix(),
+		Nonce:     base64.RawURLEncoding.EncodeToString(nonce),
+	})
+	if err != nil {
+		return "", code.WrapError(err, code.ErrEncodingJSON, "encode action token")
+	}
+	p := base64.RawURLEncoding.EncodeToString(payload)
+	return p + "." + base64.RawURLEncoding.EncodeToString(s.mac(p)), nil
+}
+
+// Verify 校验签名、用途与有效期，返回令牌所属用户
+func (s *ActionTokenSigner) Verify(token, purpose string, now time.Time) (int64, error) {
+	p, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, code.NewError(code.ErrVerificationTokenInvalid, "malformed verification token")
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.mac(p)) {
+		return 0, code.NewError(code.ErrVerificationTokenInvalid, "verification token signature mismatch")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(p)
+	if err != nil {
+		return 0, code.NewError(code.ErrVerificationTokenInvalid, "malformed verification token")
+	}
+	var claims actionClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return 0, code.NewError(code.ErrVerificationTokenInvalid, "malformed verification token")
+	}
+	if claims.Purpose != purpose {
+		return 0, code.NewError(code.ErrVerificationTokenInvalid, "verification token purpose mismatch")
+	}
+	if !now.Before(time.Unix(claims.ExpiresAt, 0)) {
+		return 0, code.NewError(code.ErrVerificationTokenInvalid, "verification token has expired")
+	}
+	return claims.UserID, nil
+}
+
+func (s *ActionTokenSigner) mac(payload string) []byte {
+	m := hmac.New(sha256.New, s.secret)
+	m.Write([]byte(payload))
+	return m.Sum(nil)
+}
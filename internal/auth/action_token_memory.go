@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+)
+
+type memoryActionToken struct {
+	token     ActionToken
+	expiresAt time.Time
+}
+
+// MemoryActionTokenStore 进程内一次性令牌存储，仅适合单实例部署、开发与测试
+type MemoryActionTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]memoryActionToken
+	now    func() time.Time
+}
+
+// NewMemoryActionTokenStore 创建进程内一次性令牌存储
+func NewMemoryActionTokenStore() *MemoryActionTokenStore {
+	return &MemoryActionTokenStore{
+		tokens: make(map[string]memoryActionToken),
+		now:    time.Now,
+	}
+}
+
+func (m *MemoryActionTokenStore) Save(_ context.Context, hash string, tok ActionToken, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for k, t := range m.tokens {
+		if !now.Before(t.expiresAt) {
+			delete(m.tokens, k)
+		}
+	}
+	m.tokens[hash] = memoryActionToken{token: tok, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (m *MemoryActionTokenStore) Consume(_ context.Context, hash string) (ActionToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tokens[hash]
+	delete(m.tokens, hash)
+	if !ok || !m.now().Before(t.expiresAt) {
+		return ActionToken{}, code.NewError(code.ErrVerificationTokenInvalid, "verification token is invalid or already used")
+	}
+	return t.token, nil
+}
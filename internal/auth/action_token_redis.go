@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/redis/go-redis/v9"
+)
+
+const redisActionTokenPrefix = "auth:action:"
+
+// RedisActionTokenStore 基于 Redis 的一次性令牌存储，多副本共享
+type RedisActionTokenStore struct {
+	rdb *redis.Client
+}
+
+// NewRedisActionTokenStore 创建 Redis 一次性令牌存储
+func NewRedisActionTokenStore(rdb *redis.Client) *RedisActionTokenStore {
+	return &RedisActionTokenStore{rdb: rdb}
+}
+
+func (r *RedisActionTokenStore) Save(ctx context.Context, hash string, tok ActionToken, ttl time.Duration) error {
+	data, err := json.Marshal(tok)
+	if err != nil {
+		return code.WrapError(err, code.ErrEncodingJSON, "encode action token")
+	}
+	return code.WrapRedisError(r.rdb.Set(ctx, redisActionTokenPrefix+hash, data, ttl).Err(), "store action token")
+}
+
+func (r *RedisActionTokenStore) Consume(ctx context.Context, hash string) (ActionToken, error) {
+	data, err := r.rdb.GetDel(ctx, redisActionTokenPrefix+hash).Bytes()
+	if err == redis.Nil {
+		return ActionToken{}, code.NewError(code.ErrVerificationTokenInvalid, "verification token is invalid or already used")
+	}
+	if err != nil {
+		return ActionToken{}, code.WrapRedisError(err, "load action token")
+	}
+	var tok ActionToken
+	if err := json.Unmarshal(data, &tok); err != nil {
+		return ActionToken{}, code.WrapError(err, code.ErrDecodingJSON, "decode action token")
+	}
+	return tok, nil
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/marmotedu/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionTokenSigner(t *testing.T) {
+	signer, err := NewActionTokenSigner(configs.Config{JWT: configs.JWTConfig{Secret: "s3cret"}})
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+
+	token, err := signer.Sign(PurposePasswordReset, 7, now.Add(time.Minute))
+	require.NoError(t, err)
+	uid, err := signer.Verify(token, PurposePasswordReset, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), uid)
+
+	other, err := signer.Sign(PurposePasswordReset, 7, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+
+	payload, sig, _ := strings.Cut(token, ".")
+	forged, err := signer.Sign(PurposePasswordReset, 8, now.Add(time.Minute))
+	require.NoError(t, err)
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	otherSigner, err := NewActionTokenSigner(configs.Config{Auth: configs.AuthConfig{Verification: configs.VerificationConfig{Secret: "other"}}})
+	require.NoError(t, err)
+	foreign, err := otherSigner.Sign(PurposePasswordReset, 7, now.Add(time.Minute))
+	require.NoError(t, err)
+
+	// 由 jwt.secret 派生的密钥与 jwt.secret 本身不同，以 jwt.secret 直接签名的令牌无效
+	jwtKeySigner, err := NewActionTokenSigner(configs.Config{Auth: configs.AuthConfig{Verification: configs.VerificationConfig{Secret: "s3cret"}}})
+	require.NoError(t, err)
+	jwtKeyed, err := jwtKeySigner.Sign(PurposePasswordReset, 7, now.Add(time.Minute))
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		token   string
+		purpose string
+		now     time.Time
+	}{
+		"purpose":   {token: token, purpose: PurposeVerifyEmail, now: now},
+		"expired":   {token: token, purpose: PurposePasswordReset, now: now.Add(time.Minute)},
+		"tampered":  {token: forgedPayload + "." + sig, purpose: PurposePasswordReset, now: now},
+		"signature": {token: payload + ".x", purpose: PurposePasswordReset, now: now},
+		"secret":    {token: foreign, purpose: PurposePasswordReset, now: now},
+		"jwt key":   {token: jwtKeyed, purpose: PurposePasswordReset, now: now},
+		"malformed": {token: "garbage", purpose: PurposePasswordReset, now: now},
+	} {
+		_, err := signer.Verify(tc.token, tc.purpose, tc.now)
+		assert.True(t, errors.IsCode(err, code.ErrVerificationTokenInvalid), name)
+	}
+}
+
+func TestMemoryActionTokenStore(t *testing.T) {
+	testActionTokenStore(t, NewMemoryActionTokenStore())
+}
+
+// TestRedisActionTokenStore 需要设置 TEST_REDIS_ADDR 指向可写的 Redis 实例
+func TestRedisActionTokenStore(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR not set")
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { _ = rdb.Close() })
+	testActionTokenStore(t, NewRedisActionTokenStore(rdb))
+}
+
+func testActionTokenStore(t *testing.T, store ActionTokenStore) {
+	ctx := context.Background()
+	tok := ActionToken{Purpose: PurposeVerifyEmail, UserID: 7, Email: "a@example.com"}
+	hash := HashToken("token-" + time.Now().String())
+
+	require.NoError(t, store.Save(ctx, hash, tok, time.Minute))
+	got, err := store.Consume(ctx, hash)
+	require.NoError(t, err)
+	assert.Equal(t, tok, got)
+
+	_, err = store.Consume(ctx, hash)
+	assert.True(t, errors.IsCode(err, code.ErrVerificationTokenInvalid))
+}
@@ -0,0 +1,98 @@
+/*
+ * API Key
+ * 服务间调用使用的 API 密钥
+ *
+ * 密钥形如 gtk_<prefix>_<secret>：prefix 明文存储用于查找，整个密钥只保存 SHA-256 摘要。
+ * secret 为 256 位随机值，摘要无需加盐或慢哈希。
+ */
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIKeyPrefix API 密钥的固定前缀，便于在日志或代码仓库中识别泄露的密钥
+const APIKeyPrefix = "gtk"
+
+// apiKeyLookupBytes 查找前缀的随机字节数
+const apiKeyLookupBytes = 6
+
+// ScopeAll 允许访问全部接口的授权范围
+const ScopeAll = "*"
+
+// NewAPIKey 生成 API 密钥，返回密钥明文与用于查找的前缀
+func NewAPIKey() (key, prefix string, err error) {
+	lookup := make([]byte, apiKeyLookupBytes)
+	if _, err := rand.Read(lookup); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	prefix = hex.EncodeToString(lookup)
+	return APIKeyPrefix + "_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), prefix, nil
+}
+
+// ParseAPIKey 校验密钥格式并返回查找前缀
+func ParseAPIKey(key string) (string, bool) {
+	// secret 为 base64url，可能包含下划线
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != APIKeyPrefix || parts[2] == "" || len(parts[1]) != apiKeyLookupBytes*2 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// VerifyAPIKey 以常量时间比较密钥与存储的摘要
+func VerifyAPIKey(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(key)), []byte(hash)) == 1
+}
+
+// ValidateScope 校验授权范围格式：* 或 METHOD:/path，METHOD 可为 *，path 以 * 结尾时按前缀匹配
+func ValidateScope(scope string) error {
+	if scope == ScopeAll {
+		return nil
+	}
+	method, path, ok := strings.Cut(scope, ":")
+	if !ok || !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("scope %q must be * or METHOD:/path", scope)
+	}
+	switch method {
+	case ScopeAll, http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		return fmt.Errorf("scope %q has invalid method %q", scope, method)
+	}
+	if i := strings.Index(path, "*"); i >= 0 && i != len(path)-1 {
+		return fmt.Errorf("scope %q may only end with *", scope)
+	}
+	return nil
+}
+
+// ScopeAllows 判断授权范围是否允许访问路由，path 为路由模板（echo.Context.Path）
+func ScopeAllows(scopes []string, method, path string) bool {
+	for _, scope := range scopes {
+		if scope == ScopeAll {
+			return true
+		}
+		m, p, ok := strings.Cut(scope, ":")
+		if !ok || (m != ScopeAll && m != method) {
+			continue
+		}
+		if p == path || (strings.HasSuffix(p, "*") && strings.HasPrefix(path, p[:len(p)-1])) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKey(t *testing.T) {
+	key, prefix, err := NewAPIKey()
+	require.NoError(t, err)
+	assert.Regexp(t, `^gtk_[0-9a-f]{12}_[A-Za-z0-9_-]{43}$`, key)
+
+	got, ok := ParseAPIKey(key)
+	require.True(t, ok)
+	assert.Equal(t, prefix, got)
+	assert.True(t, VerifyAPIKey(key, HashToken(key)))
+	assert.False(t, VerifyAPIKey(key+"x", HashToken(key)))
+
+	for _, bad := range []string{"", "gtk", "gtk_abc_def", "xyz_0123456789ab_secret", "gtk_0123456789ab_", "gtk_0123456789zz_secret"} {
+		_, ok := ParseAPIKey(bad)
+		assert.False(t, ok, bad)
+	}
+}
+
+func TestScopes(t *testing.T) {
+	for _, scope := range []string{"*", "GET:/api/users", "*:/api/orders/*", "DELETE:/api/users/:id"} {
+		assert.NoError(t, ValidateScope(scope), scope)
+	}
+	for _, scope := range []string{"", "users", "GET:api/users", "FETCH:/api/users", "GET:/api/*/orders"} {
+		assert.Error(t, ValidateScope(scope), scope)
+	}
+
+	tests := []struct {
+		scopes []string
+		method string
+		path   string
+		want   bool
+	}{
+		{scopes: []string{"*"}, method: "DELETE", path: "/api/users/:id", want: true},
+		{scopes: []string{"GET:/api/users"}, method: "GET", path: "/api/users", want: true},
+		{scopes: []string{"GET:/api/users"}, method: "POST", path: "/api/users"},
+		{scopes: []string{"GET:/api/users"}, method: "GET", path: "/api/users/:id"},
+		{scopes: []string{"*:/api/users*"}, method: "PUT", path: "/api/users/:id", want: true},
+		{scopes: []string{"GET:/api/orders", "POST:/api/orders"}, method: "POST", path: "/api/orders", want: true},
+		{method: "GET", path: "/api/users"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ScopeAllows(tt.scopes, tt.method, tt.path), "%v %s %s", tt.scopes, tt.method, tt.path)
+	}
+}
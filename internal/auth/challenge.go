@@ -0,0 +1,29 @@
+/*
+ * Challenge Store
+ * 登录第二步的待验证凭据
+ *
+ * 密码校验通过但账号开启了两步验证时，签发一个短期有效的 mfa_token 代替令牌对，
+ * 客户端携带它与验证码完成第二步。mfa_token 只以摘要形式存储，验证成功后立即作废。
+ */
+
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultChallengeTTL mfa_token 的有效期
+const DefaultChallengeTTL = 5 * time.Minute
+
+// ChallengeStore 待验证凭据存储，键为 HashToken 计算的摘要
+type ChallengeStore interface {
+	// Issue 保存凭据，ttl 后自动失效
+	Issue(ctx context.Context, hash string, userID int64, ttl time.Duration) error
+
+	// Get 返回凭据所属用户，不存在或已过期时返回 code.ErrTokenInvalid
+	Get(ctx context.Context, hash string) (int64, error)
+
+	// Consume 原子地取出并删除凭据，保证同一凭据只能完成一次登录
+	Consume(ctx context.Context, hash string) (int64, error)
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryChallenge struct {
+	userID    int64
+	expiresAt time.Time
+}
+
+// MemoryChallengeStore 进程内待验证凭据存储，仅适合单实例部署、开发与测试
+type MemoryChallengeStore struct {
+	mu         sync.Mutex
+	challenges map[string]memoryChallenge
+	now        func() time.Time
+}
+
+// NewMemoryChallengeStore 创建进程内待验证凭据存储
+func NewMemoryChallengeStore() *MemoryChallengeStore {
+	return &MemoryChallengeStore{
+		challenges: make(map[string]memoryChallenge),
+		now:        time.Now,
+	}
+}
+
+func (m *MemoryChallengeStore) Issue(_ context.Context, hash string, userID int64, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for k, c := range m.challenges {
+		if !now.Before(c.expiresAt) {
+			delete(m.challenges, k)
+		}
+	}
+	m.challenges[hash] = memoryChallenge{userID: userID, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (m *MemoryChallengeStore) Get(_ context.Context, hash string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.challenges[hash]
+	if !ok || !m.now().Before(c.expiresAt) {
+		return 0, errTokenInvalid()
+	}
+	return c.userID, nil
+}
+
+func (m *MemoryChallengeStore) Consume(_ context.Context, hash string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.challenges[hash]
+	delete(m.challenges, hash)
+	if !ok || !m.now().Before(c.expiresAt) {
+		return 0, errTokenInvalid()
+	}
+	return c.userID, nil
+}
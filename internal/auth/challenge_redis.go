@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/redis/go-redis/v9"
+)
+
+const redisChallengePrefix = "auth:mfa:"
+
+// RedisChallengeStore 基于 Redis 的待验证凭据存储，多副本共享
+type RedisChallengeStore struct {
+	rdb *redis.Client
+}
+
+// NewRedisChallengeStore 创建 Redis 待验证凭据存储
+func NewRedisChallengeStore(rdb *redis.Client) *RedisChallengeStore {
+	return &RedisChallengeStore{rdb: rdb}
+}
+
+func (r *RedisChallengeStore) Issue(ctx context.Context, hash string, userID int64, ttl time.Duration) error {
+	err := r.rdb.Set(ctx, redisChallengePrefix+hash, userID, ttl).Err()
+	return code.WrapRedisError(err, "store mfa challenge")
+}
+
+func (r *RedisChallengeStore) Get(ctx context.Context, hash string) (int64, error) {
+	return r.userID(r.rdb.Get(ctx, redisChallengePrefix+hash))
+}
+
+func (r *RedisChallengeStore) Consume(ctx context.Context, hash string) (int64, error) {
+	return r.userID(r.rdb.GetDel(ctx, redisChallengePrefix+hash))
+}
+
+func (r *RedisChallengeStore) userID(cmd *redis.StringCmd) (int64, error) {
+	val, err := cmd.Result()
+	if err == redis.Nil {
+		return 0, errTokenInvalid()
+	}
+	if err != nil {
+		return 0, code.WrapRedisError(err, "load mfa challenge")
+	}
+	id, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, errTokenInvalid()
+	}
+	return id, nil
+}
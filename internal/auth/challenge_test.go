@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/google/uuid"
+	"github.com/marmotedu/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryChallengeStore(t *testing.T) {
+	testChallengeStore(t, NewMemoryChallengeStore())
+}
+
+// TestRedisChallengeStore 需要设置 TEST_REDIS_ADDR 指向可写的 Redis 实例
+func TestRedisChallengeStore(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR not set")
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { _ = rdb.Close() })
+	testChallengeStore(t, NewRedisChallengeStore(rdb))
+}
+
+func testChallengeStore(t *testing.T, store ChallengeStore) {
+	ctx := context.Background()
+	hash := HashToken(uuid.NewString())
+
+	_, err := store.Get(ctx, hash)
+	assert.True(t, errors.IsCode(err, code.ErrTokenInvalid))
+
+	require.NoError(t, store.Issue(ctx, hash, 42, time.Minute))
+	id, err := store.Get(ctx, hash)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), id)
+
+	// 只能消费一次
+	id, err = store.Consume(ctx, hash)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), id)
+	_, err = store.Consume(ctx, hash)
+	assert.True(t, errors.IsCode(err, code.ErrTokenInvalid))
+}
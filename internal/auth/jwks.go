@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+// JWK RFC 7517 公钥表示，只包含验签所需字段
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC / OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS 公钥集合，对应 /.well-known/jwks.json 的响应体
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS 返回当前可用于验签的公钥；HS256 对称密钥永远不会公开
+func (k *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range k.verificationKeys() {
+		if jwk, ok := toJWK(key); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+func toJWK(key *SigningKey) (JWK, bool) {
+	jwk := JWK{Kid: key.Kid, Use: "sig", Alg: key.Method.Alg()}
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = b64(pub.N.Bytes())
+		jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdh, err := pub.ECDH()
+		if err != nil {
+			return JWK{}, false
+		}
+		// 非压缩格式：0x04 || X || Y，X/Y 按曲线长度定长
+		raw := ecdh.Bytes()[1:]
+		size := len(raw) / 2
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = b64(raw[:size])
+		jwk.Y = b64(raw[size:])
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = b64(pub)
+	default:
+		return JWK{}, false
+	}
+	return jwk, true
+}
+
+// PublicKey 解析 JWK 中的公钥，用于校验外部签发的令牌
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := unb64(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := unb64(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := unb64(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := unb64(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, fmt.Errorf("invalid EC public key")
+		}
+		return pub, nil
+	case "OKP":
+		x, err := unb64(j.X)
+		if err != nil {
+			return nil, err
+		}
+		if j.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("unsupported OKP key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func unb64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,268 @@
+/*
+ * Key Set
+ * JWT 签名密钥集合
+ *
+ * 未配置 jwt.keys 时沿用 jwt.secret 做 HS256 签名；配置后改用非对称密钥（RSA/ECDSA/EdDSA），
+ * 通过 kid 区分多把密钥。每把密钥可声明 not_before/not_after：
+ * 签名总是选择已生效且 not_before 最晚的私钥，验签接受所有未过 not_after 的密钥，
+ * 因此提前加入新密钥、延后下线旧密钥即可平滑轮换，已签发的令牌不受影响。
+ * 距 not_after 不足一个访问令牌有效期（另加一分钟余量）的密钥不再用于签名，
+ * 保证用它签发的令牌在过期前都能通过验签。
+ */
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// DefaultAccessTokenTTL jwt.expire 未配置时的访问令牌有效期
+	DefaultAccessTokenTTL = time.Hour
+	// keyRetireLeeway 密钥停止签名后到 not_after 之间额外保留的时间，容忍副本间的时钟偏差
+	keyRetireLeeway = time.Minute
+)
+
+// SigningKey 一把签名/验签密钥，Private 为空时仅用于验签
+type SigningKey struct {
+	Kid       string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// KeySet JWT 密钥集合
+type KeySet struct {
+	secret []byte
+	keys   []*SigningKey
+	// retire 距 not_after 不足该时长的密钥不再签名
+	retire time.Duration
+	now    func() time.Time
+}
+
+// NewKeySet 根据 jwt 配置加载密钥集合
+func NewKeySet(cfg configs.Config) (*KeySet, error) {
+	ttl := time.Duration(cfg.JWT.Expire) * time.Second
+	if ttl <= 0 {
+		ttl = DefaultAccessTokenTTL
+	}
+	ks := &KeySet{retire: ttl + keyRetireLeeway, now: time.Now}
+	if len(cfg.JWT.Keys) == 0 {
+		if cfg.JWT.Secret == "" {
+			return nil, code.NewError(code.ErrInternalServer, "jwt.secret or jwt.keys must be configured")
+		}
+		ks.secret = []byte(cfg.JWT.Secret)
+		return ks, nil
+	}
+
+	seen := make(map[string]bool, len(cfg.JWT.Keys))
+	for i, kc := range cfg.JWT.Keys {
+		key, err := loadSigningKey(kc, cfg.JWT.Algorithm)
+		if err != nil {
+			return nil, code.WrapError(err, code.ErrInternalServer, fmt.Sprintf("load jwt.keys[%d]", i))
+		}
+		if seen[key.Kid] {
+			return nil, code.NewErrorf(code.ErrInternalServer, "duplicate jwt key id %q", key.Kid)
+		}
+		seen[key.Kid] = true
+		ks.keys = append(ks.keys, key)
+	}
+	return ks, nil
+}
+
+// NewStaticKeySet 直接由密钥创建集合，便于测试与嵌入使用，按默认访问令牌有效期提前停止签名
+func NewStaticKeySet(keys ...*SigningKey) *KeySet {
+	return &KeySet{keys: keys, retire: DefaultAccessTokenTTL + keyRetireLeeway, now: time.Now}
+}
+
+// Sign 使用当前签名密钥签发令牌，并在 header 中写入 kid
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if k.secret != nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(k.secret)
+	}
+
+	key := k.current()
+	if key == nil {
+		return "", code.NewError(code.ErrInternalServer, "no active jwt signing key")
+	}
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.Kid
+	return token.SignedString(key.Private)
+}
+
+// Keyfunc 按 kid 查找验签密钥，并校验令牌算法与密钥一致，防止算法混淆攻击
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if k.secret != nil {
+		if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, fmt.Errorf("unexpected jwt signing method %q", token.Method.Alg())
+		}
+		return k.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key := k.lookup(kid)
+	if key == nil {
+		return nil, fmt.Errorf("unknown jwt key id %q", kid)
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected jwt signing method %q for key %q", token.Method.Alg(), kid)
+	}
+	return key.Public, nil
+}
+
+// current 选择已生效且 not_before 最晚的私钥，相同时取配置中靠后的一把；
+// 跳过在访问令牌过期前就会下线的密钥
+func (k *KeySet) current() *SigningKey {
+	now := k.now()
+	var picked *SigningKey
+	for _, key := range k.keys {
+		if key.Private == nil || !key.signable(now, k.retire) {
+			continue
+		}
+		if picked == nil || !key.NotBefore.Before(picked.NotBefore) {
+			picked = key
+		}
+	}
+	return picked
+}
+
+func (k *KeySet) lookup(kid string) *SigningKey {
+	now := k.now()
+	for _, key := range k.keys {
+		if key.Kid == kid && key.usable(now) {
+			return key
+		}
+	}
+	return nil
+}
+
+// verificationKeys 当前可用于验签的密钥，包括尚未开始签名的预发布密钥
+func (k *KeySet) verificationKeys() []*SigningKey {
+	now := k.now()
+	keys := make([]*SigningKey, 0, len(k.keys))
+	for _, key := range k.keys {
+		if key.usable(now) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (s *SigningKey) usable(now time.Time) bool {
+	return s.NotAfter.IsZero() || now.Before(s.NotAfter)
+}
+
+// signable 已生效，且 retire 之后仍未下线
+func (s *SigningKey) signable(now time.Time, retire time.Duration) bool {
+	return !now.Before(s.NotBefore) && s.usable(now.Add(retire))
+}
+
+func loadSigningKey(kc configs.JWTKeyConfig, defaultAlg string) (*SigningKey, error) {
+	if kc.Kid == "" {
+		return nil, fmt.Errorf("kid is required")
+	}
+	alg := kc.Algorithm
+	if alg == "" {
+		alg = defaultAlg
+	}
+	method := jwt.GetSigningMethod(alg)
+	if method == nil || strings.HasPrefix(alg, "HS") || alg == "none" {
+		return nil, fmt.Errorf("unsupported asymmetric algorithm %q", alg)
+	}
+
+	key := &SigningKey{Kid: kc.Kid, Method: method}
+	var err error
+	if key.NotBefore, err = parseKeyTime(kc.NotBefore); err != nil {
+		return nil, fmt.Errorf("not_before: %w", err)
+	}
+	if key.NotAfter, err = parseKeyTime(kc.NotAfter); err != nil {
+		return nil, fmt.Errorf("not_after: %w", err)
+	}
+
+	privatePEM, err := readPEM(kc.PrivateKey, kc.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if privatePEM != nil {
+		if key.Private, err = parsePrivateKey(method, privatePEM); err != nil {
+			return nil, err
+		}
+		key.Public = key.Private.Public()
+	}
+
+	publicPEM, err := readPEM(kc.PublicKey, kc.PublicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if publicPEM != nil && key.Public == nil {
+		if key.Public, err = parsePublicKey(method, publicPEM); err != nil {
+			return nil, err
+		}
+	}
+
+	if key.Public == nil {
+		return nil, fmt.Errorf("private_key or public_key is required")
+	}
+	return key, nil
+}
+
+func parsePrivateKey(method jwt.SigningMethod, pem []byte) (crypto.Signer, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		return jwt.ParseRSAPrivateKeyFromPEM(pem)
+	case *jwt.SigningMethodECDSA:
+		return jwt.ParseECPrivateKeyFromPEM(pem)
+	case *jwt.SigningMethodEd25519:
+		key, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+		if err != nil {
+			return nil, err
+		}
+		return key.(ed25519.PrivateKey), nil
+	}
+	return nil, fmt.Errorf("unsupported algorithm %q", method.Alg())
+}
+
+func parsePublicKey(method jwt.SigningMethod, pem []byte) (crypto.PublicKey, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		return jwt.ParseRSAPublicKeyFromPEM(pem)
+	case *jwt.SigningMethodECDSA:
+		return jwt.ParseECPublicKeyFromPEM(pem)
+	case *jwt.SigningMethodEd25519:
+		return jwt.ParseEdPublicKeyFromPEM(pem)
+	}
+	return nil, fmt.Errorf("unsupported algorithm %q", method.Alg())
+}
+
+// readPEM 优先使用内联 PEM，其次读取文件；两者都为空时返回 nil
+func readPEM(inline, file string) ([]byte, error) {
+	if inline != "" {
+		return []byte(inline), nil
+	}
+	if file == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read key file %s: %w", file, err)
+	}
+	return data, nil
+}
+
+func parseKeyTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
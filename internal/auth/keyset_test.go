@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func privatePEM(t *testing.T, key crypto.Signer) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func publicPEM(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func verify(ks *KeySet, token string) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(token, &jwt.RegisteredClaims{}, ks.Keyfunc)
+}
+
+func claimsFor(sub string) jwt.Claims {
+	return jwt.RegisteredClaims{Subject: sub, ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}
+}
+
+func TestKeySet_Algorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		alg string
+		key crypto.Signer
+		kty string
+	}{
+		{alg: "RS256", key: rsaKey, kty: "RSA"},
+		{alg: "ES256", key: ecKey, kty: "EC"},
+		{alg: "EdDSA", key: edKey, kty: "OKP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			ks, err := NewKeySet(configs.Config{JWT: configs.JWTConfig{
+				Algorithm: tt.alg,
+				Keys:      []configs.JWTKeyConfig{{Kid: "k1", PrivateKey: privatePEM(t, tt.key)}},
+			}})
+			require.NoError(t, err)
+
+			signed, err := ks.Sign(claimsFor("7"))
+			require.NoError(t, err)
+			token, err := verify(ks, signed)
+			require.NoError(t, err)
+			assert.Equal(t, "k1", token.Header["kid"])
+			assert.Equal(t, tt.alg, token.Method.Alg())
+
+			jwks := ks.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tt.kty, jwks.Keys[0].Kty)
+			assert.Equal(t, tt.alg, jwks.Keys[0].Alg)
+
+			// 仅持有公钥的一方同样可以验签
+			verifier, err := NewKeySet(configs.Config{JWT: configs.JWTConfig{
+				Algorithm: tt.alg,
+				Keys:      []configs.JWTKeyConfig{{Kid: "k1", PublicKey: publicPEM(t, tt.key.Public())}},
+			}})
+			require.NoError(t, err)
+			_, err = verify(verifier, signed)
+			assert.NoError(t, err)
+			_, err = verifier.Sign(claimsFor("7"))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	now := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	ks := NewStaticKeySet(
+		&SigningKey{Kid: "old", Method: jwt.SigningMethodES256, Private: oldKey, Public: oldKey.Public(), NotAfter: now.Add(2 * time.Hour)},
+		&SigningKey{Kid: "new", Method: jwt.SigningMethodES256, Private: newKey, Public: newKey.Public(), NotBefore: now.Add(time.Hour)},
+	)
+
+	// 新密钥生效前：旧密钥签名，新密钥已预先发布
+	ks.now = func() time.Time { return now }
+	before, err := ks.Sign(claimsFor("7"))
+	require.NoError(t, err)
+	assert.Len(t, ks.JWKS().Keys, 2)
+
+	// 新密钥生效后：切换签名密钥，旧令牌仍可验证
+	ks.now = func() time.Time { return now.Add(90 * time.Minute) }
+	after, err := ks.Sign(claimsFor("7"))
+	require.NoError(t, err)
+	token, err := jwt.ParseWithClaims(after, &jwt.RegisteredClaims{}, ks.Keyfunc, jwt.WithoutClaimsValidation())
+	require.NoError(t, err)
+	assert.Equal(t, "new", token.Header["kid"])
+	token, err = jwt.ParseWithClaims(before, &jwt.RegisteredClaims{}, ks.Keyfunc, jwt.WithoutClaimsValidation())
+	require.NoError(t, err)
+	assert.Equal(t, "old", token.Header["kid"])
+
+	// 旧密钥下线后不再接受，也不再发布
+	ks.now = func() time.Time { return now.Add(3 * time.Hour) }
+	_, err = jwt.ParseWithClaims(before, &jwt.RegisteredClaims{}, ks.Keyfunc, jwt.WithoutClaimsValidation())
+	assert.Error(t, err)
+	assert.Len(t, ks.JWKS().Keys, 1)
+}
+
+func TestKeySet_RetiresBeforeNotAfter(t *testing.T) {
+	retiring, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	fallback, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	now := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := now.Add(2 * time.Hour)
+	ks := NewStaticKeySet(
+		&SigningKey{Kid: "fallback", Method: jwt.SigningMethodES256, Private: fallback, Public: fallback.Public(), NotBefore: now.Add(-24 * time.Hour)},
+		&SigningKey{Kid: "retiring", Method: jwt.SigningMethodES256, Private: retiring, Public: retiring.Public(), NotBefore: now.Add(-time.Hour), NotAfter: notAfter},
+	)
+	signAt := func(at time.Time) string {
+		t.Helper()
+		ks.now = func() time.Time { return at }
+		token, err := ks.Sign(jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(at.Add(DefaultAccessTokenTTL))})
+		require.NoError(t, err)
+		return token
+	}
+	kidAt := func(token string, at time.Time) (string, error) {
+		ks.now = func() time.Time { return at }
+		parsed, err := jwt.ParseWithClaims(token, &jwt.RegisteredClaims{}, ks.Keyfunc, jwt.WithTimeFunc(func() time.Time { return at }))
+		if err != nil {
+			return "", err
+		}
+		return parsed.Header["kid"].(string), nil
+	}
+
+	// 剩余时间足够签发一个完整有效期的令牌：令牌在过期前始终可以验签
+	last := notAfter.Add(-DefaultAccessTokenTTL - keyRetireLeeway - time.Second)
+	token := signAt(last)
+	kid, err := kidAt(token, last.Add(DefaultAccessTokenTTL-time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "retiring", kid)
+
+	// 临近 not_after 时改用其他密钥，新令牌不会在有效期内失效
+	near := notAfter.Add(-10 * time.Minute)
+	token = signAt(near)
+	kid, err = kidAt(token, near.Add(DefaultAccessTokenTTL-time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, "fallback", kid)
+
+	// 没有可以覆盖完整有效期的密钥时拒绝签发
+	ks.keys = ks.keys[1:]
+	ks.now = func() time.Time { return near }
+	_, err = ks.Sign(claimsFor("7"))
+	assert.Error(t, err)
+	// 停止签名后仍然验签到 not_after
+	_, err = kidAt(signAt(last), near)
+	assert.NoError(t, err)
+}
+
+func TestKeySet_RejectsAlgorithmConfusion(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ks := NewStaticKeySet(&SigningKey{Kid: "k1", Method: jwt.SigningMethodRS256, Private: rsaKey, Public: rsaKey.Public()})
+
+	// 用公钥字节作为 HMAC 密钥伪造的令牌必须被拒绝
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claimsFor("1"))
+	forged.Header["kid"] = "k1"
+	signed, err := forged.SignedString([]byte(publicPEM(t, rsaKey.Public())))
+	require.NoError(t, err)
+	_, err = verify(ks, signed)
+	assert.Error(t, err)
+}
+
+func TestKeySet_SecretFallback(t *testing.T) {
+	ks, err := NewKeySet(configs.Config{JWT: configs.JWTConfig{Secret: "test-secret"}})
+	require.NoError(t, err)
+
+	signed, err := ks.Sign(claimsFor("7"))
+	require.NoError(t, err)
+	_, err = verify(ks, signed)
+	assert.NoError(t, err)
+	assert.Empty(t, ks.JWKS().Keys)
+
+	_, err = NewKeySet(configs.Config{})
+	assert.Error(t, err)
+	_, err = NewKeySet(configs.Config{JWT: configs.JWTConfig{Keys: []configs.JWTKeyConfig{{Kid: "k1", Algorithm: "HS256", PrivateKey: "x"}}}})
+	assert.Error(t, err)
+}
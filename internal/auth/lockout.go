@@ -0,0 +1,170 @@
+/*
+ * Login Guard
+ * 登录失败退避与锁定
+ *
+ * 账号与来源 IP 分别计数：每次失败后需等待的时间按失败次数指数增长，
+ * 账号在统计窗口内失败达到上限后锁定，IP 达到上限后同样在锁定时长内拒绝登录。
+ * 不存在的用户名同样计数，避免通过响应差异探测账号是否存在。
+ */
+
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+)
+
+// 登录保护默认参数
+const (
+	DefaultLockoutMaxAttempts   = 5
+	DefaultLockoutIPMaxAttempts = 20
+	DefaultLockoutWindow        = 15 * time.Minute
+	DefaultLockoutDuration      = 15 * time.Minute
+	DefaultLockoutBaseDelay     = time.Second
+	DefaultLockoutMaxDelay      = 30 * time.Second
+)
+
+const (
+	lockoutAccountPrefix = "account:"
+	lockoutIPPrefix      = "ip:"
+)
+
+// AttemptState 登录失败记录
+type AttemptState struct {
+	Failures int
+	// BlockedUntil 在此之前拒绝登录
+	BlockedUntil time.Time
+}
+
+// AttemptStore 登录失败记录存储
+type AttemptStore interface {
+	// Get 返回记录，不存在时返回零值
+	Get(ctx context.Context, key string) (AttemptState, error)
+
+	// Fail 失败次数加一并返回最新记录，window 内没有新的失败时记录过期
+	Fail(ctx context.Context, key string, window time.Duration) (AttemptState, error)
+
+	// Block 将 BlockedUntil 推迟到 until，已有更晚的时间时保持不变
+	Block(ctx context.Context, key string, until time.Time) error
+
+	// Reset 清除记录
+	Reset(ctx context.Context, key string) error
+}
+
+// LoginGuard 登录保护
+type LoginGuard struct {
+	store  AttemptStore
+	policy configs.LockoutConfig
+	now    func() time.Time
+}
+
+// NewLoginGuard 根据 [auth.lockout] 创建登录保护，未配置的参数使用默认值
+func NewLoginGuard(store AttemptStore, cfg configs.Config) *LoginGuard {
+	policy := cfg.Auth.Lockout
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultLockoutMaxAttempts
+	}
+	if policy.IPMaxAttempts <= 0 {
+		policy.IPMaxAttempts = DefaultLockoutIPMaxAttempts
+	}
+	if policy.Window <= 0 {
+		policy.Window = DefaultLockoutWindow
+	}
+	if policy.LockDuration <= 0 {
+		policy.LockDuration = DefaultLockoutDuration
+	}
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = DefaultLockoutBaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = DefaultLockoutMaxDelay
+	}
+	return &LoginGuard{store: store, policy: policy, now: time.Now}
+}
+
+// Check 登录前检查账号与来源 IP 当前是否允许尝试。
+// 账号被锁定时返回 code.ErrAccountLocked，处于退避期或 IP 被限制时返回 code.ErrTooManyAttempts。
+func (g *LoginGuard) Check(ctx context.Context, account, ip string) error {
+	now := g.now()
+	if account != "" {
+		st, err := g.store.Get(ctx, accountKey(account))
+		if err != nil {
+			return err
+		}
+		if now.Before(st.BlockedUntil) {
+			if st.Failures >= g.policy.MaxAttempts {
+				return code.NewErrorf(code.ErrAccountLocked, "account is locked, retry after %s", retryAfter(now, st.BlockedUntil))
+			}
+			return code.NewErrorf(code.ErrTooManyAttempts, "retry after %s", retryAfter(now, st.BlockedUntil))
+		}
+	}
+	if ip != "" {
+		st, err := g.store.Get(ctx, lockoutIPPrefix+ip)
+		if err != nil {
+			return err
+		}
+		if now.Before(st.BlockedUntil) {
+			return code.NewErrorf(code.ErrTooManyAttempts, "retry after %s", retryAfter(now, st.BlockedUntil))
+		}
+	}
+	return nil
+}
+
+// Fail 记录一次失败的登录
+func (g *LoginGuard) Fail(ctx context.Context, account, ip string) error {
+	if account != "" {
+		if err := g.fail(ctx, accountKey(account), g.policy.MaxAttempts); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		return g.fail(ctx, lockoutIPPrefix+ip, g.policy.IPMaxAttempts)
+	}
+	return nil
+}
+
+// Succeed 登录成功后清除账号的失败记录，IP 的记录保留到窗口结束
+func (g *LoginGuard) Succeed(ctx context.Context, account string) error {
+	return g.Unlock(ctx, account)
+}
+
+// Unlock 清除账号的失败记录与锁定
+func (g *LoginGuard) Unlock(ctx context.Context, account string) error {
+	return g.store.Reset(ctx, accountKey(account))
+}
+
+// fail 失败后进入退避期，达到 limit 次时改为锁定
+func (g *LoginGuard) fail(ctx context.Context, key string, limit int) error {
+	st, err := g.store.Fail(ctx, key, g.policy.Window)
+	if err != nil {
+		return err
+	}
+
+	until := g.now().Add(g.delay(st.Failures))
+	if st.Failures >= limit {
+		until = g.now().Add(g.policy.LockDuration)
+	}
+	return g.store.Block(ctx, key, until)
+}
+
+// delay 第 n 次失败后的退避时间
+func (g *LoginGuard) delay(failures int) time.Duration {
+	d := g.policy.BaseDelay
+	for i := 1; i < failures && d < g.policy.MaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, g.policy.MaxDelay)
+}
+
+// accountKey 用户名不区分大小写，避免变换大小写绕过计数
+func accountKey(account string) string {
+	return lockoutAccountPrefix + strings.ToLower(strings.TrimSpace(account))
+}
+
+func retryAfter(now, until time.Time) time.Duration {
+	return max(until.Sub(now).Round(time.Second), time.Second)
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryAttempt struct {
+	state     AttemptState
+	expiresAt time.Time
+}
+
+// MemoryAttemptStore 进程内登录失败记录，仅适合单实例部署、开发与测试
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]*memoryAttempt
+	now      func() time.Time
+}
+
+// NewMemoryAttemptStore 创建进程内登录失败记录存储
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{
+		attempts: make(map[string]*memoryAttempt),
+		now:      time.Now,
+	}
+}
+
+func (m *MemoryAttemptStore) Get(_ context.Context, key string) (AttemptState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if a := m.load(key); a != nil {
+		return a.state, nil
+	}
+	return AttemptState{}, nil
+}
+
+func (m *MemoryAttemptStore) Fail(_ context.Context, key string, window time.Duration) (AttemptState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep()
+	a := m.load(key)
+	if a == nil {
+		a = &memoryAttempt{}
+		m.attempts[key] = a
+	}
+	a.state.Failures++
+	if exp := m.now().Add(window); exp.After(a.expiresAt) {
+		a.expiresAt = exp
+	}
+	return a.state, nil
+}
+
+func (m *MemoryAttemptStore) Block(_ context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a := m.load(key)
+	if a == nil {
+		a = &memoryAttempt{}
+		m.attempts[key] = a
+	}
+	if until.After(a.state.BlockedUntil) {
+		a.state.BlockedUntil = until
+	}
+	if until.After(a.expiresAt) {
+		a.expiresAt = until
+	}
+	return nil
+}
+
+func (m *MemoryAttemptStore) Reset(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.attempts, key)
+	return nil
+}
+
+// load 返回未过期的记录，调用方需持有锁
+func (m *MemoryAttemptStore) load(key string) *memoryAttempt {
+	a, ok := m.attempts[key]
+	if !ok {
+		return nil
+	}
+	if !m.now().Before(a.expiresAt) {
+		delete(m.attempts, key)
+		return nil
+	}
+	return a
+}
+
+// sweep 清理过期记录，调用方需持有锁
+func (m *MemoryAttemptStore) sweep() {
+	now := m.now()
+	for key, a := range m.attempts {
+		if !now.Before(a.expiresAt) {
+			delete(m.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/redis/go-redis/v9"
+)
+
+const redisLockoutPrefix = "auth:lockout:"
+
+// failScript 失败次数加一，并保证记录至少保留一个统计窗口
+var failScript = redis.NewScript(`
+local n = redis.call('HINCRBY', KEYS[1], 'failures', 1)
+local blocked = redis.call('HGET', KEYS[1], 'blocked_until') or '0'
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[1]) then
+  redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return {n, blocked}
+`)
+
+// blockScript 只推迟不提前，并保证记录保留到限制结束
+var blockScript = redis.NewScript(`
+local cur = tonumber(redis.call('HGET', KEYS[1], 'blocked_until') or '0')
+local blocked = tonumber(ARGV[1])
+if blocked > cur then
+  redis.call('HSET', KEYS[1], 'blocked_until', ARGV[1])
+end
+local ttl = blocked - tonumber(ARGV[2])
+if ttl > 0 and redis.call('PTTL', KEYS[1]) < ttl then
+  redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+// RedisAttemptStore 基于 Redis 的登录失败记录，多副本共享计数
+type RedisAttemptStore struct {
+	rdb *redis.Client
+	now func() time.Time
+}
+
+// NewRedisAttemptStore 创建 Redis 登录失败记录存储
+func NewRedisAttemptStore(rdb *redis.Client) *RedisAttemptStore {
+	return &RedisAttemptStore{rdb: rdb, now: time.Now}
+}
+
+func (r *RedisAttemptStore) Get(ctx context.Context, key string) (AttemptState, error) {
+	vals, err := r.rdb.HMGet(ctx, redisLockoutPrefix+key, "failures", "blocked_until").Result()
+	if err != nil {
+		return AttemptState{}, code.WrapRedisError(err, "load login attempts")
+	}
+	failures, _ := vals[0].(string)
+	blocked, _ := vals[1].(string)
+	return attemptState(failures, blocked), nil
+}
+
+func (r *RedisAttemptStore) Fail(ctx context.Context, key string, window time.Duration) (AttemptState, error) {
+	res, err := failScript.Run(ctx, r.rdb, []string{redisLockoutPrefix + key}, window.Milliseconds()).Slice()
+	if err != nil {
+		return AttemptState{}, code.WrapRedisError(err, "record login attempt")
+	}
+	failures, _ := res[0].(int64)
+	blocked, _ := res[1].(string)
+	return attemptState(strconv.FormatInt(failures, 10), blocked), nil
+}
+
+func (r *RedisAttemptStore) Block(ctx context.Context, key string, until time.Time) error {
+	err := blockScript.Run(ctx, r.rdb, []string{redisLockoutPrefix + key}, until.UnixMilli(), r.now().UnixMilli()).Err()
+	return code.WrapRedisError(err, "record login attempt")
+}
+
+func (r *RedisAttemptStore) Reset(ctx context.Context, key string) error {
+	return code.WrapRedisError(r.rdb.Del(ctx, redisLockoutPrefix+key).Err(), "reset login attempts")
+}
+
+// attemptState 解析 Redis 中的记录，blocked_until 为毫秒时间戳
+func attemptState(failures, blocked string) AttemptState {
+	var st AttemptState
+	st.Failures, _ = strconv.Atoi(failures)
+	if ms, _ := strconv.ParseInt(blocked, 10, 64); ms > 0 {
+		st.BlockedUntil = time.UnixMilli(ms)
+	}
+	return st
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/google/uuid"
+	"github.com/marmotedu/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryAttemptStore(t *testing.T) {
+	testAttemptStore(t, NewMemoryAttemptStore())
+}
+
+// TestRedisAttemptStore 需要设置 TEST_REDIS_ADDR 指向可写的 Redis 实例
+func TestRedisAttemptStore(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR not set")
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { _ = rdb.Close() })
+	testAttemptStore(t, NewRedisAttemptStore(rdb))
+}
+
+func testAttemptStore(t *testing.T, store AttemptStore) {
+	ctx := context.Background()
+	key := "test:" + uuid.NewString()
+
+	st, err := store.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Zero(t, st)
+
+	st, err = store.Fail(ctx, key, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 1, st.Failures)
+	st, err = store.Fail(ctx, key, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 2, st.Failures)
+
+	// 限制只推迟不提前
+	until := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	require.NoError(t, store.Block(ctx, key, until))
+	require.NoError(t, store.Block(ctx, key, time.Now().Add(time.Minute)))
+	st, err = store.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, 2, st.Failures)
+	assert.True(t, until.Equal(st.BlockedUntil))
+
+	require.NoError(t, store.Reset(ctx, key))
+	st, err = store.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Zero(t, st)
+}
+
+func newTestLoginGuard(now *time.Time) *LoginGuard {
+	store := NewMemoryAttemptStore()
+	store.now = func() time.Time { return *now }
+	guard := NewLoginGuard(store, configs.Config{Auth: configs.AuthConfig{Lockout: configs.LockoutConfig{
+		MaxAttempts:   3,
+		IPMaxAttempts: 5,
+		Window:        time.Hour,
+		LockDuration:  10 * time.Minute,
+		BaseDelay:     time.Second,
+		MaxDelay:      4 * time.Second,
+	}}})
+	guard.now = func() time.Time { return *now }
+	return guard
+}
+
+func TestLoginGuard_Backoff(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	guard := newTestLoginGuard(&now)
+
+	require.NoError(t, guard.Check(ctx, "alice", "10.0.0.1"))
+	require.NoError(t, guard.Fail(ctx, "alice", "10.0.0.1"))
+
+	// 退避期内拒绝，过后允许
+	err := guard.Check(ctx, "alice", "10.0.0.2")
+	assert.True(t, errors.IsCode(err, code.ErrTooManyAttempts))
+	now = now.Add(time.Second)
+	require.NoError(t, guard.Check(ctx, "alice", "10.0.0.2"))
+
+	// 第二次失败后退避时间翻倍
+	require.NoError(t, guard.Fail(ctx, "alice", "10.0.0.2"))
+	now = now.Add(time.Second)
+	assert.Error(t, guard.Check(ctx, "alice", ""))
+	now = now.Add(time.Second)
+	assert.NoError(t, guard.Check(ctx, "alice", ""))
+
+	assert.Equal(t, 4*time.Second, guard.delay(10))
+}
+
+func TestLoginGuard_Lockout(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	guard := newTestLoginGuard(&now)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, guard.Fail(ctx, "Alice", ""))
+		now = now.Add(5 * time.Second)
+	}
+	// 用户名不区分大小写
+	err := guard.Check(ctx, "alice", "")
+	assert.True(t, errors.IsCode(err, code.ErrAccountLocked))
+
+	now = now.Add(10 * time.Minute)
+	require.NoError(t, guard.Check(ctx, "alice", ""))
+
+	// 窗口内再次失败立即重新锁定，管理员可以解锁
+	require.NoError(t, guard.Fail(ctx, "alice", ""))
+	err = guard.Check(ctx, "alice", "")
+	assert.True(t, errors.IsCode(err, code.ErrAccountLocked))
+	require.NoError(t, guard.Unlock(ctx, "alice"))
+	require.NoError(t, guard.Check(ctx, "alice", ""))
+}
+
+func TestLoginGuard_IP(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	guard := newTestLoginGuard(&now)
+
+	// 同一 IP 尝试不同账号
+	for i := 0; i < 5; i++ {
+		require.NoError(t, guard.Fail(ctx, uuid.NewString(), "10.0.0.1"))
+		now = now.Add(5 * time.Second)
+	}
+	err := guard.Check(ctx, "bob", "10.0.0.1")
+	assert.True(t, errors.IsCode(err, code.ErrTooManyAttempts))
+	require.NoError(t, guard.Check(ctx, "bob", "10.0.0.2"))
+
+	// 登录成功只清除账号记录
+	require.NoError(t, guard.Succeed(ctx, "bob"))
+	assert.Error(t, guard.Check(ctx, "bob", "10.0.0.1"))
+}
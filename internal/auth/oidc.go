@@ -0,0 +1,404 @@
+/*
+ * OIDC
+ * OpenID Connect 依赖方（授权码 + PKCE）
+ *
+ * 端点通过 {issuer}/.well-known/openid-configuration 在首次使用时发现，
+ * ID Token 使用 jwks_uri 发布的公钥校验签名、issuer、audience、有效期与 nonce。
+ * 遇到未知 kid 时重新拉取公钥，以适应身份提供方的密钥轮换。
+ */
+
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/marmotedu/errors"
+)
+
+const (
+	// DefaultOIDCStateTTL auth.oidc.state_ttl 未配置时的授权时限
+	DefaultOIDCStateTTL = 10 * time.Minute
+
+	oidcDiscoveryPath = "/.well-known/openid-configuration"
+	oidcHTTPTimeout   = 10 * time.Second
+	// oidcKeysRefresh 未知 kid 触发重新拉取公钥的最小间隔
+	oidcKeysRefresh = time.Minute
+	oidcLeeway      = time.Minute
+	oidcMaxBody     = 1 << 20
+)
+
+var defaultOIDCScopes = []string{"openid", "profile", "email"}
+
+// ExternalIdentity 身份提供方认证后的用户身份
+type ExternalIdentity struct {
+	Provider string
+	// Subject 身份提供方内唯一且不变的用户标识（sub）
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+// IdentityProvider 外部身份提供方，OIDC 之外的 OAuth2 提供方实现该接口后注册到 IdentityProviders
+type IdentityProvider interface {
+	// Name 提供方名称，对应路由中的 :provider
+	Name() string
+
+	// AuthCodeURL 返回授权地址，challenge 为 PKCE S256 code_challenge
+	AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error)
+
+	// Exchange 用授权码换取并校验身份，verifier 为 PKCE code_verifier
+	Exchange(ctx context.Context, authCode, verifier, nonce string) (*ExternalIdentity, error)
+}
+
+// IdentityProviders 已注册的身份提供方
+type IdentityProviders struct {
+	mu        sync.RWMutex
+	providers map[string]IdentityProvider
+}
+
+// NewIdentityProviders 根据 [auth.oidc] 创建身份提供方，端点在首次使用时发现，身份提供方不可用不影响启动
+func NewIdentityProviders(cfg configs.Config) (*IdentityProviders, error) {
+	ps := &IdentityProviders{providers: make(map[string]IdentityProvider)}
+	for _, pc := range cfg.Auth.OIDC.Providers {
+		p, err := NewOIDCProvider(pc, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := ps.Register(p); err != nil {
+			return nil, err
+		}
+	}
+	return ps, nil
+}
+
+// Register 注册身份提供方，名称不能重复
+func (ps *IdentityProviders) Register(p IdentityProvider) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if _, ok := ps.providers[p.Name()]; ok {
+		return fmt.Errorf("identity provider %q registered twice", p.Name())
+	}
+	if ps.providers == nil {
+		ps.providers = make(map[string]IdentityProvider)
+	}
+	ps.providers[p.Name()] = p
+	return nil
+}
+
+// Get 按名称查找身份提供方，不存在时返回 code.ErrOIDCProviderNotFound
+func (ps *IdentityProviders) Get(name string) (IdentityProvider, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	p, ok := ps.providers[name]
+	if !ok {
+		return nil, code.NewErrorf(code.ErrOIDCProviderNotFound, "identity provider %q not found", name)
+	}
+	return p, nil
+}
+
+// NewPKCEVerifier 生成 256 位随机 code_verifier，同样用于 state 与 nonce
+func NewPKCEVerifier() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// PKCEChallenge 计算 S256 code_challenge
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// oidcMetadata 发现文档中用到的字段
+type oidcMetadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	SigningAlgs           []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// oidcClaims ID Token 声明
+type oidcClaims struct {
+	Nonce             string `json:"nonce"`
+	AuthorizedParty   string `json:"azp"`
+	Email             string `json:"email"`
+	EmailVerified     any    `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	jwt.RegisteredClaims
+}
+
+// OIDCProvider OpenID Connect 身份提供方
+type OIDCProvider struct {
+	cfg    configs.OIDCProviderConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu          sync.Mutex
+	meta        *oidcMetadata
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+// NewOIDCProvider 创建 OIDC 身份提供方，client 为空时使用带超时的默认客户端
+func NewOIDCProvider(cfg configs.OIDCProviderConfig, client *http.Client) (*OIDCProvider, error) {
+	if cfg.Name == "" || cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("oidc provider %q: name, issuer, client_id and redirect_url are required", cfg.Name)
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = defaultOIDCScopes
+	}
+	if client == nil {
+		client = &http.Client{Timeout: oidcHTTPTimeout}
+	}
+	return &OIDCProvider{cfg: cfg, client: client, now: time.Now}, nil
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		return "", code.WrapError(err, code.ErrOIDCProvider, "invalid authorization endpoint")
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", challenge)
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, authCode, verifier, nonce string) (*ExternalIdentity, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", authCode)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", verifier)
+	// 机密客户端使用 client_secret_basic，公开客户端只依赖 PKCE
+	if p.cfg.ClientSecret == "" {
+		form.Set("client_id", p.cfg.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, code.WrapError(err, code.ErrOIDCProvider, "build token request")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.do(req, &token)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || token.Error != "" {
+		// invalid_grant 等说明授权码无效或已被使用，属于客户端错误
+		return nil, code.NewErrorf(code.ErrOIDCStateInvalid, "token exchange failed: %s %s", token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return nil, code.NewError(code.ErrOIDCTokenInvalid, "token response has no id_token")
+	}
+	return p.VerifyIDToken(ctx, token.IDToken, nonce)
+}
+
+// VerifyIDToken 校验 ID Token 并返回身份
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, raw, nonce string) (*ExternalIdentity, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	algs := meta.SigningAlgs
+	if len(algs) == 0 {
+		algs = []string{"RS256"}
+	}
+	claims := &oidcClaims{}
+	var keyErr error
+	_, err = jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := p.key(ctx, meta, kid)
+		keyErr = err
+		return key, err
+	},
+		jwt.WithValidMethods(algs),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(oidcLeeway),
+		jwt.WithTimeFunc(p.now),
+	)
+	if err != nil {
+		// 拉取公钥失败是身份提供方的问题，不能当作令牌无效
+		if errors.IsCode(keyErr, code.ErrOIDCProvider) {
+			return nil, keyErr
+		}
+		return nil, code.WrapError(err, code.ErrOIDCTokenInvalid, "invalid id token")
+	}
+	if claims.Nonce != nonce {
+		return nil, code.NewError(code.ErrOIDCTokenInvalid, "id token nonce mismatch")
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, code.NewError(code.ErrOIDCTokenInvalid, "id token azp mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, code.NewError(code.ErrOIDCTokenInvalid, "id token has no subject")
+	}
+
+	return &ExternalIdentity{
+		Provider:          p.cfg.Name,
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified == true || claims.EmailVerified == "true",
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+// metadata 发现文档只在成功后缓存，失败时下次请求重试
+func (p *OIDCProvider) metadata(ctx context.Context) (*oidcMetadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	issuer := strings.TrimRight(p.cfg.Issuer, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+oidcDiscoveryPath, nil)
+	if err != nil {
+		return nil, code.WrapError(err, code.ErrOIDCProvider, "build discovery request")
+	}
+	var meta oidcMetadata
+	status, err := p.do(req, &meta)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, code.NewErrorf(code.ErrOIDCProvider, "discovery returned status %d", status)
+	}
+	// 发现文档中的 issuer 必须与配置一致，防止被替换为其他身份提供方
+	if meta.Issuer != issuer && meta.Issuer != p.cfg.Issuer {
+		return nil, code.NewErrorf(code.ErrOIDCProvider, "discovery issuer %q does not match %q", meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, code.NewError(code.ErrOIDCProvider, "discovery document is incomplete")
+	}
+	p.meta = &meta
+	return p.meta, nil
+}
+
+// key 按 kid 查找公钥，未知 kid 时按最小间隔重新拉取
+func (p *OIDCProvider) key(ctx context.Context, meta *oidcMetadata, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if k, ok := p.lookupKey(kid); ok {
+		return k, nil
+	}
+	if p.keys != nil && p.now().Sub(p.keysFetched) < oidcKeysRefresh {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, meta.JWKSURI, nil)
+	if err != nil {
+		return nil, code.WrapError(err, code.ErrOIDCProvider, "build jwks request")
+	}
+	var set JWKS
+	status, err := p.do(req, &set)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, code.NewErrorf(code.ErrOIDCProvider, "jwks returned status %d", status)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if pub, err := jwk.PublicKey(); err == nil {
+			keys[jwk.Kid] = pub
+		}
+	}
+	p.keys = keys
+	p.keysFetched = p.now()
+
+	if k, ok := p.lookupKey(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// lookupKey 令牌未携带 kid 时，只有唯一公钥才能使用
+func (p *OIDCProvider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, k := range p.keys {
+			return k, true
+		}
+	}
+	k, ok := p.keys[kid]
+	return k, ok
+}
+
+// do 发送请求并解析 JSON 响应，网络错误与无法解析的响应按身份提供方错误处理
+func (p *OIDCProvider) do(req *http.Request, out any) (int, error) {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, code.WrapError(err, code.ErrOIDCProvider, "identity provider request failed")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, oidcMaxBody))
+	if err != nil {
+		return 0, code.WrapError(err, code.ErrOIDCProvider, "read identity provider response")
+	}
+	if err := json.Unmarshal(body, out); err != nil && resp.StatusCode == http.StatusOK {
+		return 0, code.WrapError(err, code.ErrOIDCProvider, "decode identity provider response")
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// AuthState 跳转到身份提供方前保存的授权上下文，以 state 参数为键，回调时取出并作废
+type AuthState struct {
+	Provider string `json:"provider"`
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+	// UserID 非零时表示已登录用户发起的关联，回调后把外部身份关联到该用户
+	UserID int64 `json:"user_id,omitempty"`
+}
+
+// StateStore 授权上下文存储
+type StateStore interface {
+	// Save 保存授权上下文，ttl 后自动失效
+	Save(ctx context.Context, state string, st AuthState, ttl time.Duration) error
+
+	// Consume 原子地取出并删除授权上下文，不存在或已过期时返回 code.ErrOIDCStateInvalid
+	Consume(ctx context.Context, state string) (AuthState, error)
+}
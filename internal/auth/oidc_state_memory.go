@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+)
+
+type memoryAuthState struct {
+	state     AuthState
+	expiresAt time.Time
+}
+
+// MemoryStateStore 进程内授权上下文存储，仅适合单实例部署、开发与测试
+type MemoryStateStore struct {
+	mu     sync.Mutex
+	states map[string]memoryAuthState
+	now    func() time.Time
+}
+
+// NewMemoryStateStore 创建进程内授权上下文存储
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{
+		states: make(map[string]memoryAuthState),
+		now:    time.Now,
+	}
+}
+
+func (m *MemoryStateStore) Save(_ context.Context, state string, st AuthState, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for k, s := range m.states {
+		if !now.Before(s.expiresAt) {
+			delete(m.states, k)
+		}
+	}
+	m.states[state] = memoryAuthState{state: st, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (m *MemoryStateStore) Consume(_ context.Context, state string) (AuthState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.states[state]
+	delete(m.states, state)
+	if !ok || !m.now().Before(s.expiresAt) {
+		return AuthState{}, code.NewError(code.ErrOIDCStateInvalid, "authorization state is invalid or expired")
+	}
+	return s.state, nil
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/redis/go-redis/v9"
+)
+
+const redisStatePrefix = "auth:oidc:"
+
+// RedisStateStore 基于 Redis 的授权上下文存储，回调可以落在任意副本
+type RedisStateStore struct {
+	rdb *redis.Client
+}
+
+// NewRedisStateStore 创建 Redis 授权上下文存储
+func NewRedisStateStore(rdb *redis.Client) *RedisStateStore {
+	return &RedisStateStore{rdb: rdb}
+}
+
+func (r *RedisStateStore) Save(ctx context.Context, state string, st AuthState, ttl time.Duration) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return code.WrapError(err, code.ErrEncodingJSON, "encode authorization state")
+	}
+	return code.WrapRedisError(r.rdb.Set(ctx, redisStatePrefix+HashToken(state), data, ttl).Err(), "store authorization state")
+}
+
+func (r *RedisStateStore) Consume(ctx context.Context, state string) (AuthState, error) {
+	data, err := r.rdb.GetDel(ctx, redisStatePrefix+HashToken(state)).Bytes()
+	if err == redis.Nil {
+		return AuthState{}, code.NewError(code.ErrOIDCStateInvalid, "authorization state is invalid or expired")
+	}
+	if err != nil {
+		return AuthState{}, code.WrapRedisError(err, "load authorization state")
+	}
+	var st AuthState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return AuthState{}, code.WrapError(err, code.ErrDecodingJSON, "decode authorization state")
+	}
+	return st, nil
+}
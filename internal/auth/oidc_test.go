@@ -0,0 +1,308 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/marmotedu/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubIdP 基于 httptest 的最小 OIDC 身份提供方
+type stubIdP struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+	kid string
+
+	mu sync.Mutex
+	// codes 授权码 -> 授权请求参数
+	codes map[string]url.Values
+	// claims 覆盖签发的 ID Token 声明
+	claims   jwt.MapClaims
+	jwksHits int
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	idp := &stubIdP{key: key, kid: "k1", codes: make(map[string]url.Values)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"issuer":                                idp.URL,
+			"authorization_endpoint":                idp.URL + "/authorize",
+			"token_endpoint":                        idp.URL + "/token",
+			"jwks_uri":                              idp.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		idp.jwksHits++
+		kid := idp.kid
+		idp.mu.Unlock()
+		writeJSON(w, http.StatusOK, JWKS{Keys: []JWK{{
+			Kty: "RSA", Kid: kid, Use: "sig", Alg: "RS256",
+			N: b64(key.N.Bytes()), E: b64(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		idp.mu.Lock()
+		auth, ok := idp.codes[r.PostForm.Get("code")]
+		delete(idp.codes, r.PostForm.Get("code"))
+		idp.mu.Unlock()
+
+		user, pass, _ := r.BasicAuth()
+		switch {
+		case !ok:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		case user != "client" || pass != "secret":
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		case PKCEChallenge(r.PostForm.Get("code_verifier")) != auth.Get("code_challenge"):
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "pkce"})
+		case r.PostForm.Get("redirect_uri") != auth.Get("redirect_uri"):
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		default:
+			writeJSON(w, http.StatusOK, map[string]string{
+				"access_token": "at",
+				"token_type":   "Bearer",
+				"id_token":     idp.sign(t, auth.Get("nonce")),
+			})
+		}
+	})
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+// authorize 模拟用户在身份提供方完成登录，返回授权码
+func (idp *stubIdP) authorize(t *testing.T, authURL string) string {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	code := "code-" + u.Query().Get("state")
+	idp.mu.Lock()
+	idp.codes[code] = u.Query()
+	idp.mu.Unlock()
+	return code
+}
+
+func (idp *stubIdP) sign(t *testing.T, nonce string) string {
+	t.Helper()
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            idp.URL,
+		"sub":            "idp-user-1",
+		"aud":            "client",
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Minute).Unix(),
+		"nonce":          nonce,
+		"email":          "alice@example.com",
+		"email_verified": true,
+	}
+	idp.mu.Lock()
+	for k, v := range idp.claims {
+		claims[k] = v
+	}
+	kid := idp.kid
+	idp.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(idp.key)
+	require.NoError(t, err)
+	return signed
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func newTestOIDCProvider(t *testing.T, idp *stubIdP) *OIDCProvider {
+	t.Helper()
+	p, err := NewOIDCProvider(configs.OIDCProviderConfig{
+		Name:         "stub",
+		Issuer:       idp.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "https://app.example.com/callback",
+	}, idp.Client())
+	require.NoError(t, err)
+	return p
+}
+
+func TestOIDCProvider_Flow(t *testing.T) {
+	ctx := context.Background()
+	idp := newStubIdP(t)
+	p := newTestOIDCProvider(t, idp)
+
+	verifier, err := NewPKCEVerifier()
+	require.NoError(t, err)
+	authURL, err := p.AuthCodeURL(ctx, "state-1", "nonce-1", PKCEChallenge(verifier))
+	require.NoError(t, err)
+	q, _ := url.Parse(authURL)
+	assert.Equal(t, "S256", q.Query().Get("code_challenge_method"))
+	assert.Equal(t, "openid profile email", q.Query().Get("scope"))
+
+	// code_verifier 不匹配时身份提供方拒绝
+	authCode := idp.authorize(t, authURL)
+	_, err = p.Exchange(ctx, authCode, "wrong-verifier", "nonce-1")
+	assert.True(t, errors.IsCode(err, code.ErrOIDCStateInvalid))
+
+	authCode = idp.authorize(t, authURL)
+	identity, err := p.Exchange(ctx, authCode, verifier, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, &ExternalIdentity{
+		Provider:      "stub",
+		Subject:       "idp-user-1",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+	}, identity)
+
+	// 授权码只能使用一次
+	_, err = p.Exchange(ctx, authCode, verifier, "nonce-1")
+	assert.True(t, errors.IsCode(err, code.ErrOIDCStateInvalid))
+}
+
+func TestOIDCProvider_VerifyIDToken(t *testing.T) {
+	ctx := context.Background()
+	idp := newStubIdP(t)
+	p := newTestOIDCProvider(t, idp)
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		nonce  string
+	}{
+		{name: "nonce 不一致", nonce: "other"},
+		{name: "audience 不一致", claims: jwt.MapClaims{"aud": "someone-else"}},
+		{name: "issuer 不一致", claims: jwt.MapClaims{"iss": "https://evil.example.com"}},
+		{name: "已过期", claims: jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}},
+		{name: "多个 audience 缺少 azp", claims: jwt.MapClaims{"aud": []string{"client", "other"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp.claims = tt.claims
+			t.Cleanup(func() { idp.claims = nil })
+			raw := idp.sign(t, "nonce")
+			nonce := tt.nonce
+			if nonce == "" {
+				nonce = "nonce"
+			}
+			_, err := p.VerifyIDToken(ctx, raw, nonce)
+			assert.True(t, errors.IsCode(err, code.ErrOIDCTokenInvalid), "unexpected error: %v", err)
+		})
+	}
+
+	// 其他密钥签发的令牌无法通过校验
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": idp.URL, "sub": "x", "aud": "client", "nonce": "nonce",
+		"iat": time.Now().Unix(), "exp": time.Now().Add(time.Minute).Unix(),
+	}).SignedString(other)
+	require.NoError(t, err)
+	_, err = p.VerifyIDToken(ctx, forged, "nonce")
+	assert.True(t, errors.IsCode(err, code.ErrOIDCTokenInvalid))
+}
+
+func TestOIDCProvider_KeyRotation(t *testing.T) {
+	ctx := context.Background()
+	idp := newStubIdP(t)
+	p := newTestOIDCProvider(t, idp)
+	now := time.Now()
+	p.now = func() time.Time { return now }
+
+	_, err := p.VerifyIDToken(ctx, idp.sign(t, "n"), "n")
+	require.NoError(t, err)
+
+	// 身份提供方轮换 kid 后，超过最小间隔才重新拉取公钥
+	idp.mu.Lock()
+	idp.kid = "k2"
+	idp.mu.Unlock()
+	_, err = p.VerifyIDToken(ctx, idp.sign(t, "n"), "n")
+	assert.Error(t, err)
+	now = now.Add(oidcKeysRefresh)
+	_, err = p.VerifyIDToken(ctx, idp.sign(t, "n"), "n")
+	require.NoError(t, err)
+	assert.Equal(t, 2, idp.jwksHits)
+}
+
+func TestOIDCProvider_Discovery(t *testing.T) {
+	idp := newStubIdP(t)
+	p, err := NewOIDCProvider(configs.OIDCProviderConfig{
+		Name: "stub", Issuer: idp.URL + "/other", ClientID: "client", RedirectURL: "https://app/cb",
+	}, idp.Client())
+	require.NoError(t, err)
+
+	_, err = p.AuthCodeURL(context.Background(), "s", "n", "c")
+	assert.True(t, errors.IsCode(err, code.ErrOIDCProvider))
+
+	_, err = NewOIDCProvider(configs.OIDCProviderConfig{Name: "stub"}, nil)
+	assert.Error(t, err)
+}
+
+func TestIdentityProviders(t *testing.T) {
+	ps, err := NewIdentityProviders(configs.Config{Auth: configs.AuthConfig{OIDC: configs.OIDCConfig{
+		Providers: []configs.OIDCProviderConfig{{Name: "a", Issuer: "https://a", ClientID: "c", RedirectURL: "https://app/cb"}},
+	}}})
+	require.NoError(t, err)
+
+	p, err := ps.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, "a", p.Name())
+	_, err = ps.Get("b")
+	assert.True(t, errors.IsCode(err, code.ErrOIDCProviderNotFound))
+	assert.Error(t, ps.Register(p))
+}
+
+func TestMemoryStateStore(t *testing.T) {
+	testStateStore(t, NewMemoryStateStore())
+}
+
+// TestRedisStateStore 需要设置 TEST_REDIS_ADDR 指向可写的 Redis 实例
+func TestRedisStateStore(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR not set")
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { _ = rdb.Close() })
+	testStateStore(t, NewRedisStateStore(rdb))
+}
+
+func testStateStore(t *testing.T, store StateStore) {
+	ctx := context.Background()
+	st := AuthState{Provider: "stub", Verifier: "v", Nonce: "n", UserID: 7}
+
+	state := uuid.NewString()
+
+	require.NoError(t, store.Save(ctx, state, st, time.Minute))
+	got, err := store.Consume(ctx, state)
+	require.NoError(t, err)
+	assert.Equal(t, st, got)
+
+	_, err = store.Consume(ctx, state)
+	assert.True(t, errors.IsCode(err, code.ErrOIDCStateInvalid))
+}
@@ -0,0 +1,71 @@
+/*
+ * Token Store
+ * 刷新令牌族与访问令牌吊销名单
+ *
+ * 每次登录开启一个令牌族（family），族内同一时刻只有一个有效的刷新令牌。
+ * 刷新时旧令牌被轮换为新令牌；若已轮换过的旧令牌再次出现，说明令牌泄露后被重放，
+ * 整个令牌族连同其签发的访问令牌一并吊销。
+ */
+
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+)
+
+// RefreshToken 刷新令牌记录，Hash 为令牌明文的 SHA-256 摘要
+type RefreshToken struct {
+	Hash   string
+	Family string
+	UserID int64
+	// TenantID 登录时选定的租户，刷新时沿用，保证同一令牌族签发的访问令牌属于同一租户
+	TenantID string
+	// AccessJTI 与该刷新令牌一同签发的访问令牌 jti，令牌族被吊销或轮换时一并吊销
+	AccessJTI       string
+	AccessExpiresAt time.Time
+	ExpiresAt       time.Time
+}
+
+// TokenStore 令牌存储
+type TokenStore interface {
+	// Issue 保存新令牌族的第一个刷新令牌
+	Issue(ctx context.Context, token RefreshToken) error
+
+	// Rotate 用 next 替换 presentedHash 对应的刷新令牌，返回补全了 Family/UserID/TenantID 的 next。
+	// presentedHash 已被轮换过时判定为重放：吊销整个令牌族并返回 code.ErrTokenInvalid。
+	Rotate(ctx context.Context, presentedHash string, next RefreshToken) (RefreshToken, error)
+
+	// RevokeByToken 吊销刷新令牌所在的令牌族，令牌不存在时不返回错误
+	RevokeByToken(ctx context.Context, tokenHash string) error
+
+	// RevokeFamily 吊销令牌族及其当前访问令牌
+	RevokeFamily(ctx context.Context, family string) error
+
+	// RevokeUser 吊销用户的全部令牌族（强制下线）
+	RevokeUser(ctx context.Context, userID int64) error
+
+	// RevokeAccess 将访问令牌 jti 加入吊销名单，直到其自然过期
+	RevokeAccess(ctx context.Context, jti string, expiresAt time.Time) error
+
+	// IsRevoked 判断访问令牌 jti 是否已被吊销
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// HashToken 计算令牌摘要，令牌只以摘要形式存储，避免存储泄露后被直接重放
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func errTokenInvalid() error {
+	return code.NewTokenInvalidError()
+}
+
+func errTokenReused() error {
+	return code.NewError(code.ErrTokenInvalid, "refresh token reuse detected")
+}
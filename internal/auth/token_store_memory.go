@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryFamily struct {
+	current RefreshToken
+	revoked bool
+}
+
+// MemoryTokenStore 进程内令牌存储，仅适合单实例部署、开发与测试
+type MemoryTokenStore struct {
+	mu       sync.Mutex
+	tokens   map[string]memoryTokenRef // 刷新令牌摘要 -> 令牌族
+	families map[string]*memoryFamily
+	revoked  map[string]time.Time // 访问令牌 jti -> 过期时间
+	now      func() time.Time
+}
+
+type memoryTokenRef struct {
+	family    string
+	expiresAt time.Time
+}
+
+// NewMemoryTokenStore 创建进程内令牌存储
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{
+		tokens:   make(map[string]memoryTokenRef),
+		families: make(map[string]*memoryFamily),
+		revoked:  make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+func (m *MemoryTokenStore) Issue(_ context.Context, token RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep()
+	m.families[token.Family] = &memoryFamily{current: token}
+	m.tokens[token.Hash] = memoryTokenRef{family: token.Family, expiresAt: token.ExpiresAt}
+	return nil
+}
+
+func (m *MemoryTokenStore) Rotate(_ context.Context, presentedHash string, next RefreshToken) (RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	ref, ok := m.tokens[presentedHash]
+	if !ok || now.After(ref.expiresAt) {
+		return RefreshToken{}, errTokenInvalid()
+	}
+	fam, ok := m.families[ref.family]
+	if !ok || fam.revoked || now.After(fam.current.ExpiresAt) {
+		return RefreshToken{}, errTokenInvalid()
+	}
+	if fam.current.Hash != presentedHash {
+		m.revokeFamily(fam)
+		return RefreshToken{}, errTokenReused()
+	}
+
+	// 轮换后旧访问令牌不再需要，直接吊销
+	m.revokeAccess(fam.current.AccessJTI, fam.current.AccessExpiresAt)
+
+	next.Family = fam.current.Family
+	next.UserID = fam.current.UserID
+	next.TenantID = fam.current.TenantID
+	fam.current = next
+	m.tokens[next.Hash] = memoryTokenRef{family: next.Family, expiresAt: next.ExpiresAt}
+	return next, nil
+}
+
+func (m *MemoryTokenStore) RevokeByToken(_ context.Context, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if ref, ok := m.tokens[tokenHash]; ok {
+		if fam, ok := m.families[ref.family]; ok {
+			m.revokeFamily(fam)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryTokenStore) RevokeFamily(_ context.Context, family string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if fam, ok := m.families[family]; ok {
+		m.revokeFamily(fam)
+	}
+	return nil
+}
+
+func (m *MemoryTokenStore) RevokeUser(_ context.Context, userID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, fam := range m.families {
+		if fam.current.UserID == userID {
+			m.revokeFamily(fam)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryTokenStore) RevokeAccess(_ context.Context, jti string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.revokeAccess(jti, expiresAt)
+	return nil
+}
+
+func (m *MemoryTokenStore) IsRevoked(_ context.Context, jti string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	exp, ok := m.revoked[jti]
+	return ok && m.now().Before(exp), nil
+}
+
+func (m *MemoryTokenStore) revokeFamily(fam *memoryFamily) {
+	fam.revoked = true
+	m.revokeAccess(fam.current.AccessJTI, fam.current.AccessExpiresAt)
+}
+
+func (m *MemoryTokenStore) revokeAccess(jti string, expiresAt time.Time) {
+	if jti == "" || !m.now().Before(expiresAt) {
+		return
+	}
+	m.revoked[jti] = expiresAt
+}
+
+// sweep 清理过期记录，避免进程内存储无限增长
+func (m *MemoryTokenStore) sweep() {
+	now := m.now()
+	for hash, ref := range m.tokens {
+		if now.After(ref.expiresAt) {
+			delete(m.tokens, hash)
+		}
+	}
+	for id, fam := range m.families {
+		if now.After(fam.current.ExpiresAt) {
+			delete(m.families, id)
+		}
+	}
+	for jti, exp := range m.revoked {
+		if now.After(exp) {
+			delete(m.revoked, jti)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisRefreshPrefix = "auth:rt:"
+	redisFamilyPrefix  = "auth:family:"
+	redisUserPrefix    = "auth:user:"
+	redisRevokedPrefix = "auth:revoked:"
+)
+
+// rotateScript 原子地校验并轮换令牌族的当前刷新令牌，只访问 KEYS[1]（令牌族），在 Redis Cluster 下同样可用；
+// 刷新令牌索引与访问令牌吊销名单位于其他 slot，由调用方在脚本之后写入。
+// 返回 {0} 表示令牌族无效；{1, user_id, access_jti, access_exp, tenant_id} 表示轮换成功；
+// {2, user_id, access_jti, access_exp, tenant_id} 表示检测到重放并已吊销令牌族。access_jti 为需要吊销的旧访问令牌。
+var rotateScript = redis.NewScript(`
+local f = redis.call('HMGET', KEYS[1], 'user_id', 'current', 'revoked', 'access_jti', 'access_exp', 'tenant_id')
+if not f[1] or f[3] == '1' then return {0} end
+if f[2] ~= ARGV[1] then
+  redis.call('HSET', KEYS[1], 'revoked', '1')
+  return {2, f[1], f[4] or '', f[5] or '0', f[6] or ''}
+end
+redis.call('HSET', KEYS[1], 'current', ARGV[2], 'access_jti', ARGV[3], 'access_exp', ARGV[4])
+redis.call('EXPIRE', KEYS[1], ARGV[5])
+return {1, f[1], f[4] or '', f[5] or '0', f[6] or ''}
+`)
+
+// revokeFamilyScript 标记令牌族（KEYS[1]）为已吊销，返回 {1, access_jti, access_exp}，令牌族不存在时返回 {0}
+var revokeFamilyScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then return {0} end
+local f = redis.call('HMGET', KEYS[1], 'access_jti', 'access_exp')
+redis.call('HSET', KEYS[1], 'revoked', '1')
+return {1, f[1] or '', f[2] or '0'}
+`)
+
+// RedisTokenStore 基于 Redis 的令牌存储，适合多副本部署
+type RedisTokenStore struct {
+	rdb *redis.Client
+	now func() time.Time
+}
+
+// NewRedisTokenStore 创建 Redis 令牌存储
+func NewRedisTokenStore(rdb *redis.Client) *RedisTokenStore {
+	return &RedisTokenStore{rdb: rdb, now: time.Now}
+}
+
+func (r *RedisTokenStore) Issue(ctx context.Context, token RefreshToken) error {
+	ttl := token.ExpiresAt.Sub(r.now())
+	if ttl <= 0 {
+		return errTokenInvalid()
+	}
+	familyKey := redisFamilyPrefix + token.Family
+	userKey := userFamiliesKey(token.UserID)
+
+	_, err := r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, familyKey,
+			"user_id", token.UserID,
+			"current", token.Hash,
+			"revoked", "0",
+			"access_jti", token.AccessJTI,
+			"access_exp", token.AccessExpiresAt.Unix(),
+			"tenant_id", token.TenantID,
+		)
+		pipe.Expire(ctx, familyKey, ttl)
+		pipe.Set(ctx, redisRefreshPrefix+token.Hash, token.Family, ttl)
+		pipe.SAdd(ctx, userKey, token.Family)
+		pipe.Expire(ctx, userKey, ttl)
+		return nil
+	})
+	return code.WrapRedisError(err, "issue refresh token")
+}
+
+func (r *RedisTokenStore) Rotate(ctx context.Context, presentedHash string, next RefreshToken) (RefreshToken, error) {
+	now := r.now()
+	ttl := int64(next.ExpiresAt.Sub(now) / time.Second)
+	if ttl <= 0 {
+		return RefreshToken{}, errTokenInvalid()
+	}
+
+	// 已轮换的旧令牌索引保留到过期，用于识别重放
+	family, err := r.rdb.Get(ctx, redisRefreshPrefix+presentedHash).Result()
+	if err == redis.Nil {
+		return RefreshToken{}, errTokenInvalid()
+	}
+	if err != nil {
+		return RefreshToken{}, code.WrapRedisError(err, "lookup refresh token")
+	}
+
+	res, err := rotateScript.Run(ctx, r.rdb,
+		[]string{redisFamilyPrefix + family},
+		presentedHash, next.Hash, next.AccessJTI, next.AccessExpiresAt.Unix(), ttl,
+	).Slice()
+	if err != nil {
+		return RefreshToken{}, code.WrapRedisError(err, "rotate refresh token")
+	}
+
+	status, _ := res[0].(int64)
+	if status == 0 {
+		return RefreshToken{}, errTokenInvalid()
+	}
+	// 轮换与重放都吊销旧的访问令牌
+	if err := r.revokeAccess(ctx, res[2], res[3]); err != nil {
+		return RefreshToken{}, err
+	}
+	if status == 2 {
+		return RefreshToken{}, errTokenReused()
+	}
+
+	next.Family = family
+	next.UserID, _ = strconv.ParseInt(res[1].(string), 10, 64)
+	next.TenantID, _ = res[4].(string)
+	expiration := time.Duration(ttl) * time.Second
+	if err := r.rdb.Set(ctx, redisRefreshPrefix+next.Hash, family, expiration).Err(); err != nil {
+		return RefreshToken{}, code.WrapRedisError(err, "save refresh token")
+	}
+	// 令牌族续期后同步延长用户索引，保证 RevokeUser 能找到它
+	_ = r.rdb.Expire(ctx, userFamiliesKey(next.UserID), expiration).Err()
+	return next, nil
+}
+
+func (r *RedisTokenStore) RevokeByToken(ctx context.Context, tokenHash string) error {
+	family, err := r.rdb.Get(ctx, redisRefreshPrefix+tokenHash).Result()
+	if err == redis.Nil {
+		return nil
+	}
+	if err != nil {
+		return code.WrapRedisError(err, "lookup refresh token")
+	}
+	return r.RevokeFamily(ctx, family)
+}
+
+func (r *RedisTokenStore) RevokeFamily(ctx context.Context, family string) error {
+	res, err := revokeFamilyScript.Run(ctx, r.rdb, []string{redisFamilyPrefix + family}).Slice()
+	if err != nil {
+		return code.WrapRedisError(err, "revoke token family")
+	}
+	if status, _ := res[0].(int64); status == 0 {
+		return nil
+	}
+	return r.revokeAccess(ctx, res[1], res[2])
+}
+
+func (r *RedisTokenStore) RevokeUser(ctx context.Context, userID int64) error {
+	userKey := userFamiliesKey(userID)
+	families, err := r.rdb.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return code.WrapRedisError(err, "list token families")
+	}
+	for _, family := range families {
+		if err := r.RevokeFamily(ctx, family); err != nil {
+			return err
+		}
+	}
+	return code.WrapRedisError(r.rdb.Del(ctx, userKey).Err(), "clear token families")
+}
+
+func (r *RedisTokenStore) RevokeAccess(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := expiresAt.Sub(r.now())
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	return code.WrapRedisError(r.rdb.Set(ctx, redisRevokedPrefix+jti, "1", ttl).Err(), "revoke access token")
+}
+
+func (r *RedisTokenStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	n, err := r.rdb.Exists(ctx, redisRevokedPrefix+jti).Result()
+	if err != nil {
+		return false, code.WrapRedisError(err, "check access token revocation")
+	}
+	return n > 0, nil
+}
+
+// revokeAccess 吊销脚本返回的访问令牌，jti、exp 为令牌族中保存的字符串
+func (r *RedisTokenStore) revokeAccess(ctx context.Context, jti, exp any) error {
+	id, _ := jti.(string)
+	unix, _ := strconv.ParseInt(fmt.Sprint(exp), 10, 64)
+	return r.RevokeAccess(ctx, id, time.Unix(unix, 0))
+}
+
+func userFamiliesKey(userID int64) string {
+	return redisUserPrefix + strconv.FormatInt(userID, 10) + ":families"
+}
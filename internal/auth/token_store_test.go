@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/google/uuid"
+	"github.com/marmotedu/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryTokenStore(t *testing.T) {
+	testTokenStore(t, NewMemoryTokenStore())
+}
+
+// TestRedisTokenStore 需要设置 TEST_REDIS_ADDR 指向可写的 Redis 实例
+func TestRedisTokenStore(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR not set")
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { _ = rdb.Close() })
+	testTokenStore(t, NewRedisTokenStore(rdb))
+}
+
+func newTestToken(family string, userID int64) RefreshToken {
+	now := time.Now()
+	return RefreshToken{
+		Hash:            HashToken(uuid.NewString()),
+		Family:          family,
+		UserID:          userID,
+		AccessJTI:       uuid.NewString(),
+		AccessExpiresAt: now.Add(time.Minute),
+		ExpiresAt:       now.Add(time.Hour),
+	}
+}
+
+func testTokenStore(t *testing.T, store TokenStore) {
+	ctx := context.Background()
+
+	t.Run("rotate", func(t *testing.T) {
+		first := newTestToken(uuid.NewString(), 1)
+		first.TenantID = "acme"
+		require.NoError(t, store.Issue(ctx, first))
+
+		second, err := store.Rotate(ctx, first.Hash, newTestToken("", 0))
+		require.NoError(t, err)
+		assert.Equal(t, first.Family, second.Family)
+		assert.Equal(t, int64(1), second.UserID)
+		assert.Equal(t, "acme", second.TenantID)
+
+		// 轮换后旧访问令牌被吊销，新访问令牌有效
+		revoked, err := store.IsRevoked(ctx, first.AccessJTI)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		revoked, err = store.IsRevoked(ctx, second.AccessJTI)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	t.Run("reuse revokes family", func(t *testing.T) {
+		first := newTestToken(uuid.NewString(), 2)
+		require.NoError(t, store.Issue(ctx, first))
+		second, err := store.Rotate(ctx, first.Hash, newTestToken("", 0))
+		require.NoError(t, err)
+
+		_, err = store.Rotate(ctx, first.Hash, newTestToken("", 0))
+		assert.True(t, errors.IsCode(err, code.ErrTokenInvalid))
+
+		// 合法持有者的令牌也随令牌族一起失效
+		_, err = store.Rotate(ctx, second.Hash, newTestToken("", 0))
+		assert.True(t, errors.IsCode(err, code.ErrTokenInvalid))
+		revoked, err := store.IsRevoked(ctx, second.AccessJTI)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+	})
+
+	t.Run("revoke by token", func(t *testing.T) {
+		first := newTestToken(uuid.NewString(), 3)
+		require.NoError(t, store.Issue(ctx, first))
+		require.NoError(t, store.RevokeByToken(ctx, first.Hash))
+
+		_, err := store.Rotate(ctx, first.Hash, newTestToken("", 0))
+		assert.True(t, errors.IsCode(err, code.ErrTokenInvalid))
+		require.NoError(t, store.RevokeByToken(ctx, HashToken("unknown")))
+	})
+
+	t.Run("revoke user", func(t *testing.T) {
+		a := newTestToken(uuid.NewString(), 4)
+		b := newTestToken(uuid.NewString(), 4)
+		other := newTestToken(uuid.NewString(), 5)
+		for _, tok := range []RefreshToken{a, b, other} {
+			require.NoError(t, store.Issue(ctx, tok))
+		}
+		require.NoError(t, store.RevokeUser(ctx, 4))
+
+		for _, tok := range []RefreshToken{a, b} {
+			revoked, err := store.IsRevoked(ctx, tok.AccessJTI)
+			require.NoError(t, err)
+			assert.True(t, revoked)
+		}
+		_, err := store.Rotate(ctx, other.Hash, newTestToken("", 0))
+		assert.NoError(t, err)
+	})
+
+	t.Run("revoke access", func(t *testing.T) {
+		jti := uuid.NewString()
+		require.NoError(t, store.RevokeAccess(ctx, jti, time.Now().Add(time.Minute)))
+		revoked, err := store.IsRevoked(ctx, jti)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+	})
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量
+func TestHOTP_RFC6238(t *testing.T) {
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+	for _, tt := range tests {
+		step := TOTPStep(time.Unix(tt.unix, 0))
+		assert.Equal(t, tt.want, hotp(key, uint64(step), 8), tt.unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	step := TOTPStep(now)
+
+	code, err := TOTPCode(secret, step)
+	require.NoError(t, err)
+	got, ok := ValidateTOTP(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, step, got)
+
+	// 容忍一个步长的时钟偏差
+	prev, err := TOTPCode(secret, step-1)
+	require.NoError(t, err)
+	got, ok = ValidateTOTP(secret, prev, now)
+	assert.True(t, ok)
+	assert.Equal(t, step-1, got)
+
+	old, err := TOTPCode(secret, step-2)
+	require.NoError(t, err)
+	_, ok = ValidateTOTP(secret, old, now)
+	assert.False(t, ok)
+
+	_, ok = ValidateTOTP(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	u, err := url.Parse(TOTPURI("Admin Console", "alice@example.com", "JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Admin Console:alice@example.com", u.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", u.Query().Get("secret"))
+	assert.Equal(t, "Admin Console", u.Query().Get("issuer"))
+}
@@ -88,6 +88,51 @@ const (
 
 	// ErrTooManyAttempts - 403: Too many login attempts.
 	ErrTooManyAttempts
+
+	// ErrMfaCodeInvalid - 401: MFA code is invalid.
+	ErrMfaCodeInvalid
+
+	// ErrMfaNotEnrolled - 400: MFA is not enrolled.
+	ErrMfaNotEnrolled
+
+	// ErrMfaAlreadyEnabled - 400: MFA is already enabled.
+	ErrMfaAlreadyEnabled
+
+	// ErrOIDCProviderNotFound - 404: Identity provider not found.
+	ErrOIDCProviderNotFound
+
+	// ErrOIDCStateInvalid - 400: Authorization state is invalid or expired.
+	ErrOIDCStateInvalid
+
+	// ErrOIDCTokenInvalid - 401: ID token is invalid.
+	ErrOIDCTokenInvalid
+
+	// ErrOIDCProvider - 500: Identity provider request failed.
+	ErrOIDCProvider
+
+	// ErrIdentityNotLinked - 403: External identity is not linked to a user.
+	ErrIdentityNotLinked
+
+	// ErrIdentityAlreadyLinked - 400: External identity is already linked to another user.
+	ErrIdentityAlreadyLinked
+
+	// ErrAPIKeyInvalid - 401: API key is invalid or expired.
+	ErrAPIKeyInvalid
+
+	// ErrAPIKeyNotFound - 404: API key not found.
+	ErrAPIKeyNotFound
+
+	// ErrAPIKeyScope - 403: API key scope does not allow this request.
+	ErrAPIKeyScope
+
+	// ErrVerificationTokenInvalid - 400: Verification token is invalid or expired.
+	ErrVerificationTokenInvalid
+
+	// ErrNotifyFailed - 500: Failed to deliver notification.
+	ErrNotifyFailed
+
+	// ErrConfigVersionNotFound - 404: Config version not found.
+	ErrConfigVersionNotFound
 )
 
 // 通用：编解码类错误.
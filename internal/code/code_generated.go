@@ -32,6 +32,21 @@ func init() {
 	register(ErrAccountLocked, 403, "Account is locked")
 	register(ErrAccountDisabled, 403, "Account is disabled")
 	register(ErrTooManyAttempts, 403, "Too many login attempts")
+	register(ErrMfaCodeInvalid, 401, "MFA code is invalid")
+	register(ErrMfaNotEnrolled, 400, "MFA is not enrolled")
+	register(ErrMfaAlreadyEnabled, 400, "MFA is already enabled")
+	register(ErrOIDCProviderNotFound, 404, "Identity provider not found")
+	register(ErrOIDCStateInvalid, 400, "Authorization state is invalid or expired")
+	register(ErrOIDCTokenInvalid, 401, "ID token is invalid")
+	register(ErrOIDCProvider, 500, "Identity provider request failed")
+	register(ErrIdentityNotLinked, 403, "External identity is not linked to a user")
+	register(ErrIdentityAlreadyLinked, 400, "External identity is already linked to another user")
+	register(ErrAPIKeyInvalid, 401, "API key is invalid or expired")
+	register(ErrAPIKeyNotFound, 404, "API key not found")
+	register(ErrAPIKeyScope, 403, "API key scope does not allow this request")
+	register(ErrVerificationTokenInvalid, 400, "Verification token is invalid or expired")
+	register(ErrNotifyFailed, 500, "Failed to deliver notification")
+	register(ErrConfigVersionNotFound, 404, "Config version not found")
 	register(ErrEncodingFailed, 500, "Encoding failed due to an error with the data")
 	register(ErrDecodingFailed, 500, "Decoding failed due to an error with the data")
 	register(ErrInvalidJSON, 500, "Data is not valid JSON")
@@ -48,4 +63,12 @@ func init() {
 	register(ErrUserCreateFailed, 500, "User create failed")
 	register(ErrUserUpdateFailed, 500, "User update failed")
 	register(ErrUserDeleteFailed, 500, "User delete failed")
+	register(ErrRbacPolicyNotFound, 404, "Policy not found")
+	register(ErrRbacPolicyExists, 400, "Policy already exists")
+	register(ErrRbacRoleNotFound, 404, "Role assignment not found")
+	register(ErrRbacRoleExists, 400, "Role assignment already exists")
+	register(ErrRbacInvalidImport, 400, "Invalid policy import data")
+	register(ErrRbacEnforcer, 500, "Policy enforcer error")
+	register(ErrTenantRequired, 400, "Tenant is required")
+	register(ErrTenantMismatch, 403, "Tenant does not match the authenticated principal")
 }
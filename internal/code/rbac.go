@@ -0,0 +1,20 @@
+package code
+
+//go:generate codegen -type=int
+//go:generate codegen -type=int -doc -output ./error_code_generated.md
+
+// RBAC相关错误码
+const (
+	// ErrRbacPolicyNotFound - 404: Policy not found.
+	ErrRbacPolicyNotFound int = iota + 200100
+	// ErrRbacPolicyExists - 400: Policy already exists.
+	ErrRbacPolicyExists
+	// ErrRbacRoleNotFound - 404: Role assignment not found.
+	ErrRbacRoleNotFound
+	// ErrRbacRoleExists - 400: Role assignment already exists.
+	ErrRbacRoleExists
+	// ErrRbacInvalidImport - 400: Invalid policy import data.
+	ErrRbacInvalidImport
+	// ErrRbacEnforcer - 500: Policy enforcer error.
+	ErrRbacEnforcer
+)
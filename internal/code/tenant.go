@@ -0,0 +1,12 @@
+package code
+
+//go:generate codegen -type=int
+//go:generate codegen -type=int -doc -output ./error_code_generated.md
+
+// 租户相关错误码
+const (
+	// ErrTenantRequired - 400: Tenant is required.
+	ErrTenantRequired int = iota + 200200
+	// ErrTenantMismatch - 403: Tenant does not match the authenticated principal.
+	ErrTenantMismatch
+)
@@ -1,72 +1,207 @@
 package configs
 
-import "context"
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sort"
+)
 
-// Bootstrap 仅以本地文件为入口初始化配置，随后按文件中的 etcd/consul 配置进行增量合并并挂载热更新。
-// 返回最终 Config 以及可动态读取/更新的 Store。
-func Bootstrap(path string) (Config, *Store) {
-	base := NewCfg(path)
-	merged := base
+// Bootstrap 仅以本地文件为入口初始化配置，随后按文件中的 etcd/consul/config_sources 配置进行增量合并，
+// 最后叠加 APP_ 前缀的环境变量，并挂载热更新。
+// 本地文件按 LayeredFileSource 分层：path 为基础文件，其后依次合并 config.<env>.* 与 config.local.*，
+// env 为空时依次取 APP_SYSTEM_ENV、基础文件中的 system.env。
+// 优先级从低到高为：文件 < etcd < consul < http < 目录 < 环境变量，任一来源热更新后都按该顺序重新合并；
+// 各来源只覆盖其中实际出现的键，显式写出的零值与 false 同样生效。
+// 合并结果展开 ${env:}、${file:} 引用并解密 enc:v1: 值后需通过 Config.Validate，
+// 启动时校验失败直接 panic，热更新时校验失败则丢弃该次更新。
+// 返回最终 Config 以及可动态读取/更新的 Store，Store.Sources 可查看每个键的生效来源，
+// Store.SourceStatuses 可查看远程来源的读取状态。
+func Bootstrap(path, env string) (Config, *Store) {
 	ctx := context.Background()
+	fileSource := LayeredFileSource{Path: path, Env: DetectEnv(env, path)}
+	files, err := fileSource.load(nil)
+	if err != nil {
+		panic(err)
+	}
+	slog.Info("config files loaded", slog.String("env", fileSource.Env), slog.Any("files", filePaths(files)))
+	layers := newSourceLayers(EnvSource{Prefix: DefaultEnvPrefix})
+	layers.setFiles(fileSource, files)
+	base, _ := fileSource.merge(files)
+	// etcd/consul/http 的连接凭据同样可以使用密钥引用
+	conn, _, err := ResolveSecrets(base)
+	if err != nil {
+		panic(err)
+	}
 
-	// 增量合并：etcd
-	if len(base.Etcd.Endpoints) > 0 && base.Etcd.Key != "" {
-		etcdSource := EtcdSource{
-			Endpoints:          base.Etcd.Endpoints,
-			Key:                base.Etcd.Key,
-			Format:             base.Etcd.Format,
-			Username:           base.Etcd.Username,
-			Password:           base.Etcd.Password,
-			DialTimeoutSeconds: base.Etcd.DialTimeoutSeconds,
-		}
-		if etcdCfg, err := etcdSource.Load(ctx); err == nil {
-			merged = Merge(merged, etcdCfg)
+	etcdSource := EtcdSource{
+		Endpoints:          conn.Etcd.Endpoints,
+		Key:                conn.Etcd.Key,
+		Format:             conn.Etcd.Format,
+		Username:           conn.Etcd.Username,
+		Password:           conn.Etcd.Password,
+		DialTimeoutSeconds: conn.Etcd.DialTimeoutSeconds,
+		SnapshotPath:       conn.Etcd.SnapshotPath,
+		Monitor:            NewSourceMonitor(SourceEtcd),
+	}
+	useEtcd := len(conn.Etcd.Endpoints) > 0 && conn.Etcd.Key != ""
+	consulSource := ConsulSource{
+		Address:      conn.Consul.Address,
+		Token:        conn.Consul.Token,
+		Key:          conn.Consul.Key,
+		Format:       conn.Consul.Format,
+		SnapshotPath: conn.Consul.SnapshotPath,
+		Monitor:      NewSourceMonitor(SourceConsul),
+	}
+	useConsul := conn.Consul.Address != "" && conn.Consul.Key != ""
+	httpSource := HTTPSource{
+		URL:          conn.ConfigSources.HTTP.URL,
+		Format:       conn.ConfigSources.HTTP.Format,
+		Token:        conn.ConfigSources.HTTP.Token,
+		Interval:     conn.ConfigSources.HTTP.Interval,
+		Timeout:      conn.ConfigSources.HTTP.Timeout,
+		SnapshotPath: conn.ConfigSources.HTTP.SnapshotPath,
+		Monitor:      NewSourceMonitor(SourceHTTP),
+	}
+	useHTTP := conn.ConfigSources.HTTP.URL != ""
+	dirSource := DirSource{Path: conn.ConfigSources.Dir.Path}
+	useDir := conn.ConfigSources.Dir.Path != ""
+
+	// 增量合并：etcd。远程与快照都不可用时仅记录错误，以其余来源启动，恢复后由热更新补上
+	var monitors []*SourceMonitor
+	if useEtcd {
+		monitors = append(monitors, etcdSource.Monitor)
+		if etcdCfg, keys, err := etcdSource.LoadKeys(ctx); err == nil {
+			layers.set(SourceEtcd, etcdCfg, keys)
+		} else {
+			slog.Error("load etcd config failed", slog.String("key", etcdSource.Key), slog.Any("error", err))
 		}
 	}
 	// 增量合并：consul
-	if base.Consul.Address != "" && base.Consul.Key != "" {
-		consulSource := ConsulSource{
-			Address: base.Consul.Address,
-			Token:   base.Consul.Token,
-			Key:     base.Consul.Key,
-			Format:  base.Consul.Format,
+	if useConsul {
+		monitors = append(monitors, consulSource.Monitor)
+		if consulCfg, keys, err := consulSource.LoadKeys(ctx); err == nil {
+			layers.set(SourceConsul, consulCfg, keys)
+		} else {
+			slog.Error("load consul config failed", slog.String("key", consulSource.Key), slog.Any("error", err))
+		}
+	}
+	// 增量合并：http
+	if useHTTP {
+		monitors = append(monitors, httpSource.Monitor)
+		if httpCfg, keys, err := httpSource.LoadKeys(ctx); err == nil {
+			layers.set(SourceHTTP, httpCfg, keys)
+		} else {
+			slog.Error("load http config failed", slog.String("url", httpSource.URL), slog.Any("error", err))
 		}
-		if consulCfg, err := consulSource.Load(ctx); err == nil {
-			merged = Merge(merged, consulCfg)
+	}
+	// 增量合并：目录
+	if useDir {
+		if dirCfg, keys, err := dirSource.LoadKeys(ctx); err == nil {
+			layers.set(SourceDir, dirCfg, keys)
+		} else {
+			slog.Error("load config dir failed", slog.String("dir", dirSource.Path), slog.Any("error", err))
 		}
 	}
 
+	// 启动时环境变量格式错误、密钥引用无法解析或配置校验失败直接退出，避免带着错误的配置运行
+	merged, sources, secrets, err := finalize(layers)
+	if err != nil {
+		panic(err)
+	}
 	store := NewStore(merged)
-	// 文件热更新（作为默认入口）
-	_ = FileSource{Path: path}.Watch(ctx, func(nc Config) {
-		store.Update(Merge(store.Current(), nc))
-	})
+	store.setSources(sources)
+	store.setFiles(layers.fileOrigins(sources))
+	store.setSecrets(secrets)
+	store.setMonitors(monitors)
+
+	reload := reloader(layers, store)
+	// 文件热更新（作为默认入口），环境文件与本地文件的新建、删除同样生效
+	if err := fileSource.watch(ctx, func(files []fileLayer) {
+		applyReload(layers, store, SourceFile, func() func() { return layers.setFiles(fileSource, files) })
+	}); err != nil {
+		slog.Error("watch config files failed", slog.Any("error", err))
+	}
 	// etcd 热更新（如果配置了）
-	if len(base.Etcd.Endpoints) > 0 && base.Etcd.Key != "" {
-		etcdSource := EtcdSource{
-			Endpoints:          base.Etcd.Endpoints,
-			Key:                base.Etcd.Key,
-			Format:             base.Etcd.Format,
-			Username:           base.Etcd.Username,
-			Password:           base.Etcd.Password,
-			DialTimeoutSeconds: base.Etcd.DialTimeoutSeconds,
+	if useEtcd {
+		if err := etcdSource.WatchKeys(ctx, reload(SourceEtcd)); err != nil {
+			slog.Error("watch etcd config failed", slog.Any("error", err))
 		}
-		_ = etcdSource.Watch(ctx, func(nc Config) {
-			store.Update(Merge(store.Current(), nc))
-		})
 	}
 	// consul 热更新（如果配置了）
-	if base.Consul.Address != "" && base.Consul.Key != "" {
-		consulSource := ConsulSource{
-			Address: base.Consul.Address,
-			Token:   base.Consul.Token,
-			Key:     base.Consul.Key,
-			Format:  base.Consul.Format,
+	if useConsul {
+		if err := consulSource.WatchKeys(ctx, reload(SourceConsul)); err != nil {
+			slog.Error("watch consul config failed", slog.Any("error", err))
+		}
+	}
+	// http 轮询（如果配置了）
+	if useHTTP {
+		if err := httpSource.WatchKeys(ctx, reload(SourceHTTP)); err != nil {
+			slog.Error("watch http config failed", slog.Any("error", err))
+		}
+	}
+	// 目录热更新（如果配置了）
+	if useDir {
+		if err := dirSource.WatchKeys(ctx, reload(SourceDir)); err != nil {
+			slog.Error("watch config dir failed", slog.Any("error", err))
 		}
-		_ = consulSource.Watch(ctx, func(nc Config) {
-			store.Update(Merge(store.Current(), nc))
-		})
 	}
 
 	return merged, store
 }
+
+// reloader 返回各来源的热更新回调
+func reloader(layers *sourceLayers, store *Store) func(source string) func(Config, []string) {
+	return func(source string) func(Config, []string) {
+		return func(nc Config, keys []string) {
+			applyReload(layers, store, source, func() func() { return layers.set(source, nc, keys) })
+		}
+	}
+}
+
+// applyReload 更新来源后重新合并全部来源并校验，校验失败时丢弃本次更新，
+// Store 保留上一份有效配置，之后其他来源的更新也不受影响；生效的更新记录变更摘要（敏感值已隐藏）。
+// 多个来源同时更新时逐个执行，避免撤销覆盖其他来源的更新，或较早的合并结果晚于较新的写入 Store。
+func applyReload(layers *sourceLayers, store *Store, source string, set func() (undo func())) {
+	layers.reload.Lock()
+	defer layers.reload.Unlock()
+	undo := set()
+	c, sources, secrets, err := finalize(layers)
+	if err != nil {
+		undo()
+		slog.Error("config reload rejected", slog.String("source", source), slog.Any("error", err))
+		return
+	}
+	changes := Diff(store.Current(), c)
+	// 新旧配置中的密钥都需要隐藏
+	hidden := append(append([]string(nil), store.SecretKeys()...), secrets...)
+	store.setSources(sources)
+	store.setFiles(layers.fileOrigins(sources))
+	store.apply(c, source, secrets)
+	if len(changes) > 0 {
+		slog.Info("config reloaded", slog.String("source", source), slog.Int("changed", len(changes)), changeSummary(changes, hidden))
+	}
+}
+
+// finalize 合并全部来源，展开密钥引用后校验，返回最终配置、各键来源与含密钥的键。
+// 目录来源（Kubernetes Secret 卷）提供的值同样视为密钥。
+func finalize(layers *sourceLayers) (Config, map[string]string, []string, error) {
+	merged, sources, err := layers.resolve()
+	if err != nil {
+		return Config{}, nil, nil, err
+	}
+	merged, secrets, err := ResolveSecrets(merged)
+	if err != nil {
+		return Config{}, nil, nil, err
+	}
+	for key, source := range sources {
+		if source == SourceDir && !slices.Contains(secrets, key) {
+			secrets = append(secrets, key)
+		}
+	}
+	sort.Strings(secrets)
+	if err := merged.Validate(); err != nil {
+		return Config{}, nil, nil, err
+	}
+	return merged, sources, secrets, nil
+}
@@ -65,24 +65,39 @@ type Config struct {
 	Kafka   KafkaConfig        `mapstructure:"kafka"`
 	Etcd    EtcdClientConfig   `mapstructure:"etcd"`
 	Consul  ConsulClientConfig `mapstructure:"consul"`
+	// ConfigSources 通用的配置来源，没有 etcd/consul 时同样可以集中下发配置
+	ConfigSources ConfigSourcesConfig `mapstructure:"config_sources"`
+	// Middleware HTTP 中间件管道
+	Middleware MiddlewareConfig `mapstructure:"middleware"`
+	// Tenant 多租户
+	Tenant TenantConfig `mapstructure:"tenant"`
+	// Auth 登录保护等认证相关配置
+	Auth AuthConfig `mapstructure:"auth"`
+	// Notifier 邮件等通知的投递方式
+	Notifier NotifierConfig `mapstructure:"notifier"`
+	// ConfigHistory 配置历史版本与自动回滚
+	ConfigHistory ConfigHistoryConfig `mapstructure:"config_history"`
 }
 
 type SystemConfig struct {
-	Port  string `mapstructure:"port"`
+	Port  string `mapstructure:"port" validate:"required,port"`
 	Level Level  `mapstructure:"level"`
-	Env   string `mapstructure:"env"`
+	Env   string `mapstructure:"env" validate:"omitempty,oneof=dev test prod"`
+	// TrustedProxies 可信反向代理的 IP 或 CIDR，只有来自这些地址的请求才采信 X-Forwarded-For；
+	// 为空时客户端 IP 取连接的对端地址
+	TrustedProxies []string `mapstructure:"trusted_proxies" validate:"omitempty,dive,cidr|ip"`
 }
 
 type RedisConfig struct {
 	Host     string `mapstructure:"host"`
-	Port     string `mapstructure:"port"`
+	Port     string `mapstructure:"port" validate:"omitempty,port"`
 	Password string `mapstructure:"password"`
-	Database int    `mapstructure:"database"`
+	Database int    `mapstructure:"database" validate:"gte=0"`
 }
 
 type LogConfig struct {
-	Level  string `mapstructure:"level"`
-	Format string `mapstructure:"format"`
+	Level  string `mapstructure:"level" validate:"omitempty,loglevel"`
+	Format string `mapstructure:"format" validate:"omitempty,oneof=json text color"`
 
 	Console ConsoleSinkConfig `mapstructure:"console"`
 	File    FileSinkConfig    `mapstructure:"file"`
@@ -92,8 +107,8 @@ type LogConfig struct {
 }
 
 type ConsoleSinkConfig struct {
-	Format string `mapstructure:"format"`
-	Output string `mapstructure:"output"`
+	Format string `mapstructure:"format" validate:"omitempty,oneof=json text color"`
+	Output string `mapstructure:"output" validate:"omitempty,oneof=stdout stderr"`
 }
 
 type FileSinkConfig struct {
@@ -120,23 +135,43 @@ type LokiSinkConfig struct {
 type MysqlConfig struct {
 	DockerHost   string `mapstructure:"docker_host"`
 	Host         string `mapstructure:"host"`
-	Port         string `mapstructure:"port"`
+	Port         string `mapstructure:"port" validate:"omitempty,port"`
 	User         string `mapstructure:"user"`
 	Password     string `mapstructure:"password"`
-	MaxOpenConns int    `mapstructure:"max_open_conns"`
-	MaxIdleConns int    `mapstructure:"max_idle_conns"`
+	MaxOpenConns int    `mapstructure:"max_open_conns" validate:"gte=0"`
+	MaxIdleConns int    `mapstructure:"max_idle_conns" validate:"gte=0"`
 	Database     string `mapstructure:"database"`
 }
 
 type JWTConfig struct {
-	Secret    string   `mapstructure:"secret"`
-	Expire    int      `mapstructure:"expire"`
-	SkipPaths []string `mapstructure:"skip_paths"`
+	Secret        string   `mapstructure:"secret"`
+	Expire        int      `mapstructure:"expire" validate:"gte=0"`
+	RefreshExpire int      `mapstructure:"refresh_expire" validate:"gte=0"`
+	Issuer        string   `mapstructure:"issuer"`
+	SkipPaths     []string `mapstructure:"skip_paths"`
+	// Algorithm keys 未单独声明算法时使用的默认算法（RS256/ES256/EdDSA 等）
+	Algorithm string `mapstructure:"algorithm"`
+	// Keys 非对称签名密钥，配置后不再使用 secret
+	Keys []JWTKeyConfig `mapstructure:"keys"`
+}
+
+// JWTKeyConfig 单把签名密钥，PEM 可以内联也可以指定文件
+type JWTKeyConfig struct {
+	Kid            string `mapstructure:"kid"`
+	Algorithm      string `mapstructure:"algorithm"`
+	PrivateKey     string `mapstructure:"private_key"`
+	PrivateKeyFile string `mapstructure:"private_key_file"`
+	PublicKey      string `mapstructure:"public_key"`
+	PublicKeyFile  string `mapstructure:"public_key_file"`
+	// NotBefore 开始用于签名的时间（RFC3339），为空表示立即生效
+	NotBefore string `mapstructure:"not_before"`
+	// NotAfter 停止用于验签的时间（RFC3339），应晚于最后一个令牌的过期时间
+	NotAfter string `mapstructure:"not_after"`
 }
 
 type Mongodb struct {
 	Host     string `mapstructure:"host"`
-	Port     string `mapstructure:"port"`
+	Port     string `mapstructure:"port" validate:"omitempty,port"`
 	User     string `mapstructure:"user"`
 	Password string `mapstructure:"password"`
 	DataBase string `mapstructure:"database"`
@@ -149,13 +184,213 @@ type CORSConfig struct {
 	AllowCredentials bool     `mapstructure:"allow_credentials"`
 }
 
+// MiddlewareConfig 中间件管道配置。
+// Pipeline 按顺序列出启用的中间件名称（内置或通过 fx 注册的自定义中间件），为空时使用默认管道；
+// 其余字段为内置中间件的参数，自定义中间件的参数放在 Options 中按名称索引。
+type MiddlewareConfig struct {
+	Pipeline  []string                  `mapstructure:"pipeline"`
+	Logger    LoggerMiddlewareConfig    `mapstructure:"logger"`
+	Gzip      GzipMiddlewareConfig      `mapstructure:"gzip"`
+	Casbin    CasbinMiddlewareConfig    `mapstructure:"casbin"`
+	RateLimit RateLimitMiddlewareConfig `mapstructure:"rate_limit"`
+	BodyLimit BodyLimitMiddlewareConfig `mapstructure:"body_limit"`
+	Timeout   TimeoutMiddlewareConfig   `mapstructure:"timeout"`
+	Options   map[string]map[string]any `mapstructure:"options"`
+}
+
+type LoggerMiddlewareConfig struct {
+	Format string `mapstructure:"format"`
+}
+
+type GzipMiddlewareConfig struct {
+	// Level 压缩级别 1-9，0 表示默认
+	Level int `mapstructure:"level" validate:"gte=0,lte=9"`
+}
+
+// ForTenant 返回叠加了 tenant.overrides.<id> 的配置，未配置覆盖时即为 c 本身
+func (c Config) ForTenant(id string) Config {
+	override, ok := c.Tenant.Overrides[id]
+	if id == "" || !ok {
+		return c
+	}
+	// 覆盖项不能再修改租户配置本身
+	override.Tenant = TenantConfig{}
+	return Merge(c, override)
+}
+
+// DefaultAdminUsers middleware.casbin.admin_users 未配置时的管理员主体与角色
+var DefaultAdminUsers = []string{"root", "admin"}
+
+type CasbinMiddlewareConfig struct {
+	SkipPaths []string `mapstructure:"skip_paths"`
+	// AdminUsers 跳过权限检查的主体；持有其中任一角色的用户签发令牌时标记为管理员
+	AdminUsers []string `mapstructure:"admin_users"`
+	// DomainHeader 域模型下携带域（租户）的请求头，默认 X-Tenant-ID
+	DomainHeader string `mapstructure:"domain_header"`
+}
+
+// Admins 返回管理员主体与角色，未配置时为 DefaultAdminUsers
+func (c CasbinMiddlewareConfig) Admins() []string {
+	if len(c.AdminUsers) == 0 {
+		return DefaultAdminUsers
+	}
+	return c.AdminUsers
+}
+
+type RateLimitMiddlewareConfig struct {
+	// Requests 每个窗口允许的请求数
+	Requests int           `mapstructure:"requests" validate:"gte=0"`
+	Window   time.Duration `mapstructure:"window" validate:"gte=0"`
+	// Key 限流维度：ip（默认）或 user
+	Key string `mapstructure:"key" validate:"omitempty,oneof=ip user"`
+}
+
+type BodyLimitMiddlewareConfig struct {
+	// Limit 请求体大小上限，如 "2M"
+	Limit string `mapstructure:"limit"`
+}
+
+type TimeoutMiddlewareConfig struct {
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
 type CasbinConfig struct {
+	// Model 内联的模型定义，优先于 ModelFile
 	Model     string `mapstructure:"model"`
 	ModelFile string `mapstructure:"model_file"`
+	// Domains 两者均未配置时，使用内置的域（租户）RBAC 模型代替默认 RBAC 模型
+	Domains bool `mapstructure:"domains"`
+	// WatcherChannel 配置了 Redis 时用于在副本间广播策略变更的频道
+	WatcherChannel string `mapstructure:"watcher_channel"`
+}
+
+// AuthConfig 认证相关配置
+type AuthConfig struct {
+	Lockout LockoutConfig `mapstructure:"lockout"`
+	Mfa     MfaConfig     `mapstructure:"mfa"`
+	OIDC    OIDCConfig    `mapstructure:"oidc"`
+	// Verification 重置密码与邮箱验证
+	Verification VerificationConfig `mapstructure:"verification"`
+}
+
+// VerificationConfig 重置密码与邮箱验证链接，令牌经签名、一次性有效且只保存摘要
+type VerificationConfig struct {
+	// Secret 令牌签名密钥，默认由 jwt.secret 派生，两者都未配置时每次启动随机生成（多副本部署时必须配置）
+	Secret string `mapstructure:"secret"`
+	// ResetTTL 重置密码链接有效期，默认 30m
+	ResetTTL time.Duration `mapstructure:"reset_ttl"`
+	// VerifyTTL 邮箱验证链接有效期，默认 24h
+	VerifyTTL time.Duration `mapstructure:"verify_ttl"`
+	// ResetURL 前端重置密码页面，令牌以 token 查询参数附加
+	ResetURL string `mapstructure:"reset_url"`
+	// VerifyURL 前端邮箱验证页面，令牌以 token 查询参数附加
+	VerifyURL string `mapstructure:"verify_url"`
+}
+
+// NotifierConfig 通知投递
+type NotifierConfig struct {
+	// Driver log（默认，写入日志）、file（追加写入文件）或 smtp
+	Driver string `mapstructure:"driver" validate:"omitempty,oneof=log file smtp"`
+	// From 发件人地址
+	From string `mapstructure:"from"`
+	// FilePath driver = "file" 时写入的文件
+	FilePath string     `mapstructure:"file_path"`
+	SMTP     SMTPConfig `mapstructure:"smtp"`
+}
+
+// ConfigHistoryConfig 配置历史版本与自动回滚
+type ConfigHistoryConfig struct {
+	// Size 保留的历史版本数，默认 20
+	Size int `mapstructure:"size" validate:"gte=0"`
+	// AutoRollback 热更新后的宽限期内健康检查持续失败时，自动回滚到更新前的版本
+	AutoRollback bool `mapstructure:"auto_rollback"`
+	// GracePeriod 热更新后观察健康检查的时长，默认 1m
+	GracePeriod time.Duration `mapstructure:"grace_period" validate:"gte=0"`
+	// CheckInterval 宽限期内健康检查的间隔，默认 10s
+	CheckInterval time.Duration `mapstructure:"check_interval" validate:"gte=0"`
+}
+
+// SMTPConfig SMTP 服务器
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port" validate:"omitempty,min=1,max=65535"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// TLS 直接建立 TLS 连接（通常为 465 端口），否则在服务器支持时使用 STARTTLS
+	TLS bool `mapstructure:"tls"`
+	// Timeout 连接与发送的超时时间，默认 10s
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// OIDCConfig OpenID Connect 单点登录
+type OIDCConfig struct {
+	// StateTTL 跳转到身份提供方后完成授权的时限，默认 10m
+	StateTTL time.Duration `mapstructure:"state_ttl"`
+	// Providers 身份提供方，按 name 区分
+	Providers []OIDCProviderConfig `mapstructure:"providers"`
+}
+
+// OIDCProviderConfig 单个身份提供方，端点通过 {issuer}/.well-known/openid-configuration 发现
+type OIDCProviderConfig struct {
+	Name         string `mapstructure:"name"`
+	Issuer       string `mapstructure:"issuer"`
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	RedirectURL  string `mapstructure:"redirect_url"`
+	// Scopes 默认 openid、profile、email
+	Scopes []string `mapstructure:"scopes"`
+	// LinkByEmail 首次登录时按邮箱关联已有用户，身份提供方与本地账号两侧的邮箱都必须已验证
+	LinkByEmail bool `mapstructure:"link_by_email"`
+	// AutoCreate 首次登录且无法关联时自动创建用户
+	AutoCreate bool `mapstructure:"auto_create"`
+}
+
+// MfaConfig TOTP 两步验证
+type MfaConfig struct {
+	// Issuer 身份验证器 App 中显示的服务名称，默认使用 jwt.issuer
+	Issuer string `mapstructure:"issuer"`
+	// ChallengeTTL 密码校验通过后完成第二步的时限，默认 5m
+	ChallengeTTL time.Duration `mapstructure:"challenge_ttl"`
+	// RecoveryCodes 启用时生成的恢复码数量，默认 10
+	RecoveryCodes int `mapstructure:"recovery_codes"`
+}
+
+// LockoutConfig 登录失败退避与锁定，按账号和来源 IP 分别计数
+type LockoutConfig struct {
+	// MaxAttempts 账号在统计窗口内失败达到该次数后锁定，默认 5
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// IPMaxAttempts 同一 IP 在统计窗口内失败达到该次数后拒绝其登录，默认 20
+	IPMaxAttempts int `mapstructure:"ip_max_attempts"`
+	// Window 失败计数的统计窗口，默认 15m
+	Window time.Duration `mapstructure:"window"`
+	// LockDuration 锁定时长，默认 15m
+	LockDuration time.Duration `mapstructure:"lock_duration"`
+	// BaseDelay 首次失败后的退避时间，此后每次失败翻倍，默认 1s
+	BaseDelay time.Duration `mapstructure:"base_delay"`
+	// MaxDelay 退避时间上限，默认 30s
+	MaxDelay time.Duration `mapstructure:"max_delay"`
+}
+
+// TenantConfig 多租户配置，由 tenant 中间件解析请求所属租户，数据层据此自动隔离
+type TenantConfig struct {
+	// Sources 租户来源：header、subdomain、claim，多个来源同时存在时必须一致
+	Sources []string `mapstructure:"sources" validate:"dive,oneof=header subdomain claim"`
+	// Header 携带租户的请求头，默认 X-Tenant-ID
+	Header string `mapstructure:"header"`
+	// BaseDomain 子域名来源的根域名，如 example.com 下 acme.example.com 解析为 acme
+	BaseDomain string `mapstructure:"base_domain"`
+	// Required 未解析出租户时拒绝请求
+	Required  bool     `mapstructure:"required"`
+	SkipPaths []string `mapstructure:"skip_paths"`
+	// Column 数据表中的租户字段，默认 tenant_id；含该字段的表查询时自动追加租户条件
+	Column string `mapstructure:"column"`
+	// Overrides 按租户 ID 覆盖的配置，通过 Store.ForTenant 读取；rate_limit 中间件据此按租户限流
+	Overrides map[string]Config `mapstructure:"overrides"`
 }
 
 type KafkaConfig struct {
-	Brokers  []string `mapstructure:"brokers"`
+	// Brokers 配置了 topic 时必填且不能为空列表，见 validateConfig
+	Brokers  []string `mapstructure:"brokers" validate:"omitempty,dive,hostname_port"`
 	ClientID string   `mapstructure:"client_id"`
 	Topic    string   `mapstructure:"topic"`
 }
@@ -167,6 +402,8 @@ type EtcdClientConfig struct {
 	Username           string   `mapstructure:"username"`
 	Password           string   `mapstructure:"password"`
 	DialTimeoutSeconds int      `mapstructure:"dial_timeout_seconds"`
+	// SnapshotPath 最近一次成功读取的本地快照，启动时 etcd 不可用则使用快照
+	SnapshotPath string `mapstructure:"snapshot_path"`
 }
 
 type ConsulClientConfig struct {
@@ -174,6 +411,34 @@ type ConsulClientConfig struct {
 	Token   string `mapstructure:"token"`
 	Key     string `mapstructure:"key"`
 	Format  string `mapstructure:"format"`
+	// SnapshotPath 最近一次成功读取的本地快照，启动时 consul 不可用则使用快照
+	SnapshotPath string `mapstructure:"snapshot_path"`
+}
+
+// ConfigSourcesConfig 通用的配置来源
+type ConfigSourcesConfig struct {
+	HTTP HTTPSourceConfig `mapstructure:"http"`
+	Dir  DirSourceConfig  `mapstructure:"dir"`
+}
+
+// HTTPSourceConfig 轮询 HTTP 接口读取配置，设置 url 后启用
+type HTTPSourceConfig struct {
+	URL string `mapstructure:"url" validate:"omitempty,url"`
+	// Format json|yaml|toml，为空时按 URL 路径的后缀判断
+	Format string `mapstructure:"format" validate:"omitempty,oneof=json yaml yml toml"`
+	// Token 以 Authorization: Bearer 发送
+	Token string `mapstructure:"token"`
+	// Interval 轮询间隔，默认 30s
+	Interval time.Duration `mapstructure:"interval" validate:"gte=0"`
+	// Timeout 单次请求超时，默认 10s
+	Timeout time.Duration `mapstructure:"timeout" validate:"gte=0"`
+	// SnapshotPath 最近一次成功读取的本地快照，启动时接口不可用则使用快照
+	SnapshotPath string `mapstructure:"snapshot_path"`
+}
+
+// DirSourceConfig 从目录读取配置，每个文件为一个键（Kubernetes Secret 卷），设置 path 后启用
+type DirSourceConfig struct {
+	Path string `mapstructure:"path"`
 }
 
 func InitConfig(configPath string) (err error) {
@@ -213,12 +478,16 @@ type WatchableSource interface {
 	Watch(ctx context.Context, onChange func(Config)) error
 }
 
-// NewCfgFrom 通过自定义 Source 加载 Config。
+// NewCfgFrom 通过自定义 Source 加载 Config，并展开其中的密钥引用。
 func NewCfgFrom(src Source) Config {
 	c, err := src.Load(context.Background())
 	if err != nil {
 		panic(err)
 	}
+	c, _, err = ResolveSecrets(c)
+	if err != nil {
+		panic(err)
+	}
 	return c
 }
 
@@ -1,12 +1,11 @@
 package configs
 
 import (
-	"bytes"
 	"context"
+	"log/slog"
 	"time"
 
 	"github.com/hashicorp/consul/api"
-	"github.com/spf13/viper"
 )
 
 // ConsulSource 从 Consul KV 读取配置，支持 json/yaml/toml 三种格式。
@@ -15,74 +14,92 @@ type ConsulSource struct {
 	Token   string
 	Key     string
 	Format  string // json|yaml|toml
+	// SnapshotPath 每次读取成功后保存的快照，启动时 Consul 不可用则使用快照；为空不保存
+	SnapshotPath string
+	// Monitor 记录读取状态，可为空
+	Monitor *SourceMonitor
 }
 
 func (c ConsulSource) Load(ctx context.Context) (Config, error) {
+	cfg, _, err := c.LoadKeys(ctx)
+	return cfg, err
+}
+
+// LoadKeys 加载配置并返回其中出现的键，Consul 不可用时回退到 SnapshotPath 中的快照
+func (c ConsulSource) LoadKeys(ctx context.Context) (Config, []string, error) {
 	cli, err := api.NewClient(&api.Config{Address: c.Address, Token: c.Token})
 	if err != nil {
-		return Config{}, err
-	}
-	pair, _, err := cli.KV().Get(c.Key, nil)
-	if err != nil || pair == nil {
-		return Config{}, err
-	}
-	switch c.Format {
-	case "json":
-		viper.SetConfigType("json")
-	case "yaml", "yml":
-		viper.SetConfigType("yaml")
-	default:
-		viper.SetConfigType("toml")
-	}
-	if err := viper.ReadConfig(bytes.NewBuffer(pair.Value)); err != nil {
-		return Config{}, err
+		return Config{}, nil, err
 	}
-	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
-		return Config{}, err
-	}
-	return cfg, nil
+	return c.remote().load(ctx, func(ctx context.Context) ([]byte, int64, error) {
+		pair, meta, err := cli.KV().Get(c.Key, (&api.QueryOptions{}).WithContext(ctx))
+		if err != nil {
+			return nil, 0, err
+		}
+		if pair == nil {
+			return nil, int64(meta.LastIndex), nil
+		}
+		return pair.Value, int64(pair.ModifyIndex), nil
+	})
 }
 
 // Watch 通过阻塞查询实现简单热更新
 func (c ConsulSource) Watch(ctx context.Context, onChange func(Config)) error {
+	return c.WatchKeys(ctx, func(cfg Config, _ []string) { onChange(cfg) })
+}
+
+// WatchKeys 同 Watch，同时回调其中出现的键。
+// 通过阻塞查询等待变更，Consul 不可用时按指数退避（带抖动）重试；Key 被删除时回调空配置。
+func (c ConsulSource) WatchKeys(ctx context.Context, onChange func(Config, []string)) error {
 	cli, err := api.NewClient(&api.Config{Address: c.Address, Token: c.Token})
 	if err != nil {
 		return err
 	}
+	r := c.remote()
 	go func() {
-		defer func() {}()
-		var index uint64
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			default:
-			}
-			q := &api.QueryOptions{WaitIndex: index, WaitTime: 5 * time.Minute}
+		bo := newBackoff()
+		// 从已加载的版本开始阻塞等待，不遗漏加载与 watch 之间的变更
+		index := uint64(c.Monitor.revision())
+		for ctx.Err() == nil {
+			q := (&api.QueryOptions{WaitIndex: index, WaitTime: 5 * time.Minute}).WithContext(ctx)
 			pair, meta, err := cli.KV().Get(c.Key, q)
-			if err != nil || pair == nil {
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				c.Monitor.failure(err)
+				wait := bo.next()
+				slog.Warn("consul config watch failed, retrying", slog.String("key", c.Key), slog.Duration("retry_in", wait), slog.Any("error", err))
+				sleepCtx(ctx, wait)
+				continue
+			}
+			bo.reset()
+			// 索引回退（如 Consul 重建）时从头开始
+			if meta.LastIndex < index {
+				index = 0
 				continue
 			}
 			if meta.LastIndex == index {
+				c.Monitor.success(int64(index))
 				continue
 			}
 			index = meta.LastIndex
-			switch c.Format {
-			case "json":
-				viper.SetConfigType("json")
-			case "yaml", "yml":
-				viper.SetConfigType("yaml")
-			default:
-				viper.SetConfigType("toml")
+
+			var data []byte
+			if pair != nil {
+				data = pair.Value
 			}
-			if err := viper.ReadConfig(bytes.NewBuffer(pair.Value)); err == nil {
-				var cfg Config
-				if err := viper.Unmarshal(&cfg); err == nil {
-					onChange(cfg)
-				}
+			if cfg, keys, err := r.apply(data, int64(index)); err == nil {
+				onChange(cfg, keys)
+			} else {
+				c.Monitor.failure(err)
+				slog.Error("decode consul config failed", slog.String("key", c.Key), slog.Any("error", err))
 			}
 		}
 	}()
 	return nil
 }
+
+func (c ConsulSource) remote() remote {
+	return remote{name: SourceConsul, format: c.Format, snapshot: c.SnapshotPath, monitor: c.Monitor}
+}
@@ -0,0 +1,161 @@
+package configs
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// redacted 敏感配置项在日志与变更记录中的占位值
+const redacted = "******"
+
+// FieldChange 单个配置键的变化
+type FieldChange struct {
+	Key string `json:"key"`
+	Old any    `json:"old"`
+	New any    `json:"new"`
+}
+
+// Diff 比较两份配置，返回值不同的叶子键（按键排序），切片与 map 整体比较
+func Diff(old, new Config) []FieldChange {
+	oldLeaves, newLeaves := leafValues(old), leafValues(new)
+	var changes []FieldChange
+	for key, nv := range newLeaves {
+		ov := oldLeaves[key]
+		if reflect.DeepEqual(ov.Interface(), nv.Interface()) {
+			continue
+		}
+		changes = append(changes, FieldChange{Key: key, Old: ov.Interface(), New: nv.Interface()})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// Redacted 返回隐藏了敏感值的副本：键名表明是密码、密钥等，或在 secrets（ResolveSecrets 返回的键）中
+func (c FieldChange) Redacted(secrets []string) FieldChange {
+	if newSecretSet(secrets).hides(c.Key) {
+		c.Old, c.New = redactValue(c.Old), redactValue(c.New)
+	}
+	return c
+}
+
+// Redact 返回隐藏了敏感字符串的配置副本，用于输出配置
+func Redact(c Config, secrets []string) Config {
+	set := newSecretSet(secrets)
+	rewriteStrings(reflect.ValueOf(&c).Elem(), "", func(s, path string) (string, bool) {
+		if s == "" || !set.hides(path) {
+			return "", false
+		}
+		return redacted, true
+	})
+	return c
+}
+
+// IsSensitiveKey 判断配置键是否保存密码、密钥、令牌等敏感信息
+func IsSensitiveKey(key string) bool {
+	name := key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		name = key[i+1:]
+	}
+	for _, s := range []string{"password", "secret", "token", "private_key"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	// jwt.keys、auth.oidc.providers 等结构体列表中含有私钥与客户端密钥
+	return key == "jwt.keys" || key == "auth.oidc.providers"
+}
+
+// secretSet 含有密钥的配置键
+type secretSet map[string]bool
+
+func newSecretSet(keys []string) secretSet {
+	set := make(secretSet, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set
+}
+
+// hides 键名敏感，或键本身、上级键、下级键中含有密钥
+func (s secretSet) hides(key string) bool {
+	if IsSensitiveKey(key) || s[key] {
+		return true
+	}
+	for k := range s {
+		if strings.HasPrefix(k, key+".") || strings.HasPrefix(key, k+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func redactValue(v any) any {
+	if reflect.ValueOf(v).IsZero() {
+		return v
+	}
+	return redacted
+}
+
+// changeSummary 变更摘要的日志属性，每个键一组 old/new，敏感值已隐藏
+func changeSummary(changes []FieldChange, secrets []string) slog.Attr {
+	attrs := make([]any, 0, len(changes))
+	for _, c := range changes {
+		c = c.Redacted(secrets)
+		attrs = append(attrs, slog.Group(c.Key, slog.Any("old", c.Old), slog.Any("new", c.New)))
+	}
+	return slog.Group("changes", attrs...)
+}
+
+// ToMap 把配置转换为以配置文件键名组织的嵌套 map，时长输出为 1m30s 形式，用于 JSON 输出
+func ToMap(c Config) map[string]any {
+	m, _ := toTree(reflect.ValueOf(c)).(map[string]any)
+	return m
+}
+
+func toTree(v reflect.Value) any {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(v.Int()).String()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if !isNestedStruct(v) {
+			return v.Interface()
+		}
+		m := make(map[string]any)
+		walkConfig(v, "", func(key string, f reflect.Value) bool {
+			m[key] = toTree(f)
+			return false
+		})
+		return m
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = toTree(v.Index(i))
+		}
+		return list
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = toTree(iter.Value())
+		}
+		return m
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return toTree(v.Elem())
+	default:
+		return v.Interface()
+	}
+}
@@ -0,0 +1,32 @@
+package configs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	old := Config{
+		Log:   LogConfig{Level: "info"},
+		JWT:   JWTConfig{Secret: "old-secret", SkipPaths: []string{"/a"}},
+		Mysql: MysqlConfig{Password: "p1", MaxOpenConns: 10},
+	}
+	cur := old
+	cur.Log.Level = "debug"
+	cur.JWT = JWTConfig{Secret: "new-secret", SkipPaths: []string{"/a", "/b"}}
+	cur.Mysql.MaxOpenConns = 0
+
+	changes := Diff(old, cur)
+	assert.Equal(t, []FieldChange{
+		{Key: "jwt.secret", Old: "old-secret", New: "new-secret"},
+		{Key: "jwt.skip_paths", Old: []string{"/a"}, New: []string{"/a", "/b"}},
+		{Key: "log.level", Old: "info", New: "debug"},
+		{Key: "mysql.max_open_conns", Old: 10, New: 0},
+	}, changes)
+	assert.Equal(t, FieldChange{Key: "jwt.secret", Old: redacted, New: redacted}, changes[0].Redacted(nil))
+	assert.Equal(t, changes[2], changes[2].Redacted(nil))
+	// 通过密钥引用设置的值同样隐藏
+	assert.Equal(t, redacted, changes[2].Redacted([]string{"log.level"}).New)
+	assert.Empty(t, Diff(cur, cur))
+}
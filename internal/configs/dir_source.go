@@ -0,0 +1,105 @@
+package configs
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// DirSource 从目录读取配置，每个文件为一个配置键：文件名为键路径（如 mysql.password），
+// 文件内容为值（去掉末尾换行），与 Kubernetes Secret/ConfigMap 挂载为卷时的目录结构一致。
+// 值按字段类型转换，切片以逗号分隔；以 . 开头的文件与子目录（如 Kubernetes 的 ..data）忽略。
+// 使用示例：
+//
+//	src := configs.DirSource{Path: "/etc/echo-admin/secrets"}
+type DirSource struct {
+	Path string
+}
+
+func (d DirSource) Load(ctx context.Context) (Config, error) {
+	c, _, err := d.LoadKeys(ctx)
+	return c, err
+}
+
+// LoadKeys 读取目录中的全部文件并返回其中出现的键
+func (d DirSource) LoadKeys(context.Context) (Config, []string, error) {
+	if _, err := os.ReadDir(d.Path); err != nil {
+		return Config{}, nil, err
+	}
+	files := make(map[string][]byte)
+	for _, path := range d.files() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, nil, err
+		}
+		files[path] = data
+	}
+	return decodeDir(files)
+}
+
+// Watch 监听目录，文件新建、删除或内容变化后回调新的 Config
+func (d DirSource) Watch(ctx context.Context, onChange func(Config)) error {
+	return d.WatchKeys(ctx, func(c Config, _ []string) { onChange(c) })
+}
+
+// WatchKeys 同 Watch，同时回调其中出现的键。
+// 监听目录本身及文件符号链接的目标目录，Kubernetes 替换 ..data 符号链接的更新方式同样生效。
+func (d DirSource) WatchKeys(ctx context.Context, onChange func(Config, []string)) error {
+	fw, err := newDirWatcher(d.Path, d.files, func(files map[string][]byte) {
+		c, keys, err := decodeDir(files)
+		if err != nil {
+			slog.Error("decode config dir failed", slog.String("dir", d.Path), slog.Any("error", err))
+			return
+		}
+		onChange(c, keys)
+	})
+	if err != nil {
+		return err
+	}
+	go fw.run(ctx)
+	return nil
+}
+
+// files 返回目录中的配置文件，跟随符号链接，忽略隐藏文件与子目录
+func (d DirSource) files() []string {
+	entries, err := os.ReadDir(d.Path)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(d.Path, e.Name())
+		if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// decodeDir 以文件名为键、文件内容为值解码配置
+func decodeDir(files map[string][]byte) (Config, []string, error) {
+	v := viper.New()
+	for path, data := range files {
+		key := strings.ToLower(filepath.Base(path))
+		v.Set(key, strings.TrimRight(string(data), "\r\n"))
+	}
+	var c Config
+	if err := v.Unmarshal(&c); err != nil {
+		return Config{}, nil, err
+	}
+	keys := v.AllKeys()
+	if keys == nil {
+		keys = []string{}
+	}
+	return c, keys, nil
+}
@@ -0,0 +1,101 @@
+package configs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// secretVolume 按 Kubernetes Secret 卷的结构写入 dir：键文件指向 ..data/<键>，..data 指向带时间戳的目录
+func secretVolume(t *testing.T, dir, version string, files map[string]string) {
+	t.Helper()
+	data := filepath.Join(dir, "..ts_"+version)
+	require.NoError(t, os.Mkdir(data, 0o700))
+	for name, content := range files {
+		writeFile(t, filepath.Join(data, name), content)
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			require.NoError(t, os.Symlink(filepath.Join("..data", name), link))
+		}
+	}
+	tmp := filepath.Join(dir, "..data_tmp")
+	require.NoError(t, os.Symlink(filepath.Base(data), tmp))
+	require.NoError(t, os.Rename(tmp, filepath.Join(dir, "..data")))
+}
+
+func TestDirSource_LoadKeys(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "mysql.password"), "p@ss\n")
+	writeFile(t, filepath.Join(dir, "MYSQL.MAX_OPEN_CONNS"), "50")
+	writeFile(t, filepath.Join(dir, "cors.allow_credentials"), "false")
+	writeFile(t, filepath.Join(dir, "cors.allow_origins"), "https://a.example.com,https://b.example.com")
+	writeFile(t, filepath.Join(dir, ".hidden"), "x")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "log.level"), 0o700))
+
+	c, keys, err := DirSource{Path: dir}.LoadKeys(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "p@ss", c.Mysql.Password)
+	assert.Equal(t, 50, c.Mysql.MaxOpenConns)
+	assert.False(t, c.CORS.AllowCredentials)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, c.CORS.AllowOrigins)
+	assert.ElementsMatch(t, []string{"mysql.password", "mysql.max_open_conns", "cors.allow_credentials", "cors.allow_origins"}, keys)
+
+	_, _, err = DirSource{Path: filepath.Join(dir, "missing")}.LoadKeys(context.Background())
+	assert.Error(t, err)
+
+	writeFile(t, filepath.Join(dir, "MYSQL.MAX_OPEN_CONNS"), "abc")
+	_, _, err = DirSource{Path: dir}.LoadKeys(context.Background())
+	assert.Error(t, err)
+}
+
+func TestDirSource_WatchSecretVolume(t *testing.T) {
+	dir := t.TempDir()
+	secretVolume(t, dir, "1", map[string]string{"mysql.password": "old"})
+	src := DirSource{Path: dir}
+	c, _, err := src.LoadKeys(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "old", c.Mysql.Password)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := make(chan Config, 4)
+	require.NoError(t, src.Watch(ctx, func(c Config) { got <- c }))
+
+	expect := func(check func(Config) bool) {
+		t.Helper()
+		select {
+		case c := <-got:
+			assert.True(t, check(c), "%+v", c.Mysql)
+		case <-time.After(3 * time.Second):
+			t.Fatal("expected reload")
+		}
+	}
+
+	// 替换 ..data 符号链接更新全部键
+	secretVolume(t, dir, "2", map[string]string{"mysql.password": "new", "mysql.user": "app"})
+	expect(func(c Config) bool { return c.Mysql.Password == "new" && c.Mysql.User == "app" })
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "mysql.user")))
+	expect(func(c Config) bool { return c.Mysql.User == "" })
+}
+
+func TestFinalize_DirValuesAreSecrets(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "mysql.host"), "db.internal")
+	c, keys, err := DirSource{Path: dir}.LoadKeys(context.Background())
+	require.NoError(t, err)
+
+	layers := newSourceLayers(EnvSource{Prefix: DefaultEnvPrefix})
+	layers.set(SourceFile, Config{System: SystemConfig{Port: "8080"}}, nil)
+	layers.set(SourceDir, c, keys)
+	merged, sources, secrets, err := finalize(layers)
+	require.NoError(t, err)
+	assert.Equal(t, "db.internal", merged.Mysql.Host)
+	assert.Equal(t, SourceDir, sources["mysql.host"])
+	assert.Equal(t, []string{"mysql.host"}, secrets)
+}
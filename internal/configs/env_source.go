@@ -0,0 +1,247 @@
+package configs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// DefaultEnvPrefix Bootstrap 读取的环境变量前缀，如 APP_MYSQL_HOST 覆盖 mysql.host
+const DefaultEnvPrefix = "APP"
+
+// EnvSource 环境变量来源，在 Bootstrap 中优先级最高（文件 < etcd < consul < http < 目录 < 环境变量）。
+//
+// 变量名由前缀与 mapstructure 键路径组成，点号换成下划线并转为大写：
+//
+//	APP_MYSQL_HOST=db                      mysql.host
+//	APP_JWT_SKIP_PATHS=/a,/b               jwt.skip_paths，逗号分隔
+//	APP_CORS_ALLOW_ORIGINS=["*"]           以 [ 开头时按 JSON 数组解析
+//	APP_LOG_LOKI_LABELS=app=api,team=core  log.loki.labels，map 使用 k=v 逗号分隔，或 JSON 对象
+//	APP_AUTH_OIDC_PROVIDERS=[{"name":"corp","issuer":"..."}]
+//
+// 结构体切片与 map[string]Config 等复杂类型只能以 JSON 提供，JSON 字段名同配置文件中的键。
+// 设置为空字符串同样生效，可用于清空文件中的列表。
+type EnvSource struct {
+	// Prefix 变量名前缀，为空时不加前缀
+	Prefix string
+	// Environ 返回 KEY=VALUE 形式的环境变量，默认 os.Environ，便于测试注入
+	Environ func() []string
+}
+
+func (e EnvSource) Load(ctx context.Context) (Config, error) {
+	c, _, err := e.Overlay(Config{})
+	return c, err
+}
+
+// Overlay 把环境变量写入 base 的副本，返回结果与被覆盖的键（按字典序）。
+// 与 Merge 不同，环境变量可以把值改回零值，例如 APP_SYSTEM_DEBUG=false。
+func (e EnvSource) Overlay(base Config) (Config, []string, error) {
+	env := e.lookup()
+	out := base
+	var keys []string
+	var errs []error
+	walkConfig(reflect.ValueOf(&out).Elem(), "", func(key string, v reflect.Value) bool {
+		name := e.VarName(key)
+		raw, ok := env[name]
+		if !ok {
+			return true
+		}
+		if err := setFromString(v, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			return false
+		}
+		keys = append(keys, key)
+		return false
+	})
+	if len(errs) > 0 {
+		return base, nil, errors.Join(errs...)
+	}
+	sort.Strings(keys)
+	return out, keys, nil
+}
+
+// VarName 返回配置键对应的环境变量名
+func (e EnvSource) VarName(key string) string {
+	name := strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+	if e.Prefix == "" {
+		return name
+	}
+	return strings.ToUpper(e.Prefix) + "_" + name
+}
+
+func (e EnvSource) lookup() map[string]string {
+	environ := e.Environ
+	if environ == nil {
+		environ = os.Environ
+	}
+	env := make(map[string]string)
+	for _, kv := range environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+	return env
+}
+
+// walkConfig 按 mapstructure 键深度优先遍历结构体字段，fn 返回 false 时不再进入该字段
+func walkConfig(v reflect.Value, prefix string, fn func(key string, v reflect.Value) bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		fv := v.Field(i)
+		if !fn(key, fv) {
+			continue
+		}
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+			walkConfig(fv, key, fn)
+		}
+	}
+}
+
+// setFromString 按字段类型解析环境变量的值
+func setFromString(v reflect.Value, raw string) error {
+	trimmed := strings.TrimSpace(raw)
+	switch v.Kind() {
+	case reflect.Slice:
+		if strings.HasPrefix(trimmed, "[") {
+			return setFromJSON(v, trimmed)
+		}
+		var parts []string
+		if trimmed != "" {
+			parts = strings.Split(trimmed, ",")
+		}
+		s := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			if err := setScalar(s.Index(i), strings.TrimSpace(p)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	case reflect.Map:
+		if strings.HasPrefix(trimmed, "{") {
+			return setFromJSON(v, trimmed)
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("%s must be a JSON object", v.Type())
+		}
+		m := reflect.MakeMap(v.Type())
+		if trimmed != "" {
+			for _, pair := range strings.Split(trimmed, ",") {
+				k, val, ok := strings.Cut(pair, "=")
+				if !ok {
+					return fmt.Errorf("%q is not a key=value pair", pair)
+				}
+				elem := reflect.New(v.Type().Elem()).Elem()
+				if err := setScalar(elem, strings.TrimSpace(val)); err != nil {
+					return err
+				}
+				m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(k)).Convert(v.Type().Key()), elem)
+			}
+		}
+		v.Set(m)
+		return nil
+	case reflect.Struct, reflect.Interface:
+		return setFromJSON(v, trimmed)
+	default:
+		return setScalar(v, trimmed)
+	}
+}
+
+func setScalar(v reflect.Value, raw string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Ptr:
+		elem := reflect.New(v.Type().Elem())
+		if err := setScalar(elem.Elem(), raw); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(raw))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// setFromJSON 解析 JSON 后按 mapstructure 标签解码，与配置文件使用相同的键名
+func setFromJSON(v reflect.Value, raw string) error {
+	var data any
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return err
+	}
+	target := reflect.New(v.Type())
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           target.Interface(),
+		WeaklyTypedInput: true,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(data); err != nil {
+		return err
+	}
+	v.Set(target.Elem())
+	return nil
+}
@@ -0,0 +1,110 @@
+package configs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envOf(vars ...string) func() []string {
+	return func() []string { return vars }
+}
+
+func TestEnvSource_Overlay(t *testing.T) {
+	base := Config{
+		Mysql: MysqlConfig{Host: "file-host", Port: "3306"},
+		CORS:  CORSConfig{AllowCredentials: true, AllowOrigins: []string{"a", "b", "c"}},
+		JWT:   JWTConfig{SkipPaths: []string{"/api/health"}},
+	}
+	src := EnvSource{Prefix: "app", Environ: envOf(
+		"APP_MYSQL_HOST=env-host",
+		"APP_MYSQL_MAX_OPEN_CONNS=50",
+		"APP_CORS_ALLOW_CREDENTIALS=false",
+		"APP_CORS_ALLOW_ORIGINS=x, y",
+		"APP_JWT_SKIP_PATHS=",
+		"APP_AUTH_LOCKOUT_WINDOW=15m",
+		"APP_LOG_LOKI_LABELS=app=api,team=core",
+		`APP_AUTH_OIDC_PROVIDERS=[{"name":"corp","client_id":"app","scopes":["openid"],"link_by_email":true}]`,
+		`APP_TENANT_OVERRIDES={"acme":{"middleware":{"rate_limit":{"requests":1000,"window":"1m"}}}}`,
+		"OTHER_MYSQL_HOST=ignored",
+		"MYSQL_PORT=ignored",
+	)}
+
+	got, keys, err := src.Overlay(base)
+	require.NoError(t, err)
+	assert.Equal(t, "env-host", got.Mysql.Host)
+	assert.Equal(t, "3306", got.Mysql.Port)
+	assert.Equal(t, 50, got.Mysql.MaxOpenConns)
+	assert.False(t, got.CORS.AllowCredentials)
+	assert.Equal(t, []string{"x", "y"}, got.CORS.AllowOrigins)
+	assert.Empty(t, got.JWT.SkipPaths)
+	assert.Equal(t, 15*time.Minute, got.Auth.Lockout.Window)
+	assert.Equal(t, map[string]string{"app": "api", "team": "core"}, got.Log.Loki.Labels)
+	assert.Equal(t, []OIDCProviderConfig{{Name: "corp", ClientID: "app", Scopes: []string{"openid"}, LinkByEmail: true}}, got.Auth.OIDC.Providers)
+	assert.Equal(t, 1000, got.Tenant.Overrides["acme"].Middleware.RateLimit.Requests)
+	assert.Equal(t, time.Minute, got.Tenant.Overrides["acme"].Middleware.RateLimit.Window)
+	assert.Equal(t, []string{
+		"auth.lockout.window", "auth.oidc.providers", "cors.allow_credentials", "cors.allow_origins",
+		"jwt.skip_paths", "log.loki.labels", "mysql.host", "mysql.max_open_conns", "tenant.overrides",
+	}, keys)
+
+	// 原配置不受影响
+	assert.Equal(t, []string{"a", "b", "c"}, base.CORS.AllowOrigins)
+	assert.Equal(t, "APP_MIDDLEWARE_RATE_LIMIT_REQUESTS", src.VarName("middleware.rate_limit.requests"))
+}
+
+func TestEnvSource_Errors(t *testing.T) {
+	src := EnvSource{Prefix: "APP", Environ: envOf(
+		"APP_MYSQL_MAX_OPEN_CONNS=many",
+		"APP_CORS_ALLOW_CREDENTIALS=maybe",
+		"APP_AUTH_OIDC_PROVIDERS=corp",
+		"APP_LOG_LOKI_LABELS=app",
+	)}
+	base := Config{Mysql: MysqlConfig{Host: "file-host"}}
+	got, _, err := src.Overlay(base)
+	require.Error(t, err)
+	for _, name := range []string{"APP_MYSQL_MAX_OPEN_CONNS", "APP_CORS_ALLOW_CREDENTIALS", "APP_AUTH_OIDC_PROVIDERS", "APP_LOG_LOKI_LABELS"} {
+		assert.Contains(t, err.Error(), name)
+	}
+	assert.Equal(t, base, got)
+}
+
+func TestSourceLayers(t *testing.T) {
+	layers := newSourceLayers(EnvSource{Prefix: "APP", Environ: envOf("APP_JWT_SECRET=from-env")})
+	layers.set(SourceFile, Config{
+		Mysql: MysqlConfig{Host: "file-host", Port: "3306"},
+		JWT:   JWTConfig{Secret: "from-file", Expire: 60},
+	}, nil)
+	layers.set(SourceEtcd, Config{Mysql: MysqlConfig{Host: "etcd-host"}, JWT: JWTConfig{Secret: "from-etcd"}}, nil)
+	layers.set(SourceConsul, Config{Mysql: MysqlConfig{Port: "3307"}}, nil)
+
+	got, sources, err := layers.resolve()
+	require.NoError(t, err)
+	assert.Equal(t, "etcd-host", got.Mysql.Host)
+	assert.Equal(t, "3307", got.Mysql.Port)
+	assert.Equal(t, "from-env", got.JWT.Secret)
+	assert.Equal(t, SourceEtcd, sources["mysql.host"])
+	assert.Equal(t, SourceConsul, sources["mysql.port"])
+	assert.Equal(t, SourceEnv, sources["jwt.secret"])
+	assert.Equal(t, SourceFile, sources["jwt.expire"])
+	assert.Equal(t, SourceDefault, sources["redis.host"])
+
+	// 低优先级来源更新后环境变量依旧生效
+	layers.set(SourceFile, Config{Mysql: MysqlConfig{Host: "file-host-2"}, JWT: JWTConfig{Secret: "rotated"}}, nil)
+	got, sources, err = layers.resolve()
+	require.NoError(t, err)
+	assert.Equal(t, "etcd-host", got.Mysql.Host)
+	assert.Equal(t, "from-env", got.JWT.Secret)
+	assert.Equal(t, SourceDefault, sources["jwt.expire"])
+
+	store := NewStore(got)
+	store.setSources(sources)
+	list := store.Sources()
+	require.NotEmpty(t, list)
+	assert.Contains(t, list, KeySource{Key: "jwt.secret", Source: SourceEnv})
+	for i := 1; i < len(list); i++ {
+		assert.Less(t, list[i-1].Key, list[i].Key)
+	}
+}
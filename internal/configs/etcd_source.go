@@ -1,11 +1,12 @@
 package configs
 
 import (
-	"bytes"
 	"context"
+	"errors"
+	"log/slog"
 	"time"
 
-	"github.com/spf13/viper"
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
@@ -20,95 +21,141 @@ type EtcdSource struct {
 	Username           string
 	Password           string
 	DialTimeoutSeconds int
+	// SnapshotPath 每次读取成功后保存的快照，启动时 etcd 不可用则使用快照；为空不保存
+	SnapshotPath string
+	// Monitor 记录读取状态，可为空
+	Monitor *SourceMonitor
 }
 
 func (e EtcdSource) Load(ctx context.Context) (Config, error) {
-	dialTimeout := 5 * time.Second
-	if e.DialTimeoutSeconds > 0 {
-		dialTimeout = time.Duration(e.DialTimeoutSeconds) * time.Second
-	}
-	cli, err := clientv3.New(clientv3.Config{
-		Endpoints:   e.Endpoints,
-		Username:    e.Username,
-		Password:    e.Password,
-		DialTimeout: dialTimeout,
+	c, _, err := e.LoadKeys(ctx)
+	return c, err
+}
+
+// LoadKeys 加载配置并返回其中出现的键，etcd 不可用时回退到 SnapshotPath 中的快照
+func (e EtcdSource) LoadKeys(ctx context.Context) (Config, []string, error) {
+	return e.remote().load(ctx, func(ctx context.Context) ([]byte, int64, error) {
+		cli, err := e.client()
+		if err != nil {
+			return nil, 0, err
+		}
+		defer func() { _ = cli.Close() }()
+		return e.get(ctx, cli)
 	})
-	if err != nil {
-		return Config{}, err
-	}
-	defer func() { _ = cli.Close() }()
+}
 
-	cctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-	resp, err := cli.Get(cctx, e.Key)
-	if err != nil {
-		return Config{}, err
-	}
-	if len(resp.Kvs) == 0 {
-		return Config{}, nil
-	}
-	data := resp.Kvs[0].Value
+// Watch 支持 etcd 热更新：watch 指定 Key，变更后回调新的 Config
+func (e EtcdSource) Watch(ctx context.Context, onChange func(Config)) error {
+	return e.WatchKeys(ctx, func(c Config, _ []string) { onChange(c) })
+}
 
-	// 选择解析格式
-	switch e.Format {
-	case "json":
-		viper.SetConfigType("json")
-	case "yaml", "yml":
-		viper.SetConfigType("yaml")
-	default:
-		viper.SetConfigType("toml")
-	}
+// WatchKeys 同 Watch，同时回调其中出现的键。
+// 连接失败或 watch 中断时按指数退避（带抖动）重试，每次建立 watch 前重新读取一次以补上中断期间的变更；
+// Key 被删除时回调空配置。
+func (e EtcdSource) WatchKeys(ctx context.Context, onChange func(Config, []string)) error {
+	r := e.remote()
+	go func() {
+		bo := newBackoff()
+		var cli *clientv3.Client
+		defer func() {
+			if cli != nil {
+				_ = cli.Close()
+			}
+		}()
+		rev := e.Monitor.revision()
+		for ctx.Err() == nil {
+			if cli == nil {
+				c, err := e.client()
+				if err != nil {
+					e.retry(ctx, bo, err)
+					continue
+				}
+				cli = c
+			}
 
-	if err := viper.ReadConfig(bytes.NewBuffer(data)); err != nil {
-		return Config{}, err
-	}
-	var c Config
-	if err := viper.Unmarshal(&c); err != nil {
-		return Config{}, err
-	}
-	return c, nil
+			// 每次（重新）建立 watch 前读取一次，补上加载后或中断期间的变更
+			data, latest, err := e.get(ctx, cli)
+			if err != nil {
+				e.retry(ctx, bo, err)
+				continue
+			}
+			if latest != rev {
+				if c, keys, err := r.apply(data, latest); err == nil {
+					onChange(c, keys)
+				} else {
+					e.Monitor.failure(err)
+				}
+			} else {
+				e.Monitor.success(latest)
+			}
+			rev = latest
+
+			for wresp := range cli.Watch(clientv3.WithRequireLeader(ctx), e.Key, clientv3.WithRev(rev+1)) {
+				if err := wresp.Err(); err != nil {
+					e.Monitor.failure(err)
+					break
+				}
+				for _, ev := range wresp.Events {
+					var data []byte
+					if ev.Type == mvccpb.PUT {
+						data = ev.Kv.Value
+					}
+					rev = ev.Kv.ModRevision
+					if c, keys, err := r.apply(data, rev); err == nil {
+						onChange(c, keys)
+					} else {
+						e.Monitor.failure(err)
+						slog.Error("decode etcd config failed", slog.String("key", e.Key), slog.Any("error", err))
+					}
+				}
+				bo.reset()
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			e.retry(ctx, bo, errWatchClosed)
+		}
+	}()
+	return nil
 }
 
-// Watch 支持 etcd 热更新：watch 指定 Key，变更后回调新的 Config
-func (e EtcdSource) Watch(ctx context.Context, onChange func(Config)) error {
+// errWatchClosed watch 因连接异常或版本被压缩而中断
+var errWatchClosed = errors.New("etcd watch closed")
+
+func (e EtcdSource) retry(ctx context.Context, bo *backoff, err error) {
+	e.Monitor.failure(err)
+	wait := bo.next()
+	slog.Warn("etcd config watch failed, retrying", slog.String("key", e.Key), slog.Duration("retry_in", wait), slog.Any("error", err))
+	sleepCtx(ctx, wait)
+}
+
+func (e EtcdSource) remote() remote {
+	return remote{name: SourceEtcd, format: e.Format, snapshot: e.SnapshotPath, monitor: e.Monitor}
+}
+
+func (e EtcdSource) client() (*clientv3.Client, error) {
 	dialTimeout := 5 * time.Second
 	if e.DialTimeoutSeconds > 0 {
 		dialTimeout = time.Duration(e.DialTimeoutSeconds) * time.Second
 	}
-	cli, err := clientv3.New(clientv3.Config{
+	return clientv3.New(clientv3.Config{
 		Endpoints:   e.Endpoints,
 		Username:    e.Username,
 		Password:    e.Password,
 		DialTimeout: dialTimeout,
 	})
+}
+
+// get 读取 Key 的内容与 ModRevision，Key 不存在时返回空内容与集群当前版本
+func (e EtcdSource) get(ctx context.Context, cli *clientv3.Client) ([]byte, int64, error) {
+	cctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	resp, err := cli.Get(cctx, e.Key)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
-
-	go func() {
-		defer func() { _ = cli.Close() }()
-		rch := cli.Watch(ctx, e.Key)
-		for wresp := range rch {
-			for _, ev := range wresp.Events {
-				if ev.Kv == nil {
-					continue
-				}
-				switch e.Format {
-				case "json":
-					viper.SetConfigType("json")
-				case "yaml", "yml":
-					viper.SetConfigType("yaml")
-				default:
-					viper.SetConfigType("toml")
-				}
-				if err := viper.ReadConfig(bytes.NewBuffer(ev.Kv.Value)); err == nil {
-					var c Config
-					if err := viper.Unmarshal(&c); err == nil {
-						onChange(c)
-					}
-				}
-			}
-		}
-	}()
-	return nil
+	if len(resp.Kvs) == 0 {
+		return nil, resp.Header.Revision, nil
+	}
+	return resp.Kvs[0].Value, resp.Kvs[0].ModRevision, nil
 }
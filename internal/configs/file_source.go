@@ -3,10 +3,14 @@ package configs
 import (
 	"bytes"
 	"context"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
 
-	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 )
 
@@ -14,55 +18,117 @@ import (
 type FileSource struct{ Path string }
 
 func (f FileSource) Load(ctx context.Context) (Config, error) {
-	if err := viperInit(f.Path); err != nil {
-		return Config{}, err
+	c, _, err := f.LoadKeys(ctx)
+	return c, err
+}
+
+// LoadKeys 加载配置并返回文件中出现的键
+func (f FileSource) LoadKeys(ctx context.Context) (Config, []string, error) {
+	if f.Path == "" {
+		return f.decode(nil)
 	}
-	viper.SetEnvPrefix("ECHOADMIN")
-	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-	var c Config
-	if err := viper.Unmarshal(&c); err != nil {
-		return Config{}, err
+	content, err := os.ReadFile(f.Path)
+	if err != nil {
+		return Config{}, nil, err
 	}
-	return c, nil
+	return f.decode(content)
 }
 
 // Watch 支持本地文件热更新，变更后回调新的 Config
 func (f FileSource) Watch(ctx context.Context, onChange func(Config)) error {
+	return f.WatchKeys(ctx, func(c Config, _ []string) { onChange(c) })
+}
+
+// WatchKeys 同 Watch，同时回调文件中出现的键。
+// 监听文件所在目录，编辑器 rename 保存与 Kubernetes ConfigMap 的符号链接替换同样能触发更新；
+// 内容未变化时不回调，解码失败时记录错误并保留上一份配置。
+func (f FileSource) WatchKeys(ctx context.Context, onChange func(Config, []string)) error {
 	if f.Path == "" {
 		return nil
 	}
-	viper.SetConfigFile(f.Path)
-	viper.WatchConfig()
-	viper.OnConfigChange(func(e fsnotify.Event) {
-		var c Config
-		if err := viper.Unmarshal(&c); err == nil {
-			onChange(c)
+	fw, err := newFileWatcher([]string{f.Path}, func(files map[string][]byte) {
+		data, ok := files[f.Path]
+		if !ok {
+			// 文件被删除，保留上一份配置
+			return
+		}
+		c, keys, err := f.decode(data)
+		if err != nil {
+			slog.Error("decode config file failed", slog.String("file", f.Path), slog.Any("error", err))
+			return
 		}
+		onChange(c, keys)
 	})
+	if err != nil {
+		return err
+	}
+	go fw.run(ctx)
 	return nil
 }
 
+// fileMu 保护文件来源使用的全局 viper 实例
+var fileMu sync.Mutex
+
+// decode 通过全局 viper 解码文件内容（保留 ECHOADMIN_ 前缀环境变量的兼容行为），并返回其中出现的键
+func (f FileSource) decode(content []byte) (Config, []string, error) {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	if content != nil {
+		viper.SetConfigType(fileFormat(f.Path))
+		if err := viper.ReadConfig(bytes.NewReader(content)); err != nil {
+			return Config{}, nil, err
+		}
+	}
+	viper.SetEnvPrefix("ECHOADMIN")
+	viper.AutomaticEnv()
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	return unmarshalKeys()
+}
+
+// unmarshalKeys 解码 viper 当前读取的配置，并返回其中出现的键
+func unmarshalKeys() (Config, []string, error) {
+	var c Config
+	if err := viper.Unmarshal(&c); err != nil {
+		return Config{}, nil, err
+	}
+	return c, configKeys(viper.GetViper()), nil
+}
+
+// configKeys 返回 viper 中出现的键。AllKeys 展开时会丢掉空 map（如 labels = {}），
+// 这里补上该 map 的键，使显式配置的空 map 可以清空低优先级来源的同一 map
+func configKeys(v *viper.Viper) []string {
+	keys := v.AllKeys()
+	for key, f := range leafValues(Config{}) {
+		if f.Kind() != reflect.Map || !v.IsSet(key) || slices.ContainsFunc(keys, func(k string) bool {
+			return k == key || strings.HasPrefix(k, key+".")
+		}) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// fileFormat 根据文件后缀返回配置类型，默认 toml
+func fileFormat(path string) string {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")) {
+	case "json":
+		return "json"
+	case "yaml", "yml":
+		return "yaml"
+	default:
+		return "toml"
+	}
+}
+
 // viperInit 支持根据文件后缀自动设置配置类型
 func viperInit(configPath string) (err error) {
 	if configPath != "" {
-		// 根据文件后缀自动设置类型
-		ext := ""
-		if dot := strings.LastIndex(configPath, "."); dot >= 0 {
-			ext = strings.ToLower(configPath[dot+1:])
-		}
-		switch ext {
-		case "json":
-			viper.SetConfigType("json")
-		case "yaml", "yml":
-			viper.SetConfigType("yaml")
-		default:
-			viper.SetConfigType("toml")
-		}
 		content, err := os.ReadFile(configPath)
 		if err != nil {
 			return err
 		}
+		viper.SetConfigType(fileFormat(configPath))
 		return viper.ReadConfig(bytes.NewBuffer(content))
 	}
 	return nil
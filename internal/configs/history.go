@@ -0,0 +1,168 @@
+package configs
+
+import (
+	"errors"
+	"time"
+)
+
+// DefaultHistorySize 默认保留的历史版本数
+const DefaultHistorySize = 20
+
+// 历史版本的特殊来源，其余版本的来源为触发更新的配置来源（file、etcd、consul）
+const (
+	// SourceInitial 启动时加载的配置
+	SourceInitial = "initial"
+	// SourceUpdate 直接调用 Store.Update 产生的版本
+	SourceUpdate = "update"
+	// SourceRollback 回滚产生的版本
+	SourceRollback = "rollback"
+)
+
+// ErrVersionNotFound 历史版本不存在或已被淘汰
+var ErrVersionNotFound = errors.New("config version not found")
+
+// Version 配置的一个历史版本
+type Version struct {
+	// ID 版本号，从 1 开始递增
+	ID int64 `json:"id"`
+	// Source 触发该版本的来源
+	Source string `json:"source"`
+	// Revision 远程来源的版本（etcd ModRevision、consul ModifyIndex），其他来源为 0
+	Revision int64 `json:"revision"`
+	// RollbackOf 回滚产生的版本对应的目标版本
+	RollbackOf int64     `json:"rollback_of,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	// Changes 相对上一版本的变化，可能含有密钥，输出前使用 Redacted
+	Changes []FieldChange `json:"changes"`
+
+	config  Config
+	secrets []string
+}
+
+// Config 返回该版本的完整配置
+func (v Version) Config() Config {
+	return v.config
+}
+
+// Redacted 返回隐藏了敏感值的副本，新旧配置中的密钥都会隐藏
+func (v Version) Redacted(previous []string) Version {
+	hidden := append(append([]string(nil), previous...), v.secrets...)
+	changes := make([]FieldChange, len(v.Changes))
+	for i, c := range v.Changes {
+		changes[i] = c.Redacted(hidden)
+	}
+	v.Changes = changes
+	return v
+}
+
+// Versions 返回保留的历史版本，最新的在前
+func (s *Store) Versions() []Version {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]Version, len(s.history))
+	for i, v := range s.history {
+		list[len(list)-1-i] = v
+	}
+	return list
+}
+
+// Version 返回指定的历史版本
+func (s *Store) Version(id int64) (Version, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.version(id)
+}
+
+// RedactedVersions 返回隐藏了敏感值的历史版本，最新的在前
+func (s *Store) RedactedVersions() []Version {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]Version, len(s.history))
+	for i, v := range s.history {
+		var previous []string
+		if i > 0 {
+			previous = s.history[i-1].secrets
+		}
+		list[len(list)-1-i] = v.Redacted(previous)
+	}
+	return list
+}
+
+// Latest 返回当前生效的版本
+func (s *Store) Latest() Version {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.history) == 0 {
+		return Version{}
+	}
+	return s.history[len(s.history)-1]
+}
+
+// Rollback 以历史版本的配置生成一个新版本并通知订阅者，返回新版本；目标即当前版本时直接返回当前版本。
+// 回滚只修改运行中的配置，任一来源的下一次更新会重新合并全部来源，需要同时修正出问题的来源（如 etcd 中的配置）。
+func (s *Store) Rollback(id int64) (Version, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	target, ok := s.version(id)
+	if !ok {
+		return Version{}, ErrVersionNotFound
+	}
+	if latest := s.history[len(s.history)-1]; latest.ID == id {
+		return latest, nil
+	}
+	s.secrets = target.secrets
+	v, _ := s.commit(target.config, Version{Source: SourceRollback, RollbackOf: id})
+	return v, nil
+}
+
+func (s *Store) version(id int64) (Version, bool) {
+	for _, v := range s.history {
+		if v.ID == id {
+			return v, true
+		}
+	}
+	return Version{}, false
+}
+
+// commit 替换当前配置、记录历史版本并通知订阅者，调用方持有 s.mu。
+// 配置没有变化时不记录版本，返回 false。
+func (s *Store) commit(c Config, v Version) (Version, bool) {
+	old := s.Current()
+	s.v.Store(c)
+	changes := Diff(old, c)
+	if len(changes) == 0 && len(s.history) > 0 {
+		s.history[len(s.history)-1].secrets = s.secrets
+		return s.history[len(s.history)-1], false
+	}
+
+	s.nextID++
+	v.ID = s.nextID
+	v.Timestamp = time.Now()
+	v.Changes = changes
+	v.config = c
+	v.secrets = s.secrets
+	if v.Revision == 0 {
+		v.Revision = s.revisionOf(v.Source)
+	}
+	size := c.ConfigHistory.Size
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	s.history = append(s.history, v)
+	if len(s.history) > size {
+		s.history = append([]Version(nil), s.history[len(s.history)-size:]...)
+	}
+
+	s.notifyLocked(old, c, v)
+	return v, true
+}
+
+// revisionOf 返回远程来源当前的版本
+func (s *Store) revisionOf(source string) int64 {
+	for _, m := range s.monitors {
+		if st := m.Status(); st.Name == source {
+			return st.Revision
+		}
+	}
+	return 0
+}
@@ -0,0 +1,105 @@
+package configs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_History(t *testing.T) {
+	s := NewStore(Config{Log: LogConfig{Level: "info"}})
+	initial := s.Latest()
+	assert.Equal(t, int64(1), initial.ID)
+	assert.Equal(t, SourceInitial, initial.Source)
+
+	m := NewSourceMonitor(SourceEtcd)
+	m.success(42)
+	s.setMonitors([]*SourceMonitor{m})
+	c := s.Current()
+	c.Log.Level = "debug"
+	s.apply(c, SourceEtcd, nil)
+
+	v := s.Latest()
+	assert.Equal(t, int64(2), v.ID)
+	assert.Equal(t, SourceEtcd, v.Source)
+	assert.Equal(t, int64(42), v.Revision)
+	assert.Equal(t, []FieldChange{{Key: "log.level", Old: "info", New: "debug"}}, v.Changes)
+	assert.Equal(t, "debug", v.Config().Log.Level)
+
+	// 没有变化的更新不记录版本
+	s.Update(c)
+	assert.Len(t, s.Versions(), 2)
+	assert.Equal(t, []int64{2, 1}, versionIDs(s.Versions()))
+}
+
+func TestStore_HistorySize(t *testing.T) {
+	s := NewStore(Config{ConfigHistory: ConfigHistoryConfig{Size: 3}})
+	for _, port := range []string{"8081", "8082", "8083", "8084"} {
+		c := s.Current()
+		c.System.Port = port
+		s.Update(c)
+	}
+	assert.Equal(t, []int64{5, 4, 3}, versionIDs(s.Versions()))
+	_, ok := s.Version(1)
+	assert.False(t, ok)
+	_, err := s.Rollback(1)
+	assert.ErrorIs(t, err, ErrVersionNotFound)
+}
+
+func TestStore_Rollback(t *testing.T) {
+	s := NewStore(Config{Mysql: MysqlConfig{MaxOpenConns: 10}})
+	ch := s.Subscribe("mysql")
+	c := s.Current()
+	c.Mysql.MaxOpenConns = 1000
+	s.Update(c)
+	<-ch
+
+	v, err := s.Rollback(1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), v.ID)
+	assert.Equal(t, SourceRollback, v.Source)
+	assert.Equal(t, int64(1), v.RollbackOf)
+	assert.Equal(t, []FieldChange{{Key: "mysql.max_open_conns", Old: 1000, New: 10}}, v.Changes)
+	assert.Equal(t, 10, s.Current().Mysql.MaxOpenConns)
+
+	select {
+	case got := <-ch:
+		assert.Equal(t, 10, got.Mysql.MaxOpenConns)
+	case <-time.After(time.Second):
+		t.Fatal("rollback should notify subscribers")
+	}
+
+	// 回滚到当前版本不产生新版本
+	v, err = s.Rollback(3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), v.ID)
+	assert.Len(t, s.Versions(), 3)
+}
+
+func TestStore_RollbackRestoresSecrets(t *testing.T) {
+	s := NewStore(Config{Mysql: MysqlConfig{Host: "db-1"}})
+	s.setSecrets([]string{"mysql.host"})
+	c := s.Current()
+	c.Mysql.Host = "db-2"
+	s.apply(c, SourceFile, nil)
+	assert.Empty(t, s.SecretKeys())
+
+	// 前一版本中的密钥在变化记录中隐藏
+	v := s.RedactedVersions()[0]
+	assert.Equal(t, []FieldChange{{Key: "mysql.host", Old: redacted, New: redacted}}, v.Changes)
+
+	_, err := s.Rollback(1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mysql.host"}, s.SecretKeys())
+	assert.Equal(t, redacted, s.Redacted().Mysql.Host)
+}
+
+func versionIDs(versions []Version) []int64 {
+	ids := make([]int64, len(versions))
+	for i, v := range versions {
+		ids[i] = v.ID
+	}
+	return ids
+}
@@ -4,14 +4,10 @@ import (
 	"context"
 	"log/slog"
 	"sync"
-	"time"
-
-	"github.com/fsnotify/fsnotify"
 )
 
 // HotReloader 配置热重载器
 type HotReloader struct {
-	watcher    *fsnotify.Watcher
 	store      *Store
 	callbacks  []func(*Config)
 	mu         sync.RWMutex
@@ -22,15 +18,9 @@ type HotReloader struct {
 
 // NewHotReloader 创建配置热重载器
 func NewHotReloader(store *Store, configPath string) (*HotReloader, error) {
-	watcher, err := fsnotify.NewWatcher()
-	if err != nil {
-		return nil, err
-	}
-
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &HotReloader{
-		watcher:    watcher,
 		store:      store,
 		callbacks:  make([]func(*Config), 0),
 		ctx:        ctx,
@@ -39,13 +29,20 @@ func NewHotReloader(store *Store, configPath string) (*HotReloader, error) {
 	}, nil
 }
 
-// Watch 开始监听配置文件变化
+// Watch 开始监听配置文件变化。
+// 监听的是文件所在目录，rename 保存与 ConfigMap 符号链接替换同样生效，内容未变化时不重载
 func (hr *HotReloader) Watch(configPath string) error {
-	if err := hr.watcher.Add(configPath); err != nil {
+	fw, err := newFileWatcher([]string{configPath}, func(files map[string][]byte) {
+		// 文件被删除时保留当前配置
+		if _, ok := files[configPath]; ok {
+			hr.handleChange()
+		}
+	})
+	if err != nil {
 		return err
 	}
 
-	go hr.watchLoop()
+	go fw.run(hr.ctx)
 	return nil
 }
 
@@ -56,52 +53,26 @@ func (hr *HotReloader) AddCallback(callback func(*Config)) {
 	hr.callbacks = append(hr.callbacks, callback)
 }
 
-// watchLoop 监听循环
-func (hr *HotReloader) watchLoop() {
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-hr.ctx.Done():
-			return
-		case event, ok := <-hr.watcher.Events:
-			if !ok {
-				return
-			}
-			hr.handleEvent(event)
-		case err, ok := <-hr.watcher.Errors:
-			if !ok {
-				return
-			}
-			slog.Error("config watcher error", slog.Any("error", err))
-		case <-ticker.C:
-			// 定期检查，防止事件丢失
-		}
-	}
-}
-
-// handleEvent 处理文件变化事件
-func (hr *HotReloader) handleEvent(event fsnotify.Event) {
-	if event.Op&fsnotify.Write == fsnotify.Write {
-		slog.Info("config file changed, reloading...", slog.String("file", event.Name))
-
-		// 延迟重载，避免文件写入未完成
-		time.Sleep(100 * time.Millisecond)
+// handleChange 处理文件内容变化（已去抖）
+func (hr *HotReloader) handleChange() {
+	slog.Info("config file changed, reloading...", slog.String("file", hr.configPath))
 
-		if err := hr.reloadConfig(); err != nil {
-			slog.Error("failed to reload config", slog.Any("error", err))
-			return
-		}
-
-		slog.Info("config reloaded successfully")
+	if err := hr.reloadConfig(); err != nil {
+		slog.Error("failed to reload config", slog.Any("error", err))
+		return
 	}
+
+	slog.Info("config reloaded successfully")
 }
 
 // reloadConfig 重新加载配置
 func (hr *HotReloader) reloadConfig() error {
 	// 重新加载配置
 	config := NewCfg(hr.configPath)
+	// 校验失败时保留上一份有效配置
+	if err := config.Validate(); err != nil {
+		return err
+	}
 
 	// 更新存储
 	hr.store.Update(config)
@@ -129,7 +100,7 @@ func (hr *HotReloader) reloadConfig() error {
 // Close 关闭热重载器
 func (hr *HotReloader) Close() error {
 	hr.cancel()
-	return hr.watcher.Close()
+	return nil
 }
 
 // ConfigReloadCallback 配置重载回调函数类型
@@ -138,10 +109,7 @@ type ConfigReloadCallback func(*Config)
 // RegisterConfigReloadCallback 注册配置重载回调
 func (s *Store) RegisterConfigReloadCallback(callback ConfigReloadCallback) {
 	// 通过订阅机制实现配置重载回调
-	ch := s.Subscribe("*")
-	go func() {
-		for config := range ch {
-			callback(&config)
-		}
-	}()
+	_, _ = s.SubscribeFunc("*", func(c Change) {
+		callback(&c.Config)
+	})
 }
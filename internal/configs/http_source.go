@@ -0,0 +1,182 @@
+package configs
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	defaultHTTPInterval = 30 * time.Second
+	defaultHTTPTimeout  = 10 * time.Second
+	// maxHTTPConfigSize 响应体上限，避免异常响应占用过多内存
+	maxHTTPConfigSize = 4 << 20
+)
+
+// HTTPSource 轮询 HTTP 接口读取配置，支持 json/yaml/toml 三种格式。
+// 使用 ETag/If-None-Match 条件请求，配置未变化时服务端返回 304 即可；不支持 ETag 的服务端按内容哈希判断变化。
+// 使用示例：
+//
+//	src := configs.HTTPSource{URL: "https://config.example.com/echo-admin.toml", Token: "xxx"}
+type HTTPSource struct {
+	URL string
+	// Format json|yaml|toml，为空时按 URL 路径的后缀判断，默认 toml
+	Format string
+	// Token 非空时以 Authorization: Bearer 发送
+	Token string
+	// Interval 轮询间隔，默认 30s
+	Interval time.Duration
+	// Timeout 单次请求超时，默认 10s
+	Timeout time.Duration
+	// SnapshotPath 每次读取成功后保存的快照，启动时接口不可用则使用快照；为空不保存
+	SnapshotPath string
+	// Monitor 记录读取状态，可为空
+	Monitor *SourceMonitor
+	// Client 为空时使用 http.DefaultClient
+	Client *http.Client
+}
+
+// httpResponse 一次请求的结果，notModified 时 data 为空
+type httpResponse struct {
+	data        []byte
+	etag        string
+	notModified bool
+}
+
+func (h HTTPSource) Load(ctx context.Context) (Config, error) {
+	c, _, err := h.LoadKeys(ctx)
+	return c, err
+}
+
+// LoadKeys 加载配置并返回其中出现的键，接口不可用时回退到 SnapshotPath 中的快照
+func (h HTTPSource) LoadKeys(ctx context.Context) (Config, []string, error) {
+	return h.remote().load(ctx, func(ctx context.Context) ([]byte, int64, error) {
+		resp, err := h.fetch(ctx, "")
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.data, 0, nil
+	})
+}
+
+// Watch 按 Interval 轮询，内容变化后回调新的 Config
+func (h HTTPSource) Watch(ctx context.Context, onChange func(Config)) error {
+	return h.WatchKeys(ctx, func(c Config, _ []string) { onChange(c) })
+}
+
+// WatchKeys 同 Watch，同时回调其中出现的键。
+// 服务端返回 304 或内容未变化时不回调；请求失败时按指数退避（带抖动）重试；返回 404 时回调空配置。
+func (h HTTPSource) WatchKeys(ctx context.Context, onChange func(Config, []string)) error {
+	r := h.remote()
+	go func() {
+		bo := newBackoff()
+		var (
+			etag string
+			// hash 上一次回调的内容；第一次轮询的结果与加载时相同时同样回调，合并后没有变化不会记录
+			hash [sha256.Size]byte
+			wait = h.interval()
+		)
+		for sleepCtx(ctx, wait) {
+			resp, err := h.fetch(ctx, etag)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				h.Monitor.failure(err)
+				wait = bo.next()
+				slog.Warn("http config poll failed, retrying", slog.String("url", h.URL), slog.Duration("retry_in", wait), slog.Any("error", err))
+				continue
+			}
+			bo.reset()
+			wait = h.interval()
+			if resp.notModified {
+				h.Monitor.success(0)
+				continue
+			}
+			etag = resp.etag
+			sum := sha256.Sum256(resp.data)
+			if sum == hash {
+				h.Monitor.success(0)
+				continue
+			}
+			if c, keys, err := r.apply(resp.data, 0); err == nil {
+				hash = sum
+				onChange(c, keys)
+			} else {
+				h.Monitor.failure(err)
+				slog.Error("decode http config failed", slog.String("url", h.URL), slog.Any("error", err))
+			}
+		}
+	}()
+	return nil
+}
+
+func (h HTTPSource) remote() remote {
+	format := h.Format
+	if format == "" {
+		if u, err := url.Parse(h.URL); err == nil {
+			format = fileFormat(u.Path)
+		}
+	}
+	return remote{name: SourceHTTP, format: format, snapshot: h.SnapshotPath, monitor: h.Monitor}
+}
+
+func (h HTTPSource) interval() time.Duration {
+	if h.Interval > 0 {
+		return h.Interval
+	}
+	return defaultHTTPInterval
+}
+
+// fetch 请求配置，etag 非空时发送 If-None-Match；404 视为配置不存在，返回空内容
+func (h HTTPSource) fetch(ctx context.Context, etag string) (httpResponse, error) {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+	if err != nil {
+		return httpResponse{}, err
+	}
+	if h.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.Token)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return httpResponse{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return httpResponse{etag: etag, notModified: true}, nil
+	case http.StatusNotFound:
+		return httpResponse{}, nil
+	default:
+		return httpResponse{}, fmt.Errorf("fetch config from %s: unexpected status %s", h.URL, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPConfigSize+1))
+	if err != nil {
+		return httpResponse{}, err
+	}
+	if len(data) > maxHTTPConfigSize {
+		return httpResponse{}, fmt.Errorf("fetch config from %s: response exceeds %d bytes", h.URL, maxHTTPConfigSize)
+	}
+	return httpResponse{data: data, etag: resp.Header.Get("ETag")}, nil
+}
@@ -0,0 +1,115 @@
+package configs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configServer 返回可修改内容的配置接口，内容为空时返回 404
+type configServer struct {
+	mu          sync.Mutex
+	body, etag  string
+	notModified int
+}
+
+func (s *configServer) set(body, etag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body, s.etag = body, etag
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer t0ken" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.body == "" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Header.Get("If-None-Match") == s.etag {
+		s.notModified++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", s.etag)
+	_, _ = w.Write([]byte(s.body))
+}
+
+func TestHTTPSource_LoadKeys(t *testing.T) {
+	srv := &configServer{}
+	srv.set(`{"log":{"level":"debug"}}`, `"v1"`)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	snapshot := filepath.Join(t.TempDir(), "http.json")
+	src := HTTPSource{URL: ts.URL + "/config.json", Token: "t0ken", SnapshotPath: snapshot, Monitor: NewSourceMonitor(SourceHTTP)}
+	c, keys, err := src.LoadKeys(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "debug", c.Log.Level)
+	assert.Equal(t, []string{"log.level"}, keys)
+	assert.True(t, src.Monitor.Status().Healthy())
+
+	// 接口不可用时使用快照
+	ts.Close()
+	c, _, err = src.LoadKeys(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "debug", c.Log.Level)
+	assert.True(t, src.Monitor.Status().FromSnapshot)
+}
+
+func TestHTTPSource_Unauthorized(t *testing.T) {
+	ts := httptest.NewServer(&configServer{})
+	defer ts.Close()
+	_, _, err := HTTPSource{URL: ts.URL}.LoadKeys(context.Background())
+	assert.ErrorContains(t, err, "401")
+}
+
+func TestHTTPSource_Watch(t *testing.T) {
+	srv := &configServer{}
+	srv.set("[log]\nlevel = \"info\"\n", `"v1"`)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := make(chan []string, 8)
+	src := HTTPSource{URL: ts.URL, Token: "t0ken", Interval: 10 * time.Millisecond}
+	require.NoError(t, src.WatchKeys(ctx, func(c Config, keys []string) { got <- append(keys, c.Log.Level) }))
+
+	expect := func(want []string) {
+		t.Helper()
+		select {
+		case keys := <-got:
+			assert.Equal(t, want, keys)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("expected change %v", want)
+		}
+	}
+	expect([]string{"log.level", "info"})
+
+	// 内容未变化时服务端返回 304，不回调
+	assert.Eventually(t, func() bool {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		return srv.notModified >= 2
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Empty(t, got)
+
+	srv.set("[log]\nlevel = \"warn\"\n", `"v2"`)
+	expect([]string{"log.level", "warn"})
+
+	// 404 时为空配置
+	srv.set("", "")
+	expect([]string{""})
+}
@@ -0,0 +1,264 @@
+package configs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileExtensions 环境文件与本地文件支持的后缀
+var fileExtensions = []string{".toml", ".yaml", ".yml", ".json"}
+
+// localFileName 本机覆盖文件的名称（不含后缀），不提交到仓库
+const localFileName = "local"
+
+// LayeredFileSource 按环境分层的本地配置文件，优先级从低到高为：
+//
+//	configs/config.toml        基础配置（必须存在）
+//	configs/config.<env>.toml  环境配置，Env 为空时跳过
+//	configs/config.local.toml  本机覆盖，不提交到仓库
+//
+// 环境文件与本地文件与基础文件同目录、同名前缀，可以是 toml/yaml/json 中的任意一种，不存在时跳过，
+// 同一层存在多种后缀时报错。后加载的文件只覆盖其中实际出现的键；Env 非空时同时作为 system.env。
+type LayeredFileSource struct {
+	Path string
+	Env  string
+}
+
+// fileLayer 一个参与合并的配置文件
+type fileLayer struct {
+	path   string
+	config Config
+	keys   []string
+}
+
+func (f LayeredFileSource) Load(ctx context.Context) (Config, error) {
+	c, _, err := f.LoadKeys(ctx)
+	return c, err
+}
+
+// LoadKeys 按顺序合并全部文件，返回合并结果与其中出现的键
+func (f LayeredFileSource) LoadKeys(ctx context.Context) (Config, []string, error) {
+	files, err := f.load(nil)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	c, keys := f.merge(files)
+	return c, keys, nil
+}
+
+// Watch 任一文件变化（含环境文件、本地文件的新建与删除）后重新合并并回调
+func (f LayeredFileSource) Watch(ctx context.Context, onChange func(Config)) error {
+	return f.WatchKeys(ctx, func(c Config, _ []string) { onChange(c) })
+}
+
+// WatchKeys 同 Watch，同时回调其中出现的键
+func (f LayeredFileSource) WatchKeys(ctx context.Context, onChange func(Config, []string)) error {
+	return f.watch(ctx, func(files []fileLayer) {
+		onChange(f.merge(files))
+	})
+}
+
+// Files 返回当前参与合并的文件，按优先级从低到高排列
+func (f LayeredFileSource) Files() ([]string, error) {
+	return f.paths(func(p string) bool {
+		_, err := os.Stat(p)
+		return err == nil
+	})
+}
+
+// paths 返回基础文件与存在的环境文件、本地文件
+func (f LayeredFileSource) paths(exists func(string) bool) ([]string, error) {
+	paths := []string{f.Path}
+	for _, candidates := range f.overlays() {
+		path, err := pickFile(candidates, exists)
+		if err != nil {
+			return nil, err
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// overlays 返回环境文件与本地文件两层的候选路径
+func (f LayeredFileSource) overlays() [][]string {
+	dir := filepath.Dir(f.Path)
+	stem := strings.TrimSuffix(filepath.Base(f.Path), filepath.Ext(f.Path))
+	variant := func(name string) []string {
+		paths := make([]string, len(fileExtensions))
+		for i, ext := range fileExtensions {
+			paths[i] = filepath.Join(dir, stem+"."+name+ext)
+		}
+		return paths
+	}
+	var layers [][]string
+	if f.Env != "" && f.Env != localFileName {
+		layers = append(layers, variant(f.Env))
+	}
+	return append(layers, variant(localFileName))
+}
+
+// candidates 返回全部可能参与合并的文件，用于监听
+func (f LayeredFileSource) candidates() []string {
+	paths := []string{f.Path}
+	for _, layer := range f.overlays() {
+		paths = append(paths, layer...)
+	}
+	return paths
+}
+
+// pickFile 返回一层候选文件中存在的那个，都不存在时返回空字符串
+func pickFile(candidates []string, exists func(string) bool) (string, error) {
+	var found []string
+	for _, p := range candidates {
+		if exists(p) {
+			found = append(found, p)
+		}
+	}
+	if len(found) > 1 {
+		return "", fmt.Errorf("ambiguous config files %s, keep only one", strings.Join(found, ", "))
+	}
+	if len(found) == 0 {
+		return "", nil
+	}
+	return found[0], nil
+}
+
+// load 读取并解码参与合并的文件；contents 非空时使用其中的内容（监听回调读取的内容），否则读取磁盘
+func (f LayeredFileSource) load(contents map[string][]byte) ([]fileLayer, error) {
+	read := func(path string) ([]byte, error) {
+		if contents == nil {
+			return os.ReadFile(path)
+		}
+		data, ok := contents[path]
+		if !ok {
+			return nil, fmt.Errorf("open %s: %w", path, os.ErrNotExist)
+		}
+		return data, nil
+	}
+	var paths []string
+	var err error
+	if contents == nil {
+		paths, err = f.Files()
+	} else {
+		paths, err = f.paths(func(p string) bool {
+			_, ok := contents[p]
+			return ok
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]fileLayer, 0, len(paths))
+	for _, path := range paths {
+		data, err := read(path)
+		if err != nil {
+			return nil, err
+		}
+		c, keys, err := FileSource{Path: path}.decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		files = append(files, fileLayer{path: path, config: c, keys: keys})
+	}
+	return files, nil
+}
+
+// merge 按顺序合并文件，Env 非空时覆盖 system.env
+func (f LayeredFileSource) merge(files []fileLayer) (Config, []string) {
+	var c Config
+	var keys []string
+	for _, file := range files {
+		c = MergeKeys(c, file.config, file.keys)
+		keys = append(keys, file.keys...)
+	}
+	if f.Env != "" {
+		c.System.Env = f.Env
+		keys = append(keys, "system.env")
+	}
+	return c, keys
+}
+
+// origins 返回每个叶子键最终取值所在的文件
+func (f LayeredFileSource) origins(files []fileLayer) map[string]string {
+	leaves := leafValues(Config{})
+	origins := make(map[string]string)
+	var env string
+	for _, file := range files {
+		set := newKeySet(file.keys)
+		for key := range leaves {
+			if set.covers(key) || set.partial[key] {
+				origins[key] = file.path
+			}
+		}
+		if set.covers("system.env") {
+			env = file.config.System.Env
+		}
+	}
+	// system.env 由 Env 指定且与文件中的值不同时不来自文件
+	if f.Env != "" && f.Env != env {
+		delete(origins, "system.env")
+	}
+	return origins
+}
+
+// watch 监听全部候选文件，变化后重新读取并回调；读取失败时记录错误并保留上一份配置
+func (f LayeredFileSource) watch(ctx context.Context, onChange func([]fileLayer)) error {
+	if f.Path == "" {
+		return nil
+	}
+	fw, err := newFileWatcher(f.candidates(), func(contents map[string][]byte) {
+		if _, ok := contents[f.Path]; !ok {
+			// 基础文件被删除，保留上一份配置
+			return
+		}
+		files, err := f.load(contents)
+		if err != nil {
+			slog.Error("reload config files failed", slog.String("file", f.Path), slog.Any("error", err))
+			return
+		}
+		onChange(files)
+	})
+	if err != nil {
+		return err
+	}
+	go fw.run(ctx)
+	return nil
+}
+
+// filePaths 返回文件路径列表
+func filePaths(files []fileLayer) []string {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.path
+	}
+	return paths
+}
+
+// DetectEnv 返回运行环境：flag 非空时使用 flag，其次为环境变量 APP_SYSTEM_ENV，最后为基础文件中的 system.env
+func DetectEnv(flag, path string) string {
+	if flag != "" {
+		return flag
+	}
+	if v, ok := os.LookupEnv(EnvSource{Prefix: DefaultEnvPrefix}.VarName("system.env")); ok && v != "" {
+		return v
+	}
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	c, _, err := decodeConfig(data, fileFormat(path))
+	if err != nil {
+		return ""
+	}
+	return c.System.Env
+}
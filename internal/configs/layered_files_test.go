@@ -0,0 +1,145 @@
+package configs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// layeredDir 创建基础文件 config.toml 及其他文件，返回基础文件路径
+func layeredDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		writeFile(t, filepath.Join(dir, name), content)
+	}
+	return filepath.Join(dir, "config.toml")
+}
+
+func TestLayeredFileSource_Merge(t *testing.T) {
+	path := layeredDir(t, map[string]string{
+		"config.toml":       "[system]\nport = \"8080\"\nenv = \"dev\"\n[log]\nlevel = \"debug\"\n[cors]\nallow_credentials = true\n",
+		"config.prod.yaml":  "log:\n  level: warn\ncors:\n  allow_credentials: false\n",
+		"config.local.json": `{"system":{"port":"9090"}}`,
+		"config.test.toml":  "[log]\nlevel = \"info\"\n",
+	})
+	src := LayeredFileSource{Path: path, Env: "prod"}
+	dir := filepath.Dir(path)
+
+	files, err := src.Files()
+	require.NoError(t, err)
+	assert.Equal(t, []string{path, filepath.Join(dir, "config.prod.yaml"), filepath.Join(dir, "config.local.json")}, files)
+
+	c, keys, err := src.LoadKeys(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "9090", c.System.Port)
+	assert.Equal(t, "warn", c.Log.Level)
+	// 环境文件显式写出的 false 同样覆盖基础文件
+	assert.False(t, c.CORS.AllowCredentials)
+	assert.Equal(t, "prod", c.System.Env)
+	assert.Contains(t, keys, "cors.allow_credentials")
+
+	loaded, err := src.load(nil)
+	require.NoError(t, err)
+	origins := src.origins(loaded)
+	assert.Equal(t, filepath.Join(dir, "config.local.json"), origins["system.port"])
+	assert.Equal(t, filepath.Join(dir, "config.prod.yaml"), origins["log.level"])
+	assert.NotContains(t, origins, "system.env")
+}
+
+func TestLayeredFileSource_NoEnv(t *testing.T) {
+	path := layeredDir(t, map[string]string{
+		"config.toml":      "[system]\nenv = \"dev\"\n",
+		"config.prod.toml": "[log]\nlevel = \"warn\"\n",
+	})
+	c, _, err := LayeredFileSource{Path: path}.LoadKeys(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "dev", c.System.Env)
+	assert.Empty(t, c.Log.Level)
+}
+
+func TestLayeredFileSource_Ambiguous(t *testing.T) {
+	path := layeredDir(t, map[string]string{
+		"config.toml":       "",
+		"config.local.toml": "",
+		"config.local.yaml": "",
+	})
+	_, _, err := LayeredFileSource{Path: path}.LoadKeys(context.Background())
+	assert.ErrorContains(t, err, "ambiguous config files")
+}
+
+func TestDetectEnv(t *testing.T) {
+	path := layeredDir(t, map[string]string{"config.toml": "[system]\nenv = \"test\"\n"})
+	t.Setenv("APP_SYSTEM_ENV", "")
+
+	assert.Equal(t, "prod", DetectEnv("prod", path))
+	assert.Equal(t, "test", DetectEnv("", path))
+	assert.Equal(t, "", DetectEnv("", filepath.Join(t.TempDir(), "missing.toml")))
+
+	t.Setenv("APP_SYSTEM_ENV", "dev")
+	assert.Equal(t, "dev", DetectEnv("", path))
+	assert.Equal(t, "prod", DetectEnv("prod", path))
+}
+
+func TestSourceLayers_FileOrigins(t *testing.T) {
+	path := layeredDir(t, map[string]string{
+		"config.toml":       "[mysql]\nhost = \"base\"\nport = 3306\n",
+		"config.local.toml": "[mysql]\nhost = \"local\"\n",
+	})
+	t.Setenv("APP_MYSQL_PORT", "3307")
+	src := LayeredFileSource{Path: path}
+	files, err := src.load(nil)
+	require.NoError(t, err)
+
+	layers := newSourceLayers(EnvSource{Prefix: DefaultEnvPrefix})
+	layers.setFiles(src, files)
+	_, sources, err := layers.resolve()
+	require.NoError(t, err)
+	origins, loaded := layers.fileOrigins(sources)
+	assert.Equal(t, filePaths(files), loaded)
+
+	store := NewStore(Config{})
+	store.setSources(sources)
+	store.setFiles(origins, loaded)
+	byKey := make(map[string]KeySource)
+	for _, ks := range store.Sources() {
+		byKey[ks.Key] = ks
+	}
+	assert.Equal(t, KeySource{Key: "mysql.host", Source: SourceFile, File: filepath.Join(filepath.Dir(path), "config.local.toml")}, byKey["mysql.host"])
+	// 被环境变量覆盖的键不再指向文件
+	assert.Equal(t, KeySource{Key: "mysql.port", Source: SourceEnv}, byKey["mysql.port"])
+	assert.Equal(t, []string{path, filepath.Join(filepath.Dir(path), "config.local.toml")}, store.Files())
+}
+
+func TestLayeredFileSource_WatchLocalFile(t *testing.T) {
+	path := layeredDir(t, map[string]string{"config.toml": "[log]\nlevel = \"info\"\n"})
+	local := filepath.Join(filepath.Dir(path), "config.local.yaml")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := make(chan Config, 4)
+	require.NoError(t, LayeredFileSource{Path: path}.Watch(ctx, func(c Config) { got <- c }))
+
+	expect := func(level string) {
+		t.Helper()
+		select {
+		case c := <-got:
+			assert.Equal(t, level, c.Log.Level)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("expected reload with log.level %q", level)
+		}
+	}
+
+	// 新建本地文件后覆盖基础文件
+	writeFile(t, local, "log:\n  level: debug\n")
+	expect("debug")
+
+	// 删除后恢复基础文件的值
+	require.NoError(t, os.Remove(local))
+	expect("info")
+}
@@ -1,191 +1,127 @@
 package configs
 
-// Merge 将 src 非零值覆盖到 dst（浅合并，适合配置）
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Merge 将 src 中的非零值深度合并到 dst：结构体按 mapstructure 键逐字段递归，map 按键合并，切片整体替换。
+// 零值无法与"未设置"区分，需要把配置改回零值或 false 时使用 MergeKeys。
 func Merge(dst, src Config) Config {
-	// System
-	if src.System.Port != "" {
-		dst.System.Port = src.System.Port
-	}
-	if src.System.Level != 0 {
-		dst.System.Level = src.System.Level
-	}
-	// Log
-	if src.Log.Level != "" {
-		dst.Log.Level = src.Log.Level
-	}
-	if src.Log.Format != "" {
-		dst.Log.Format = src.Log.Format
-	}
-	// Console
-	if src.Log.Console.Format != "" {
-		dst.Log.Console.Format = src.Log.Console.Format
-	}
-	if src.Log.Console.Output != "" {
-		dst.Log.Console.Output = src.Log.Console.Output
-	}
-	// File
-	if src.Log.File.Filename != "" {
-		dst.Log.File.Filename = src.Log.File.Filename
-	}
-	if src.Log.File.MaxSize != 0 {
-		dst.Log.File.MaxSize = src.Log.File.MaxSize
-	}
-	if src.Log.File.MaxBackups != 0 {
-		dst.Log.File.MaxBackups = src.Log.File.MaxBackups
-	}
-	if src.Log.File.MaxAge != 0 {
-		dst.Log.File.MaxAge = src.Log.File.MaxAge
-	}
-	if src.Log.File.Compress {
-		dst.Log.File.Compress = true
-	}
-	if src.Log.File.Format != "" {
-		dst.Log.File.Format = src.Log.File.Format
-	}
-	// Elasticsearch
-	if src.Log.Elasticsearch.URL != "" {
-		dst.Log.Elasticsearch.URL = src.Log.Elasticsearch.URL
-	}
-	if src.Log.Elasticsearch.Index != "" {
-		dst.Log.Elasticsearch.Index = src.Log.Elasticsearch.Index
-	}
-	if src.Log.Elasticsearch.Timeout != 0 {
-		dst.Log.Elasticsearch.Timeout = src.Log.Elasticsearch.Timeout
-	}
-	// Loki
-	if src.Log.Loki.URL != "" {
-		dst.Log.Loki.URL = src.Log.Loki.URL
-	}
-	if len(src.Log.Loki.Labels) > 0 {
-		dst.Log.Loki.Labels = src.Log.Loki.Labels
-	}
-	if src.Log.Loki.Timeout != 0 {
-		dst.Log.Loki.Timeout = src.Log.Loki.Timeout
-	}
-	// Mysql
-	if src.Mysql.Host != "" {
-		dst.Mysql.Host = src.Mysql.Host
-	}
-	if src.Mysql.Port != "" {
-		dst.Mysql.Port = src.Mysql.Port
-	}
-	if src.Mysql.User != "" {
-		dst.Mysql.User = src.Mysql.User
-	}
-	if src.Mysql.Password != "" {
-		dst.Mysql.Password = src.Mysql.Password
-	}
-	if src.Mysql.Database != "" {
-		dst.Mysql.Database = src.Mysql.Database
-	}
-	if src.Mysql.DockerHost != "" {
-		dst.Mysql.DockerHost = src.Mysql.DockerHost
-	}
-	if src.Mysql.MaxOpenConns != 0 {
-		dst.Mysql.MaxOpenConns = src.Mysql.MaxOpenConns
-	}
-	if src.Mysql.MaxIdleConns != 0 {
-		dst.Mysql.MaxIdleConns = src.Mysql.MaxIdleConns
-	}
-	// Redis
-	if src.Redis.Host != "" {
-		dst.Redis.Host = src.Redis.Host
-	}
-	if src.Redis.Port != "" {
-		dst.Redis.Port = src.Redis.Port
-	}
-	if src.Redis.Password != "" {
-		dst.Redis.Password = src.Redis.Password
-	}
-	if src.Redis.Database != 0 {
-		dst.Redis.Database = src.Redis.Database
-	}
-	// Mongo
-	if src.Mongodb.Host != "" {
-		dst.Mongodb.Host = src.Mongodb.Host
-	}
-	if src.Mongodb.Port != "" {
-		dst.Mongodb.Port = src.Mongodb.Port
-	}
-	if src.Mongodb.User != "" {
-		dst.Mongodb.User = src.Mongodb.User
-	}
-	if src.Mongodb.Password != "" {
-		dst.Mongodb.Password = src.Mongodb.Password
-	}
-	if src.Mongodb.DataBase != "" {
-		dst.Mongodb.DataBase = src.Mongodb.DataBase
-	}
-	// Kafka
-	if len(src.Kafka.Brokers) > 0 {
-		dst.Kafka.Brokers = src.Kafka.Brokers
-	}
-	if src.Kafka.ClientID != "" {
-		dst.Kafka.ClientID = src.Kafka.ClientID
-	}
-	if src.Kafka.Topic != "" {
-		dst.Kafka.Topic = src.Kafka.Topic
-	}
-	// JWT
-	if src.JWT.Secret != "" {
-		dst.JWT.Secret = src.JWT.Secret
-	}
-	if src.JWT.Expire != 0 {
-		dst.JWT.Expire = src.JWT.Expire
-	}
-	if len(src.JWT.SkipPaths) > 0 {
-		dst.JWT.SkipPaths = src.JWT.SkipPaths
-	}
-	// CORS
-	if len(src.CORS.AllowOrigins) > 0 {
-		dst.CORS.AllowOrigins = src.CORS.AllowOrigins
-	}
-	if len(src.CORS.AllowHeaders) > 0 {
-		dst.CORS.AllowHeaders = src.CORS.AllowHeaders
-	}
-	if len(src.CORS.AllowMethods) > 0 {
-		dst.CORS.AllowMethods = src.CORS.AllowMethods
-	}
-	if src.CORS.AllowCredentials {
-		dst.CORS.AllowCredentials = true
-	}
-	// Casbin
-	if src.Casbin.Model != "" {
-		dst.Casbin.Model = src.Casbin.Model
-	}
-	if src.Casbin.ModelFile != "" {
-		dst.Casbin.ModelFile = src.Casbin.ModelFile
-	}
-	// Etcd/Consul 客户端配置
-	if len(src.Etcd.Endpoints) > 0 {
-		dst.Etcd.Endpoints = src.Etcd.Endpoints
-	}
-	if src.Etcd.Key != "" {
-		dst.Etcd.Key = src.Etcd.Key
-	}
-	if src.Etcd.Format != "" {
-		dst.Etcd.Format = src.Etcd.Format
-	}
-	if src.Etcd.Username != "" {
-		dst.Etcd.Username = src.Etcd.Username
-	}
-	if src.Etcd.Password != "" {
-		dst.Etcd.Password = src.Etcd.Password
+	return MergeKeys(dst, src, nonZeroKeys(src))
+}
+
+// MergeKeys 把 src 中 keys 列出的配置项深度合并到 dst，零值与 false 同样覆盖。
+// keys 为 mapstructure 键路径（如 mysql.host），可以是叶子键、上级键（覆盖整个子树），
+// 也可以是 map 内部的键（如 log.loki.labels.app，合并该 map）；列出 map 本身且 src 中为空时清空该 map。
+func MergeKeys(dst, src Config, keys []string) Config {
+	if len(keys) == 0 {
+		return dst
+	}
+	mergeStruct(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(src), "", newKeySet(keys))
+	return dst
+}
+
+func mergeStruct(dst, src reflect.Value, prefix string, set keySet) {
+	walkConfig(dst, prefix, func(key string, v reflect.Value) bool {
+		covered, partial := set.covers(key), set.partial[key]
+		if !covered && !partial {
+			return false
+		}
+		s := fieldByKey(src, strings.TrimPrefix(key, prefixDot(prefix)))
+		switch {
+		case isNestedStruct(v) && !covered:
+			mergeStruct(v, s, key, set)
+		case v.Kind() == reflect.Map && covered && s.Len() == 0:
+			// 显式设置的空 map 清空低优先级来源的条目
+			v.Set(s)
+		case v.Kind() == reflect.Map:
+			mergeMap(v, s)
+		default:
+			v.Set(s)
+		}
+		return false
+	})
+}
+
+// mergeMap 合并 map 条目，始终生成新 map，避免修改调用方持有的 dst
+func mergeMap(dst, src reflect.Value) {
+	if src.Len() == 0 {
+		return
 	}
-	if src.Etcd.DialTimeoutSeconds != 0 {
-		dst.Etcd.DialTimeoutSeconds = src.Etcd.DialTimeoutSeconds
+	m := reflect.MakeMapWithSize(dst.Type(), dst.Len()+src.Len())
+	for _, k := range dst.MapKeys() {
+		m.SetMapIndex(k, dst.MapIndex(k))
 	}
-	if src.Consul.Address != "" {
-		dst.Consul.Address = src.Consul.Address
+	for _, k := range src.MapKeys() {
+		m.SetMapIndex(k, src.MapIndex(k))
 	}
-	if src.Consul.Token != "" {
-		dst.Consul.Token = src.Consul.Token
+	dst.Set(m)
+}
+
+// fieldByKey 返回结构体中 mapstructure 键对应的字段
+func fieldByKey(v reflect.Value, name string) reflect.Value {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("mapstructure"), ",")
+		if tag == name || (tag == "" && strings.ToLower(t.Field(i).Name) == name) {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+func prefixDot(prefix string) string {
+	if prefix == "" {
+		return ""
 	}
-	if src.Consul.Key != "" {
-		dst.Consul.Key = src.Consul.Key
+	return prefix + "."
+}
+
+func isNestedStruct(v reflect.Value) bool {
+	return v.Kind() == reflect.Struct && v.Type() != reflect.TypeOf(time.Time{})
+}
+
+// nonZeroKeys 返回配置中取值非零的叶子键
+func nonZeroKeys(c Config) []string {
+	var keys []string
+	for key, v := range leafValues(c) {
+		// 空 map 与未设置同样视为零值，需要清空时使用 MergeKeys
+		if !v.IsZero() && (v.Kind() != reflect.Map || v.Len() > 0) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// keySet 来源设置的键：exact 为键本身，partial 为这些键的全部上级路径
+type keySet struct {
+	exact   map[string]bool
+	partial map[string]bool
+}
+
+func newKeySet(keys []string) keySet {
+	set := keySet{exact: make(map[string]bool, len(keys)), partial: make(map[string]bool)}
+	for _, key := range keys {
+		key = strings.ToLower(key)
+		set.exact[key] = true
+		for i := strings.LastIndex(key, "."); i > 0; i = strings.LastIndex(key[:i], ".") {
+			set.partial[key[:i]] = true
+		}
+	}
+	return set
+}
+
+// covers 键本身或其上级键被设置
+func (s keySet) covers(key string) bool {
+	if s.exact[key] {
+		return true
 	}
-	if src.Consul.Format != "" {
-		dst.Consul.Format = src.Consul.Format
+	for i := strings.LastIndex(key, "."); i > 0; i = strings.LastIndex(key[:i], ".") {
+		if s.exact[key[:i]] {
+			return true
+		}
 	}
-	return dst
+	return false
 }
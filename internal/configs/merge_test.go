@@ -0,0 +1,169 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fillLeaves 为每个叶子字段填入非零值
+func fillLeaves(t *testing.T, c *Config) {
+	t.Helper()
+	walkConfig(reflect.ValueOf(c).Elem(), "", func(key string, v reflect.Value) bool {
+		if isNestedStruct(v) {
+			return true
+		}
+		switch v.Kind() {
+		case reflect.String:
+			v.SetString(key)
+		case reflect.Bool:
+			v.SetBool(true)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(7)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v.SetUint(7)
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(7)
+		case reflect.Slice:
+			v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		case reflect.Map:
+			m := reflect.MakeMap(v.Type())
+			m.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), reflect.New(v.Type().Elem()).Elem())
+			v.Set(m)
+		default:
+			t.Fatalf("unhandled kind %s at %s", v.Kind(), key)
+		}
+		return false
+	})
+}
+
+func TestMerge_AllFields(t *testing.T) {
+	var src Config
+	fillLeaves(t, &src)
+
+	// 新增字段无需改动 Merge 即可合并
+	assert.Equal(t, src, Merge(Config{}, src))
+	// 零值不覆盖
+	assert.Equal(t, src, Merge(src, Config{}))
+}
+
+func TestMergeKeys(t *testing.T) {
+	dst := Config{
+		CORS:   CORSConfig{AllowCredentials: true, AllowOrigins: []string{"a", "b"}},
+		Mysql:  MysqlConfig{Host: "db", Port: "3306", MaxOpenConns: 10},
+		Redis:  RedisConfig{Host: "cache"},
+		Log:    LogConfig{Loki: LokiSinkConfig{Labels: map[string]string{"app": "api", "env": "dev"}}},
+		Tenant: TenantConfig{Overrides: map[string]Config{"acme": {System: SystemConfig{Port: ":1"}}}},
+	}
+	src := Config{
+		CORS:   CORSConfig{AllowOrigins: []string{"c"}},
+		Mysql:  MysqlConfig{Host: "db2"},
+		Log:    LogConfig{Loki: LokiSinkConfig{Labels: map[string]string{"env": "prod"}}},
+		Tenant: TenantConfig{Overrides: map[string]Config{"beta": {System: SystemConfig{Port: ":2"}}}},
+	}
+
+	got := MergeKeys(dst, src, []string{
+		"cors.allow_credentials", "cors.allow_origins", "mysql.max_open_conns",
+		"log.loki.labels.env", "tenant.overrides.beta.system.port",
+	})
+	assert.False(t, got.CORS.AllowCredentials, "显式设置的 false 覆盖 true")
+	assert.Equal(t, []string{"c"}, got.CORS.AllowOrigins)
+	assert.Equal(t, "db", got.Mysql.Host, "未列出的键保持不变")
+	assert.Equal(t, 0, got.Mysql.MaxOpenConns)
+	assert.Equal(t, "cache", got.Redis.Host)
+	assert.Equal(t, map[string]string{"app": "api", "env": "prod"}, got.Log.Loki.Labels)
+	assert.Len(t, got.Tenant.Overrides, 2)
+	// dst 持有的 map 不被修改
+	assert.Equal(t, "dev", dst.Log.Loki.Labels["env"])
+	assert.Len(t, dst.Tenant.Overrides, 1)
+
+	// 上级键覆盖整个子树
+	got = MergeKeys(dst, src, []string{"mysql"})
+	assert.Equal(t, MysqlConfig{Host: "db2"}, got.Mysql)
+
+	assert.Equal(t, dst, MergeKeys(dst, src, nil))
+
+	// 显式设置的空 map 清空 dst 中的条目；Merge 中空 map 视为未设置
+	empty := Config{Log: LogConfig{Loki: LokiSinkConfig{Labels: map[string]string{}}}}
+	assert.Empty(t, MergeKeys(dst, empty, []string{"log.loki.labels"}).Log.Loki.Labels)
+	assert.Equal(t, dst.Log.Loki.Labels, Merge(dst, empty).Log.Loki.Labels)
+	assert.Len(t, dst.Log.Loki.Labels, 2)
+}
+
+func TestSourceLayers_ClearMap(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) FileSource {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return FileSource{Path: path}
+	}
+
+	base, baseKeys, err := write("base.toml", `
+[log.loki]
+labels = { app = "api", env = "dev" }
+`).LoadKeys(t.Context())
+	require.NoError(t, err)
+	remote, remoteKeys, err := decodeConfig([]byte("[log.loki]\nlabels = {}\n"), "toml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"log.loki.labels"}, remoteKeys)
+
+	layers := newSourceLayers(EnvSource{Environ: envOf()})
+	layers.set(SourceFile, base, baseKeys)
+	got, _, err := layers.resolve()
+	require.NoError(t, err)
+	assert.Len(t, got.Log.Loki.Labels, 2)
+
+	// 高优先级来源显式配置空 map 时清空 log.loki.labels
+	layers.set(SourceEtcd, remote, remoteKeys)
+	got, sources, err := layers.resolve()
+	require.NoError(t, err)
+	assert.Empty(t, got.Log.Loki.Labels)
+	assert.Equal(t, SourceEtcd, sources["log.loki.labels"])
+}
+
+func TestSourceLayers_ExplicitZero(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) FileSource {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return FileSource{Path: path}
+	}
+
+	base, baseKeys, err := write("base.toml", `
+[cors]
+allow_credentials = true
+allow_origins = ["https://a.example.com"]
+
+[middleware.rate_limit]
+requests = 100
+window = "1m"
+`).LoadKeys(t.Context())
+	require.NoError(t, err)
+	remote, remoteKeys, err := write("remote.toml", `
+[cors]
+allow_credentials = false
+
+[middleware.rate_limit]
+requests = 0
+`).LoadKeys(t.Context())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"cors.allow_credentials", "middleware.rate_limit.requests"}, remoteKeys)
+
+	layers := newSourceLayers(EnvSource{Environ: envOf()})
+	layers.set(SourceFile, base, baseKeys)
+	layers.set(SourceEtcd, remote, remoteKeys)
+	got, sources, err := layers.resolve()
+	require.NoError(t, err)
+	assert.False(t, got.CORS.AllowCredentials)
+	assert.Equal(t, 0, got.Middleware.RateLimit.Requests)
+	assert.Equal(t, time.Minute, got.Middleware.RateLimit.Window)
+	assert.Equal(t, []string{"https://a.example.com"}, got.CORS.AllowOrigins)
+	assert.Equal(t, SourceEtcd, sources["cors.allow_credentials"])
+	assert.Equal(t, SourceFile, sources["cors.allow_origins"])
+	assert.Equal(t, SourceDefault, sources["cors.allow_headers"])
+}
@@ -0,0 +1,262 @@
+package configs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// SourceStatus 远程配置来源的状态，用于健康检查
+type SourceStatus struct {
+	Name string `json:"name"`
+	// LastSuccess 最近一次成功读取远程配置的时间
+	LastSuccess time.Time `json:"last_success,omitempty"`
+	// LastError 最近一次失败的原因，成功后保留以便排查
+	LastError   string    `json:"last_error,omitempty"`
+	LastErrorAt time.Time `json:"last_error_at,omitempty"`
+	// Revision 当前生效配置的版本：etcd 为 ModRevision，consul 为 ModifyIndex，http 为 0
+	Revision int64 `json:"revision"`
+	// FromSnapshot 远程不可用，当前生效的是本地快照
+	FromSnapshot bool `json:"from_snapshot"`
+}
+
+// Healthy 最近一次读取成功且未使用快照
+func (s SourceStatus) Healthy() bool {
+	if s.FromSnapshot {
+		return false
+	}
+	return s.LastError == "" || s.LastSuccess.After(s.LastErrorAt)
+}
+
+// SourceMonitor 记录远程来源的读取状态，并发安全；为 nil 时不记录
+type SourceMonitor struct {
+	mu     sync.RWMutex
+	status SourceStatus
+}
+
+// NewSourceMonitor 创建来源状态记录
+func NewSourceMonitor(name string) *SourceMonitor {
+	return &SourceMonitor{status: SourceStatus{Name: name}}
+}
+
+// Status 返回当前状态
+func (m *SourceMonitor) Status() SourceStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+func (m *SourceMonitor) revision() int64 {
+	if m == nil {
+		return 0
+	}
+	return m.Status().Revision
+}
+
+func (m *SourceMonitor) success(revision int64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status.LastSuccess = time.Now()
+	m.status.Revision = revision
+	m.status.FromSnapshot = false
+}
+
+func (m *SourceMonitor) failure(err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status.LastError = err.Error()
+	m.status.LastErrorAt = time.Now()
+}
+
+func (m *SourceMonitor) snapshot(revision int64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status.Revision = revision
+	m.status.FromSnapshot = true
+}
+
+// remoteFetch 读取远程原始内容与版本，配置不存在时返回 nil 内容
+type remoteFetch func(ctx context.Context) (data []byte, revision int64, err error)
+
+// remote 远程来源的公共部分：解码、快照与状态
+type remote struct {
+	name     string
+	format   string
+	snapshot string
+	monitor  *SourceMonitor
+}
+
+// load 读取远程配置，成功后保存快照；远程不可用时回退到快照，快照也不可用时返回远程的错误
+func (r remote) load(ctx context.Context, fetch remoteFetch) (Config, []string, error) {
+	data, rev, err := fetch(ctx)
+	if err == nil {
+		c, keys, err := r.apply(data, rev)
+		if err != nil {
+			r.monitor.failure(err)
+		}
+		return c, keys, err
+	}
+	r.monitor.failure(err)
+
+	snap, serr := readSnapshot(r.snapshot)
+	if serr != nil {
+		return Config{}, nil, err
+	}
+	c, keys, derr := decodeConfig(snap.Data, r.format)
+	if derr != nil {
+		return Config{}, nil, err
+	}
+	r.monitor.snapshot(snap.Revision)
+	slog.Warn("remote config unavailable, using last known good snapshot",
+		slog.String("source", r.name),
+		slog.String("snapshot", r.snapshot),
+		slog.Time("saved_at", snap.SavedAt),
+		slog.Int64("revision", snap.Revision),
+		slog.Any("error", err))
+	return c, keys, nil
+}
+
+// apply 解码远程内容，成功后记录状态并保存快照
+func (r remote) apply(data []byte, rev int64) (Config, []string, error) {
+	c, keys, err := decodeConfig(data, r.format)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	r.monitor.success(rev)
+	if err := writeSnapshot(r.snapshot, snapshot{Source: r.name, Format: r.format, Revision: rev, SavedAt: time.Now(), Data: data}); err != nil {
+		slog.Warn("save config snapshot failed", slog.String("source", r.name), slog.Any("error", err))
+	}
+	return c, keys, nil
+}
+
+// decodeConfig 使用独立的 viper 实例解码，避免多个来源并发修改全局实例。
+// 内容为空时返回空配置与空键列表（远程配置不存在或已删除）。
+func decodeConfig(data []byte, format string) (Config, []string, error) {
+	if len(data) == 0 {
+		return Config{}, []string{}, nil
+	}
+	v := viper.New()
+	switch format {
+	case "json":
+		v.SetConfigType("json")
+	case "yaml", "yml":
+		v.SetConfigType("yaml")
+	default:
+		v.SetConfigType("toml")
+	}
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return Config{}, nil, err
+	}
+	var c Config
+	if err := v.Unmarshal(&c); err != nil {
+		return Config{}, nil, err
+	}
+	return c, configKeys(v), nil
+}
+
+// snapshot 远程配置的本地快照，保存原始内容以便按相同格式解码
+type snapshot struct {
+	Source   string    `json:"source"`
+	Format   string    `json:"format"`
+	Revision int64     `json:"revision"`
+	SavedAt  time.Time `json:"saved_at"`
+	Data     []byte    `json:"data"`
+}
+
+// writeSnapshot 原子写入快照，path 为空时不保存。快照可能含有明文密码，权限为 0600。
+func writeSnapshot(path string, s snapshot) error {
+	if path == "" {
+		return nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(f.Name()) }()
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func readSnapshot(path string) (snapshot, error) {
+	if path == "" {
+		return snapshot{}, errors.New("snapshot disabled")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return snapshot{}, err
+	}
+	var s snapshot
+	if err := json.Unmarshal(b, &s); err != nil {
+		return snapshot{}, err
+	}
+	return s, nil
+}
+
+// backoff 带抖动的指数退避，每次等待时间在 [d/2, d] 之间随机，避免多个副本同时重连
+type backoff struct {
+	base, max time.Duration
+	attempt   int
+}
+
+func newBackoff() *backoff {
+	return &backoff{base: time.Second, max: time.Minute}
+}
+
+func (b *backoff) next() time.Duration {
+	d := b.max
+	if b.attempt < 30 {
+		if exp := b.base << b.attempt; exp < b.max {
+			d = exp
+		}
+	}
+	b.attempt++
+	return d/2 + rand.N(d/2+1)
+}
+
+func (b *backoff) reset() {
+	b.attempt = 0
+}
+
+// sleepCtx 等待 d，ctx 取消时返回 false
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,155 @@
+package configs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeConfig(t *testing.T) {
+	c, keys, err := decodeConfig([]byte("[system]\nport = \"8080\"\n"), "toml")
+	require.NoError(t, err)
+	assert.Equal(t, "8080", c.System.Port)
+	assert.Equal(t, []string{"system.port"}, keys)
+
+	c, keys, err = decodeConfig([]byte(`{"log":{"level":"debug"}}`), "json")
+	require.NoError(t, err)
+	assert.Equal(t, "debug", c.Log.Level)
+	assert.Equal(t, []string{"log.level"}, keys)
+
+	c, keys, err = decodeConfig([]byte("log:\n  level: warn\n"), "yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "warn", c.Log.Level)
+	assert.Equal(t, []string{"log.level"}, keys)
+
+	// 内容为空（Key 不存在或被删除）时为空配置
+	c, keys, err = decodeConfig(nil, "toml")
+	require.NoError(t, err)
+	assert.Equal(t, Config{}, c)
+	assert.Empty(t, keys)
+
+	_, _, err = decodeConfig([]byte("{"), "json")
+	assert.Error(t, err)
+}
+
+func TestDecodeConfig_Concurrent(t *testing.T) {
+	// 各来源使用独立实例，并发解码互不影响
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c, keys, err := decodeConfig([]byte(`{"log":{"level":"debug"}}`), "json")
+			assert.NoError(t, err)
+			assert.Equal(t, "debug", c.Log.Level)
+			assert.Equal(t, []string{"log.level"}, keys)
+		}()
+		go func() {
+			defer wg.Done()
+			c, keys, err := decodeConfig([]byte("[system]\nport = \"9090\"\n"), "toml")
+			assert.NoError(t, err)
+			assert.Equal(t, "9090", c.System.Port)
+			assert.Equal(t, []string{"system.port"}, keys)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRemote_LoadFallsBackToSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots", "etcd.json")
+	monitor := NewSourceMonitor(SourceEtcd)
+	r := remote{name: SourceEtcd, format: "toml", snapshot: path, monitor: monitor}
+	ctx := context.Background()
+
+	// 首次读取成功后保存快照
+	c, keys, err := r.load(ctx, func(context.Context) ([]byte, int64, error) {
+		return []byte("[log]\nlevel = \"debug\"\n"), 7, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "debug", c.Log.Level)
+	assert.Equal(t, []string{"log.level"}, keys)
+	assert.True(t, monitor.Status().Healthy())
+	assert.EqualValues(t, 7, monitor.Status().Revision)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// 远程不可用时使用快照
+	unavailable := errors.New("connection refused")
+	monitor = NewSourceMonitor(SourceEtcd)
+	r.monitor = monitor
+	c, keys, err = r.load(ctx, func(context.Context) ([]byte, int64, error) {
+		return nil, 0, unavailable
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "debug", c.Log.Level)
+	assert.Equal(t, []string{"log.level"}, keys)
+	status := monitor.Status()
+	assert.True(t, status.FromSnapshot)
+	assert.False(t, status.Healthy())
+	assert.EqualValues(t, 7, status.Revision)
+	assert.Equal(t, "connection refused", status.LastError)
+
+	// 恢复后不再标记为快照
+	_, _, err = r.load(ctx, func(context.Context) ([]byte, int64, error) {
+		return []byte("[log]\nlevel = \"info\"\n"), 8, nil
+	})
+	require.NoError(t, err)
+	status = monitor.Status()
+	assert.False(t, status.FromSnapshot)
+	assert.True(t, status.Healthy())
+	assert.EqualValues(t, 8, status.Revision)
+}
+
+func TestRemote_LoadWithoutSnapshot(t *testing.T) {
+	unavailable := errors.New("connection refused")
+	fetch := func(context.Context) ([]byte, int64, error) { return nil, 0, unavailable }
+
+	// 未配置快照
+	_, _, err := remote{format: "toml"}.load(context.Background(), fetch)
+	assert.ErrorIs(t, err, unavailable)
+
+	// 快照文件不存在
+	r := remote{format: "toml", snapshot: filepath.Join(t.TempDir(), "missing.json")}
+	_, _, err = r.load(context.Background(), fetch)
+	assert.ErrorIs(t, err, unavailable)
+}
+
+func TestBackoff(t *testing.T) {
+	b := &backoff{base: 100 * time.Millisecond, max: time.Second}
+	for i, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		want *= time.Millisecond
+		d := b.next()
+		assert.GreaterOrEqual(t, d, want/2, "attempt %d", i)
+		assert.LessOrEqual(t, d, want, "attempt %d", i)
+	}
+
+	b.reset()
+	d := b.next()
+	assert.GreaterOrEqual(t, d, 50*time.Millisecond)
+	assert.LessOrEqual(t, d, 100*time.Millisecond)
+}
+
+func TestStore_SourceStatuses(t *testing.T) {
+	s := NewStore(Config{})
+	assert.Empty(t, s.SourceStatuses())
+
+	m := NewSourceMonitor(SourceConsul)
+	s.setMonitors([]*SourceMonitor{m})
+	m.failure(errors.New("no leader"))
+	statuses := s.SourceStatuses()
+	require.Len(t, statuses, 1)
+	assert.Equal(t, SourceConsul, statuses[0].Name)
+	assert.False(t, statuses[0].Healthy())
+
+	m.success(3)
+	assert.True(t, s.SourceStatuses()[0].Healthy())
+}
@@ -0,0 +1,258 @@
+package configs
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// EncryptedPrefix 加密配置值的前缀，其后为 base64(nonce || AES-256-GCM 密文)
+	EncryptedPrefix = "enc:v1:"
+	// KeyEnv 保存解密密钥（base64 编码的 32 字节）的环境变量
+	KeyEnv = "APP_CONFIG_KEY"
+	// KeyFileEnv 指向解密密钥文件的环境变量，KeyEnv 未设置时使用
+	KeyFileEnv = "APP_CONFIG_KEY_FILE"
+)
+
+// secretRef 匹配 ${env:NAME} 与 ${file:/path}，$${ 转义为字面量 ${
+var secretRef = regexp.MustCompile(`\$?\$\{(env|file):([^}]*)\}`)
+
+// ResolveSecrets 展开配置中所有字符串值里的密钥引用并解密加密值，返回结果与含密钥的配置键。
+//
+//	password = "${env:MYSQL_PASSWORD}"          读取环境变量
+//	secret   = "${file:/run/secrets/jwt}"      读取文件内容（去掉末尾换行）
+//	password = "enc:v1:..."                    使用 APP_CONFIG_KEY 解密
+//
+// 引用可以嵌入在其他文本中（如 DSN），结构体列表与 map 中的值同样展开。
+// 返回的键不含切片下标，写入 Store 后用于在配置输出与日志中隐藏这些值。
+func ResolveSecrets(c Config) (Config, []string, error) {
+	r := &secretResolver{seen: make(map[string]bool)}
+	rewriteStrings(reflect.ValueOf(&c).Elem(), "", r.resolveString)
+	if len(r.errs) > 0 {
+		return Config{}, nil, errors.Join(r.errs...)
+	}
+	keys := make([]string, 0, len(r.seen))
+	for key := range r.seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return c, keys, nil
+}
+
+type secretResolver struct {
+	key  []byte
+	seen map[string]bool
+	errs []error
+}
+
+// rewriteStrings 递归遍历 v 中的字符串（含结构体列表、map 的值），fn 返回 true 时替换为新值。
+// path 为 mapstructure 键路径，切片不带下标，map 带条目键；map 会复制后再写回，不修改原 map。
+func rewriteStrings(v reflect.Value, path string, fn func(s, path string) (string, bool)) {
+	switch v.Kind() {
+	case reflect.String:
+		if s, ok := fn(v.String(), path); ok {
+			v.SetString(s)
+		}
+	case reflect.Struct:
+		if !isNestedStruct(v) {
+			return
+		}
+		walkConfig(v, path, func(key string, f reflect.Value) bool {
+			if isNestedStruct(f) {
+				return true
+			}
+			rewriteStrings(f, key, fn)
+			return false
+		})
+	case reflect.Slice:
+		if v.Len() == 0 || v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		// 复制后再写回，不修改来源持有的底层数组
+		s := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(s, v)
+		for i := 0; i < s.Len(); i++ {
+			rewriteStrings(s.Index(i), path, fn)
+		}
+		v.Set(s)
+	case reflect.Map:
+		if v.Len() == 0 {
+			return
+		}
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(iter.Value())
+			rewriteStrings(e, prefixDot(path)+fmt.Sprint(iter.Key().Interface()), fn)
+			m.SetMapIndex(iter.Key(), e)
+		}
+		v.Set(m)
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		e := reflect.New(v.Elem().Type()).Elem()
+		e.Set(v.Elem())
+		rewriteStrings(e, path, fn)
+		v.Set(e)
+	}
+}
+
+// resolveString 返回展开后的值，未包含引用时 ok 为 false
+func (r *secretResolver) resolveString(s, path string) (string, bool) {
+	if strings.HasPrefix(s, EncryptedPrefix) {
+		plain, err := r.decrypt(s)
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("%s: %w", path, err))
+			return "", false
+		}
+		r.seen[path] = true
+		return plain, true
+	}
+	if !strings.Contains(s, "${") {
+		return "", false
+	}
+	resolved := false
+	out := secretRef.ReplaceAllStringFunc(s, func(m string) string {
+		if strings.HasPrefix(m, "$$") {
+			return m[1:]
+		}
+		sub := secretRef.FindStringSubmatch(m)
+		val, err := lookupRef(sub[1], sub[2])
+		if err != nil {
+			r.errs = append(r.errs, fmt.Errorf("%s: %w", path, err))
+			return ""
+		}
+		resolved = true
+		return val
+	})
+	if resolved {
+		r.seen[path] = true
+	}
+	return out, out != s
+}
+
+func (r *secretResolver) decrypt(s string) (string, error) {
+	if r.key == nil {
+		key, err := LoadEncryptionKey()
+		if err != nil {
+			return "", err
+		}
+		r.key = key
+	}
+	return Decrypt(r.key, s)
+}
+
+func lookupRef(kind, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("empty ${%s:} reference", kind)
+	}
+	switch kind {
+	case "env":
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return v, nil
+	default:
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+}
+
+// LoadEncryptionKey 从 APP_CONFIG_KEY 或 APP_CONFIG_KEY_FILE 读取解密密钥
+func LoadEncryptionKey() ([]byte, error) {
+	raw, ok := os.LookupEnv(KeyEnv)
+	if !ok {
+		path := os.Getenv(KeyFileEnv)
+		if path == "" {
+			return nil, fmt.Errorf("encrypted value found but neither %s nor %s is set", KeyEnv, KeyFileEnv)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		raw = string(b)
+	}
+	return ParseEncryptionKey(raw)
+}
+
+// ParseEncryptionKey 解析 base64 编码的 32 字节密钥
+func ParseEncryptionKey(raw string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("config key must be base64 encoded: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("config key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// GenerateEncryptionKey 生成 base64 编码的随机密钥
+func GenerateEncryptionKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Encrypt 加密配置值，返回 enc:v1: 前缀的密文
+func Encrypt(key []byte, plain string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密 Encrypt 生成的密文
+func Decrypt(key []byte, value string) (string, error) {
+	data, ok := strings.CutPrefix(value, EncryptedPrefix)
+	if !ok {
+		return "", fmt.Errorf("value does not start with %s", EncryptedPrefix)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("decrypt failed, wrong key or corrupted value")
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
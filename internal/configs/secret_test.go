@@ -0,0 +1,111 @@
+package configs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	key, err := GenerateEncryptionKey()
+	require.NoError(t, err)
+	t.Setenv(KeyEnv, key)
+	raw, err := ParseEncryptionKey(key)
+	require.NoError(t, err)
+	encrypted, err := Encrypt(raw, "jwt-secret")
+	require.NoError(t, err)
+
+	secretFile := filepath.Join(t.TempDir(), "redis")
+	require.NoError(t, os.WriteFile(secretFile, []byte("redis-pass\n"), 0o600))
+	t.Setenv("TEST_MYSQL_PASSWORD", "mysql-pass")
+	t.Setenv("TEST_CLIENT_SECRET", "oidc-secret")
+
+	labels := map[string]string{"token": "${env:TEST_MYSQL_PASSWORD}", "app": "api"}
+	providers := []OIDCProviderConfig{{Name: "corp", ClientSecret: "${env:TEST_CLIENT_SECRET}"}}
+	src := Config{
+		Mysql:  MysqlConfig{Host: "db", Password: "${env:TEST_MYSQL_PASSWORD}"},
+		Redis:  RedisConfig{Password: "${file:" + secretFile + "}"},
+		JWT:    JWTConfig{Secret: encrypted},
+		Kafka:  KafkaConfig{ClientID: "app-$${env:LITERAL}"},
+		Log:    LogConfig{Loki: LokiSinkConfig{Labels: labels}},
+		Auth:   AuthConfig{OIDC: OIDCConfig{Providers: providers}},
+		System: SystemConfig{Env: "prefix-${env:TEST_MYSQL_PASSWORD}-suffix"},
+	}
+
+	c, keys, err := ResolveSecrets(src)
+	require.NoError(t, err)
+	assert.Equal(t, "mysql-pass", c.Mysql.Password)
+	assert.Equal(t, "redis-pass", c.Redis.Password)
+	assert.Equal(t, "jwt-secret", c.JWT.Secret)
+	assert.Equal(t, "app-${env:LITERAL}", c.Kafka.ClientID, "$${ 转义为字面量")
+	assert.Equal(t, "mysql-pass", c.Log.Loki.Labels["token"])
+	assert.Equal(t, "oidc-secret", c.Auth.OIDC.Providers[0].ClientSecret)
+	assert.Equal(t, "prefix-mysql-pass-suffix", c.System.Env)
+	assert.Equal(t, []string{
+		"auth.oidc.providers.client_secret", "jwt.secret", "log.loki.labels.token",
+		"mysql.password", "redis.password", "system.env",
+	}, keys)
+
+	// 不修改来源持有的 map 与切片
+	assert.Equal(t, "${env:TEST_MYSQL_PASSWORD}", labels["token"])
+	assert.Equal(t, "${env:TEST_CLIENT_SECRET}", providers[0].ClientSecret)
+
+	// 输出时隐藏
+	redactedCfg := Redact(c, keys)
+	assert.Equal(t, redacted, redactedCfg.System.Env)
+	assert.Equal(t, redacted, redactedCfg.Log.Loki.Labels["token"])
+	assert.Equal(t, "api", redactedCfg.Log.Loki.Labels["app"])
+	assert.Equal(t, redacted, redactedCfg.Auth.OIDC.Providers[0].ClientSecret)
+	assert.Equal(t, "corp", redactedCfg.Auth.OIDC.Providers[0].Name)
+	assert.Equal(t, "db", redactedCfg.Mysql.Host)
+	assert.Equal(t, "mysql-pass", c.Mysql.Password)
+}
+
+func TestResolveSecrets_Errors(t *testing.T) {
+	_, _, err := ResolveSecrets(Config{
+		Mysql: MysqlConfig{Password: "${env:TEST_UNSET_VARIABLE}"},
+		Redis: RedisConfig{Password: "${file:/nonexistent/secret}"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mysql.password: environment variable TEST_UNSET_VARIABLE is not set")
+	assert.Contains(t, err.Error(), "redis.password")
+
+	t.Setenv(KeyEnv, "")
+	_, _, err = ResolveSecrets(Config{JWT: JWTConfig{Secret: EncryptedPrefix + "AAAA"}})
+	assert.ErrorContains(t, err, "jwt.secret")
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key, err := GenerateEncryptionKey()
+	require.NoError(t, err)
+	raw, err := ParseEncryptionKey(key)
+	require.NoError(t, err)
+
+	enc, err := Encrypt(raw, "p@ss")
+	require.NoError(t, err)
+	assert.Contains(t, enc, EncryptedPrefix)
+	plain, err := Decrypt(raw, enc)
+	require.NoError(t, err)
+	assert.Equal(t, "p@ss", plain)
+
+	other, _ := GenerateEncryptionKey()
+	otherRaw, _ := ParseEncryptionKey(other)
+	_, err = Decrypt(otherRaw, enc)
+	assert.Error(t, err)
+
+	// 密钥从文件读取
+	t.Setenv(KeyEnv, "")
+	os.Unsetenv(KeyEnv)
+	path := filepath.Join(t.TempDir(), "key")
+	require.NoError(t, os.WriteFile(path, []byte(key+"\n"), 0o600))
+	t.Setenv(KeyFileEnv, path)
+	loaded, err := LoadEncryptionKey()
+	require.NoError(t, err)
+	assert.Equal(t, raw, loaded)
+
+	_, err = ParseEncryptionKey("c2hvcnQ=")
+	assert.Error(t, err)
+}
@@ -0,0 +1,162 @@
+package configs
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 配置来源名称，按优先级从低到高排列
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEtcd    = "etcd"
+	SourceConsul  = "consul"
+	SourceHTTP    = "http"
+	SourceDir     = "dir"
+	SourceEnv     = "env"
+)
+
+// KeySource 配置键及其生效来源
+type KeySource struct {
+	Key    string `json:"key"`
+	Source string `json:"source"`
+	// File 来源为配置文件时所在的文件（基础文件、环境文件或本地文件）
+	File string `json:"file,omitempty"`
+}
+
+// KeyedSource 可选：报告来源中实际出现的配置键（mapstructure 路径）。
+// Bootstrap 按这些键合并，来源显式写出的零值与 false 也会覆盖低优先级来源；
+// 未实现该接口的来源按非零值合并。
+type KeyedSource interface {
+	LoadKeys(ctx context.Context) (Config, []string, error)
+	WatchKeys(ctx context.Context, onChange func(Config, []string)) error
+}
+
+// sourceLayer 来源最近一次加载的配置及其设置的键
+type sourceLayer struct {
+	config Config
+	keys   []string
+	// files、origins 仅配置文件来源使用：参与合并的文件与每个键所在的文件
+	files   []string
+	origins map[string]string
+}
+
+// sourceLayers 保存各来源最近一次加载的配置，按固定优先级合并：
+// 文件 < etcd < consul < http < 目录 < 环境变量。任一来源热更新时整体重新合并，
+// 保证环境变量等高优先级来源不会被低优先级来源的更新覆盖。
+type sourceLayers struct {
+	mu sync.Mutex
+	// reload 串行化热更新：更新来源、合并校验与写入 Store（或撤销）作为一个整体执行
+	reload sync.Mutex
+	env    EnvSource
+	layers map[string]sourceLayer
+}
+
+func newSourceLayers(env EnvSource) *sourceLayers {
+	return &sourceLayers{env: env, layers: make(map[string]sourceLayer)}
+}
+
+// set 记录来源的最新配置，keys 为 nil 时视为设置了全部非零值。
+// 返回的 undo 恢复该来源之前的配置，用于丢弃校验失败的更新。
+func (l *sourceLayers) set(source string, c Config, keys []string) (undo func()) {
+	if keys == nil {
+		keys = nonZeroKeys(c)
+	}
+	return l.put(source, sourceLayer{config: c, keys: keys})
+}
+
+// setFiles 记录分层配置文件的合并结果及每个键所在的文件，返回值同 set
+func (l *sourceLayers) setFiles(src LayeredFileSource, files []fileLayer) (undo func()) {
+	c, keys := src.merge(files)
+	return l.put(SourceFile, sourceLayer{config: c, keys: keys, files: filePaths(files), origins: src.origins(files)})
+}
+
+func (l *sourceLayers) put(source string, layer sourceLayer) (undo func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	prev, existed := l.layers[source]
+	l.layers[source] = layer
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if existed {
+			l.layers[source] = prev
+		} else {
+			delete(l.layers, source)
+		}
+	}
+}
+
+// resolve 合并全部来源，返回最终配置与每个键的生效来源
+func (l *sourceLayers) resolve() (Config, map[string]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var merged Config
+	leaves := leafValues(merged)
+	sources := make(map[string]string, len(leaves))
+	for key := range leaves {
+		sources[key] = SourceDefault
+	}
+	for _, name := range []string{SourceFile, SourceEtcd, SourceConsul, SourceHTTP, SourceDir} {
+		layer, ok := l.layers[name]
+		if !ok {
+			continue
+		}
+		merged = MergeKeys(merged, layer.config, layer.keys)
+		set := newKeySet(layer.keys)
+		for key := range leaves {
+			if set.covers(key) || set.partial[key] {
+				sources[key] = name
+			}
+		}
+	}
+	merged, envKeys, err := l.env.Overlay(merged)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	for _, key := range envKeys {
+		sources[key] = SourceEnv
+	}
+	return merged, sources, nil
+}
+
+// fileOrigins 返回生效来源为配置文件的键所在的文件，以及参与合并的全部文件
+func (l *sourceLayers) fileOrigins(sources map[string]string) (map[string]string, []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	layer := l.layers[SourceFile]
+	origins := make(map[string]string)
+	for key, source := range sources {
+		if file := layer.origins[key]; source == SourceFile && file != "" {
+			origins[key] = file
+		}
+	}
+	return origins, layer.files
+}
+
+// leafValues 以 mapstructure 键路径索引配置的叶子字段，切片与 map 视为叶子
+func leafValues(c Config) map[string]reflect.Value {
+	values := make(map[string]reflect.Value)
+	walkConfig(reflect.ValueOf(&c).Elem(), "", func(key string, v reflect.Value) bool {
+		if v.Kind() == reflect.Struct && v.Type() != reflect.TypeOf(time.Time{}) {
+			return true
+		}
+		values[key] = v
+		return false
+	})
+	return values
+}
+
+// sortedSources 转换为按键排序的列表
+func sortedSources(sources map[string]string) []KeySource {
+	list := make([]KeySource, 0, len(sources))
+	for key, source := range sources {
+		list = append(list, KeySource{Key: key, Source: source})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
@@ -1,20 +1,52 @@
 package configs
 
 import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // Store 提供原子读取/更新配置的能力，用于热更新场景
 type Store struct {
 	v    atomic.Value // holds Config
 	mu   sync.RWMutex
-	subs map[string][]chan Config // key="*" 表示全量
+	subs []*subscription
+	// sources 每个配置键的生效来源，由 Bootstrap 维护
+	sources map[string]string
+	// secrets 通过密钥引用或加密值设置的配置键
+	secrets []string
+	// fileOrigins 来源为配置文件的键所在的文件，files 参与合并的配置文件，由 Bootstrap 维护
+	fileOrigins map[string]string
+	files       []string
+	// monitors 远程来源的读取状态，由 Bootstrap 维护
+	monitors []*SourceMonitor
+	// history 历史版本，最新的在后，数量由 config_history.size 限制
+	history []Version
+	nextID  int64
+}
+
+// Change 订阅路径上的一次变更
+type Change struct {
+	// Key 订阅的路径，"*" 表示全量
+	Key string
+	// Old、New 路径对应的值：子树为结构体，叶子为字段值，"*" 时为 Config
+	Old, New any
+	// Config 更新后的完整配置
+	Config Config
+	// Version、Source 本次更新记录的历史版本及其来源，订阅者异步处理时不需要再读取最新版本
+	Version int64
+	Source  string
 }
 
 func NewStore(initial Config) *Store {
-	s := &Store{subs: make(map[string][]chan Config)}
+	s := &Store{}
 	s.v.Store(initial)
+	s.nextID = 1
+	s.history = []Version{{ID: 1, Source: SourceInitial, Timestamp: time.Now(), config: initial}}
 	return s
 }
 
@@ -23,23 +55,283 @@ func (s *Store) Current() Config {
 	return c
 }
 
+// Update 替换当前配置，并通知订阅路径发生变化的订阅者；配置有变化时记录为一个历史版本
 func (s *Store) Update(c Config) {
-	s.v.Store(c)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commit(c, Version{Source: SourceUpdate})
+}
+
+// apply 由 Bootstrap 在来源热更新后调用，同时更新含密钥的配置键
+func (s *Store) apply(c Config, source string, secrets []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secrets = secrets
+	s.commit(c, Version{Source: source})
+}
+
+// notifyLocked 通知订阅路径发生变化的订阅者，调用方持有 s.mu
+func (s *Store) notifyLocked(old, c Config, v Version) {
+	for _, sub := range s.subs {
+		oldV, newV := valueAt(old, sub.key), valueAt(c, sub.key)
+		if reflect.DeepEqual(oldV, newV) {
+			continue
+		}
+		sub.notify(Change{Key: sub.key, Old: oldV, New: newV, Config: c, Version: v.ID, Source: v.Source})
+	}
+}
+
+// Sources 返回每个配置键的生效来源（file、etcd、consul、env 或 default），按键排序；
+// 来源为配置文件时 File 为所在的文件
+func (s *Store) Sources() []KeySource {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	for _, ch := range s.subs["*"] {
+	list := sortedSources(s.sources)
+	for i := range list {
+		list[i].File = s.fileOrigins[list[i].Key]
+	}
+	return list
+}
+
+func (s *Store) setSources(sources map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sources = sources
+}
+
+// Files 返回参与合并的配置文件（基础文件、环境文件、本地文件），按优先级从低到高排列
+func (s *Store) Files() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.files
+}
+
+func (s *Store) setFiles(origins map[string]string, files []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fileOrigins = origins
+	s.files = files
+}
+
+// SecretKeys 返回通过 ${env:}、${file:} 或 enc:v1: 设置的配置键
+func (s *Store) SecretKeys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.secrets
+}
+
+// setSecrets 设置当前配置中含密钥的配置键，当前版本记录同一份键
+func (s *Store) setSecrets(keys []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secrets = keys
+	if n := len(s.history); n > 0 {
+		s.history[n-1].secrets = keys
+	}
+}
+
+// SourceStatuses 返回远程来源（etcd、consul）的读取状态，未配置远程来源时为空
+func (s *Store) SourceStatuses() []SourceStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	statuses := make([]SourceStatus, 0, len(s.monitors))
+	for _, m := range s.monitors {
+		statuses = append(statuses, m.Status())
+	}
+	return statuses
+}
+
+func (s *Store) setMonitors(monitors []*SourceMonitor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.monitors = monitors
+}
+
+// Redacted 返回隐藏了密码、密钥及密钥引用值的当前配置，用于输出与排查
+func (s *Store) Redacted() Config {
+	return Redact(s.Current(), s.SecretKeys())
+}
+
+// Subscribe 订阅配置更新，key 为 "*"（全量）或 mapstructure 键路径（如 log、jwt.skip_paths、
+// tenant.overrides.acme），仅在该子树变化时收到更新后的完整配置。
+// 通道只保留最新一次配置：消费不及时时旧值被新值替换，不会丢失最新配置。
+// key 不是合法路径时 panic。
+func (s *Store) Subscribe(key string) <-chan Config {
+	mustPath(key)
+	sub := &subscription{key: key, ch: make(chan Config, 1)}
+	s.add(sub)
+	return sub.ch
+}
+
+// Unsubscribe 取消 Subscribe 返回的订阅并关闭通道
+func (s *Store) Unsubscribe(ch <-chan Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, sub := range s.subs {
+		if sub.ch != nil && (<-chan Config)(sub.ch) == ch {
+			s.subs = append(s.subs[:i:i], s.subs[i+1:]...)
+			close(sub.ch)
+			return
+		}
+	}
+}
+
+// SubscribeFunc 以回调方式订阅 key 对应子树的变化，返回取消订阅的函数。
+// 每个订阅按顺序在独立的 goroutine 中回调；回调未返回期间的多次变化合并为一次，
+// Old 为合并前的旧值、New 为最新值，因此不会阻塞 Update，也不会丢失最新配置。
+func (s *Store) SubscribeFunc(key string, fn func(Change)) (unsubscribe func(), err error) {
+	if err := checkPath(key); err != nil {
+		return nil, err
+	}
+	sub := &subscription{key: key, fn: fn}
+	s.add(sub)
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for i, v := range s.subs {
+			if v == sub {
+				s.subs = append(s.subs[:i:i], s.subs[i+1:]...)
+				break
+			}
+		}
+		sub.close()
+	}, nil
+}
+
+func (s *Store) add(sub *subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs = append(s.subs, sub)
+}
+
+// ForTenant 返回当前配置叠加 tenant.overrides.<id> 后的结果。
+// 每次调用都读取最新配置，热更新后无需重新获取。
+func (s *Store) ForTenant(id string) Config {
+	return s.Current().ForTenant(id)
+}
+
+// subscription 单个订阅：ch 用于 Subscribe，fn 用于 SubscribeFunc
+type subscription struct {
+	key string
+	ch  chan Config
+	fn  func(Change)
+
+	mu      sync.Mutex
+	pending *Change
+	running bool
+	closed  bool
+}
+
+// notify 由 Update 在持有 Store 锁时调用，不会阻塞
+func (s *subscription) notify(c Change) {
+	if s.ch != nil {
+		// 缓冲已满时丢弃未消费的旧值；发送方由 Store 锁串行化，第二次发送必然成功
 		select {
-		case ch <- c:
+		case s.ch <- c.Config:
 		default:
+			select {
+			case <-s.ch:
+			default:
+			}
+			s.ch <- c.Config
+		}
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	if s.pending != nil {
+		c.Old = s.pending.Old
+	}
+	s.pending = &c
+	if !s.running {
+		s.running = true
+		go s.run()
+	}
+}
+
+func (s *subscription) run() {
+	for {
+		s.mu.Lock()
+		c := s.pending
+		s.pending = nil
+		if c == nil || s.closed {
+			s.running = false
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+		// 合并后又变回原值时无需回调
+		if !reflect.DeepEqual(c.Old, c.New) {
+			s.call(*c)
 		}
 	}
 }
 
-// Subscribe 订阅配置更新；key 目前支持 "*"（全量），预留子树订阅扩展
-func (s *Store) Subscribe(key string) <-chan Config {
+func (s *subscription) call(c Change) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("config subscriber panic", slog.String("key", c.Key), slog.Any("panic", r))
+		}
+	}()
+	s.fn(c)
+}
+
+func (s *subscription) close() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	ch := make(chan Config, 1)
-	s.subs[key] = append(s.subs[key], ch)
-	return ch
+	s.closed = true
+	s.pending = nil
+}
+
+// valueAt 返回路径对应的值，路径已由 checkPath 校验
+func valueAt(c Config, key string) any {
+	if key == "*" {
+		return c
+	}
+	v, _ := lookupPath(reflect.ValueOf(c), key)
+	return v.Interface()
+}
+
+func checkPath(key string) error {
+	if key == "*" {
+		return nil
+	}
+	_, err := lookupPath(reflect.ValueOf(Config{}), key)
+	return err
+}
+
+func mustPath(key string) {
+	if err := checkPath(key); err != nil {
+		panic(err)
+	}
+}
+
+// lookupPath 按 mapstructure 键路径取值，键为字符串的 map 可以继续按条目（区分大小写）取值，不存在的条目为零值
+func lookupPath(v reflect.Value, key string) (reflect.Value, error) {
+	if key == "" {
+		return reflect.Value{}, fmt.Errorf("empty config key")
+	}
+	for _, name := range strings.Split(key, ".") {
+		switch {
+		case isNestedStruct(v):
+			f := fieldByKey(v, strings.ToLower(name))
+			if !f.IsValid() {
+				return reflect.Value{}, fmt.Errorf("unknown config key %q", key)
+			}
+			v = f
+		case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+			e := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !e.IsValid() {
+				e = reflect.Zero(v.Type().Elem())
+			}
+			v = e
+		default:
+			return reflect.Value{}, fmt.Errorf("unknown config key %q", key)
+		}
+	}
+	return v, nil
 }
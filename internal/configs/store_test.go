@@ -0,0 +1,146 @@
+package configs
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_ForTenant(t *testing.T) {
+	s := NewStore(Config{
+		Middleware: MiddlewareConfig{RateLimit: RateLimitMiddlewareConfig{Requests: 100}},
+		Tenant: TenantConfig{
+			Overrides: map[string]Config{
+				"acme": {
+					Middleware: MiddlewareConfig{RateLimit: RateLimitMiddlewareConfig{Requests: 1000}},
+					Tenant:     TenantConfig{Required: true},
+				},
+			},
+		},
+	})
+
+	assert.Equal(t, 1000, s.ForTenant("acme").Middleware.RateLimit.Requests)
+	assert.False(t, s.ForTenant("acme").Tenant.Required)
+	assert.Equal(t, 100, s.ForTenant("other").Middleware.RateLimit.Requests)
+	assert.Equal(t, 100, s.ForTenant("").Middleware.RateLimit.Requests)
+
+	// 热更新后读取到新的覆盖项
+	c := s.Current()
+	c.Tenant.Overrides = map[string]Config{
+		"acme": {Middleware: MiddlewareConfig{RateLimit: RateLimitMiddlewareConfig{Requests: 10}}},
+	}
+	s.Update(c)
+	assert.Equal(t, 10, s.ForTenant("acme").Middleware.RateLimit.Requests)
+}
+
+func TestStore_SubscribeSubtree(t *testing.T) {
+	s := NewStore(Config{Log: LogConfig{Level: "info"}})
+	logCh := s.Subscribe("log")
+	allCh := s.Subscribe("*")
+
+	// 其他子树变化不通知 log 订阅者
+	c := s.Current()
+	c.JWT.SkipPaths = []string{"/api/health"}
+	s.Update(c)
+	assert.Empty(t, logCh)
+	assert.Len(t, allCh, 1)
+
+	// 未及时消费时只保留最新配置
+	for _, level := range []string{"debug", "warn"} {
+		c.Log.Level = level
+		s.Update(c)
+	}
+	require.Len(t, logCh, 1)
+	assert.Equal(t, "warn", (<-logCh).Log.Level)
+	assert.Equal(t, "warn", (<-allCh).Log.Level)
+
+	// 内容未变化的更新不通知
+	s.Update(c)
+	assert.Empty(t, logCh)
+
+	s.Unsubscribe(logCh)
+	_, ok := <-logCh
+	assert.False(t, ok)
+	c.Log.Level = "error"
+	s.Update(c)
+
+	assert.Panics(t, func() { s.Subscribe("log.nope") })
+}
+
+func TestStore_SubscribeFunc(t *testing.T) {
+	s := NewStore(Config{JWT: JWTConfig{SkipPaths: []string{"/a"}}})
+
+	var mu sync.Mutex
+	var changes []Change
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	unsubscribe, err := s.SubscribeFunc("jwt.skip_paths", func(c Change) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, c)
+	})
+	require.NoError(t, err)
+
+	c := s.Current()
+	c.JWT.SkipPaths = []string{"/b"}
+	s.Update(c)
+	<-started
+	for _, paths := range [][]string{{"/c"}, {"/d"}} {
+		c.JWT.SkipPaths = paths
+		s.Update(c) // 回调阻塞时 Update 不阻塞
+	}
+	c.Log.Level = "debug" // 其他子树的变化不触发回调
+	s.Update(c)
+	close(release)
+
+	// 第一次变化正在回调，其余两次合并为一次，且送达最新值
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(changes) == 2
+	}, time.Second, 5*time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, "jwt.skip_paths", changes[0].Key)
+	assert.Equal(t, []string{"/a"}, changes[0].Old)
+	assert.Equal(t, []string{"/b"}, changes[0].New)
+	assert.Equal(t, int64(2), changes[0].Version)
+	assert.Equal(t, SourceUpdate, changes[0].Source)
+	assert.Equal(t, []string{"/b"}, changes[1].Old)
+	assert.Equal(t, []string{"/d"}, changes[1].New)
+	assert.Equal(t, []string{"/d"}, changes[1].Config.JWT.SkipPaths)
+	// 合并后的通知携带最新一次变化的版本
+	assert.Equal(t, int64(4), changes[1].Version)
+	mu.Unlock()
+
+	unsubscribe()
+	c.JWT.SkipPaths = nil
+	s.Update(c)
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	assert.Len(t, changes, 2)
+	mu.Unlock()
+
+	_, err = s.SubscribeFunc("jwt.nope", func(Change) {})
+	assert.Error(t, err)
+}
+
+func TestStore_SubscribeMapEntry(t *testing.T) {
+	s := NewStore(Config{})
+	ch := s.Subscribe("tenant.overrides.Acme.middleware")
+
+	c := s.Current()
+	c.Tenant.Overrides = map[string]Config{"other": {System: SystemConfig{Port: ":1"}}}
+	s.Update(c)
+	assert.Empty(t, ch)
+
+	c.Tenant.Overrides = map[string]Config{"Acme": {Middleware: MiddlewareConfig{Pipeline: []string{"cors"}}}}
+	s.Update(c)
+	assert.Len(t, ch, 1)
+}
@@ -0,0 +1,142 @@
+package configs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// minProdJWTSecret 生产环境下 HMAC 签名密钥的最小长度
+const minProdJWTSecret = 32
+
+// logLevels 日志级别，与 log.parseLevel 一致（不区分大小写）
+var logLevels = map[string]bool{"debug": true, "info": true, "warn": true, "warning": true, "error": true}
+
+// FieldError 单个配置项的校验错误，Key 为 mapstructure 键路径（如 system.port）
+type FieldError struct {
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+// ValidationError 汇总配置中的全部校验错误
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid config:")
+	for _, f := range e.Fields {
+		b.WriteString("\n  ")
+		b.WriteString(f.Key)
+		b.WriteString(": ")
+		b.WriteString(f.Message)
+	}
+	return b.String()
+}
+
+var (
+	validateOnce sync.Once
+	configValid  *validator.Validate
+)
+
+// Validate 按 validate 标签与跨字段规则校验配置，一次返回全部错误（*ValidationError）。
+// Bootstrap 启动时校验失败直接退出，热更新时校验失败则丢弃本次更新，Store 保留上一份有效配置。
+func (c Config) Validate() error {
+	err := configValidator().Struct(c)
+	if err == nil {
+		return nil
+	}
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+	out := &ValidationError{Fields: make([]FieldError, 0, len(verrs))}
+	for _, fe := range verrs {
+		// 去掉根结构体名 Config.
+		_, key, _ := strings.Cut(fe.Namespace(), ".")
+		out.Fields = append(out.Fields, FieldError{Key: key, Message: fieldMessage(fe)})
+	}
+	return out
+}
+
+func configValidator() *validator.Validate {
+	validateOnce.Do(func() {
+		v := validator.New(validator.WithRequiredStructEnabled())
+		// 错误路径使用配置文件中的键名
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+			if name == "" {
+				return strings.ToLower(f.Name)
+			}
+			return name
+		})
+		_ = v.RegisterValidation("port", func(fl validator.FieldLevel) bool {
+			return validPort(fl.Field().String())
+		})
+		_ = v.RegisterValidation("loglevel", func(fl validator.FieldLevel) bool {
+			return logLevels[strings.ToLower(fl.Field().String())]
+		})
+		v.RegisterStructValidation(validateConfig, Config{})
+		configValid = v
+	})
+	return configValid
+}
+
+// validateConfig 依赖多个配置段的规则
+func validateConfig(sl validator.StructLevel) {
+	c := sl.Current().Interface().(Config)
+	// 生产环境使用 HMAC 签名时密钥长度不足容易被暴力破解
+	if c.System.Env == "prod" && len(c.JWT.Keys) == 0 && len(c.JWT.Secret) < minProdJWTSecret {
+		sl.ReportError(c.JWT.Secret, "jwt.secret", "Secret", "prodsecret", strconv.Itoa(minProdJWTSecret))
+	}
+	// required_with 把非 nil 的空切片视为已配置，配置文件中的 brokers = [] 需要单独检查
+	if c.Kafka.Topic != "" && len(c.Kafka.Brokers) == 0 {
+		sl.ReportError(c.Kafka.Brokers, "kafka.brokers", "Brokers", "required_with", "Topic")
+	}
+}
+
+// validPort 接受 8080、:8080 与 host:8080
+func validPort(s string) bool {
+	if strings.Contains(s, ":") {
+		var err error
+		if _, s, err = net.SplitHostPort(s); err != nil {
+			return false
+		}
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n > 0 && n <= 65535
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_with":
+		return fmt.Sprintf("is required when %s is set", strings.ToLower(fe.Param()))
+	case "port":
+		return fmt.Sprintf("%q is not a valid port, expected 8080, :8080 or host:8080", fe.Value())
+	case "loglevel":
+		return fmt.Sprintf("%q is not a valid log level, expected debug, info, warn or error", fe.Value())
+	case "oneof":
+		return fmt.Sprintf("%q is not one of %s", fe.Value(), strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "hostname_port":
+		return fmt.Sprintf("%q is not a valid host:port", fe.Value())
+	case "cidr|ip":
+		return fmt.Sprintf("%q is not a valid IP or CIDR", fe.Value())
+	case "prodsecret":
+		return fmt.Sprintf("must be at least %s characters in prod (or configure jwt.keys)", fe.Param())
+	case "gte", "min":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "lte", "max":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	default:
+		return fmt.Sprintf("failed on the %q rule", fe.Tag())
+	}
+}
@@ -0,0 +1,144 @@
+package configs
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validConfig() Config {
+	return Config{
+		System: SystemConfig{Port: ":9322", Env: "dev"},
+		Mysql:  MysqlConfig{Port: "3306", MaxOpenConns: 10},
+		Log:    LogConfig{Level: "INFO", Format: "json"},
+		JWT:    JWTConfig{Secret: "short"},
+	}
+}
+
+func TestValidate_OK(t *testing.T) {
+	assert.NoError(t, validConfig().Validate())
+
+	c := validConfig()
+	c.System.Port = "0.0.0.0:8080"
+	c.Kafka = KafkaConfig{Brokers: []string{"kafka:9092"}, Topic: "events"}
+	c.System.Env = "prod"
+	c.System.TrustedProxies = []string{"10.0.0.0/8", "127.0.0.1"}
+	c.JWT.Secret = "0123456789abcdef0123456789abcdef"
+	assert.NoError(t, c.Validate())
+
+	// 非对称签名不需要 secret
+	c.JWT = JWTConfig{Keys: []JWTKeyConfig{{Kid: "k1"}}}
+	assert.NoError(t, c.Validate())
+}
+
+func TestValidate_ReportsAllFields(t *testing.T) {
+	c := validConfig()
+	c.System.Port = ":99999"
+	c.System.Env = "prod"
+	c.Mysql.Port = "mysql"
+	c.Mysql.MaxIdleConns = -1
+	c.Log.Level = "verbose"
+	c.Kafka.Topic = "events"
+	c.Middleware.RateLimit.Key = "tenant"
+	c.Tenant.Sources = []string{"header", "cookie"}
+	c.System.TrustedProxies = []string{"10.0.0.0/8", "proxy"}
+
+	err := c.Validate()
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+
+	got := make(map[string]string)
+	for _, f := range verr.Fields {
+		got[f.Key] = f.Message
+	}
+	assert.Len(t, got, 9)
+	for _, key := range []string{
+		"system.port", "mysql.port", "mysql.max_idle_conns", "log.level", "kafka.brokers",
+		"jwt.secret", "middleware.rate_limit.key", "tenant.sources[1]", "system.trusted_proxies[1]",
+	} {
+		assert.Contains(t, got, key)
+	}
+	assert.Equal(t, "is required when topic is set", got["kafka.brokers"])
+	assert.Equal(t, `"proxy" is not a valid IP or CIDR`, got["system.trusted_proxies[1]"])
+	assert.Contains(t, err.Error(), "jwt.secret: must be at least 32 characters in prod")
+}
+
+func TestValidate_KafkaBrokers(t *testing.T) {
+	c := validConfig()
+	c.Kafka = KafkaConfig{Brokers: []string{}}
+	assert.NoError(t, c.Validate())
+
+	for _, brokers := range [][]string{nil, {}} {
+		c.Kafka = KafkaConfig{Brokers: brokers, Topic: "events"}
+		err := c.Validate()
+		var verr *ValidationError
+		require.True(t, errors.As(err, &verr))
+		require.Len(t, verr.Fields, 1)
+		assert.Equal(t, "kafka.brokers", verr.Fields[0].Key)
+		assert.Equal(t, "is required when topic is set", verr.Fields[0].Message)
+	}
+
+	c.Kafka = KafkaConfig{Brokers: []string{"kafka"}, Topic: "events"}
+	assert.Error(t, c.Validate())
+}
+
+func TestValidPort(t *testing.T) {
+	for _, s := range []string{"80", ":8080", "localhost:443", "[::1]:65535"} {
+		assert.True(t, validPort(s), s)
+	}
+	for _, s := range []string{"", ":", "0", "65536", "http", "host:", "a:b:1"} {
+		assert.False(t, validPort(s), s)
+	}
+}
+
+func TestReloader_RejectsInvalid(t *testing.T) {
+	layers := newSourceLayers(EnvSource{Environ: envOf()})
+	base := validConfig()
+	layers.set(SourceFile, base, nil)
+	merged, _, err := layers.resolve()
+	require.NoError(t, err)
+	store := NewStore(merged)
+	reload := reloader(layers, store)
+
+	// etcd 推送无效配置：被拒绝，Store 保持不变
+	reload(SourceEtcd)(Config{Log: LogConfig{Level: "loud"}}, []string{"log.level"})
+	assert.Equal(t, "INFO", store.Current().Log.Level)
+
+	// 之后文件的有效更新不会被之前拒绝的 etcd 配置拖累
+	base.Middleware.Timeout.Timeout = time.Second
+	reload(SourceFile)(base, nil)
+	assert.Equal(t, time.Second, store.Current().Middleware.Timeout.Timeout)
+	assert.Equal(t, "INFO", store.Current().Log.Level)
+
+	reload(SourceEtcd)(Config{Log: LogConfig{Level: "warn"}}, []string{"log.level"})
+	assert.Equal(t, "warn", store.Current().Log.Level)
+}
+
+func TestReloader_Concurrent(t *testing.T) {
+	layers := newSourceLayers(EnvSource{Environ: envOf()})
+	base := validConfig()
+	layers.set(SourceFile, base, nil)
+	merged, _, err := layers.resolve()
+	require.NoError(t, err)
+	store := NewStore(merged)
+	reload := reloader(layers, store)
+
+	// etcd 同时推送无效配置时，被拒绝的更新不会撤销或拖累文件的有效更新
+	for i := 1; i <= 100; i++ {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reload(SourceEtcd)(Config{Log: LogConfig{Level: "loud"}}, []string{"log.level"})
+		}()
+		base.Middleware.Timeout.Timeout = time.Duration(i) * time.Millisecond
+		reload(SourceFile)(base, nil)
+		wg.Wait()
+		require.Equal(t, time.Duration(i)*time.Millisecond, store.Current().Middleware.Timeout.Timeout)
+	}
+	assert.Equal(t, "INFO", store.Current().Log.Level)
+}
@@ -0,0 +1,180 @@
+package configs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// defaultDebounce 合并一次保存产生的多个事件（写临时文件、rename、chmod 等）
+	defaultDebounce = 100 * time.Millisecond
+	// rearmInterval 所在目录被删除后重新建立监听的间隔
+	rearmInterval = time.Second
+)
+
+// fileWatcher 监听一组配置文件的内容变化。
+//
+// 监听的是文件所在目录而不是文件本身：编辑器先写临时文件再 rename 覆盖、Kubernetes ConfigMap
+// 通过替换 ..data 符号链接更新，都会使文件本身的 watch 失效。路径为符号链接时同时监听链接目标所在目录，
+// 每次检查后按最新的链接目标重新建立监听。目录中的事件经过去抖后比较全部文件的内容哈希，
+// 文件内容变化、新建或删除时才回调。
+type fileWatcher struct {
+	paths []string
+	// dir、list 用于监听整个目录：每次检查前由 list 重新列出目录中的文件，目录本身始终监听
+	dir      string
+	list     func() []string
+	debounce time.Duration
+	// onChange 参数为当前存在的文件内容，以传入的路径为键
+	onChange func(files map[string][]byte)
+	watcher  *fsnotify.Watcher
+	// dirs 当前监听的目录
+	dirs map[string]bool
+	hash [sha256.Size]byte
+}
+
+// newFileWatcher 创建监听器并以文件当前内容作为比较基准，调用 run 后开始监听；文件可以尚不存在
+func newFileWatcher(paths []string, onChange func(files map[string][]byte)) (*fileWatcher, error) {
+	return initWatcher(&fileWatcher{paths: paths, onChange: onChange})
+}
+
+// newDirWatcher 监听目录 dir，list 返回目录中当前的文件，文件新建、删除或内容变化时回调
+func newDirWatcher(dir string, list func() []string, onChange func(files map[string][]byte)) (*fileWatcher, error) {
+	return initWatcher(&fileWatcher{paths: list(), dir: dir, list: list, onChange: onChange})
+}
+
+func initWatcher(fw *fileWatcher) (*fileWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	fw.debounce = defaultDebounce
+	fw.watcher = w
+	fw.dirs = make(map[string]bool)
+	_, fw.hash = fw.read()
+	if err := fw.arm(); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	return fw, nil
+}
+
+// run 处理事件直到 ctx 取消，退出时关闭 watcher
+func (fw *fileWatcher) run(ctx context.Context) {
+	defer func() { _ = fw.watcher.Close() }()
+
+	timer := time.NewTimer(fw.debounce)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-fw.watcher.Events:
+			if !ok {
+				return
+			}
+			// 监听的目录被删除或移走后 fsnotify 会移除其 watch，检查时重新建立
+			if fw.dirs[ev.Name] && ev.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+				delete(fw.dirs, ev.Name)
+			}
+			timer.Reset(fw.debounce)
+		case err, ok := <-fw.watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Error("config watcher error", slog.Any("files", fw.paths), slog.Any("error", err))
+		case <-timer.C:
+			if !fw.check() {
+				timer.Reset(rearmInterval)
+			}
+		}
+	}
+}
+
+// check 重新建立监听并比较文件内容，变化时回调；所在目录无法监听时返回 false，由调用方稍后重试
+func (fw *fileWatcher) check() bool {
+	if fw.list != nil {
+		fw.paths = fw.list()
+	}
+	armed := fw.arm() == nil
+	files, h := fw.read()
+	if h != fw.hash {
+		fw.hash = h
+		fw.onChange(files)
+	}
+	return armed
+}
+
+// read 读取存在的文件，返回内容与整体哈希；替换过程中暂时不存在的文件视为已删除
+func (fw *fileWatcher) read() (map[string][]byte, [sha256.Size]byte) {
+	files := make(map[string][]byte, len(fw.paths))
+	h := sha256.New()
+	for i, path := range fw.paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		files[path] = data
+		_ = binary.Write(h, binary.LittleEndian, [2]int64{int64(i), int64(len(data))})
+		h.Write([]byte(path))
+		h.Write(data)
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return files, sum
+}
+
+// arm 监听文件所在目录（或 dir）及符号链接目标所在目录，并移除不再需要的监听
+func (fw *fileWatcher) arm() error {
+	parents := make(map[string]bool, len(fw.paths))
+	want := make(map[string]bool, len(fw.paths))
+	if fw.dir != "" {
+		abs, err := filepath.Abs(fw.dir)
+		if err != nil {
+			return err
+		}
+		parents[abs] = true
+		want[abs] = true
+	}
+	for _, path := range fw.paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		parents[filepath.Dir(abs)] = true
+		want[filepath.Dir(abs)] = true
+		if real, err := filepath.EvalSymlinks(abs); err == nil {
+			want[filepath.Dir(real)] = true
+		}
+	}
+	for dir := range fw.dirs {
+		if !want[dir] {
+			_ = fw.watcher.Remove(dir)
+			delete(fw.dirs, dir)
+		}
+	}
+	var parentErr error
+	for dir := range want {
+		if fw.dirs[dir] {
+			continue
+		}
+		if err := fw.watcher.Add(dir); err != nil {
+			if parents[dir] {
+				parentErr = err
+			}
+			continue
+		}
+		fw.dirs[dir] = true
+	}
+	return parentErr
+}
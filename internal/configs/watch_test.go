@@ -0,0 +1,164 @@
+package configs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startWatcher 监听 path，回调的内容写入返回的通道
+func startWatcher(t *testing.T, path string) <-chan string {
+	t.Helper()
+	changes := make(chan string, 16)
+	fw, err := newFileWatcher([]string{path}, func(files map[string][]byte) {
+		if data, ok := files[path]; ok {
+			changes <- string(data)
+		}
+	})
+	require.NoError(t, err)
+	fw.debounce = 20 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go fw.run(ctx)
+	return changes
+}
+
+func expectChange(t *testing.T, changes <-chan string, want string) {
+	t.Helper()
+	select {
+	case got := <-changes:
+		assert.Equal(t, want, got)
+	case <-time.After(3 * time.Second):
+		t.Fatalf("expected change %q", want)
+	}
+}
+
+func expectNoChange(t *testing.T, changes <-chan string) {
+	t.Helper()
+	select {
+	case got := <-changes:
+		t.Fatalf("unexpected change %q", got)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestFileWatcher_Write(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeFile(t, path, "a")
+	changes := startWatcher(t, path)
+
+	writeFile(t, path, "b")
+	expectChange(t, changes, "b")
+
+	// 内容不变（如 touch、chmod）不回调
+	writeFile(t, path, "b")
+	require.NoError(t, os.Chmod(path, 0o644))
+	expectNoChange(t, changes)
+}
+
+func TestFileWatcher_Debounce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeFile(t, path, "a")
+	changes := startWatcher(t, path)
+
+	// 一次保存中的多次写入合并为一次回调
+	for _, s := range []string{"b", "c", "d"} {
+		writeFile(t, path, s)
+	}
+	expectChange(t, changes, "d")
+	expectNoChange(t, changes)
+}
+
+func TestFileWatcher_RenameOnSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	writeFile(t, path, "a")
+	changes := startWatcher(t, path)
+
+	// 编辑器写临时文件后 rename 覆盖
+	for _, s := range []string{"b", "c"} {
+		tmp := filepath.Join(dir, ".config.toml.swp")
+		writeFile(t, tmp, s)
+		require.NoError(t, os.Rename(tmp, path))
+		expectChange(t, changes, s)
+	}
+
+	// rename 之后原地写入仍然生效
+	writeFile(t, path, "d")
+	expectChange(t, changes, "d")
+}
+
+func TestFileWatcher_RemoveAndRecreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeFile(t, path, "a")
+	changes := startWatcher(t, path)
+
+	require.NoError(t, os.Remove(path))
+	expectNoChange(t, changes)
+
+	writeFile(t, path, "b")
+	expectChange(t, changes, "b")
+}
+
+func TestFileWatcher_ConfigMapSymlinkSwap(t *testing.T) {
+	// 模拟 Kubernetes ConfigMap 挂载：config.toml -> ..data/config.toml，..data -> ..<timestamp>
+	dir := t.TempDir()
+	version := func(name, content string) {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, name), 0o755))
+		writeFile(t, filepath.Join(dir, name, "config.toml"), content)
+	}
+	version("..2024_01_01", "a")
+	require.NoError(t, os.Symlink("..2024_01_01", filepath.Join(dir, "..data")))
+	path := filepath.Join(dir, "config.toml")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "config.toml"), path))
+	changes := startWatcher(t, path)
+
+	swap := func(name string) {
+		old, err := os.Readlink(filepath.Join(dir, "..data"))
+		require.NoError(t, err)
+		require.NoError(t, os.Symlink(name, filepath.Join(dir, "..data_tmp")))
+		require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, old)))
+	}
+
+	version("..2024_01_02", "b")
+	swap("..2024_01_02")
+	expectChange(t, changes, "b")
+
+	// 再次替换同样生效
+	version("..2024_01_03", "c")
+	swap("..2024_01_03")
+	expectChange(t, changes, "c")
+}
+
+func TestFileSource_WatchKeys(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	writeFile(t, path, "[log]\nlevel = \"info\"\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	got := make(chan Config, 4)
+	require.NoError(t, FileSource{Path: path}.Watch(ctx, func(c Config) { got <- c }))
+
+	tmp := filepath.Join(dir, "config.toml.tmp")
+	writeFile(t, tmp, "[log]\nlevel = \"debug\"\n")
+	require.NoError(t, os.Rename(tmp, path))
+
+	select {
+	case c := <-got:
+		assert.Equal(t, "debug", c.Log.Level)
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected reload after rename")
+	}
+}
@@ -3,6 +3,7 @@ package health
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -13,16 +14,32 @@ import (
 type HealthChecker struct {
 	db    *gorm.DB
 	redis *redis.Client
+
+	mu        sync.RWMutex
+	reporters map[string]Reporter
+}
+
+// Reporter 由其他组件注册的检查项
+type Reporter interface {
+	HealthCheck(ctx context.Context) Check
 }
 
 // NewHealthChecker 创建健康检查器
 func NewHealthChecker(db *gorm.DB, redis *redis.Client) *HealthChecker {
 	return &HealthChecker{
-		db:    db,
-		redis: redis,
+		db:        db,
+		redis:     redis,
+		reporters: make(map[string]Reporter),
 	}
 }
 
+// Register 注册检查项，同名检查项会被覆盖
+func (hc *HealthChecker) Register(name string, r Reporter) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.reporters[name] = r
+}
+
 // CheckResult 健康检查结果
 type CheckResult struct {
 	Status    string           `json:"status"`
@@ -58,6 +75,11 @@ func (hc *HealthChecker) CheckAll(ctx context.Context) CheckResult {
 	systemCheck := hc.checkSystem()
 	result.Checks["system"] = systemCheck
 
+	// 已注册的检查项
+	for name, check := range hc.CheckComponents(ctx) {
+		result.Checks[name] = check
+	}
+
 	// 确定整体状态
 	for _, check := range result.Checks {
 		if check.Status != "healthy" {
@@ -163,6 +185,21 @@ func (hc *HealthChecker) checkSystem() Check {
 	}
 }
 
+// CheckComponents 只执行已注册的检查项
+func (hc *HealthChecker) CheckComponents(ctx context.Context) map[string]Check {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+
+	checks := make(map[string]Check, len(hc.reporters))
+	for name, r := range hc.reporters {
+		start := time.Now()
+		check := r.HealthCheck(ctx)
+		check.Duration = time.Since(start)
+		checks[name] = check
+	}
+	return checks
+}
+
 // CheckDatabase 单独检查数据库
 func (hc *HealthChecker) CheckDatabase(ctx context.Context) Check {
 	return hc.checkDatabase(ctx)
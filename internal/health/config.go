@@ -0,0 +1,53 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/configs"
+)
+
+// configSources 报告远程配置来源（etcd、consul、http）的读取状态
+type configSources struct {
+	store *configs.Store
+}
+
+// ConfigSources 返回远程配置来源的检查项：任一来源最近一次读取失败或正在使用本地快照时为 unhealthy
+func ConfigSources(store *configs.Store) Reporter {
+	return configSources{store: store}
+}
+
+// HealthCheck 实现 Reporter，每个来源输出版本与最近一次成功、失败的时间
+func (c configSources) HealthCheck(context.Context) Check {
+	check := Check{Status: "healthy", LastChecked: time.Now()}
+	statuses := c.store.SourceStatuses()
+	if len(statuses) == 0 {
+		check.Message = "no remote config source"
+		return check
+	}
+	parts := make([]string, 0, len(statuses))
+	for _, s := range statuses {
+		parts = append(parts, describeSource(s))
+		if !s.Healthy() {
+			check.Status = "unhealthy"
+		}
+	}
+	check.Message = strings.Join(parts, "; ")
+	return check
+}
+
+func describeSource(s configs.SourceStatus) string {
+	msg := fmt.Sprintf("%s revision %d", s.Name, s.Revision)
+	if s.FromSnapshot {
+		msg += " (from snapshot)"
+	}
+	if !s.LastSuccess.IsZero() {
+		msg += ", last success at " + s.LastSuccess.Format(time.RFC3339)
+	}
+	if s.LastError != "" {
+		msg += fmt.Sprintf(", last error at %s: %s", s.LastErrorAt.Format(time.RFC3339), s.LastError)
+	}
+	return msg
+}
@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/configs"
+)
+
+const (
+	defaultGracePeriod   = time.Minute
+	defaultCheckInterval = 10 * time.Second
+	// rollbackAfter 宽限期内连续失败多少次后回滚，避免偶发抖动触发回滚
+	rollbackAfter = 2
+)
+
+// ignoredOnRollback 不作为回滚依据的检查项：远程配置来源不可达与新配置无关
+var ignoredOnRollback = map[string]bool{"config": true}
+
+// rollbackGuard 配置热更新后观察健康检查，宽限期内持续失败时回滚到更新前的版本
+type rollbackGuard struct {
+	store *configs.Store
+	check func(ctx context.Context) CheckResult
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// AutoRollback 在 config_history.auto_rollback 开启时，每次配置热更新后的 grace_period 内
+// 按 check_interval 执行全部健康检查，连续两次有检查项不健康（config 检查项除外）时回滚到更新前的版本。
+// 新的热更新会取消上一次的观察；回滚产生的版本不再观察。返回的函数用于停止。
+func AutoRollback(store *configs.Store, hc *HealthChecker) (stop func(), err error) {
+	g := &rollbackGuard{store: store, check: hc.CheckAll}
+	unsubscribe, err := store.SubscribeFunc("*", g.onChange)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		unsubscribe()
+		g.reset(nil)
+	}, nil
+}
+
+// onChange 观察通知中的版本；通知异步送达，此时 Store 中可能已有更新的版本
+func (g *rollbackGuard) onChange(change configs.Change) {
+	cfg := change.Config.ConfigHistory
+	if !cfg.AutoRollback || change.Source == configs.SourceRollback || change.Source == configs.SourceInitial {
+		g.reset(nil)
+		return
+	}
+	grace, interval := cfg.GracePeriod, cfg.CheckInterval
+	if grace <= 0 {
+		grace = defaultGracePeriod
+	}
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	g.reset(cancel)
+	go g.watch(ctx, change.Version, interval)
+}
+
+// reset 取消正在进行的观察
+func (g *rollbackGuard) reset(cancel context.CancelFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cancel != nil {
+		g.cancel()
+	}
+	g.cancel = cancel
+}
+
+// watch 观察版本 id 生效后的健康状态
+func (g *rollbackGuard) watch(ctx context.Context, id int64, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		failing := unhealthyChecks(g.check(ctx))
+		if ctx.Err() != nil {
+			return
+		}
+		if len(failing) == 0 {
+			failures = 0
+			continue
+		}
+		failures++
+		if failures < rollbackAfter {
+			continue
+		}
+		g.rollback(id, failing)
+		return
+	}
+}
+
+// rollback 回滚到版本 id 之前的版本；之后已有新版本时放弃
+func (g *rollbackGuard) rollback(id int64, failing []string) {
+	versions := g.store.Versions()
+	if len(versions) < 2 || versions[0].ID != id {
+		return
+	}
+	target := versions[1].ID
+	v, err := g.store.Rollback(target)
+	if err != nil {
+		slog.Error("config auto rollback failed", slog.Int64("version", id), slog.Int64("target", target), slog.Any("error", err))
+		return
+	}
+	slog.Error("config auto rolled back after failed health checks",
+		slog.Int64("version", id),
+		slog.Int64("target", target),
+		slog.Int64("new_version", v.ID),
+		slog.Any("checks", failing))
+}
+
+// unhealthyChecks 返回不健康的检查项名称
+func unhealthyChecks(result CheckResult) []string {
+	var failing []string
+	for name, check := range result.Checks {
+		if check.Status != "healthy" && !ignoredOnRollback[name] {
+			failing = append(failing, name)
+		}
+	}
+	sort.Strings(failing)
+	return failing
+}
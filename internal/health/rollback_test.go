@@ -0,0 +1,125 @@
+package health
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startGuard 以可控的检查结果启动自动回滚，healthy 为 false 时 database 检查项不健康
+func startGuard(t *testing.T, store *configs.Store, healthy *atomic.Bool) {
+	t.Helper()
+	g := &rollbackGuard{store: store, check: func(context.Context) CheckResult {
+		status := "healthy"
+		if !healthy.Load() {
+			status = "unhealthy"
+		}
+		return CheckResult{Checks: map[string]Check{
+			"database": {Status: status},
+			// 远程配置来源不可达不触发回滚
+			"config": {Status: "unhealthy"},
+		}}
+	}}
+	unsubscribe, err := store.SubscribeFunc("*", g.onChange)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		unsubscribe()
+		g.reset(nil)
+	})
+}
+
+func guardedConfig(pool int) configs.Config {
+	return configs.Config{
+		Mysql: configs.MysqlConfig{MaxOpenConns: pool},
+		ConfigHistory: configs.ConfigHistoryConfig{
+			AutoRollback:  true,
+			GracePeriod:   time.Second,
+			CheckInterval: 10 * time.Millisecond,
+		},
+	}
+}
+
+func TestAutoRollback_RollsBackOnFailedChecks(t *testing.T) {
+	store := configs.NewStore(guardedConfig(10))
+	var healthy atomic.Bool
+	startGuard(t, store, &healthy)
+
+	store.Update(guardedConfig(1000))
+
+	assert.Eventually(t, func() bool {
+		return store.Latest().Source == configs.SourceRollback
+	}, 2*time.Second, 10*time.Millisecond)
+	latest := store.Latest()
+	assert.Equal(t, int64(1), latest.RollbackOf)
+	assert.Equal(t, 10, store.Current().Mysql.MaxOpenConns)
+
+	// 回滚产生的版本不再观察
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, latest.ID, store.Latest().ID)
+}
+
+func TestAutoRollback_KeepsHealthyReload(t *testing.T) {
+	store := configs.NewStore(guardedConfig(10))
+	var healthy atomic.Bool
+	healthy.Store(true)
+	startGuard(t, store, &healthy)
+
+	store.Update(guardedConfig(20))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 20, store.Current().Mysql.MaxOpenConns)
+	assert.Equal(t, configs.SourceUpdate, store.Latest().Source)
+}
+
+func TestAutoRollback_Disabled(t *testing.T) {
+	initial := guardedConfig(10)
+	initial.ConfigHistory.AutoRollback = false
+	store := configs.NewStore(initial)
+	var healthy atomic.Bool
+	startGuard(t, store, &healthy)
+
+	next := guardedConfig(20)
+	next.ConfigHistory.AutoRollback = false
+	store.Update(next)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 20, store.Current().Mysql.MaxOpenConns)
+}
+
+func TestAutoRollback_StaleNotification(t *testing.T) {
+	store := configs.NewStore(guardedConfig(10))
+	store.Update(guardedConfig(20))
+	store.Update(guardedConfig(30))
+	g := &rollbackGuard{store: store, check: func(context.Context) CheckResult {
+		return CheckResult{Checks: map[string]Check{"database": {Status: "unhealthy"}}}
+	}}
+	t.Cleanup(func() { g.reset(nil) })
+
+	// 版本 2 的通知晚于版本 3 送达：观察的是版本 2，之后已有新版本时放弃回滚，不会误回滚版本 3
+	g.onChange(configs.Change{Key: "*", Config: guardedConfig(20), Version: 2, Source: configs.SourceUpdate})
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int64(3), store.Latest().ID)
+	assert.Equal(t, 30, store.Current().Mysql.MaxOpenConns)
+}
+
+func TestAutoRollback_AfterGracePeriod(t *testing.T) {
+	initial := guardedConfig(10)
+	initial.ConfigHistory.GracePeriod = 50 * time.Millisecond
+	store := configs.NewStore(initial)
+	var healthy atomic.Bool
+	healthy.Store(true)
+	startGuard(t, store, &healthy)
+
+	next := guardedConfig(20)
+	next.ConfigHistory.GracePeriod = 50 * time.Millisecond
+	store.Update(next)
+	time.Sleep(150 * time.Millisecond)
+
+	// 宽限期过后的故障不回滚
+	healthy.Store(false)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 20, store.Current().Mysql.MaxOpenConns)
+}
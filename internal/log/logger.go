@@ -61,17 +61,32 @@ func (m *MultiSink) Close() error {
 type DefaultLogger struct {
 	slog *slog.Logger
 	sink Sink
-	mu   sync.RWMutex
+	// level 与 With/WithGroup 派生的记录器共享，SetLevel 后同时生效
+	level *slog.LevelVar
+	mu    sync.RWMutex
 }
 
 func NewDefaultLogger(sink Sink, level slog.Level) *DefaultLogger {
-	handler := &SinkHandler{sink: sink, level: level}
+	lv := new(slog.LevelVar)
+	lv.Set(level)
+	handler := &SinkHandler{sink: sink, level: lv}
 	return &DefaultLogger{
-		slog: slog.New(handler),
-		sink: sink,
+		slog:  slog.New(handler),
+		sink:  sink,
+		level: lv,
 	}
 }
 
+// SetLevel 运行时调整日志级别
+func (l *DefaultLogger) SetLevel(level slog.Level) {
+	l.level.Set(level)
+}
+
+// Level 返回当前日志级别
+func (l *DefaultLogger) Level() slog.Level {
+	return l.level.Level()
+}
+
 func (l *DefaultLogger) Debug(msg string, attrs ...slog.Attr) {
 	l.slog.LogAttrs(context.Background(), slog.LevelDebug, msg, attrs...)
 }
@@ -119,26 +134,28 @@ func (l *DefaultLogger) With(attrs ...slog.Attr) Logger {
 		args = append(args, attr.Key, attr.Value.Any())
 	}
 	return &DefaultLogger{
-		slog: l.slog.With(args...),
-		sink: l.sink,
+		slog:  l.slog.With(args...),
+		sink:  l.sink,
+		level: l.level,
 	}
 }
 
 func (l *DefaultLogger) WithGroup(name string) Logger {
 	return &DefaultLogger{
-		slog: l.slog.WithGroup(name),
-		sink: l.sink,
+		slog:  l.slog.WithGroup(name),
+		sink:  l.sink,
+		level: l.level,
 	}
 }
 
 // SinkHandler slog.Handler 实现
 type SinkHandler struct {
 	sink  Sink
-	level slog.Level
+	level slog.Leveler
 }
 
 func (h *SinkHandler) Enabled(ctx context.Context, level slog.Level) bool {
-	return level >= h.level
+	return level >= h.level.Level()
 }
 
 func (h *SinkHandler) Handle(ctx context.Context, r slog.Record) error {
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/NSObjects/go-template/internal/configs"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -207,3 +208,20 @@ func TestGlobalLogger(t *testing.T) {
 	assert.NotNil(t, globalLogger)
 	assert.Equal(t, logger, globalLogger)
 }
+
+func TestWatchLevel(t *testing.T) {
+	logger := NewDefaultLogger(NewConsoleSink(ConsoleSinkConfig{Format: "text"}), slog.LevelInfo)
+	child := logger.With(slog.String("module", "test"))
+	store := configs.NewStore(configs.Config{Log: configs.LogConfig{Level: "info"}})
+
+	stop, err := WatchLevel(store, logger)
+	assert.NoError(t, err)
+	defer stop()
+
+	c := store.Current()
+	c.Log.Level = "debug"
+	store.Update(c)
+	assert.Eventually(t, func() bool { return logger.Level() == slog.LevelDebug }, time.Second, 5*time.Millisecond)
+	// 派生的记录器共享级别
+	assert.True(t, child.(*DefaultLogger).slog.Enabled(context.Background(), slog.LevelDebug))
+}
@@ -0,0 +1,26 @@
+package log
+
+import (
+	"log/slog"
+
+	"github.com/NSObjects/go-template/internal/configs"
+)
+
+// LevelSetter 支持运行时调整级别的日志记录器
+type LevelSetter interface {
+	SetLevel(level slog.Level)
+}
+
+// WatchLevel 订阅 log.level，热更新后调整日志级别，返回取消订阅的函数。
+// logger 不支持调整级别时不订阅。
+func WatchLevel(store *configs.Store, logger Logger) (func(), error) {
+	setter, ok := logger.(LevelSetter)
+	if !ok {
+		return func() {}, nil
+	}
+	return store.SubscribeFunc("log.level", func(c configs.Change) {
+		level := parseLevel(c.Config.Log.Level)
+		setter.SetLevel(level)
+		logger.Info("log level changed", slog.String("level", level.String()))
+	})
+}
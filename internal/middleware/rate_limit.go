@@ -44,10 +44,7 @@ func (rl *RateLimiter) RateLimit(config RateLimitConfig) echo.MiddlewareFunc {
 			}
 
 			if !allowed {
-				return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
-					"code":    429,
-					"message": "请求过于频繁，请稍后再试",
-				})
+				return TooManyRequests(c)
 			}
 
 			return next(c)
@@ -55,6 +52,14 @@ func (rl *RateLimiter) RateLimit(config RateLimitConfig) echo.MiddlewareFunc {
 	}
 }
 
+// TooManyRequests 限流拒绝时的统一响应
+func TooManyRequests(c echo.Context) error {
+	return c.JSON(http.StatusTooManyRequests, map[string]interface{}{
+		"code":    429,
+		"message": "请求过于频繁，请稍后再试",
+	})
+}
+
 // isAllowed 检查是否允许请求
 func (rl *RateLimiter) isAllowed(ctx context.Context, key string, config RateLimitConfig) (bool, error) {
 	now := time.Now()
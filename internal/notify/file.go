@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+)
+
+// FileNotifier 把通知以邮件格式追加写入文件，适合开发与端到端测试
+type FileNotifier struct {
+	mu   sync.Mutex
+	path string
+	from string
+	now  func() time.Time
+}
+
+// NewFileNotifier 创建文件通知
+func NewFileNotifier(path, from string) *FileNotifier {
+	return &FileNotifier{path: path, from: from, now: time.Now}
+}
+
+func (n *FileNotifier) Send(_ context.Context, msg Message) error {
+	if err := validateHeader("recipient", msg.To); err != nil {
+		return err
+	}
+	if err := validateHeader("subject", msg.Subject); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(n.path), 0o755); err != nil {
+		return code.WrapError(err, code.ErrNotifyFailed, "create notification directory")
+	}
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return code.WrapError(err, code.ErrNotifyFailed, "open notification file")
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "Date: %s\nFrom: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		n.now().Format(time.RFC1123Z), n.from, msg.To, msg.Subject, msg.Body)
+	if err != nil {
+		return code.WrapError(err, code.ErrNotifyFailed, "write notification file")
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/NSObjects/go-template/internal/log"
+)
+
+// LogNotifier 把通知写入日志，便于开发环境直接从日志中取得链接
+type LogNotifier struct{}
+
+// NewLogNotifier 创建日志通知
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Send(_ context.Context, msg Message) error {
+	if err := validateHeader("recipient", msg.To); err != nil {
+		return err
+	}
+	log.Info("notification",
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body),
+	)
+	return nil
+}
@@ -0,0 +1,56 @@
+/*
+ * Notify
+ * 邮件等通知投递：开发环境写入日志或文件，生产环境通过 SMTP 发送
+ */
+
+package notify
+
+import (
+	"context"
+	"strings"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+)
+
+// Message 一条纯文本通知
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier 通知投递接口
+type Notifier interface {
+	// Send 投递通知，失败时返回 code.ErrNotifyFailed
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewNotifier 根据 notifier.driver 创建通知投递实现
+func NewNotifier(cfg configs.Config) (Notifier, error) {
+	nc := cfg.Notifier
+	switch strings.ToLower(nc.Driver) {
+	case "", "log":
+		return NewLogNotifier(), nil
+	case "file":
+		if nc.FilePath == "" {
+			return nil, code.NewError(code.ErrInternalServer, "notifier.file_path must be configured for the file driver")
+		}
+		return NewFileNotifier(nc.FilePath, nc.From), nil
+	case "smtp":
+		if nc.SMTP.Host == "" || nc.From == "" {
+			return nil, code.NewError(code.ErrInternalServer, "notifier.smtp.host and notifier.from must be configured for the smtp driver")
+		}
+		return NewSMTPNotifier(nc), nil
+	default:
+		return nil, code.NewErrorf(code.ErrInternalServer, "unknown notifier driver %q", nc.Driver)
+	}
+}
+
+// validateHeader 拒绝包含换行的收件人与主题，防止邮件头注入
+func validateHeader(name, value string) error {
+	if strings.ContainsAny(value, "\r\n") {
+		return code.NewErrorf(code.ErrNotifyFailed, "notification %s contains line breaks", name)
+	}
+	return nil
+}
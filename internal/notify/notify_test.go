@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewNotifier(t *testing.T) {
+	n, err := NewNotifier(configs.Config{})
+	require.NoError(t, err)
+	assert.IsType(t, &LogNotifier{}, n)
+
+	n, err = NewNotifier(configs.Config{Notifier: configs.NotifierConfig{Driver: "file", FilePath: "mail.log"}})
+	require.NoError(t, err)
+	assert.IsType(t, &FileNotifier{}, n)
+
+	n, err = NewNotifier(configs.Config{Notifier: configs.NotifierConfig{Driver: "smtp", From: "a@example.com", SMTP: configs.SMTPConfig{Host: "localhost"}}})
+	require.NoError(t, err)
+	assert.IsType(t, &SMTPNotifier{}, n)
+
+	for _, nc := range []configs.NotifierConfig{
+		{Driver: "file"},
+		{Driver: "smtp", From: "a@example.com"},
+		{Driver: "pigeon"},
+	} {
+		_, err := NewNotifier(configs.Config{Notifier: nc})
+		assert.Error(t, err, nc.Driver)
+	}
+}
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail", "out.log")
+	n := NewFileNotifier(path, "no-reply@example.com")
+	ctx := context.Background()
+
+	require.NoError(t, n.Send(ctx, Message{To: "a@example.com", Subject: "Reset", Body: "link-1"}))
+	require.NoError(t, n.Send(ctx, Message{To: "b@example.com", Subject: "Verify", Body: "link-2"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "To: a@example.com\nSubject: Reset\n\nlink-1")
+	assert.Contains(t, string(data), "To: b@example.com\nSubject: Verify\n\nlink-2")
+
+	err = n.Send(ctx, Message{To: "a@example.com\r\nBcc: x@example.com", Subject: "Reset"})
+	assert.True(t, errors.IsCode(err, code.ErrNotifyFailed))
+}
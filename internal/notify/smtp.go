@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+)
+
+// defaultSMTPTimeout 未配置 notifier.smtp.timeout 时的连接与发送超时
+const defaultSMTPTimeout = 10 * time.Second
+
+// SMTPNotifier 通过 SMTP 发送邮件。
+// smtp.tls 为 true 时直接建立 TLS 连接，否则在服务器支持时升级为 STARTTLS；
+// 配置了 username 时使用 PLAIN 认证（net/smtp 只允许在加密连接或本机上发送密码）。
+type SMTPNotifier struct {
+	cfg       configs.SMTPConfig
+	from      string
+	tlsConfig *tls.Config
+	now       func() time.Time
+}
+
+// NewSMTPNotifier 创建 SMTP 通知
+func NewSMTPNotifier(cfg configs.NotifierConfig) *SMTPNotifier {
+	return &SMTPNotifier{
+		cfg:       cfg.SMTP,
+		from:      cfg.From,
+		tlsConfig: &tls.Config{ServerName: cfg.SMTP.Host, MinVersion: tls.VersionTLS12},
+		now:       time.Now,
+	}
+}
+
+func (n *SMTPNotifier) Send(ctx context.Context, msg Message) error {
+	if err := validateHeader("recipient", msg.To); err != nil {
+		return err
+	}
+	if err := validateHeader("subject", msg.Subject); err != nil {
+		return err
+	}
+	data, err := n.compose(msg)
+	if err != nil {
+		return code.WrapError(err, code.ErrNotifyFailed, "compose mail")
+	}
+	if err := n.send(ctx, msg.To, data); err != nil {
+		return code.WrapError(err, code.ErrNotifyFailed, "send mail")
+	}
+	return nil
+}
+
+func (n *SMTPNotifier) send(ctx context.Context, to string, data []byte) error {
+	timeout := n.cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultSMTPTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(n.cfg.Host, strconv.Itoa(n.port()))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if n.cfg.TLS {
+		conn = tls.Client(conn, n.tlsConfig)
+	}
+
+	c, err := smtp.NewClient(conn, n.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if !n.cfg.TLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(n.tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if n.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(n.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (n *SMTPNotifier) port() int {
+	if n.cfg.Port != 0 {
+		return n.cfg.Port
+	}
+	if n.cfg.TLS {
+		return 465
+	}
+	return 587
+}
+
+// compose 生成 UTF-8 纯文本邮件，主题按 RFC 2047 编码，正文使用 quoted-printable
+func (n *SMTPNotifier) compose(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", n.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", n.now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
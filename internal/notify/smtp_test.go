@@ -0,0 +1,166 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpSession 假 SMTP 服务器收到的一次投递
+type smtpSession struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer 只实现发送流程所需命令的 SMTP 服务器，reject 非空时拒绝该收件人
+func fakeSMTPServer(t *testing.T, reject string) (string, <-chan smtpSession) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	sessions := make(chan smtpSession, 4)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, reject, sessions)
+		}
+	}()
+	return ln.Addr().String(), sessions
+}
+
+func serveSMTP(conn net.Conn, reject string, sessions chan<- smtpSession) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { _, _ = io.WriteString(conn, s+"\r\n") }
+
+	var s smtpSession
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			reply("250-fake")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(cmd, "AUTH PLAIN "):
+			raw, _ := base64.StdEncoding.DecodeString(line[len("AUTH PLAIN "):])
+			s.auth = string(raw)
+			reply("235 ok")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			s.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			reply("250 ok")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			to := strings.Trim(line[len("RCPT TO:"):], "<> ")
+			if to == reject {
+				reply("550 no such user")
+				continue
+			}
+			s.to = append(s.to, to)
+			reply("250 ok")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.data = data.String()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			sessions <- s
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func newTestSMTPNotifier(t *testing.T, addr string, smtpCfg configs.SMTPConfig) *SMTPNotifier {
+	t.Helper()
+	host, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	smtpCfg.Host = host
+	smtpCfg.Port, err = strconv.Atoi(port)
+	require.NoError(t, err)
+	smtpCfg.Timeout = 5 * time.Second
+	return NewSMTPNotifier(configs.NotifierConfig{From: "no-reply@example.com", SMTP: smtpCfg})
+}
+
+func TestSMTPNotifier_Send(t *testing.T) {
+	addr, sessions := fakeSMTPServer(t, "")
+	n := newTestSMTPNotifier(t, addr, configs.SMTPConfig{Username: "mailer", Password: "pw"})
+
+	body := "请在 30 分钟内打开链接重置密码：\nhttps://app.example.com/reset-password?token=abc.def"
+	err := n.Send(context.Background(), Message{To: "alice@example.com", Subject: "重置密码", Body: body})
+	require.NoError(t, err)
+
+	var s smtpSession
+	select {
+	case s = <-sessions:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail received")
+	}
+	assert.Equal(t, "\x00mailer\x00pw", s.auth)
+	assert.Equal(t, "no-reply@example.com", s.from)
+	assert.Equal(t, []string{"alice@example.com"}, s.to)
+
+	header, encoded, ok := strings.Cut(s.data, "\r\n\r\n")
+	require.True(t, ok)
+	assert.Contains(t, header, "To: alice@example.com\r\n")
+	assert.Contains(t, header, "Subject: =?utf-8?q?")
+	assert.Contains(t, header, "Content-Transfer-Encoding: quoted-printable")
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(encoded)))
+	require.NoError(t, err)
+	// DATA 阶段换行统一转换为 CRLF
+	assert.Equal(t, strings.ReplaceAll(body, "\n", "\r\n"), strings.TrimRight(string(decoded), "\r\n"))
+}
+
+func TestSMTPNotifier_Errors(t *testing.T) {
+	addr, _ := fakeSMTPServer(t, "bounce@example.com")
+	n := newTestSMTPNotifier(t, addr, configs.SMTPConfig{})
+	ctx := context.Background()
+
+	err := n.Send(ctx, Message{To: "bounce@example.com", Subject: "hi", Body: "x"})
+	assert.True(t, errors.IsCode(err, code.ErrNotifyFailed))
+
+	err = n.Send(ctx, Message{To: "alice@example.com", Subject: "hi\r\nBcc: eve@example.com", Body: "x"})
+	assert.True(t, errors.IsCode(err, code.ErrNotifyFailed))
+
+	// 服务器不可达
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closed := ln.Addr().String()
+	require.NoError(t, ln.Close())
+	err = newTestSMTPNotifier(t, closed, configs.SMTPConfig{}).Send(ctx, Message{To: "alice@example.com", Subject: "hi"})
+	assert.True(t, errors.IsCode(err, code.ErrNotifyFailed))
+}
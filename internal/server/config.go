@@ -9,9 +9,13 @@
 package server
 
 import (
+	"log/slog"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/labstack/echo/v4"
 )
 
 // ServerConfig 服务器配置
@@ -30,6 +34,8 @@ type ServerConfig struct {
 	HideBanner bool
 	// 是否启用调试模式
 	Debug bool
+	// 可信反向代理的 IP 或 CIDR
+	TrustedProxies []string
 }
 
 // DefaultServerConfig 默认服务器配置
@@ -55,5 +61,35 @@ func FromAppConfig(cfg configs.Config) *ServerConfig {
 		ShutdownTimeout: 10 * time.Second,
 		HideBanner:      true,
 		Debug:           cfg.System.Level == 1, // 1 = debug, 2 = online
+		TrustedProxies:  cfg.System.TrustedProxies,
 	}
 }
+
+// ipExtractor 未配置可信代理时直接取连接的对端地址，避免伪造 X-Forwarded-For 绕过按 IP 的限流与登录锁定；
+// 配置后只信任来自这些地址的 X-Forwarded-For，无法解析的地址被忽略
+func ipExtractor(trustedProxies []string) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			slog.Warn("ignore invalid trusted proxy", slog.String("proxy", proxy), slog.Any("error", err))
+			continue
+		}
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
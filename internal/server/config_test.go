@@ -6,10 +6,13 @@
 package server
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -104,3 +107,26 @@ func TestServerConfigFields(t *testing.T) {
 	assert.False(t, config.HideBanner)
 	assert.True(t, config.Debug)
 }
+
+func TestIPExtractor(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		remote  string
+		want    string
+	}{
+		{name: "未配置可信代理时忽略 X-Forwarded-For", remote: "203.0.113.7:1234", want: "203.0.113.7"},
+		{name: "来自可信代理", proxies: []string{"10.0.0.0/8"}, remote: "10.0.0.1:1234", want: "198.51.100.9"},
+		{name: "单个地址", proxies: []string{"10.0.0.1"}, remote: "10.0.0.1:1234", want: "198.51.100.9"},
+		{name: "来自不可信地址", proxies: []string{"10.0.0.0/8"}, remote: "203.0.113.7:1234", want: "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			req.Header.Set(echo.HeaderXForwardedFor, "198.51.100.9")
+			assert.Equal(t, tt.want, ipExtractor(tt.proxies)(req))
+		})
+	}
+}
@@ -10,15 +10,20 @@ package server
 
 import (
 	"context"
-	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
 	"syscall"
 	"time"
 
+	"github.com/NSObjects/go-template/internal/api/biz"
+	"github.com/NSObjects/go-template/internal/api/data/db"
 	"github.com/NSObjects/go-template/internal/api/service"
+	"github.com/NSObjects/go-template/internal/auth"
 	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/health"
 	"github.com/NSObjects/go-template/internal/resp"
 	"github.com/NSObjects/go-template/internal/server/middlewares"
 	"github.com/casbin/casbin/v2"
@@ -35,6 +40,14 @@ type EchoServer struct {
 	routers []service.RegisterRouter
 	cfg     configs.Config
 	store   *configs.Store
+	tokens  auth.TokenStore
+	keys    *auth.KeySet
+	account middlewares.AccountChecker
+	health  *health.HealthChecker
+	// registry、deps 与 chain 用于配置热更新后重建中间件管道
+	registry *middlewares.Registry
+	deps     middlewares.Deps
+	chain    *middlewares.Chain
 }
 
 // Server 获取Echo实例
@@ -47,27 +60,62 @@ type Params struct {
 	fx.In
 
 	Routes   []service.RegisterRouter `group:"routes"`
-	Enforcer *casbin.Enforcer
+	Enforcer *casbin.SyncedEnforcer
 	Cfg      configs.Config
 	Store    *configs.Store
+	// Tokens 访问令牌吊销名单，未提供时不做吊销检查
+	Tokens auth.TokenStore `optional:"true"`
+	// Auth 提供后拒绝已禁用账号的访问令牌
+	Auth biz.AuthUseCase `optional:"true"`
+	// APIKeys 提供给 api_key 中间件校验 API 密钥
+	APIKeys biz.APIKeyUseCase `optional:"true"`
+	// Keys JWT 密钥集合，提供后按 kid 验签并发布 JWKS
+	Keys *auth.KeySet `optional:"true"`
+	// Data 提供 Redis 给限流等中间件
+	Data *db.DataManager `optional:"true"`
+	// Health 提供后 /api/health/components 返回各组件的检查结果（仅管理员）
+	Health *health.HealthChecker `optional:"true"`
+	// Middlewares 通过 ProvideMiddleware 注册的自定义中间件
+	Middlewares []middlewares.Definition `group:"middlewares"`
 }
 
 // NewEchoServer 创建Echo服务器实例
-func NewEchoServer(p Params) *EchoServer {
+func NewEchoServer(p Params) (*EchoServer, error) {
 	s := &EchoServer{
 		server:  echo.New(),
 		config:  FromAppConfig(p.Cfg),
 		routers: p.Routes,
 		cfg:     p.Cfg,
 		store:   p.Store,
+		tokens:  p.Tokens,
+		keys:    p.Keys,
+		health:  p.Health,
+	}
+	if p.Auth != nil {
+		s.account = p.Auth
 	}
 
 	// 配置服务器
 	s.setupServer()
-	s.loadMiddleware(p.Enforcer)
+	if err := s.loadMiddleware(p); err != nil {
+		return nil, err
+	}
+	if err := s.watchConfig(); err != nil {
+		return nil, err
+	}
 	s.registerRouter()
 
-	return s
+	return s, nil
+}
+
+// ProvideMiddleware 注册自定义中间件，在 middleware.pipeline 中按名称启用
+func ProvideMiddleware(name string, factory middlewares.Factory) fx.Option {
+	return fx.Provide(fx.Annotate(
+		func() middlewares.Definition {
+			return middlewares.Definition{Name: name, Factory: factory}
+		},
+		fx.ResultTags(`group:"middlewares"`),
+	))
 }
 
 // setupServer 配置服务器基础设置
@@ -78,6 +126,9 @@ func (s *EchoServer) setupServer() {
 	// 设置错误处理器
 	s.server.HTTPErrorHandler = middlewares.ErrorHandler
 
+	// 客户端 IP 只从可信代理的 X-Forwarded-For 中读取
+	s.server.IPExtractor = ipExtractor(s.config.TrustedProxies)
+
 	// 应用服务器配置
 	s.server.HideBanner = s.config.HideBanner
 	s.server.Debug = s.config.Debug
@@ -88,55 +139,94 @@ func (s *EchoServer) setupServer() {
 	s.server.Server.IdleTimeout = s.config.IdleTimeout
 }
 
-// loadMiddleware 加载中间件
-func (s *EchoServer) loadMiddleware(enforce *casbin.Enforcer) {
-	// 创建中间件配置
-	config := s.createMiddlewareConfig()
+// loadMiddleware 按 middleware.pipeline 构建并应用中间件
+func (s *EchoServer) loadMiddleware(p Params) error {
+	registry := middlewares.NewRegistry()
+	for _, def := range p.Middlewares {
+		if err := registry.Register(def.Name, def.Factory); err != nil {
+			return err
+		}
+	}
 
-	// 应用基础中间件
-	middlewares.ApplyMiddlewares(s.server, config)
+	deps := middlewares.Deps{Enforcer: p.Enforcer}
+	if p.Data != nil {
+		deps.Redis = p.Data.Redis
+	}
+	if p.APIKeys != nil {
+		deps.APIKeys = p.APIKeys
+	}
+	s.registry, s.deps = registry, deps
 
-	// 应用Casbin中间件
-	middlewares.ApplyCasbinMiddleware(s.server, enforce, config.Casbin)
+	chain, err := s.buildMiddleware()
+	if err != nil {
+		return err
+	}
+	s.chain = middlewares.NewChain(chain)
+	s.server.Use(s.chain.Middleware())
+	return nil
 }
 
-// createMiddlewareConfig 创建中间件配置
-func (s *EchoServer) createMiddlewareConfig() *middlewares.MiddlewareConfig {
-	cur := s.store.Current()
+// buildMiddleware 按当前配置构建中间件管道
+func (s *EchoServer) buildMiddleware() ([]echo.MiddlewareFunc, error) {
+	deps := s.deps
+	deps.Config = s.store.Current()
+	deps.JWT = s.jwtConfig()
+	chain, err := s.registry.Build(s.middlewarePipeline(), deps)
+	if err != nil {
+		return nil, errors.Wrap(err, "build middleware pipeline")
+	}
+	return chain, nil
+}
 
-	// 创建JWT配置 - 禁用JWT用于演示
-	jwtConfig := middlewares.CreateJWTConfig(
-		cur.JWT.Secret,
-		cur.JWT.SkipPaths,
-		false, // 禁用JWT
-	)
-
-	// 调试日志
-	fmt.Printf("DEBUG: JWT Config - Enabled: %v, SkipPaths: %v\n", jwtConfig.Enabled, jwtConfig.SkipPaths)
-
-	// 创建Casbin配置
-	casbinConfig := middlewares.CreateCasbinConfig(
-		false, // 默认禁用Casbin
-		[]string{
-			"/api/health",
-			"/api/info",
-			"/api/login",
-			"/api/users",
-		},
-		[]string{"root", "admin"},
-	)
-
-	return &middlewares.MiddlewareConfig{
-		EnableRecovery: true,
-		EnableLogger:   true,
-		EnableGzip:     true,
-		EnableCORS:     true,
-		EnableJWT:      jwtConfig.Enabled,
-		EnableCasbin:   casbinConfig.Enabled,
-		LoggerFormat:   "method=${method}, uri=${uri}, status=${status}, latency=${latency_human}\n",
-		JWT:            jwtConfig,
-		Casbin:         casbinConfig,
+// watchConfig 中间件相关配置（CORS、JWT 与 Casbin 跳过路径、限流等）热更新后重建管道，
+// 新配置无法构建时保留当前管道
+func (s *EchoServer) watchConfig() error {
+	_, err := s.store.SubscribeFunc("*", func(c configs.Change) {
+		old, cur := c.Old.(configs.Config), c.New.(configs.Config)
+		if !middlewareConfigChanged(old, cur) {
+			return
+		}
+		chain, err := s.buildMiddleware()
+		if err != nil {
+			slog.Error("rebuild middleware pipeline failed, keeping the current one", slog.Any("error", err))
+			return
+		}
+		s.chain.Swap(chain)
+		slog.Info("middleware pipeline rebuilt", slog.Any("pipeline", s.middlewarePipeline()))
+	})
+	return err
+}
+
+// middlewareConfigChanged 判断中间件依赖的配置是否变化
+func middlewareConfigChanged(old, cur configs.Config) bool {
+	return !reflect.DeepEqual(old.Middleware, cur.Middleware) ||
+		!reflect.DeepEqual(old.CORS, cur.CORS) ||
+		!reflect.DeepEqual(old.JWT, cur.JWT) ||
+		!reflect.DeepEqual(old.Tenant, cur.Tenant)
+}
+
+// middlewarePipeline 返回启用的中间件名称，未配置时使用默认管道
+func (s *EchoServer) middlewarePipeline() []string {
+	if pipeline := s.store.Current().Middleware.Pipeline; len(pipeline) > 0 {
+		return pipeline
+	}
+	return middlewares.DefaultPipeline
+}
+
+// jwtConfig 创建JWT中间件配置，是否启用由管道决定
+func (s *EchoServer) jwtConfig() *middlewares.JWTConfig {
+	cur := s.store.Current()
+	jwtConfig := middlewares.CreateJWTConfig(cur.JWT.Secret, cur.JWT.SkipPaths, true)
+	if s.tokens != nil {
+		jwtConfig.Revocation = s.tokens
+	}
+	if s.keys != nil {
+		jwtConfig.KeyFunc = s.keys.Keyfunc
 	}
+	if s.account != nil {
+		jwtConfig.Accounts = s.account
+	}
+	return jwtConfig
 }
 
 // registerRouter 注册路由
@@ -151,11 +241,19 @@ func (s *EchoServer) registerRouter() {
 
 	// 注册系统路由
 	s.registerSystemRoutes(apiGroup)
+
+	// 公钥发布，供其他服务验签
+	if s.keys != nil {
+		s.server.GET("/.well-known/jwks.json", func(c echo.Context) error {
+			c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=300")
+			return c.JSON(http.StatusOK, s.keys.JWKS())
+		})
+	}
 }
 
 // registerSystemRoutes 注册系统路由
 func (s *EchoServer) registerSystemRoutes(g *echo.Group) {
-	// 健康检查
+	// 健康检查，无需认证，只返回存活状态
 	g.GET("/health", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]interface{}{
 			"status": "ok",
@@ -163,6 +261,18 @@ func (s *EchoServer) registerSystemRoutes(g *echo.Group) {
 		})
 	})
 
+	// 组件检查结果含来源地址与错误信息，仅管理员可见；仅供观测，不影响存活探针
+	g.GET("/health/components", func(c echo.Context) error {
+		checks := map[string]health.Check{}
+		if s.health != nil {
+			checks = s.health.CheckComponents(c.Request().Context())
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"checks": checks,
+			"time":   time.Now().Format(time.RFC3339),
+		})
+	}, service.RequireAdmin)
+
 	// 路由信息
 	g.GET("/routes", func(c echo.Context) error {
 		return resp.ListDataResponse(c, s.server.Routes(), int64(len(s.server.Routes())))
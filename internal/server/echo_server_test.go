@@ -6,14 +6,23 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/NSObjects/go-template/internal/api/service"
 	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/health"
+	"github.com/NSObjects/go-template/internal/server/middlewares"
+	"github.com/NSObjects/go-template/internal/utils"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockRegisterRouter 模拟路由注册器
@@ -34,7 +43,8 @@ func TestEchoServer_Server(t *testing.T) {
 		Store:    &configs.Store{},
 	}
 
-	server := NewEchoServer(params)
+	server, err := NewEchoServer(params)
+	require.NoError(t, err)
 
 	assert.NotNil(t, server)
 	assert.NotNil(t, server.Server())
@@ -59,7 +69,7 @@ func TestEchoServer_setupServer(t *testing.T) {
 	assert.Equal(t, server.config.IdleTimeout, server.server.Server.IdleTimeout)
 }
 
-func TestEchoServer_createMiddlewareConfig(t *testing.T) {
+func TestEchoServer_middlewarePipeline(t *testing.T) {
 	store := &configs.Store{}
 
 	server := &EchoServer{
@@ -68,17 +78,94 @@ func TestEchoServer_createMiddlewareConfig(t *testing.T) {
 		store:  store,
 	}
 
-	config := server.createMiddlewareConfig()
+	// 未配置时使用默认管道，JWT与Casbin默认不启用
+	pipeline := server.middlewarePipeline()
+	assert.Equal(t, middlewares.DefaultPipeline, pipeline)
+	assert.NotContains(t, pipeline, middlewares.NameJWT)
+	assert.NotContains(t, pipeline, middlewares.NameCasbin)
+	assert.NotNil(t, server.jwtConfig())
+}
+
+func TestEchoServer_loadMiddleware(t *testing.T) {
+	cfg := configs.Config{
+		Middleware: configs.MiddlewareConfig{
+			Pipeline: []string{middlewares.NameRecovery, "request_id"},
+		},
+	}
+	store := configs.NewStore(cfg)
+
+	calls := 0
+	params := Params{
+		Cfg:   cfg,
+		Store: store,
+		Middlewares: []middlewares.Definition{{
+			Name: "request_id",
+			Factory: func(middlewares.Deps) (echo.MiddlewareFunc, error) {
+				calls++
+				return middleware.RequestID(), nil
+			},
+		}},
+	}
+
+	server, err := NewEchoServer(params)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
 
-	assert.NotNil(t, config)
-	assert.True(t, config.EnableRecovery)
-	assert.True(t, config.EnableLogger)
-	assert.True(t, config.EnableGzip)
-	assert.True(t, config.EnableCORS)
-	assert.False(t, config.EnableJWT) // 默认情况下JWT应该被禁用
-	assert.False(t, config.EnableCasbin)
-	assert.NotNil(t, config.JWT)
-	assert.NotNil(t, config.Casbin)
+	req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+	rec := httptest.NewRecorder()
+	server.Server().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get(echo.HeaderXRequestID))
+
+	// 未注册的中间件名称应导致启动失败
+	store = configs.NewStore(configs.Config{Middleware: configs.MiddlewareConfig{Pipeline: []string{"missing"}}})
+	_, err = NewEchoServer(Params{Store: store})
+	assert.Error(t, err)
+}
+
+func TestEchoServer_reloadMiddleware(t *testing.T) {
+	cfg := configs.Config{
+		CORS: configs.CORSConfig{AllowOrigins: []string{"https://a.example.com"}},
+		Middleware: configs.MiddlewareConfig{
+			Pipeline: []string{middlewares.NameCORS},
+		},
+	}
+	store := configs.NewStore(cfg)
+	server, err := NewEchoServer(Params{Cfg: cfg, Store: store})
+	require.NoError(t, err)
+
+	allowed := func(origin string) string {
+		req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+		req.Header.Set(echo.HeaderOrigin, origin)
+		rec := httptest.NewRecorder()
+		server.Server().ServeHTTP(rec, req)
+		return rec.Header().Get(echo.HeaderAccessControlAllowOrigin)
+	}
+	assert.Equal(t, "https://a.example.com", allowed("https://a.example.com"))
+	assert.Empty(t, allowed("https://b.example.com"))
+
+	cfg.CORS.AllowOrigins = []string{"https://b.example.com"}
+	store.Update(cfg)
+	assert.Eventually(t, func() bool {
+		return allowed("https://b.example.com") == "https://b.example.com"
+	}, time.Second, 5*time.Millisecond)
+	assert.Empty(t, allowed("https://a.example.com"))
+
+	// 无法构建的新配置不影响当前管道
+	cfg.Middleware.Pipeline = []string{middlewares.NameCORS, middlewares.NameRateLimit}
+	store.Update(cfg)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, "https://b.example.com", allowed("https://b.example.com"))
+
+	// 限流规则生效
+	cfg.Middleware.RateLimit = configs.RateLimitMiddlewareConfig{Requests: 1, Window: time.Minute}
+	store.Update(cfg)
+	status := func() int {
+		rec := httptest.NewRecorder()
+		server.Server().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+		return rec.Code
+	}
+	assert.Eventually(t, func() bool { return status() == http.StatusTooManyRequests }, time.Second, 5*time.Millisecond)
 }
 
 func TestEchoServer_registerSystemRoutes(t *testing.T) {
@@ -117,6 +204,56 @@ func TestEchoServer_registerSystemRoutes(t *testing.T) {
 	assert.True(t, hasInfoRoute, "Info route should be registered")
 }
 
+// staticReporter 固定返回结果的检查项
+type staticReporter health.Check
+
+func (r staticReporter) HealthCheck(context.Context) health.Check {
+	return health.Check(r)
+}
+
+func TestEchoServer_healthChecks(t *testing.T) {
+	hc := health.NewHealthChecker(nil, nil)
+	hc.Register("casbin", staticReporter{Status: "unhealthy", Message: "last update failed"})
+
+	server, err := NewEchoServer(Params{Store: &configs.Store{}, Health: hc})
+	require.NoError(t, err)
+	// 带 X-Test-Admin 的请求以管理员身份访问
+	server.Server().Pre(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Header.Get("X-Test-Admin") != "" {
+				utils.SetPrincipal(c, &utils.Principal{ID: "1", Admin: true})
+			}
+			return next(c)
+		}
+	})
+	serveAs := func(path string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if admin {
+			req.Header.Set("X-Test-Admin", "1")
+		}
+		rec := httptest.NewRecorder()
+		server.Server().ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 公开的存活探针不返回组件详情，组件异常也不影响
+	rec := serveAs("/api/health", false)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "casbin")
+	assert.NotContains(t, rec.Body.String(), "last update failed")
+
+	// 组件详情仅管理员可见
+	assert.Equal(t, http.StatusUnauthorized, serveAs("/api/health/components", false).Code)
+	rec = serveAs("/api/health/components", true)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Checks map[string]health.Check `json:"checks"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "unhealthy", body.Checks["casbin"].Status)
+	assert.Equal(t, "last update failed", body.Checks["casbin"].Message)
+}
+
 func TestEchoServer_registerRouter(t *testing.T) {
 	// 创建模拟路由注册器
 	mockRouter := new(MockRegisterRouter)
@@ -182,7 +319,8 @@ func TestEchoServer_NewEchoServer(t *testing.T) {
 		Store:    store,
 	}
 
-	server := NewEchoServer(params)
+	server, err := NewEchoServer(params)
+	require.NoError(t, err)
 
 	assert.NotNil(t, server)
 	assert.NotNil(t, server.server)
@@ -0,0 +1,64 @@
+/*
+ * API Key Middleware
+ * API 密钥认证中间件
+ */
+
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/NSObjects/go-template/internal/auth"
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// HeaderAPIKey 携带 API 密钥的请求头
+	HeaderAPIKey = "X-API-Key"
+	// apiKeyScheme Authorization 请求头中的 API 密钥认证方案
+	apiKeyScheme = "ApiKey"
+)
+
+// APIKeyAuthenticator 校验 API 密钥并返回认证主体
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*utils.Principal, error)
+}
+
+// APIKey API 密钥认证中间件。
+// 请求携带 "Authorization: ApiKey <key>" 或 "X-API-Key: <key>" 时校验密钥与授权范围并写入认证主体，
+// 之后的 jwt 中间件不再要求访问令牌；未携带密钥的请求原样放行，交由 jwt 等后续中间件处理。
+func APIKey(authenticator APIKeyAuthenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := extractAPIKey(c.Request().Header)
+			if key == "" {
+				return next(c)
+			}
+
+			p, err := authenticator.AuthenticateAPIKey(c.Request().Context(), key)
+			if err != nil {
+				return err
+			}
+			if !auth.ScopeAllows(p.Scopes, c.Request().Method, c.Path()) {
+				return code.NewErrorf(code.ErrAPIKeyScope, "api key scope does not allow %s %s", c.Request().Method, c.Path())
+			}
+			utils.SetPrincipal(c, p)
+			return next(c)
+		}
+	}
+}
+
+func extractAPIKey(h http.Header) string {
+	if key := h.Get(HeaderAPIKey); key != "" {
+		return strings.TrimSpace(key)
+	}
+	scheme, key, ok := strings.Cut(h.Get(echo.HeaderAuthorization), " ")
+	if ok && strings.EqualFold(scheme, apiKeyScheme) {
+		return strings.TrimSpace(key)
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type apiKeySet map[string]*utils.Principal
+
+func (s apiKeySet) AuthenticateAPIKey(_ context.Context, key string) (*utils.Principal, error) {
+	if p, ok := s[key]; ok {
+		return p, nil
+	}
+	return nil, code.NewError(code.ErrAPIKeyInvalid, "api key is invalid")
+}
+
+func TestAPIKey(t *testing.T) {
+	keys := apiKeySet{
+		"full":     {ID: "7", Name: "svc", Method: utils.AuthMethodAPIKey, Scopes: []string{"*"}},
+		"readonly": {ID: "8", Name: "reader", Method: utils.AuthMethodAPIKey, Scopes: []string{"GET:/api/users*"}},
+	}
+	// api_key 在前，jwt 在后：携带密钥的请求不再要求访问令牌
+	chain := func(h echo.HandlerFunc) echo.HandlerFunc {
+		return APIKey(keys)(JWT(&JWTConfig{SigningKey: []byte("test-secret"), Enabled: true})(h))
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		header   string
+		value    string
+		wantCode int
+		wantID   string
+	}{
+		{name: "X-API-Key", method: http.MethodGet, header: HeaderAPIKey, value: "full", wantID: "7"},
+		{name: "Authorization ApiKey", method: http.MethodDelete, header: echo.HeaderAuthorization, value: "ApiKey full", wantID: "7"},
+		{name: "授权范围内", method: http.MethodGet, header: HeaderAPIKey, value: "readonly", wantID: "8"},
+		{name: "超出授权范围", method: http.MethodDelete, header: HeaderAPIKey, value: "readonly", wantCode: code.ErrAPIKeyScope},
+		{name: "无效密钥", method: http.MethodGet, header: HeaderAPIKey, value: "wrong", wantCode: code.ErrAPIKeyInvalid},
+		{name: "未携带密钥时由 jwt 处理", method: http.MethodGet, wantCode: code.ErrSignatureInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			var got *utils.Principal
+			e.Add(tt.method, "/api/users/:id", chain(func(c echo.Context) error {
+				got, _ = utils.PrincipalFromContext(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(tt.method, "/api/users/1", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			e.Router().Find(tt.method, "/api/users/1", c)
+
+			err := c.Handler()(c)
+			if tt.wantCode != 0 {
+				assert.True(t, errors.IsCode(err, tt.wantCode), "unexpected error: %v", err)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, got)
+			assert.Equal(t, tt.wantID, got.ID)
+			assert.Equal(t, utils.AuthMethodAPIKey, got.Method)
+		})
+	}
+}
@@ -10,6 +10,7 @@ package middlewares
 
 import (
 	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
 	"github.com/NSObjects/go-template/internal/utils"
 	"github.com/casbin/casbin/v2"
 	"github.com/golang-jwt/jwt/v5"
@@ -28,6 +29,21 @@ type CasbinConfig struct {
 	SkipPaths []string
 	// 管理员用户
 	AdminUsers []string
+	// DomainGetter 域（租户）模型下获取请求所属的域，默认读取 X-Tenant-ID 请求头
+	DomainGetter func(c echo.Context) (string, error)
+}
+
+// DefaultDomainHeader 默认携带域（租户）的请求头
+const DefaultDomainHeader = "X-Tenant-ID"
+
+// HeaderDomainGetter 从请求头读取域
+func HeaderDomainGetter(header string) func(c echo.Context) (string, error) {
+	if header == "" {
+		header = DefaultDomainHeader
+	}
+	return func(c echo.Context) (string, error) {
+		return c.Request().Header.Get(header), nil
+	}
 }
 
 // DefaultCasbinConfig 默认Casbin配置
@@ -40,12 +56,12 @@ func DefaultCasbinConfig() *CasbinConfig {
 			"/api/login",
 			"/api/users",
 		},
-		AdminUsers: []string{"root", "admin"},
+		AdminUsers: configs.DefaultAdminUsers,
 	}
 }
 
 // Casbin Casbin权限控制中间件
-func Casbin(enforce *casbin.Enforcer, config *CasbinConfig) echo.MiddlewareFunc {
+func Casbin(enforce *casbin.SyncedEnforcer, config *CasbinConfig) echo.MiddlewareFunc {
 	if !config.Enabled || enforce == nil {
 		// 如果Casbin未启用或enforcer为空，返回空中间件
 		return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -53,8 +69,13 @@ func Casbin(enforce *casbin.Enforcer, config *CasbinConfig) echo.MiddlewareFunc
 		}
 	}
 
+	domains := utils.IsDomainModel(enforce)
+	domainGetter := config.DomainGetter
+	if domainGetter == nil {
+		domainGetter = HeaderDomainGetter(DefaultDomainHeader)
+	}
+
 	return casbin_mw.MiddlewareWithConfig(casbin_mw.Config{
-		Enforcer: enforce,
 		Skipper: func(c echo.Context) bool {
 			path := c.Path()
 			return lo.Contains(config.SkipPaths, path)
@@ -63,6 +84,13 @@ func Casbin(enforce *casbin.Enforcer, config *CasbinConfig) echo.MiddlewareFunc
 			return errors.WrapC(internal, code.ErrPermissionDenied, "权限不足")
 		},
 		UserGetter: func(c echo.Context) (string, error) {
+			if p, ok := utils.GetPrincipal(c); ok {
+				if p.Admin {
+					return "root", nil
+				}
+				return p.ID, nil
+			}
+
 			token, ok := c.Get("user").(*jwt.Token)
 			if !ok {
 				return "", errors.WrapC(errors.New("token is nil"), code.ErrSignatureInvalid, "JWT签名无效")
@@ -94,8 +122,23 @@ func Casbin(enforce *casbin.Enforcer, config *CasbinConfig) echo.MiddlewareFunc
 			path := c.Path()
 			method := c.Request().Method
 
-			// 使用Casbin进行权限检查
-			allowed, err := enforce.Enforce(user, path, method)
+			// 使用Casbin进行权限检查，域模型下角色与策略都限定在请求所属的域内
+			var (
+				allowed bool
+				err     error
+			)
+			if domains {
+				domain, derr := domainGetter(c)
+				if derr != nil {
+					return false, derr
+				}
+				if domain == "" {
+					return false, nil
+				}
+				allowed, err = enforce.Enforce(user, domain, path, method)
+			} else {
+				allowed, err = enforce.Enforce(user, path, method)
+			}
 			if err != nil {
 				return false, errors.WrapC(err, code.ErrPermissionDenied, "权限检查失败")
 			}
@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/casbin/casbin/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCasbin_Domains(t *testing.T) {
+	m, err := utils.LoadCasbinModel(configs.CasbinConfig{Domains: true})
+	require.NoError(t, err)
+	e, err := casbin.NewSyncedEnforcer(m)
+	require.NoError(t, err)
+	_, err = e.AddPolicy("admin", "tenant1", "/api/users", http.MethodGet)
+	require.NoError(t, err)
+	_, err = e.AddGroupingPolicy("7", "admin", "tenant1")
+	require.NoError(t, err)
+
+	srv := echo.New()
+	srv.HTTPErrorHandler = ErrorHandler
+	srv.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("user", &jwt.Token{Claims: &utils.JwtCustomClaims{ID: 7}})
+			return next(c)
+		}
+	})
+	srv.Use(Casbin(e, CreateCasbinConfig(true, nil, nil)))
+	srv.GET("/api/users", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	tests := []struct {
+		name   string
+		domain string
+		want   int
+	}{
+		{name: "角色所在的域", domain: "tenant1", want: http.StatusOK},
+		{name: "其他域", domain: "tenant2", want: http.StatusForbidden},
+		{name: "未指定域", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+			if tt.domain != "" {
+				req.Header.Set(DefaultDomainHeader, tt.domain)
+			}
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
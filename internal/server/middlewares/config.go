@@ -9,6 +9,7 @@
 package middlewares
 
 import (
+	"github.com/NSObjects/go-template/internal/configs"
 	"github.com/casbin/casbin/v2"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -34,6 +35,8 @@ type MiddlewareConfig struct {
 	JWT *JWTConfig
 	// Casbin配置
 	Casbin *CasbinConfig
+	// CORS配置，未设置的字段使用默认值
+	CORS configs.CORSConfig
 }
 
 // DefaultMiddlewareConfig 默认中间件配置
@@ -51,7 +54,8 @@ func DefaultMiddlewareConfig() *MiddlewareConfig {
 	}
 }
 
-// ApplyMiddlewares 应用中间件
+// ApplyMiddlewares 按开关应用固定顺序的中间件。
+// 服务器已改用 Registry 按 middleware.pipeline 构建，此函数保留给直接组装 echo 实例的场景。
 func ApplyMiddlewares(e *echo.Echo, config *MiddlewareConfig) {
 	if config == nil {
 		config = DefaultMiddlewareConfig()
@@ -76,24 +80,7 @@ func ApplyMiddlewares(e *echo.Echo, config *MiddlewareConfig) {
 
 	// CORS中间件
 	if config.EnableCORS {
-		e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-			AllowOrigins: []string{"*"},
-			AllowHeaders: []string{
-				echo.HeaderOrigin,
-				echo.HeaderContentType,
-				echo.HeaderAccept,
-				echo.HeaderAuthorization,
-			},
-			AllowMethods: []string{
-				echo.GET,
-				echo.HEAD,
-				echo.PUT,
-				echo.PATCH,
-				echo.POST,
-				echo.DELETE,
-				echo.OPTIONS,
-			},
-		}))
+		e.Use(middleware.CORSWithConfig(corsConfig(config.CORS)))
 	}
 
 	// JWT中间件
@@ -105,7 +92,7 @@ func ApplyMiddlewares(e *echo.Echo, config *MiddlewareConfig) {
 // ApplyCasbinMiddleware 应用Casbin中间件
 func ApplyCasbinMiddleware(e *echo.Echo, enforce interface{}, config *CasbinConfig) {
 	if config != nil && config.Enabled {
-		if enforcer, ok := enforce.(*casbin.Enforcer); ok {
+		if enforcer, ok := enforce.(*casbin.SyncedEnforcer); ok {
 			e.Use(Casbin(enforcer, config))
 		}
 	}
@@ -15,6 +15,10 @@ import (
 
 // ErrorHandler 增强的错误处理器
 func ErrorHandler(err error, c echo.Context) {
+	// 限流等中间件已自行写出响应后可能以 nil 调用
+	if err == nil {
+		return
+	}
 	// 记录错误开始时间
 	start := time.Now()
 
@@ -72,7 +76,7 @@ func handleHTTPError(err *echo.HTTPError, c echo.Context) {
 	var bizErr error
 	message := extractErrorMessage(err.Message)
 	switch err.Code {
-	case http.StatusBadRequest:
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
 		bizErr = errors.WithCode(code.ErrBadRequest, "%s", message)
 	case http.StatusUnauthorized:
 		bizErr = errors.WithCode(code.ErrUnauthorized, "%s", message)
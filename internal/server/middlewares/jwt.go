@@ -9,7 +9,7 @@
 package middlewares
 
 import (
-	"fmt"
+	"context"
 	"strings"
 
 	"github.com/NSObjects/go-template/internal/code"
@@ -24,10 +24,26 @@ import (
 type JWTConfig struct {
 	// 签名密钥
 	SigningKey []byte
+	// 按 kid 查找验签密钥，设置后忽略 SigningKey
+	KeyFunc jwt.Keyfunc
 	// 跳过路径
 	SkipPaths []string
 	// 是否启用
 	Enabled bool
+	// 访问令牌吊销检查，为空时不检查
+	Revocation RevocationChecker
+	// 账号状态检查，为空时不检查
+	Accounts AccountChecker
+}
+
+// RevocationChecker 判断访问令牌 jti 是否已被吊销
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// AccountChecker 校验令牌所属账号仍然可用（未被禁用）
+type AccountChecker interface {
+	CheckAccount(ctx context.Context, userID int64) error
 }
 
 // DefaultJWTConfig 默认JWT配置
@@ -52,24 +68,25 @@ func JWT(config *JWTConfig) echo.MiddlewareFunc {
 		}
 	}
 
-	return echojwt.WithConfig(echojwt.Config{
+	parse := echojwt.WithConfig(echojwt.Config{
 		NewClaimsFunc: func(c echo.Context) jwt.Claims {
 			return new(utils.JwtCustomClaims)
 		},
 		SigningKey: config.SigningKey,
+		KeyFunc:    config.KeyFunc,
 		Skipper: func(c echo.Context) bool {
-			path := c.Path()
-
-			// 调试日志
-			fmt.Printf("DEBUG: Request path: %s, Skip paths: %v\n", path, config.SkipPaths)
+			// 已通过 API 密钥等其他方式认证
+			if _, ok := utils.GetPrincipal(c); ok {
+				return true
+			}
 
+			path := c.Path()
 			for _, skipPath := range config.SkipPaths {
 				// 支持精确匹配和前缀匹配
 				if path == skipPath ||
 					(len(skipPath) > 0 && skipPath[len(skipPath)-1] == '*' &&
 						len(path) >= len(skipPath)-1 &&
 						strings.HasPrefix(path, skipPath[:len(skipPath)-1])) {
-					fmt.Printf("DEBUG: Skipping JWT for path: %s\n", path)
 					return true
 				}
 			}
@@ -79,6 +96,73 @@ func JWT(config *JWTConfig) echo.MiddlewareFunc {
 			return errors.WrapC(err, code.ErrSignatureInvalid, "JWT签名无效")
 		},
 	})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		next = setPrincipal(next)
+		if config.Accounts != nil {
+			next = checkAccount(config.Accounts, next)
+		}
+		if config.Revocation != nil {
+			next = checkRevocation(config.Revocation, next)
+		}
+		return parse(next)
+	}
+}
+
+// checkAccount 拒绝已禁用账号的访问令牌，无需等待令牌过期
+func checkAccount(checker AccountChecker, next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token, ok := c.Get("user").(*jwt.Token)
+		if !ok {
+			return next(c)
+		}
+		claims, ok := token.Claims.(*utils.JwtCustomClaims)
+		if !ok {
+			return next(c)
+		}
+		if err := checker.CheckAccount(c.Request().Context(), claims.ID); err != nil {
+			return err
+		}
+		return next(c)
+	}
+}
+
+// setPrincipal 将令牌中的身份写入请求，供 utils.GetUserID 等读取
+func setPrincipal(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token, ok := c.Get("user").(*jwt.Token)
+		if !ok {
+			return next(c)
+		}
+		if claims, ok := token.Claims.(*utils.JwtCustomClaims); ok {
+			utils.SetPrincipal(c, utils.PrincipalFromClaims(claims))
+		}
+		return next(c)
+	}
+}
+
+// checkRevocation 拒绝已注销、已轮换或被强制下线的访问令牌
+func checkRevocation(checker RevocationChecker, next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// 跳过路径不会写入令牌
+		token, ok := c.Get("user").(*jwt.Token)
+		if !ok {
+			return next(c)
+		}
+		claims, ok := token.Claims.(*utils.JwtCustomClaims)
+		if !ok || claims.RegisteredClaims.ID == "" {
+			return next(c)
+		}
+
+		revoked, err := checker.IsRevoked(c.Request().Context(), claims.RegisteredClaims.ID)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return code.NewError(code.ErrTokenInvalid, "token has been revoked")
+		}
+		return next(c)
+	}
 }
 
 // CreateJWTConfig 从应用配置创建JWT配置
@@ -0,0 +1,129 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/code"
+	ratelimit "github.com/NSObjects/go-template/internal/middleware"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type revokedSet map[string]bool
+
+func (r revokedSet) IsRevoked(_ context.Context, jti string) (bool, error) {
+	return r[jti], nil
+}
+
+func signTestToken(t *testing.T, secret, jti string) string {
+	t.Helper()
+	claims := &utils.JwtCustomClaims{
+		Name: "alice",
+		ID:   1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return signed
+}
+
+func TestJWT_Revocation(t *testing.T) {
+	mw := JWT(&JWTConfig{
+		SigningKey: []byte("test-secret"),
+		Enabled:    true,
+		Revocation: revokedSet{"revoked-jti": true},
+	})
+	handler := mw(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	tests := []struct {
+		name    string
+		jti     string
+		wantErr bool
+	}{
+		{name: "active token", jti: "active-jti"},
+		{name: "revoked token", jti: "revoked-jti", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+signTestToken(t, "test-secret", tt.jti))
+			rec := httptest.NewRecorder()
+
+			err := handler(e.NewContext(req, rec))
+			if tt.wantErr {
+				assert.True(t, errors.IsCode(err, code.ErrTokenInvalid))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+	}
+}
+
+func TestJWT_Principal(t *testing.T) {
+	mw := JWT(&JWTConfig{SigningKey: []byte("test-secret"), Enabled: true})
+
+	var (
+		principal *utils.Principal
+		userID    string
+		rateKey   string
+	)
+	handler := mw(func(c echo.Context) error {
+		principal, _ = utils.GetPrincipal(c)
+		userID = utils.GetUserID(utils.BuildContext(c))
+		rateKey = ratelimit.UserKeyFunc(c)
+		return c.NoContent(http.StatusOK)
+	})
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+signTestToken(t, "test-secret", "jti"))
+	require.NoError(t, handler(e.NewContext(req, httptest.NewRecorder())))
+
+	require.NotNil(t, principal)
+	assert.Equal(t, "1", principal.ID)
+	assert.Equal(t, "alice", principal.Name)
+	assert.Equal(t, utils.AuthMethodJWT, principal.Method)
+	assert.Equal(t, "1", userID)
+	assert.Equal(t, "rate_limit:user:1", rateKey)
+}
+
+type disabledAccounts map[int64]bool
+
+func (d disabledAccounts) CheckAccount(_ context.Context, userID int64) error {
+	if d[userID] {
+		return code.NewError(code.ErrAccountDisabled, "account is disabled")
+	}
+	return nil
+}
+
+func TestJWT_DisabledAccount(t *testing.T) {
+	handler := JWT(&JWTConfig{
+		SigningKey: []byte("test-secret"),
+		Enabled:    true,
+		Accounts:   disabledAccounts{1: true},
+	})(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+signTestToken(t, "test-secret", "jti"))
+	err := handler(e.NewContext(req, httptest.NewRecorder()))
+	assert.True(t, errors.IsCode(err, code.ErrAccountDisabled))
+}
@@ -0,0 +1,393 @@
+/*
+ * Middleware Pipeline
+ * 配置驱动的中间件管道
+ *
+ * 中间件按名称注册到 Registry，middleware.pipeline 决定启用哪些以及执行顺序，
+ * 参数来自 [middleware.<name>]（内置）或 [middleware.options.<name>]（自定义）。
+ */
+
+package middlewares
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/configs"
+	ratelimit "github.com/NSObjects/go-template/internal/middleware"
+	"github.com/NSObjects/go-template/internal/tenant"
+	"github.com/casbin/casbin/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/labstack/gommon/bytes"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+)
+
+// 内置中间件名称
+const (
+	NameRecovery  = "recovery"
+	NameLogger    = "logger"
+	NameGzip      = "gzip"
+	NameCORS      = "cors"
+	NameJWT       = "jwt"
+	NameAPIKey    = "api_key"
+	NameCasbin    = "casbin"
+	NameTenant    = "tenant"
+	NameRateLimit = "rate_limit"
+	NameBodyLimit = "body_limit"
+	NameTimeout   = "timeout"
+)
+
+// DefaultPipeline middleware.pipeline 未配置时的默认管道
+var DefaultPipeline = []string{NameRecovery, NameLogger, NameGzip, NameCORS}
+
+const (
+	defaultLoggerFormat = "method=${method}, uri=${uri}, status=${status}, latency=${latency_human}\n"
+	defaultBodyLimit    = "4M"
+)
+
+// Deps 构造中间件时可用的依赖
+type Deps struct {
+	Config   configs.Config
+	Enforcer *casbin.SyncedEnforcer
+	Redis    *redis.Client
+	// JWT 为空时根据 Config.JWT 生成
+	JWT *JWTConfig
+	// APIKeys 校验 API 密钥，启用 api_key 中间件时必须提供
+	APIKeys APIKeyAuthenticator
+	// Options 当前中间件在 middleware.options.<name> 下的参数
+	Options map[string]any
+}
+
+// Factory 根据依赖构造中间件
+type Factory func(d Deps) (echo.MiddlewareFunc, error)
+
+// Definition 具名中间件，自定义中间件以此形式注册
+type Definition struct {
+	Name    string
+	Factory Factory
+}
+
+// Registry 中间件注册表
+type Registry struct {
+	factories map[string]Factory
+}
+
+// NewRegistry 创建注册表并注册全部内置中间件
+func NewRegistry() *Registry {
+	r := &Registry{factories: make(map[string]Factory)}
+	r.factories[NameRecovery] = newRecovery
+	r.factories[NameLogger] = newLogger
+	r.factories[NameGzip] = newGzip
+	r.factories[NameCORS] = newCORS
+	r.factories[NameJWT] = newJWT
+	r.factories[NameAPIKey] = newAPIKey
+	r.factories[NameCasbin] = newCasbin
+	r.factories[NameTenant] = newTenant
+	r.factories[NameRateLimit] = newRateLimit
+	r.factories[NameBodyLimit] = newBodyLimit
+	r.factories[NameTimeout] = newTimeout
+	return r
+}
+
+// Register 注册自定义中间件，名称不能与已有中间件重复
+func (r *Registry) Register(name string, f Factory) error {
+	if name == "" || f == nil {
+		return fmt.Errorf("middleware name and factory are required")
+	}
+	if _, ok := r.factories[name]; ok {
+		return fmt.Errorf("middleware %q already registered", name)
+	}
+	r.factories[name] = f
+	return nil
+}
+
+// Names 返回已注册的中间件名称
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Build 按顺序构造中间件，名称未注册或参数无效时返回错误
+func (r *Registry) Build(pipeline []string, d Deps) ([]echo.MiddlewareFunc, error) {
+	seen := make(map[string]bool, len(pipeline))
+	chain := make([]echo.MiddlewareFunc, 0, len(pipeline))
+	for _, name := range pipeline {
+		f, ok := r.factories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown middleware %q, available: %v", name, r.Names())
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("middleware %q listed more than once", name)
+		}
+		seen[name] = true
+
+		d.Options = d.Config.Middleware.Options[name]
+		mw, err := f(d)
+		if err != nil {
+			return nil, fmt.Errorf("middleware %q: %w", name, err)
+		}
+		chain = append(chain, mw)
+	}
+	return chain, nil
+}
+
+// chainNextKey 保存本次请求中链之后的处理器，链只在 Swap 时组装一次，末端从 echo.Context 取出 next 继续执行
+const chainNextKey = "middlewares.chain.next"
+
+// Chain 可在运行时整体替换的中间件链，配置热更新后重新 Build 并 Swap，
+// 正在处理的请求继续使用旧链
+type Chain struct {
+	v atomic.Pointer[echo.HandlerFunc]
+}
+
+// NewChain 创建中间件链
+func NewChain(mws []echo.MiddlewareFunc) *Chain {
+	c := &Chain{}
+	c.Swap(mws)
+	return c
+}
+
+// Swap 替换中间件链，各中间件的包装在此完成，请求处理时不再重复执行
+func (c *Chain) Swap(mws []echo.MiddlewareFunc) {
+	h := echo.HandlerFunc(func(ctx echo.Context) error {
+		return ctx.Get(chainNextKey).(echo.HandlerFunc)(ctx)
+	})
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	c.v.Store(&h)
+}
+
+// Middleware 返回按当前链依次执行的中间件
+func (c *Chain) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			ctx.Set(chainNextKey, next)
+			return (*c.v.Load())(ctx)
+		}
+	}
+}
+
+func newRecovery(Deps) (echo.MiddlewareFunc, error) {
+	return ErrorRecovery(), nil
+}
+
+func newLogger(d Deps) (echo.MiddlewareFunc, error) {
+	format := d.Config.Middleware.Logger.Format
+	if format == "" {
+		format = defaultLoggerFormat
+	}
+	return middleware.LoggerWithConfig(middleware.LoggerConfig{Format: format}), nil
+}
+
+func newGzip(d Deps) (echo.MiddlewareFunc, error) {
+	level := d.Config.Middleware.Gzip.Level
+	if level < 0 || level > 9 {
+		return nil, fmt.Errorf("invalid gzip level %d", level)
+	}
+	return middleware.GzipWithConfig(middleware.GzipConfig{Level: level}), nil
+}
+
+func newCORS(d Deps) (echo.MiddlewareFunc, error) {
+	return middleware.CORSWithConfig(corsConfig(d.Config.CORS)), nil
+}
+
+// corsConfig 将 [cors] 转换为 echo 配置，未配置的字段使用宽松的默认值
+func corsConfig(c configs.CORSConfig) middleware.CORSConfig {
+	cfg := middleware.CORSConfig{
+		AllowOrigins:     c.AllowOrigins,
+		AllowHeaders:     c.AllowHeaders,
+		AllowMethods:     c.AllowMethods,
+		AllowCredentials: c.AllowCredentials,
+	}
+	if len(cfg.AllowOrigins) == 0 {
+		cfg.AllowOrigins = []string{"*"}
+	}
+	if len(cfg.AllowHeaders) == 0 {
+		cfg.AllowHeaders = []string{
+			echo.HeaderOrigin,
+			echo.HeaderContentType,
+			echo.HeaderAccept,
+			echo.HeaderAuthorization,
+		}
+	}
+	if len(cfg.AllowMethods) == 0 {
+		cfg.AllowMethods = []string{
+			echo.GET,
+			echo.HEAD,
+			echo.PUT,
+			echo.PATCH,
+			echo.POST,
+			echo.DELETE,
+			echo.OPTIONS,
+		}
+	}
+	return cfg
+}
+
+func newJWT(d Deps) (echo.MiddlewareFunc, error) {
+	cfg := d.JWT
+	if cfg == nil {
+		cfg = CreateJWTConfig(d.Config.JWT.Secret, d.Config.JWT.SkipPaths, true)
+	}
+	if cfg.KeyFunc == nil && len(cfg.SigningKey) == 0 {
+		return nil, fmt.Errorf("jwt.secret or jwt.keys must be configured")
+	}
+	enabled := *cfg
+	enabled.Enabled = true
+	return JWT(&enabled), nil
+}
+
+func newAPIKey(d Deps) (echo.MiddlewareFunc, error) {
+	if d.APIKeys == nil {
+		return nil, fmt.Errorf("api key authenticator is not available")
+	}
+	return APIKey(d.APIKeys), nil
+}
+
+func newCasbin(d Deps) (echo.MiddlewareFunc, error) {
+	if d.Enforcer == nil {
+		return nil, fmt.Errorf("casbin enforcer is not available")
+	}
+	defaults := DefaultCasbinConfig()
+	skipPaths := d.Config.Middleware.Casbin.SkipPaths
+	if len(skipPaths) == 0 {
+		skipPaths = defaults.SkipPaths
+	}
+	cfg := CreateCasbinConfig(true, skipPaths, d.Config.Middleware.Casbin.Admins())
+	cfg.DomainGetter = TenantDomainGetter(d.Config.Middleware.Casbin.DomainHeader)
+	return Casbin(d.Enforcer, cfg), nil
+}
+
+func newTenant(d Deps) (echo.MiddlewareFunc, error) {
+	resolver, err := tenant.NewResolver(d.Config.Tenant)
+	if err != nil {
+		return nil, err
+	}
+	return Tenant(TenantConfig{
+		Resolver:  resolver,
+		Required:  d.Config.Tenant.Required,
+		SkipPaths: d.Config.Tenant.SkipPaths,
+	}), nil
+}
+
+// newRateLimit 配置了 Redis 时使用滑动窗口分布式限流，否则退化为进程内令牌桶。
+// tenant.overrides.<id>.middleware.rate_limit 覆盖了限流规则的租户使用独立的限流器与计数，
+// 租户由 tenant 中间件解析，需要放在其之后
+func newRateLimit(d Deps) (echo.MiddlewareFunc, error) {
+	base, err := rateLimiter(d, d.Config.Middleware.RateLimit, "")
+	if err != nil {
+		return nil, err
+	}
+	tenants := make(map[string]echo.MiddlewareFunc)
+	for id := range d.Config.Tenant.Overrides {
+		rule := d.Config.ForTenant(id).Middleware.RateLimit
+		if rule == d.Config.Middleware.RateLimit {
+			continue
+		}
+		limiter, err := rateLimiter(d, rule, id)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", id, err)
+		}
+		tenants[id] = limiter
+	}
+	if len(tenants) == 0 {
+		return base, nil
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		limited := base(next)
+		overrides := make(map[string]echo.HandlerFunc, len(tenants))
+		for id, limiter := range tenants {
+			overrides[id] = limiter(next)
+		}
+		return func(c echo.Context) error {
+			if h, ok := overrides[TenantID(c)]; ok {
+				return h(c)
+			}
+			return limited(c)
+		}
+	}, nil
+}
+
+// rateLimiter 按规则创建限流器，tenantID 非空时限流键带上租户前缀，与默认规则的计数互不影响
+func rateLimiter(d Deps, c configs.RateLimitMiddlewareConfig, tenantID string) (echo.MiddlewareFunc, error) {
+	if c.Requests <= 0 {
+		return nil, fmt.Errorf("rate_limit.requests must be positive")
+	}
+	window := c.Window
+	if window <= 0 {
+		window = time.Second
+	}
+
+	var keyFunc func(echo.Context) string
+	switch c.Key {
+	case "", "ip":
+		keyFunc = ratelimit.DefaultKeyFunc
+	case "user":
+		// 未登录请求仍按 IP 限流
+		keyFunc = func(ctx echo.Context) string {
+			if key := ratelimit.UserKeyFunc(ctx); key != "" {
+				return key
+			}
+			return ratelimit.DefaultKeyFunc(ctx)
+		}
+	default:
+		return nil, fmt.Errorf("invalid rate_limit.key %q, expected ip or user", c.Key)
+	}
+	if tenantID != "" {
+		key := keyFunc
+		keyFunc = func(ctx echo.Context) string {
+			return "tenant:" + tenantID + ":" + key(ctx)
+		}
+	}
+
+	if d.Redis != nil {
+		return ratelimit.NewRateLimiter(d.Redis).RateLimit(ratelimit.RateLimitConfig{
+			Requests: c.Requests,
+			Window:   window,
+			KeyFunc:  keyFunc,
+		}), nil
+	}
+
+	store := middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+		Rate:      rate.Limit(float64(c.Requests) / window.Seconds()),
+		Burst:     c.Requests,
+		ExpiresIn: window * 3,
+	})
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store: store,
+		IdentifierExtractor: func(ctx echo.Context) (string, error) {
+			return keyFunc(ctx), nil
+		},
+		DenyHandler: func(ctx echo.Context, _ string, _ error) error {
+			return ratelimit.TooManyRequests(ctx)
+		},
+	}), nil
+}
+
+func newBodyLimit(d Deps) (echo.MiddlewareFunc, error) {
+	limit := d.Config.Middleware.BodyLimit.Limit
+	if limit == "" {
+		limit = defaultBodyLimit
+	}
+	if _, err := bytes.Parse(limit); err != nil {
+		return nil, fmt.Errorf("invalid body_limit.limit %q: %w", limit, err)
+	}
+	return middleware.BodyLimit(limit), nil
+}
+
+func newTimeout(d Deps) (echo.MiddlewareFunc, error) {
+	timeout := d.Config.Middleware.Timeout.Timeout
+	if timeout <= 0 {
+		return nil, fmt.Errorf("timeout.timeout must be positive")
+	}
+	return middleware.ContextTimeoutWithConfig(middleware.ContextTimeoutConfig{Timeout: timeout}), nil
+}
@@ -0,0 +1,204 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve 使用给定管道处理一次请求
+func serve(t *testing.T, chain []echo.MiddlewareFunc, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	e := echo.New()
+	e.HTTPErrorHandler = ErrorHandler
+	e.Use(chain...)
+	e.Any("/api/ping", func(c echo.Context) error {
+		return c.String(http.StatusOK, "pong")
+	})
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRegistry_BuildOrder(t *testing.T) {
+	r := NewRegistry()
+	var order []string
+	for _, name := range []string{"first", "second"} {
+		name := name
+		require.NoError(t, r.Register(name, func(Deps) (echo.MiddlewareFunc, error) {
+			return func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					order = append(order, name)
+					return next(c)
+				}
+			}, nil
+		}))
+	}
+
+	chain, err := r.Build([]string{"second", NameRecovery, "first"}, Deps{})
+	require.NoError(t, err)
+	rec := serve(t, chain, httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"second", "first"}, order)
+}
+
+func TestChain_ComposesOncePerSwap(t *testing.T) {
+	var wraps int
+	var order []string
+	mw := func(name string) echo.MiddlewareFunc {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			wraps++
+			return func(c echo.Context) error {
+				order = append(order, name)
+				return next(c)
+			}
+		}
+	}
+	chain := NewChain([]echo.MiddlewareFunc{mw("a"), mw("b")})
+	for i := 0; i < 3; i++ {
+		rec := serve(t, []echo.MiddlewareFunc{chain.Middleware()}, httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+		assert.Equal(t, "pong", rec.Body.String())
+	}
+	assert.Equal(t, 2, wraps)
+	assert.Equal(t, []string{"a", "b", "a", "b", "a", "b"}, order)
+
+	order = nil
+	chain.Swap([]echo.MiddlewareFunc{mw("c")})
+	rec := serve(t, []echo.MiddlewareFunc{chain.Middleware()}, httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 3, wraps)
+	assert.Equal(t, []string{"c"}, order)
+}
+
+func TestRegistry_Errors(t *testing.T) {
+	r := NewRegistry()
+	assert.Error(t, r.Register(NameCORS, func(Deps) (echo.MiddlewareFunc, error) { return nil, nil }))
+
+	tests := []struct {
+		name     string
+		pipeline []string
+		cfg      configs.Config
+	}{
+		{name: "unknown middleware", pipeline: []string{"missing"}},
+		{name: "duplicate middleware", pipeline: []string{NameGzip, NameGzip}},
+		{name: "casbin without enforcer", pipeline: []string{NameCasbin}},
+		{name: "jwt without key", pipeline: []string{NameJWT}},
+		{name: "api key without authenticator", pipeline: []string{NameAPIKey}},
+		{name: "rate limit without requests", pipeline: []string{NameRateLimit}},
+		{name: "timeout without duration", pipeline: []string{NameTimeout}},
+		{
+			name:     "invalid body limit",
+			pipeline: []string{NameBodyLimit},
+			cfg:      configs.Config{Middleware: configs.MiddlewareConfig{BodyLimit: configs.BodyLimitMiddlewareConfig{Limit: "lots"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := r.Build(tt.pipeline, Deps{Config: tt.cfg})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRegistry_CORSFromConfig(t *testing.T) {
+	cfg := configs.Config{CORS: configs.CORSConfig{AllowOrigins: []string{"https://admin.example.com"}}}
+	chain, err := NewRegistry().Build([]string{NameCORS}, Deps{Config: cfg})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+	req.Header.Set(echo.HeaderOrigin, "https://admin.example.com")
+	rec := serve(t, chain, req)
+	assert.Equal(t, "https://admin.example.com", rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+
+	req = httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+	req.Header.Set(echo.HeaderOrigin, "https://evil.example.com")
+	rec = serve(t, chain, req)
+	assert.Empty(t, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+}
+
+func TestRegistry_BodyLimit(t *testing.T) {
+	cfg := configs.Config{Middleware: configs.MiddlewareConfig{BodyLimit: configs.BodyLimitMiddlewareConfig{Limit: "1K"}}}
+	chain, err := NewRegistry().Build([]string{NameBodyLimit}, Deps{Config: cfg})
+	require.NoError(t, err)
+
+	rec := serve(t, chain, httptest.NewRequest(http.MethodPost, "/api/ping", strings.NewReader(strings.Repeat("x", 2048))))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRegistry_RateLimitMemory(t *testing.T) {
+	cfg := configs.Config{Middleware: configs.MiddlewareConfig{RateLimit: configs.RateLimitMiddlewareConfig{Requests: 2}}}
+	chain, err := NewRegistry().Build([]string{NameRateLimit}, Deps{Config: cfg})
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Use(chain...)
+	e.GET("/api/ping", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	codes := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+		codes = append(codes, rec.Code)
+	}
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+}
+
+func TestRegistry_RateLimitTenantOverride(t *testing.T) {
+	cfg := configs.Config{
+		Middleware: configs.MiddlewareConfig{RateLimit: configs.RateLimitMiddlewareConfig{Requests: 1}},
+		Tenant: configs.TenantConfig{Overrides: map[string]configs.Config{
+			"acme": {Middleware: configs.MiddlewareConfig{RateLimit: configs.RateLimitMiddlewareConfig{Requests: 3}}},
+		}},
+	}
+	chain, err := NewRegistry().Build([]string{NameTenant, NameRateLimit}, Deps{Config: cfg})
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.Use(chain...)
+	e.GET("/api/ping", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	serveAs := func(tenantID string, n int) []int {
+		codes := make([]int, 0, n)
+		for i := 0; i < n; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+			if tenantID != "" {
+				req.Header.Set("X-Tenant-ID", tenantID)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			codes = append(codes, rec.Code)
+		}
+		return codes
+	}
+
+	// 覆盖了规则的租户使用独立的计数，不受默认规则影响
+	assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests}, serveAs("", 2))
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, serveAs("acme", 4))
+	assert.Equal(t, []int{http.StatusTooManyRequests}, serveAs("globex", 1))
+
+	cfg.Tenant.Overrides["acme"] = configs.Config{Middleware: configs.MiddlewareConfig{RateLimit: configs.RateLimitMiddlewareConfig{Key: "cookie"}}}
+	_, err = NewRegistry().Build([]string{NameTenant, NameRateLimit}, Deps{Config: cfg})
+	assert.Error(t, err)
+}
+
+func TestRegistry_Options(t *testing.T) {
+	r := NewRegistry()
+	var got map[string]any
+	require.NoError(t, r.Register("tenant_header", func(d Deps) (echo.MiddlewareFunc, error) {
+		got = d.Options
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }, nil
+	}))
+
+	cfg := configs.Config{Middleware: configs.MiddlewareConfig{
+		Options: map[string]map[string]any{"tenant_header": {"header": "X-Tenant"}},
+	}}
+	_, err := r.Build([]string{"tenant_header"}, Deps{Config: cfg})
+	require.NoError(t, err)
+	assert.Equal(t, "X-Tenant", got["header"])
+}
@@ -0,0 +1,80 @@
+/*
+ * Tenant Middleware
+ * 多租户解析中间件
+ */
+
+package middlewares
+
+import (
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/tenant"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/samber/lo"
+)
+
+// TenantConfig 租户中间件配置
+type TenantConfig struct {
+	Resolver *tenant.Resolver
+	// 未解析出租户时拒绝请求
+	Required bool
+	// 跳过路径
+	SkipPaths []string
+}
+
+// Tenant 解析请求所属租户，写入 echo.Context 与请求 context，
+// utils.BuildContext 构造的业务 context 随之携带租户，数据层据此自动隔离。
+// 需要放在 api_key、jwt 之后，已认证的请求使用认证主体所属的租户。
+func Tenant(config TenantConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if lo.Contains(config.SkipPaths, c.Path()) {
+				return next(c)
+			}
+
+			tenantID, err := config.Resolver.Resolve(c)
+			if err != nil {
+				return err
+			}
+			if tenantID == "" {
+				if config.Required {
+					return code.NewError(code.ErrTenantRequired, "tenant is required")
+				}
+				return next(c)
+			}
+
+			c.Set("tenant_id", tenantID)
+			req := c.Request()
+			c.SetRequest(req.WithContext(utils.WithTenantID(req.Context(), tenantID)))
+			return next(c)
+		}
+	}
+}
+
+// TenantID 返回 tenant 中间件解析出的租户
+func TenantID(c echo.Context) string {
+	tenantID, _ := c.Get("tenant_id").(string)
+	return tenantID
+}
+
+// TenantDomainGetter 优先使用 tenant 中间件解析出的租户作为 casbin 域；
+// 未启用时使用认证主体所属的租户，请求头携带的域与之不一致时拒绝；没有认证主体时回退到请求头
+func TenantDomainGetter(header string) func(c echo.Context) (string, error) {
+	fallback := HeaderDomainGetter(header)
+	return func(c echo.Context) (string, error) {
+		if tenantID := TenantID(c); tenantID != "" {
+			return tenantID, nil
+		}
+		domain, err := fallback(c)
+		if err != nil {
+			return "", err
+		}
+		if p, ok := utils.GetPrincipal(c); ok {
+			if domain != "" && domain != p.TenantID {
+				return "", code.NewError(code.ErrTenantMismatch, "domain does not match the authenticated principal")
+			}
+			return p.TenantID, nil
+		}
+		return domain, nil
+	}
+}
@@ -0,0 +1,140 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenant(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    configs.TenantConfig
+		host   string
+		header string
+		claim  string
+		// authenticated 请求已认证，claim 非空时同样视为已认证
+		authenticated bool
+		want          int
+		tenant        string
+	}{
+		{name: "请求头", header: "acme", want: http.StatusOK, tenant: "acme"},
+		{name: "令牌", claim: "acme", want: http.StatusOK, tenant: "acme"},
+		{name: "令牌与请求头一致", claim: "acme", header: "acme", want: http.StatusOK, tenant: "acme"},
+		{name: "令牌与请求头不一致", claim: "acme", header: "globex", want: http.StatusForbidden},
+		{name: "令牌未携带租户时不采用请求头", authenticated: true, header: "acme", want: http.StatusForbidden},
+		{name: "令牌未携带租户", authenticated: true, want: http.StatusOK},
+		{
+			name:  "来源不含令牌时同样以令牌为准",
+			cfg:   configs.TenantConfig{Sources: []string{"header"}},
+			claim: "acme", header: "globex", want: http.StatusForbidden,
+		},
+		{
+			name:  "令牌与子域名不一致",
+			cfg:   configs.TenantConfig{Sources: []string{"subdomain"}, BaseDomain: "example.com"},
+			claim: "acme", host: "globex.example.com", want: http.StatusForbidden,
+		},
+		{name: "未携带租户", want: http.StatusOK},
+		{name: "必须携带租户", cfg: configs.TenantConfig{Required: true}, want: http.StatusBadRequest},
+		{
+			name: "子域名",
+			cfg:  configs.TenantConfig{Sources: []string{"subdomain"}, BaseDomain: "example.com", Required: true},
+			host: "acme.example.com:8080", want: http.StatusOK, tenant: "acme",
+		},
+		{
+			name: "多级子域名",
+			cfg:  configs.TenantConfig{Sources: []string{"subdomain"}, BaseDomain: "example.com", Required: true},
+			host: "a.acme.example.com", want: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw, err := NewRegistry().Build([]string{NameTenant}, Deps{Config: configs.Config{Tenant: tt.cfg}})
+			require.NoError(t, err)
+
+			var got string
+			e := echo.New()
+			e.HTTPErrorHandler = ErrorHandler
+			e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					if tt.claim != "" || tt.authenticated {
+						utils.SetPrincipal(c, &utils.Principal{ID: "7", TenantID: tt.claim})
+					}
+					return next(c)
+				}
+			})
+			e.Use(mw...)
+			e.GET("/api/ping", func(c echo.Context) error {
+				got = utils.GetTenantID(utils.BuildContext(c))
+				return c.NoContent(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tt.want, rec.Code)
+			assert.Equal(t, tt.tenant, got)
+		})
+	}
+}
+
+func TestTenant_InvalidConfig(t *testing.T) {
+	_, err := NewRegistry().Build([]string{NameTenant}, Deps{Config: configs.Config{
+		Tenant: configs.TenantConfig{Sources: []string{"subdomain"}},
+	}})
+	assert.Error(t, err)
+	_, err = NewRegistry().Build([]string{NameTenant}, Deps{Config: configs.Config{
+		Tenant: configs.TenantConfig{Sources: []string{"cookie"}},
+	}})
+	assert.Error(t, err)
+}
+
+func TestTenantDomainGetter(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *utils.Principal
+		header    string
+		want      string
+		wantErr   bool
+	}{
+		{name: "未认证使用请求头", header: "acme", want: "acme"},
+		{name: "使用令牌所属租户", principal: &utils.Principal{ID: "7", TenantID: "acme"}, want: "acme"},
+		{name: "请求头与令牌一致", principal: &utils.Principal{ID: "7", TenantID: "acme"}, header: "acme", want: "acme"},
+		{name: "请求头与令牌不一致", principal: &utils.Principal{ID: "7", TenantID: "acme"}, header: "globex", wantErr: true},
+		{name: "令牌未携带租户", principal: &utils.Principal{ID: "7"}, header: "acme", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/ping", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+			if tt.principal != nil {
+				utils.SetPrincipal(c, tt.principal)
+			}
+
+			got, err := TenantDomainGetter("")(c)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultColumn tenant.column 未配置时的租户字段
+const DefaultColumn = "tenant_id"
+
+type skipKey struct{}
+
+// SkipScope 返回不做租户隔离的 context，仅用于跨租户的后台任务与平台管理
+func SkipScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey{}, true)
+}
+
+func skipped(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipKey{}).(bool)
+	return skip
+}
+
+// Plugin GORM 插件：对含租户字段的表，查询、更新、删除自动追加 tenant_id 条件，
+// 创建时自动填充 tenant_id。租户取自 utils.GetTenantID(ctx)，
+// 因此 query.Query 的 WithContext(ctx) 调用无需再手写租户条件。
+// context 中没有租户时拒绝访问这些表，而不是返回全部租户的数据。
+type Plugin struct {
+	column string
+}
+
+// NewPlugin 创建插件，column 为空时使用 tenant_id
+func NewPlugin(column string) *Plugin {
+	if column == "" {
+		column = DefaultColumn
+	}
+	return &Plugin{column: column}
+}
+
+func (p *Plugin) Name() string {
+	return "tenant"
+}
+
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("tenant:create", p.assign); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("tenant:query", p.scope); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("tenant:row", p.scope); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenant:update", p.scopeWrite); err != nil {
+		return err
+	}
+	return cb.Delete().Before("gorm:delete").Register("tenant:delete", p.scopeWrite)
+}
+
+// field 返回当前语句需要隔离的租户字段，不需要隔离时返回 nil
+func (p *Plugin) field(db *gorm.DB) *schema.Field {
+	stmt := db.Statement
+	// Raw/Exec 的 SQL 已经写好，由调用方负责
+	if db.Error != nil || stmt.Schema == nil || stmt.SQL.Len() > 0 || skipped(stmt.Context) {
+		return nil
+	}
+	return stmt.Schema.LookUpField(p.column)
+}
+
+// tenantID 返回 context 中的租户，缺失时为语句记录错误
+func (p *Plugin) tenantID(db *gorm.DB) (string, bool) {
+	id := utils.GetTenantID(db.Statement.Context)
+	if id == "" {
+		_ = db.AddError(code.NewErrorf(code.ErrTenantRequired, "tenant is required to access %s", db.Statement.Schema.Table))
+		return "", false
+	}
+	return id, true
+}
+
+func (p *Plugin) scope(db *gorm.DB) {
+	f := p.field(db)
+	if f == nil {
+		return
+	}
+	id, ok := p.tenantID(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: f.DBName}, Value: id},
+	}})
+}
+
+// scopeWrite 租户条件会让 gorm 的全表更新保护失效，因此在追加前自行检查
+func (p *Plugin) scopeWrite(db *gorm.DB) {
+	if p.field(db) == nil {
+		return
+	}
+	if _, ok := db.Statement.Clauses["WHERE"]; !ok && !db.AllowGlobalUpdate && !hasPrimaryKey(db.Statement) {
+		_ = db.AddError(gorm.ErrMissingWhereClause)
+		return
+	}
+	p.scope(db)
+}
+
+func (p *Plugin) assign(db *gorm.DB) {
+	f := p.field(db)
+	if f == nil {
+		return
+	}
+	id, ok := p.tenantID(db)
+	if !ok {
+		return
+	}
+
+	ctx := db.Statement.Context
+	set := func(rv reflect.Value) {
+		if v, zero := f.ValueOf(ctx, rv); !zero {
+			// 不允许写入其他租户的数据
+			if fmt.Sprint(v) != id {
+				_ = db.AddError(code.NewErrorf(code.ErrTenantMismatch, "%s does not match the current tenant", f.DBName))
+			}
+			return
+		}
+		if err := f.Set(ctx, rv, id); err != nil {
+			_ = db.AddError(err)
+		}
+	}
+
+	switch rv := reflect.Indirect(db.Statement.ReflectValue); rv.Kind() {
+	case reflect.Struct:
+		set(rv)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			set(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Map:
+		switch dest := db.Statement.Dest.(type) {
+		case map[string]interface{}:
+			p.assignMap(db, f, dest, id)
+		case *map[string]interface{}:
+			p.assignMap(db, f, *dest, id)
+		case []map[string]interface{}:
+			for _, m := range dest {
+				p.assignMap(db, f, m, id)
+			}
+		}
+	}
+}
+
+// assignMap gorm 同时接受字段名与列名作为键
+func (p *Plugin) assignMap(db *gorm.DB, f *schema.Field, m map[string]interface{}, id string) {
+	for _, key := range []string{f.Name, f.DBName} {
+		if v, ok := m[key]; ok {
+			if fmt.Sprint(v) != id {
+				_ = db.AddError(code.NewErrorf(code.ErrTenantMismatch, "%s does not match the current tenant", f.DBName))
+			}
+			return
+		}
+	}
+	m[f.DBName] = id
+}
+
+// hasPrimaryKey 模型或更新值带有主键时，gorm 会据此生成条件
+func hasPrimaryKey(stmt *gorm.Statement) bool {
+	pk := stmt.Schema.PrioritizedPrimaryField
+	if pk == nil {
+		return false
+	}
+	for _, v := range []reflect.Value{stmt.ReflectValue, reflect.ValueOf(stmt.Dest)} {
+		rv := reflect.Indirect(v)
+		switch rv.Kind() {
+		case reflect.Struct:
+			if rv.Type() != stmt.Schema.ModelType {
+				continue
+			}
+			if _, zero := pk.ValueOf(stmt.Context, rv); !zero {
+				return true
+			}
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				elem := reflect.Indirect(rv.Index(i))
+				if elem.Kind() != reflect.Struct || elem.Type() != stmt.Schema.ModelType {
+					break
+				}
+				if _, zero := pk.ValueOf(stmt.Context, elem); !zero {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
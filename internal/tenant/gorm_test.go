@@ -0,0 +1,102 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/glebarez/sqlite"
+	"github.com/marmotedu/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type note struct {
+	ID       int64
+	TenantID string
+	Title    string
+}
+
+// setting 不含租户字段的表不受影响
+type setting struct {
+	ID   int64
+	Name string
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	// 内存数据库按连接隔离
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&note{}, &setting{}))
+	require.NoError(t, db.Use(NewPlugin("")))
+	return db
+}
+
+func TestPlugin_Scope(t *testing.T) {
+	db := newTestDB(t)
+	acme := utils.WithTenantID(context.Background(), "acme")
+	globex := utils.WithTenantID(context.Background(), "globex")
+
+	require.NoError(t, db.WithContext(acme).Create(&[]note{{Title: "a1"}, {Title: "a2"}}).Error)
+	require.NoError(t, db.WithContext(globex).Create(&note{Title: "g1"}).Error)
+	require.NoError(t, db.WithContext(globex).Model(&note{}).Create(map[string]interface{}{"Title": "g2"}).Error)
+
+	// 创建时不能写入其他租户
+	err := db.WithContext(acme).Create(&note{TenantID: "globex", Title: "x"}).Error
+	assert.True(t, errors.IsCode(err, code.ErrTenantMismatch))
+
+	var notes []note
+	require.NoError(t, db.WithContext(acme).Find(&notes).Error)
+	require.Len(t, notes, 2)
+	for _, n := range notes {
+		assert.Equal(t, "acme", n.TenantID)
+	}
+
+	var count int64
+	require.NoError(t, db.WithContext(globex).Model(&note{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+
+	// 按主键也取不到其他租户的数据
+	var n note
+	err = db.WithContext(globex).First(&n, notes[0].ID).Error
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	res := db.WithContext(globex).Model(&note{}).Where("title = ?", "a1").Update("title", "hacked")
+	require.NoError(t, res.Error)
+	assert.Zero(t, res.RowsAffected)
+	res = db.WithContext(globex).Delete(&note{ID: notes[0].ID})
+	require.NoError(t, res.Error)
+	assert.Zero(t, res.RowsAffected)
+
+	// 租户条件不能替代全表更新保护
+	err = db.WithContext(acme).Model(&note{}).Update("title", "all").Error
+	assert.ErrorIs(t, err, gorm.ErrMissingWhereClause)
+	res = db.WithContext(acme).Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&note{})
+	require.NoError(t, res.Error)
+	assert.Equal(t, int64(2), res.RowsAffected)
+
+	require.NoError(t, db.WithContext(SkipScope(context.Background())).Model(&note{}).Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestPlugin_MissingTenant(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	var notes []note
+	err := db.WithContext(ctx).Find(&notes).Error
+	assert.True(t, errors.IsCode(err, code.ErrTenantRequired))
+	err = db.WithContext(ctx).Create(&note{Title: "x"}).Error
+	assert.True(t, errors.IsCode(err, code.ErrTenantRequired))
+
+	require.NoError(t, db.WithContext(ctx).Create(&setting{Name: "x"}).Error)
+	var settings []setting
+	require.NoError(t, db.WithContext(ctx).Find(&settings).Error)
+	assert.Len(t, settings, 1)
+}
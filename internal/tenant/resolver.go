@@ -0,0 +1,128 @@
+/*
+ * Tenant
+ * 多租户：解析请求所属租户，并在数据层按租户自动隔离
+ */
+
+package tenant
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/NSObjects/go-template/internal/code"
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/NSObjects/go-template/internal/utils"
+	"github.com/labstack/echo/v4"
+)
+
+// 租户来源
+const (
+	SourceHeader    = "header"
+	SourceSubdomain = "subdomain"
+	SourceClaim     = "claim"
+)
+
+// DefaultHeader tenant.header 未配置时携带租户的请求头
+const DefaultHeader = "X-Tenant-ID"
+
+// DefaultSources tenant.sources 未配置时的来源
+var DefaultSources = []string{SourceClaim, SourceHeader}
+
+// Resolver 按配置的来源解析请求所属租户
+type Resolver struct {
+	sources    []string
+	header     string
+	baseDomain string
+}
+
+// NewResolver 根据 [tenant] 创建解析器，来源未知或缺少必要参数时返回错误
+func NewResolver(cfg configs.TenantConfig) (*Resolver, error) {
+	r := &Resolver{
+		sources:    cfg.Sources,
+		header:     cfg.Header,
+		baseDomain: strings.ToLower(strings.Trim(cfg.BaseDomain, ".")),
+	}
+	if len(r.sources) == 0 {
+		r.sources = DefaultSources
+	}
+	if r.header == "" {
+		r.header = DefaultHeader
+	}
+	for _, source := range r.sources {
+		switch source {
+		case SourceHeader, SourceClaim:
+		case SourceSubdomain:
+			if r.baseDomain == "" {
+				return nil, fmt.Errorf("tenant.base_domain is required for the subdomain source")
+			}
+		default:
+			return nil, fmt.Errorf("unknown tenant source %q, expected header, subdomain or claim", source)
+		}
+	}
+	return r, nil
+}
+
+// Resolve 返回请求所属租户，未携带租户时返回空字符串。
+// 已认证的请求只属于认证主体所属的租户：请求头、子域名携带的租户与之不一致时拒绝，
+// 认证主体没有租户时同样不采用请求头，避免通过请求头访问令牌所属租户之外的数据。
+// 未认证的请求（如登录）按来源解析，多个来源都携带租户时必须一致。
+func (r *Resolver) Resolve(c echo.Context) (string, error) {
+	if p, ok := utils.GetPrincipal(c); ok {
+		for _, source := range r.sources {
+			if id := r.lookup(c, source); id != "" && id != p.TenantID {
+				return "", code.NewErrorf(code.ErrTenantMismatch, "tenant from %s does not match the authenticated principal", source)
+			}
+		}
+		return p.TenantID, nil
+	}
+
+	var tenantID, from string
+	for _, source := range r.sources {
+		id := r.lookup(c, source)
+		if id == "" {
+			continue
+		}
+		if tenantID == "" {
+			tenantID, from = id, source
+			continue
+		}
+		if id != tenantID {
+			return "", code.NewErrorf(code.ErrTenantMismatch, "tenant from %s does not match tenant from %s", source, from)
+		}
+	}
+	return tenantID, nil
+}
+
+func (r *Resolver) lookup(c echo.Context, source string) string {
+	switch source {
+	case SourceHeader:
+		return strings.TrimSpace(c.Request().Header.Get(r.header))
+	case SourceSubdomain:
+		return r.subdomain(c.Request().Host)
+	case SourceClaim:
+		return claimTenant(c)
+	}
+	return ""
+}
+
+// subdomain 只接受根域名下的一级子域名，acme.example.com 解析为 acme
+func (r *Resolver) subdomain(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	label, ok := strings.CutSuffix(host, "."+r.baseDomain)
+	if !ok || label == "" || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// claimTenant 读取认证中间件写入的认证主体所属租户
+func claimTenant(c echo.Context) string {
+	if p, ok := utils.GetPrincipal(c); ok {
+		return p.TenantID
+	}
+	return ""
+}
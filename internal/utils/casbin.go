@@ -1,23 +1,84 @@
 package utils
 
 import (
+	"context"
+	"embed"
+	"fmt"
+	"strings"
+
 	"github.com/NSObjects/go-template/internal/configs"
 	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
 	gormadapter "github.com/casbin/gorm-adapter/v3"
+	"github.com/redis/go-redis/v9"
+	"github.com/samber/lo"
 	"go.uber.org/fx"
 	"gorm.io/gorm"
 )
 
-// NewCasbinEnforcer 创建Casbin权限控制器
-func NewCasbinEnforcer(db *gorm.DB, cfg configs.Config) (*casbin.Enforcer, error) {
+//go:embed casbin_models/*.conf
+var casbinModels embed.FS
+
+// 内置模型
+const (
+	casbinModelRBAC            = "casbin_models/rbac.conf"
+	casbinModelRBACWithDomains = "casbin_models/rbac_with_domains.conf"
+)
+
+// LoadCasbinModel 按 model（内联）> model_file > 内置模型的顺序加载权限模型
+func LoadCasbinModel(cfg configs.CasbinConfig) (model.Model, error) {
+	switch {
+	case strings.TrimSpace(cfg.Model) != "":
+		m, err := model.NewModelFromString(cfg.Model)
+		if err != nil {
+			return nil, fmt.Errorf("parse casbin.model: %w", err)
+		}
+		return m, nil
+	case cfg.ModelFile != "":
+		m, err := model.NewModelFromFile(cfg.ModelFile)
+		if err != nil {
+			return nil, fmt.Errorf("load casbin.model_file %q: %w", cfg.ModelFile, err)
+		}
+		return m, nil
+	}
+
+	name := casbinModelRBAC
+	if cfg.Domains {
+		name = casbinModelRBACWithDomains
+	}
+	text, err := casbinModels.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewModelFromString(string(text))
+}
+
+// IsDomainModel 请求定义包含 dom 时为域（租户）模型，鉴权需要 sub, dom, obj, act 四个参数
+func IsDomainModel(e *casbin.SyncedEnforcer) bool {
+	if e == nil {
+		return false
+	}
+	r, ok := e.GetModel()["r"]["r"]
+	return ok && lo.Contains(r.Tokens, "r_dom")
+}
+
+// NewCasbinEnforcer 创建Casbin权限控制器。
+// 策略同步器在后台修改策略的同时请求仍在鉴权，使用读写锁保护的 SyncedEnforcer
+func NewCasbinEnforcer(db *gorm.DB, cfg configs.Config) (*casbin.SyncedEnforcer, error) {
 	// 使用GORM适配器
 	adapter, err := gormadapter.NewAdapterByDB(db)
 	if err != nil {
 		return nil, err
 	}
 
+	// 加载模型
+	m, err := LoadCasbinModel(cfg.Casbin)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建enforcer
-	enforcer, err := casbin.NewEnforcer("configs/rbac_model.conf", adapter)
+	enforcer, err := casbin.NewSyncedEnforcer(m, adapter)
 	if err != nil {
 		return nil, err
 	}
@@ -31,7 +92,37 @@ func NewCasbinEnforcer(db *gorm.DB, cfg configs.Config) (*casbin.Enforcer, error
 	return enforcer, nil
 }
 
+// NewCasbinPolicyWatcher 为 enforcer 绑定策略同步器。
+// 配置了 Redis 时通过 pub/sub 在副本间同步，否则只在进程内同步（单实例部署）
+func NewCasbinPolicyWatcher(lc fx.Lifecycle, e *casbin.SyncedEnforcer, client *redis.Client, cfg configs.Config) (*CasbinWatcher, error) {
+	var (
+		w   *CasbinWatcher
+		err error
+	)
+	if client != nil {
+		w, err = NewRedisCasbinWatcher(client, cfg.Casbin.WatcherChannel)
+	} else {
+		w, err = NewLocalCasbinWatcher(NewCasbinBus())
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Attach(e); err != nil {
+		w.Close()
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			w.Close()
+			return nil
+		},
+	})
+	return w, nil
+}
+
 // CasbinModule Casbin模块
 var CasbinModule = fx.Module("casbin",
 	fx.Provide(NewCasbinEnforcer),
+	fx.Provide(NewCasbinPolicyWatcher),
 )
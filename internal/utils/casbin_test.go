@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NSObjects/go-template/internal/configs"
+	"github.com/casbin/casbin/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCasbinModel(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "model.conf")
+	require.NoError(t, os.WriteFile(file, []byte(testCasbinModel), 0o600))
+
+	tests := []struct {
+		name    string
+		cfg     configs.CasbinConfig
+		domains bool
+		wantErr bool
+	}{
+		{name: "内置默认模型"},
+		{name: "内置域模型", cfg: configs.CasbinConfig{Domains: true}, domains: true},
+		{name: "内联模型", cfg: configs.CasbinConfig{Model: testCasbinModel, Domains: true}},
+		{name: "模型文件", cfg: configs.CasbinConfig{ModelFile: file}},
+		{name: "内联模型优先于文件", cfg: configs.CasbinConfig{Model: testCasbinModel, ModelFile: "missing.conf"}},
+		{name: "模型文件不存在", cfg: configs.CasbinConfig{ModelFile: "missing.conf"}, wantErr: true},
+		{name: "内联模型无效", cfg: configs.CasbinConfig{Model: "[request_definition]"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := LoadCasbinModel(tt.cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			e, err := casbin.NewSyncedEnforcer(m)
+			require.NoError(t, err)
+			assert.Equal(t, tt.domains, IsDomainModel(e))
+		})
+	}
+}
@@ -0,0 +1,333 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NSObjects/go-template/internal/health"
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultCasbinChannel casbin.watcher_channel 未配置时使用的 Redis 频道
+const DefaultCasbinChannel = "casbin:policy"
+
+// 策略变更类型，与 persist.WatcherEx 的回调一一对应
+const (
+	casbinAddPolicies          = "add_policies"
+	casbinRemovePolicies       = "remove_policies"
+	casbinRemoveFilteredPolicy = "remove_filtered_policy"
+	casbinUpdatePolicies       = "update_policies"
+	// casbinReload 无法增量描述的变更（SavePolicy 等），接收方从存储全量加载
+	casbinReload = "reload"
+)
+
+// CasbinTransport 广播策略变更的通道
+type CasbinTransport interface {
+	// Publish 广播一条消息
+	Publish(ctx context.Context, payload []byte) error
+	// Subscribe 订阅消息直到返回的函数被调用；payload 为 nil 表示可能丢失了消息，需要全量重新加载
+	Subscribe(ctx context.Context, handler func(payload []byte)) (func(), error)
+}
+
+// casbinUpdate 广播的策略变更
+type casbinUpdate struct {
+	// Origin 发送方实例，用于忽略自己发出的消息
+	Origin      string     `json:"origin"`
+	Method      string     `json:"method"`
+	Sec         string     `json:"sec,omitempty"`
+	Ptype       string     `json:"ptype,omitempty"`
+	Rules       [][]string `json:"rules,omitempty"`
+	NewRules    [][]string `json:"new_rules,omitempty"`
+	FieldIndex  int        `json:"field_index,omitempty"`
+	FieldValues []string   `json:"field_values,omitempty"`
+}
+
+// CasbinWatcher 在多个副本之间同步策略变更。
+// 本地 enforcer 修改策略后广播变更内容，其他副本收到后增量应用，无需重新加载整张 casbin_rule 表。
+type CasbinWatcher struct {
+	id        string
+	transport CasbinTransport
+
+	mu       sync.RWMutex
+	callback func(string)
+	cancel   func()
+	lastSync time.Time
+	lastErr  error
+}
+
+var (
+	_ persist.WatcherEx        = (*CasbinWatcher)(nil)
+	_ persist.UpdatableWatcher = (*CasbinWatcher)(nil)
+)
+
+// NewCasbinWatcher 创建策略同步器并开始订阅
+func NewCasbinWatcher(transport CasbinTransport) (*CasbinWatcher, error) {
+	w := &CasbinWatcher{id: uuid.NewString(), transport: transport}
+	cancel, err := transport.Subscribe(context.Background(), w.receive)
+	if err != nil {
+		return nil, fmt.Errorf("subscribe casbin updates: %w", err)
+	}
+	w.cancel = cancel
+	return w, nil
+}
+
+// NewRedisCasbinWatcher 基于 Redis pub/sub 的策略同步器，用于多副本部署
+func NewRedisCasbinWatcher(client *redis.Client, channel string) (*CasbinWatcher, error) {
+	if channel == "" {
+		channel = DefaultCasbinChannel
+	}
+	return NewCasbinWatcher(&redisCasbinTransport{client: client, channel: channel})
+}
+
+// NewLocalCasbinWatcher 基于进程内总线的策略同步器，用于单实例部署与测试
+func NewLocalCasbinWatcher(bus *CasbinBus) (*CasbinWatcher, error) {
+	return NewCasbinWatcher(bus)
+}
+
+// Attach 将同步器绑定到 enforcer：本地修改会被广播，收到的变更会增量应用到该 enforcer
+func (w *CasbinWatcher) Attach(e *casbin.SyncedEnforcer) error {
+	if err := e.SetWatcher(w); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.lastSync = time.Now()
+	w.mu.Unlock()
+	return w.SetUpdateCallback(func(msg string) {
+		w.markSynced(applyCasbinUpdate(e, msg))
+	})
+}
+
+// LastSync 最近一次与其他副本同步策略的时间
+func (w *CasbinWatcher) LastSync() time.Time {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.lastSync
+}
+
+// HealthCheck 实现 health.Reporter，报告最近一次同步的时间与结果
+func (w *CasbinWatcher) HealthCheck(context.Context) health.Check {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	check := health.Check{Status: "healthy", LastChecked: time.Now()}
+	if w.lastSync.IsZero() {
+		check.Message = "policy not synced yet"
+	} else {
+		check.Message = "last sync at " + w.lastSync.Format(time.RFC3339)
+	}
+	if w.lastErr != nil {
+		check.Status = "unhealthy"
+		check.Message = fmt.Sprintf("%s, last update failed: %v", check.Message, w.lastErr)
+	}
+	return check
+}
+
+func (w *CasbinWatcher) markSynced(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lastErr = err
+	if err == nil {
+		w.lastSync = time.Now()
+	}
+}
+
+// receive 处理订阅到的消息，自己发出的消息直接忽略
+func (w *CasbinWatcher) receive(payload []byte) {
+	if payload == nil {
+		payload, _ = json.Marshal(casbinUpdate{Method: casbinReload})
+	} else {
+		var u casbinUpdate
+		if err := json.Unmarshal(payload, &u); err != nil {
+			w.markSynced(fmt.Errorf("decode casbin update: %w", err))
+			return
+		}
+		if u.Origin == w.id {
+			return
+		}
+	}
+
+	w.mu.RLock()
+	callback := w.callback
+	w.mu.RUnlock()
+	if callback != nil {
+		callback(string(payload))
+	}
+}
+
+func (w *CasbinWatcher) publish(u casbinUpdate) error {
+	u.Origin = w.id
+	payload, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return w.transport.Publish(context.Background(), payload)
+}
+
+func (w *CasbinWatcher) SetUpdateCallback(callback func(string)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callback = callback
+	return nil
+}
+
+func (w *CasbinWatcher) Update() error {
+	return w.publish(casbinUpdate{Method: casbinReload})
+}
+
+func (w *CasbinWatcher) Close() {
+	w.mu.Lock()
+	cancel := w.cancel
+	w.cancel = nil
+	w.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (w *CasbinWatcher) UpdateForAddPolicy(sec, ptype string, params ...string) error {
+	return w.UpdateForAddPolicies(sec, ptype, params)
+}
+
+func (w *CasbinWatcher) UpdateForRemovePolicy(sec, ptype string, params ...string) error {
+	return w.UpdateForRemovePolicies(sec, ptype, params)
+}
+
+func (w *CasbinWatcher) UpdateForRemoveFilteredPolicy(sec, ptype string, fieldIndex int, fieldValues ...string) error {
+	return w.publish(casbinUpdate{Method: casbinRemoveFilteredPolicy, Sec: sec, Ptype: ptype, FieldIndex: fieldIndex, FieldValues: fieldValues})
+}
+
+func (w *CasbinWatcher) UpdateForSavePolicy(model.Model) error {
+	return w.Update()
+}
+
+func (w *CasbinWatcher) UpdateForAddPolicies(sec string, ptype string, rules ...[]string) error {
+	return w.publish(casbinUpdate{Method: casbinAddPolicies, Sec: sec, Ptype: ptype, Rules: rules})
+}
+
+func (w *CasbinWatcher) UpdateForRemovePolicies(sec string, ptype string, rules ...[]string) error {
+	return w.publish(casbinUpdate{Method: casbinRemovePolicies, Sec: sec, Ptype: ptype, Rules: rules})
+}
+
+func (w *CasbinWatcher) UpdateForUpdatePolicy(sec string, ptype string, oldRule, newRule []string) error {
+	return w.UpdateForUpdatePolicies(sec, ptype, [][]string{oldRule}, [][]string{newRule})
+}
+
+func (w *CasbinWatcher) UpdateForUpdatePolicies(sec string, ptype string, oldRules, newRules [][]string) error {
+	return w.publish(casbinUpdate{Method: casbinUpdatePolicies, Sec: sec, Ptype: ptype, Rules: oldRules, NewRules: newRules})
+}
+
+// applyCasbinUpdate 将其他副本的变更应用到本地 enforcer。
+// Self* 系列方法既不写回存储（发送方已写入）也不再次广播。
+func applyCasbinUpdate(e *casbin.SyncedEnforcer, msg string) error {
+	var u casbinUpdate
+	if err := json.Unmarshal([]byte(msg), &u); err != nil {
+		return fmt.Errorf("decode casbin update: %w", err)
+	}
+
+	var err error
+	switch u.Method {
+	case casbinAddPolicies:
+		_, err = e.SelfAddPoliciesEx(u.Sec, u.Ptype, u.Rules)
+	case casbinRemovePolicies:
+		_, err = e.SelfRemovePolicies(u.Sec, u.Ptype, u.Rules)
+	case casbinRemoveFilteredPolicy:
+		_, err = e.SelfRemoveFilteredPolicy(u.Sec, u.Ptype, u.FieldIndex, u.FieldValues...)
+	case casbinUpdatePolicies:
+		_, err = e.SelfUpdatePolicies(u.Sec, u.Ptype, u.Rules, u.NewRules)
+	default:
+		if e.GetAdapter() == nil {
+			return fmt.Errorf("apply casbin %s: enforcer has no adapter", u.Method)
+		}
+		err = e.LoadPolicy()
+	}
+	if err != nil {
+		return fmt.Errorf("apply casbin %s: %w", u.Method, err)
+	}
+	return nil
+}
+
+// redisCasbinTransport 基于 Redis pub/sub 的广播通道
+type redisCasbinTransport struct {
+	client  *redis.Client
+	channel string
+}
+
+func (t *redisCasbinTransport) Publish(ctx context.Context, payload []byte) error {
+	return t.client.Publish(ctx, t.channel, payload).Err()
+}
+
+func (t *redisCasbinTransport) Subscribe(ctx context.Context, handler func([]byte)) (func(), error) {
+	sub := t.client.Subscribe(ctx, t.channel)
+	if _, err := sub.Receive(ctx); err != nil {
+		_ = sub.Close()
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// 断线重连后会再次收到订阅确认，期间的消息可能已丢失，需要全量加载
+		for msg := range sub.ChannelWithSubscriptions() {
+			switch m := msg.(type) {
+			case *redis.Subscription:
+				if m.Kind == "subscribe" {
+					handler(nil)
+				}
+			case *redis.Message:
+				handler([]byte(m.Payload))
+			}
+		}
+	}()
+
+	return func() {
+		_ = sub.Close()
+		<-done
+	}, nil
+}
+
+// CasbinBus 进程内广播总线，同一总线上的同步器互相可见
+type CasbinBus struct {
+	mu       sync.RWMutex
+	next     int
+	handlers map[int]func([]byte)
+}
+
+// NewCasbinBus 创建进程内广播总线
+func NewCasbinBus() *CasbinBus {
+	return &CasbinBus{handlers: make(map[int]func([]byte))}
+}
+
+// Publish 同步投递给所有订阅者
+func (b *CasbinBus) Publish(_ context.Context, payload []byte) error {
+	b.mu.RLock()
+	handlers := make([]func([]byte), 0, len(b.handlers))
+	for _, h := range b.handlers {
+		handlers = append(handlers, h)
+	}
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(payload)
+	}
+	return nil
+}
+
+func (b *CasbinBus) Subscribe(_ context.Context, handler func([]byte)) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.next
+	b.next++
+	b.handlers[id] = handler
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}, nil
+}
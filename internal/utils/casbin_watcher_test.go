@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	fileadapter "github.com/casbin/casbin/v2/persist/file-adapter"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCasbinModel = `
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[role_definition]
+g = _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub) && r.obj == p.obj && r.act == p.act
+`
+
+// newReplica 模拟一个副本：共享同一份策略存储，各自持有 enforcer 与同步器
+func newReplica(t *testing.T, policyFile string, newWatcher func() (*CasbinWatcher, error)) (*casbin.SyncedEnforcer, *CasbinWatcher) {
+	t.Helper()
+	m, err := model.NewModelFromString(testCasbinModel)
+	require.NoError(t, err)
+	e, err := casbin.NewSyncedEnforcer(m, fileadapter.NewAdapter(policyFile))
+	require.NoError(t, err)
+	// 文件适配器不支持增量写入，由 SavePolicy 统一落盘
+	e.EnableAutoSave(false)
+
+	w, err := newWatcher()
+	require.NoError(t, err)
+	require.NoError(t, w.Attach(e))
+	t.Cleanup(w.Close)
+	return e, w
+}
+
+func eventuallyAllowed(t *testing.T, e *casbin.SyncedEnforcer, want bool, rvals ...interface{}) {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		ok, err := e.Enforce(rvals...)
+		return err == nil && ok == want
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func testCasbinSync(t *testing.T, newWatcher func() (*CasbinWatcher, error)) {
+	policyFile := filepath.Join(t.TempDir(), "policy.csv")
+	require.NoError(t, os.WriteFile(policyFile, nil, 0o600))
+
+	a, _ := newReplica(t, policyFile, newWatcher)
+	b, wb := newReplica(t, policyFile, newWatcher)
+	synced := wb.LastSync()
+
+	// 增量变更
+	_, err := a.AddPolicy("admin", "/api/users", "GET")
+	require.NoError(t, err)
+	_, err = a.AddGroupingPolicy("alice", "admin")
+	require.NoError(t, err)
+	eventuallyAllowed(t, b, true, "alice", "/api/users", "GET")
+	assert.True(t, wb.LastSync().After(synced))
+
+	_, err = a.UpdatePolicy([]string{"admin", "/api/users", "GET"}, []string{"admin", "/api/users", "PUT"})
+	require.NoError(t, err)
+	eventuallyAllowed(t, b, false, "alice", "/api/users", "GET")
+	eventuallyAllowed(t, b, true, "alice", "/api/users", "PUT")
+
+	_, err = a.RemoveGroupingPolicy("alice", "admin")
+	require.NoError(t, err)
+	eventuallyAllowed(t, b, false, "alice", "/api/users", "PUT")
+
+	// 全量变更：写入存储后通知其他副本重新加载
+	_, err = a.AddPolicy("bob", "/api/posts", "GET")
+	require.NoError(t, err)
+	require.NoError(t, a.SavePolicy())
+	eventuallyAllowed(t, b, true, "bob", "/api/posts", "GET")
+
+	// 本地修改不会被自己的同步器重复应用
+	ok, err := a.HasPolicy("admin", "/api/users", "PUT")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "healthy", wb.HealthCheck(context.Background()).Status)
+}
+
+func TestLocalCasbinWatcher(t *testing.T) {
+	bus := NewCasbinBus()
+	testCasbinSync(t, func() (*CasbinWatcher, error) { return NewLocalCasbinWatcher(bus) })
+}
+
+// TestRedisCasbinWatcher 需要设置 TEST_REDIS_ADDR 指向可用的 Redis 实例
+func TestRedisCasbinWatcher(t *testing.T) {
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR not set")
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	channel := "casbin:test:" + uuid.NewString()
+	testCasbinSync(t, func() (*CasbinWatcher, error) { return NewRedisCasbinWatcher(rdb, channel) })
+}
+
+// TestCasbinWatcher_ConcurrentEnforce 同步器在后台修改策略的同时请求仍在鉴权，需配合 -race 运行
+func TestCasbinWatcher_ConcurrentEnforce(t *testing.T) {
+	policyFile := filepath.Join(t.TempDir(), "policy.csv")
+	require.NoError(t, os.WriteFile(policyFile, nil, 0o600))
+	bus := NewCasbinBus()
+	newWatcher := func() (*CasbinWatcher, error) { return NewLocalCasbinWatcher(bus) }
+	a, _ := newReplica(t, policyFile, newWatcher)
+	b, _ := newReplica(t, policyFile, newWatcher)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ctx.Err() == nil {
+			_, _ = b.Enforce("alice", "/api/users", "GET")
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		_, err := a.AddPolicy("alice", "/api/users", "GET")
+		require.NoError(t, err)
+		_, err = a.RemovePolicy("alice", "/api/users", "GET")
+		require.NoError(t, err)
+	}
+	cancel()
+	<-done
+
+	_, err := a.AddPolicy("alice", "/api/users", "GET")
+	require.NoError(t, err)
+	eventuallyAllowed(t, b, true, "alice", "/api/users", "GET")
+}
+
+func TestCasbinWatcher_ReportsFailedUpdate(t *testing.T) {
+	bus := NewCasbinBus()
+	w, err := NewLocalCasbinWatcher(bus)
+	require.NoError(t, err)
+	defer w.Close()
+
+	m, err := model.NewModelFromString(testCasbinModel)
+	require.NoError(t, err)
+	e, err := casbin.NewSyncedEnforcer(m)
+	require.NoError(t, err)
+	require.NoError(t, w.Attach(e))
+
+	// 无适配器时无法全量加载，同步失败需要体现在健康检查中
+	require.NoError(t, bus.Publish(context.Background(), []byte(`{"origin":"other","method":"reload"}`)))
+	check := w.HealthCheck(context.Background())
+	assert.Equal(t, "unhealthy", check.Status)
+	assert.Contains(t, check.Message, "last sync at")
+}
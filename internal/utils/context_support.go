@@ -17,12 +17,15 @@ type TraceContext struct {
 	SpanID    string
 	RequestID string
 	UserID    string
+	TenantID  string
+	ClientIP  string
 	StartTime time.Time
 }
 
 // ExtractTraceContext 从 echo.Context 中提取链路追踪信息
 func ExtractTraceContext(c echo.Context) *TraceContext {
 	tc := &TraceContext{
+		ClientIP:  c.RealIP(),
 		StartTime: time.Now(),
 	}
 
@@ -46,6 +49,15 @@ func ExtractTraceContext(c echo.Context) *TraceContext {
 		if uid, ok := userID.(string); ok {
 			tc.UserID = uid
 		}
+	} else if p, ok := PrincipalFromContext(c.Request().Context()); ok {
+		tc.UserID = p.ID
+	}
+
+	// 提取租户 ID (如果已解析)
+	if tenantID, ok := c.Get("tenant_id").(string); ok {
+		tc.TenantID = tenantID
+	} else {
+		tc.TenantID = GetTenantID(c.Request().Context())
 	}
 
 	return tc
@@ -61,7 +73,12 @@ func BuildContext(c echo.Context) context.Context {
 	ctx = context.WithValue(ctx, "span_id", tc.SpanID)
 	ctx = context.WithValue(ctx, "request_id", tc.RequestID)
 	ctx = context.WithValue(ctx, "user_id", tc.UserID)
+	ctx = context.WithValue(ctx, "tenant_id", tc.TenantID)
+	ctx = context.WithValue(ctx, "client_ip", tc.ClientIP)
 	ctx = context.WithValue(ctx, "start_time", tc.StartTime)
+	if p, ok := GetPrincipal(c); ok {
+		ctx = context.WithValue(ctx, "principal", p)
+	}
 
 	return ctx
 }
@@ -90,6 +107,27 @@ func GetUserID(ctx context.Context) string {
 	return ""
 }
 
+// GetTenantID 从 context 中获取 TenantID
+func GetTenantID(ctx context.Context) string {
+	if tenantID, ok := ctx.Value("tenant_id").(string); ok {
+		return tenantID
+	}
+	return ""
+}
+
+// WithTenantID 为 context 添加租户信息
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, "tenant_id", tenantID)
+}
+
+// GetClientIP 从 context 中获取客户端 IP
+func GetClientIP(ctx context.Context) string {
+	if clientIP, ok := ctx.Value("client_ip").(string); ok {
+		return clientIP
+	}
+	return ""
+}
+
 // GetStartTime 从 context 中获取请求开始时间
 func GetStartTime(ctx context.Context) time.Time {
 	if startTime, ok := ctx.Value("start_time").(time.Time); ok {
@@ -131,6 +131,7 @@ func TestBuildContext(t *testing.T) {
 	assert.Equal(t, "span-789", ctx.Value("span_id"))
 	assert.Equal(t, "req-123", GetRequestID(ctx))
 	assert.Equal(t, "user-001", GetUserID(ctx))
+	assert.Equal(t, "192.0.2.1", GetClientIP(ctx))
 	assert.NotZero(t, GetStartTime(ctx))
 }
 
@@ -227,6 +228,47 @@ func TestGetUserID(t *testing.T) {
 	}
 }
 
+func TestTenantContext(t *testing.T) {
+	e := echo.New()
+
+	// 中间件写入请求 context 的租户同样会带入业务 context
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req = req.WithContext(WithTenantID(req.Context(), "tenant-1"))
+	c := e.NewContext(req, httptest.NewRecorder())
+	assert.Equal(t, "tenant-1", GetTenantID(BuildContext(c)))
+
+	// echo.Context 上的值优先
+	c.Set("tenant_id", "tenant-2")
+	assert.Equal(t, "tenant-2", GetTenantID(BuildContext(c)))
+
+	assert.Empty(t, GetTenantID(context.Background()))
+}
+
+func TestPrincipal(t *testing.T) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/test", nil), httptest.NewRecorder())
+	_, ok := GetPrincipal(c)
+	assert.False(t, ok)
+
+	SetPrincipal(c, PrincipalFromClaims(&JwtCustomClaims{ID: 7, Name: "alice", Roles: []string{"editor"}, TenantID: "acme"}))
+
+	p, ok := GetPrincipal(c)
+	assert.True(t, ok)
+	assert.Equal(t, "7", p.ID)
+	assert.True(t, p.HasRole("editor"))
+	assert.False(t, p.HasRole("admin"))
+
+	// 业务 context 与请求 context 均可读取
+	ctx := BuildContext(c)
+	assert.Equal(t, "7", GetUserID(ctx))
+	p, ok = PrincipalFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "acme", p.TenantID)
+	p, ok = PrincipalFromContext(c.Request().Context())
+	assert.True(t, ok)
+	assert.Equal(t, "alice", p.Name)
+}
+
 func TestGetStartTime(t *testing.T) {
 	now := time.Now()
 
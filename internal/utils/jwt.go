@@ -13,8 +13,11 @@ package utils
 import "github.com/golang-jwt/jwt/v5"
 
 type JwtCustomClaims struct {
-	Name  string `json:"name"`
-	ID    int64  `json:"id" `
-	Admin bool   `json:"admin"`
+	Name  string   `json:"name"`
+	ID    int64    `json:"id" `
+	Admin bool     `json:"admin"`
+	Roles []string `json:"roles,omitempty"`
+	// TenantID 多租户部署下令牌所属的租户
+	TenantID string `json:"tenant_id,omitempty"`
 	jwt.RegisteredClaims
 }
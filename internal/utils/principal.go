@@ -0,0 +1,76 @@
+/*
+ * 认证主体
+ * 各认证方式（JWT、API 密钥等）在认证成功后写入 Principal，下游统一读取，不再依赖具体的令牌格式。
+ */
+package utils
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/samber/lo"
+)
+
+// 认证方式
+const (
+	AuthMethodJWT    = "jwt"
+	AuthMethodAPIKey = "api_key"
+)
+
+// Principal 已认证的调用方
+type Principal struct {
+	ID       string
+	Name     string
+	Roles    []string
+	TenantID string
+	Admin    bool
+	// Method 认证方式
+	Method string
+	// Scopes API 密钥的授权范围，JWT 认证时为空
+	Scopes []string
+}
+
+// HasRole 是否拥有指定角色
+func (p *Principal) HasRole(role string) bool {
+	return lo.Contains(p.Roles, role)
+}
+
+// PrincipalFromClaims 由访问令牌构造认证主体
+func PrincipalFromClaims(claims *JwtCustomClaims) *Principal {
+	return &Principal{
+		ID:       strconv.FormatInt(claims.ID, 10),
+		Name:     claims.Name,
+		Roles:    claims.Roles,
+		TenantID: claims.TenantID,
+		Admin:    claims.Admin,
+		Method:   AuthMethodJWT,
+	}
+}
+
+// SetPrincipal 将认证主体写入 echo.Context 与请求 context，
+// 同时写入 user_id，供 GetUserID、按用户限流等使用
+func SetPrincipal(c echo.Context, p *Principal) {
+	c.Set("principal", p)
+	c.Set("user_id", p.ID)
+	req := c.Request()
+	c.SetRequest(req.WithContext(WithPrincipal(req.Context(), p)))
+}
+
+// GetPrincipal 从 echo.Context 中获取认证主体
+func GetPrincipal(c echo.Context) (*Principal, bool) {
+	p, ok := c.Get("principal").(*Principal)
+	return p, ok && p != nil
+}
+
+// WithPrincipal 为 context 添加认证主体
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	ctx = context.WithValue(ctx, "principal", p)
+	return context.WithValue(ctx, "user_id", p.ID)
+}
+
+// PrincipalFromContext 从 context 中获取认证主体
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value("principal").(*Principal)
+	return p, ok && p != nil
+}
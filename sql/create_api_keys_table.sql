@@ -0,0 +1,15 @@
+-- 创建 API 密钥表
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL COMMENT '名称',
+    prefix VARCHAR(16) NOT NULL COMMENT '查找前缀',
+    hash CHAR(64) NOT NULL COMMENT '密钥 SHA-256 摘要',
+    user_id BIGINT UNSIGNED NOT NULL COMMENT '所属用户ID，调用方以该用户身份访问',
+    scopes VARCHAR(1000) NOT NULL DEFAULT '' COMMENT '授权范围，空格分隔',
+    expires_at TIMESTAMP NULL DEFAULT NULL COMMENT '过期时间，为空表示永不过期',
+    last_used_at TIMESTAMP NULL DEFAULT NULL COMMENT '最近使用时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    UNIQUE INDEX idx_prefix (prefix),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='API 密钥表';
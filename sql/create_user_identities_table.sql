@@ -0,0 +1,11 @@
+-- 创建外部身份关联表
+CREATE TABLE IF NOT EXISTS user_identities (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    provider VARCHAR(50) NOT NULL COMMENT '身份提供方名称',
+    subject VARCHAR(255) NOT NULL COMMENT '身份提供方内的用户标识（sub）',
+    email VARCHAR(100) NOT NULL DEFAULT '' COMMENT '关联时的邮箱',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    UNIQUE INDEX idx_provider_subject (provider, subject),
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='外部身份关联表';
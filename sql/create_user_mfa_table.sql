@@ -0,0 +1,19 @@
+-- 创建两步验证表
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id BIGINT UNSIGNED NOT NULL PRIMARY KEY COMMENT '用户ID',
+    secret VARCHAR(64) NOT NULL COMMENT 'TOTP 密钥（Base32）',
+    enabled TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否已启用',
+    last_step BIGINT NOT NULL DEFAULT 0 COMMENT '最近一次使用的时间步长，用于拒绝重放',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间'
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='两步验证表';
+
+-- 创建两步验证恢复码表
+CREATE TABLE IF NOT EXISTS user_mfa_recovery_codes (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+    hash CHAR(60) NOT NULL COMMENT '恢复码哈希',
+    used_at TIMESTAMP NULL DEFAULT NULL COMMENT '使用时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    INDEX idx_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='两步验证恢复码表';
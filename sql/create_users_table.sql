@@ -4,9 +4,13 @@ CREATE TABLE IF NOT EXISTS users (
     username VARCHAR(50) NOT NULL UNIQUE COMMENT '用户名',
     email VARCHAR(100) NOT NULL UNIQUE COMMENT '邮箱',
     age INT DEFAULT 0 COMMENT '年龄',
+    email_verified_at DATETIME NULL COMMENT '邮箱验证时间，邮箱变更后清空',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
     INDEX idx_username (username),
     INDEX idx_email (email),
     INDEX idx_created_at (created_at)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户表';
+
+-- 已有部署升级：
+-- ALTER TABLE users ADD COLUMN email_verified_at DATETIME NULL COMMENT '邮箱验证时间，邮箱变更后清空';